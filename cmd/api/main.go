@@ -9,19 +9,35 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/chicogong/media-pipeline/pkg/api"
 	"github.com/chicogong/media-pipeline/pkg/auth"
+	"github.com/chicogong/media-pipeline/pkg/executor"
+	_ "github.com/chicogong/media-pipeline/pkg/operators/builtin" // Register built-in operators
+	"github.com/chicogong/media-pipeline/pkg/schemas"
 	"github.com/chicogong/media-pipeline/pkg/store"
 )
 
 var (
-	port      = flag.Int("port", 8080, "Server port")
-	host      = flag.String("host", "0.0.0.0", "Server host")
-	jwtSecret = flag.String("jwt-secret", getEnv("JWT_SECRET", ""), "JWT secret key")
-	authMode  = flag.String("auth-mode", getEnv("AUTH_MODE", "optional"), "Authentication mode: required or optional")
+	port           = flag.Int("port", 8080, "Server port")
+	host           = flag.String("host", "0.0.0.0", "Server host")
+	jwtSecret      = flag.String("jwt-secret", getEnv("JWT_SECRET", ""), "JWT secret key")
+	authMode       = flag.String("auth-mode", getEnv("AUTH_MODE", "optional"), "Authentication mode: required or optional")
+	maxJobsPerUser = flag.Int("max-jobs-per-user", 0, "Maximum concurrent jobs per user (0 = unlimited)")
+	maxAutoRetries = flag.Int("max-auto-retries", 0, "Automatic retries for retryable job failures")
+	s3Endpoint     = flag.String("s3-endpoint", getEnv("S3_ENDPOINT", ""), "Custom S3 endpoint for S3-compatible storage (e.g. MinIO, R2)")
+	s3Region       = flag.String("s3-region", getEnv("S3_REGION", ""), "S3 region (defaults to the AWS environment)")
+	s3PathStyle    = flag.Bool("s3-path-style", false, "Use path-style S3 addressing (required by most S3-compatible stores)")
+	verifyUploads  = flag.Bool("verify-uploads", false, "Verify uploaded outputs against their local checksums")
+	maxDownloads   = flag.Int("download-concurrency", executor.DefaultDownloadConcurrency, "Number of job inputs downloaded in parallel")
+	maxUploads     = flag.Int("upload-concurrency", executor.DefaultUploadConcurrency, "Number of job outputs uploaded in parallel")
+	snapshotFile   = flag.String("snapshot-file", getEnv("SNAPSHOT_FILE", ""), "Job store snapshot loaded on startup and saved on shutdown")
+	maxBodySize    = flag.Int64("max-body-size", api.DefaultMaxBodySize, "Maximum request body size in bytes for job routes")
+	webhookSecret  = flag.String("webhook-secret", getEnv("WEBHOOK_SECRET", ""), "Secret used to sign job webhooks (unsigned if empty)")
+	requestTimeout = flag.Duration("request-timeout", api.DefaultRequestTimeout, "Maximum time a request may take before it fails with 503")
 )
 
 // getEnv gets environment variable with default value
@@ -67,16 +83,48 @@ func main() {
 
 	// Create store
 	log.Println("Initializing store...")
-	s := store.NewMemoryStore()
+	s, err := loadStore(*snapshotFile)
+	if err != nil {
+		log.Fatalf("Failed to load snapshot: %v", err)
+	}
 	defer s.Close()
+	loadedVersion := s.Version()
 
 	// Create API server
 	log.Println("Creating API server...")
-	server := api.NewServer(s)
+	server := api.NewServerWithOptions(s,
+		api.WithStorageConfig(executor.StorageConfig{
+			S3Endpoint:          *s3Endpoint,
+			S3Region:            *s3Region,
+			S3PathStyle:         *s3PathStyle,
+			VerifyUploads:       *verifyUploads,
+			DownloadConcurrency: *maxDownloads,
+			UploadConcurrency:   *maxUploads,
+		}),
+		api.WithMaxConcurrentJobsPerUser(*maxJobsPerUser),
+		api.WithMaxRetries(*maxAutoRetries),
+		api.WithWebhookSecret(*webhookSecret),
+	)
 	defer server.Close()
 
+	// Resume jobs that were still queued when the snapshot was taken
+	pending, err := s.ListJobs(context.Background(), &store.ListFilter{
+		Status: []schemas.JobState{schemas.JobStatePending},
+	})
+	if err != nil {
+		log.Fatalf("Failed to list pending jobs: %v", err)
+	}
+	for _, job := range pending {
+		if err := server.Processor().Enqueue(job.JobID); err != nil {
+			log.Printf("Failed to resume job %s: %v", job.JobID, err)
+		}
+	}
+
 	// Setup HTTP router
-	mux := setupRoutes(server, authMiddleware)
+	mux := setupRoutes(server, authMiddleware, routeConfig{
+		MaxBodySize:    *maxBodySize,
+		RequestTimeout: *requestTimeout,
+	})
 
 	// Create HTTP server
 	addr := fmt.Sprintf("%s:%d", *host, *port)
@@ -84,10 +132,18 @@ func main() {
 		Addr:         addr,
 		Handler:      mux,
 		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
+		WriteTimeout: *requestTimeout + 5*time.Second, // Leave time to send the 503
 		IdleTimeout:  60 * time.Second,
 	}
 
+	// Process submitted jobs on the server's worker pool
+	processCtx, stopProcessing := context.WithCancel(context.Background())
+	processingDone := make(chan struct{})
+	go func() {
+		defer close(processingDone)
+		server.ListenAndProcessJobs(processCtx)
+	}()
+
 	// Start server in goroutine
 	go func() {
 		log.Printf("Starting server on %s", addr)
@@ -111,15 +167,84 @@ func main() {
 		log.Printf("Server forced to shutdown: %v", err)
 	}
 
+	// Wait for in-flight jobs to finish
+	stopProcessing()
+	<-processingDone
+
+	if *snapshotFile != "" && s.Version() != loadedVersion {
+		if err := s.SaveSnapshotFile(*snapshotFile); err != nil {
+			log.Printf("Failed to save snapshot: %v", err)
+		} else {
+			log.Printf("Saved job snapshot to %s", *snapshotFile)
+		}
+	}
+
 	log.Println("Server stopped")
 }
 
-func setupRoutes(server *api.Server, authMiddleware *auth.AuthMiddleware) *http.ServeMux {
+// loadStore restores the job store from path, or returns an empty store
+// if path is empty or does not exist yet
+func loadStore(path string) (*store.MemoryStore, error) {
+	if path == "" {
+		return store.NewMemoryStore(), nil
+	}
+
+	s, err := store.LoadSnapshotFile(path)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Loaded job snapshot from %s", path)
+	return s, nil
+}
+
+// routeConfig holds the limits applied to routes
+type routeConfig struct {
+	MaxBodySize int64
+
+	// RequestTimeout applies to every route not listed in RouteTimeouts,
+	// which is keyed by route pattern
+	RequestTimeout time.Duration
+	RouteTimeouts  map[string]time.Duration
+}
+
+// timeout returns the request timeout for pattern
+func (c routeConfig) timeout(pattern string) time.Duration {
+	if d, ok := c.RouteTimeouts[pattern]; ok {
+		return d
+	}
+	return c.RequestTimeout
+}
+
+func setupRoutes(server *api.Server, authMiddleware *auth.AuthMiddleware, cfg routeConfig) *http.ServeMux {
 	mux := http.NewServeMux()
 
 	// Health check (no auth required)
 	mux.HandleFunc("/health", api.Chain(
 		server.HandleHealth,
+		api.TimeoutMiddleware(cfg.timeout("/health")),
+		api.LoggingMiddleware,
+	))
+
+	// Operator discovery (no auth required)
+	mux.HandleFunc("/api/v1/operators", api.Chain(
+		server.HandleListOperators,
+		api.TimeoutMiddleware(cfg.timeout("/api/v1/operators")),
+		api.RecoveryMiddleware,
+		api.CORSMiddleware,
+		api.LoggingMiddleware,
+	))
+	mux.HandleFunc("/api/v1/operators/categories", api.Chain(
+		server.HandleListOperatorCategories,
+		api.TimeoutMiddleware(cfg.timeout("/api/v1/operators/categories")),
+		api.RecoveryMiddleware,
+		api.CORSMiddleware,
+		api.LoggingMiddleware,
+	))
+	mux.HandleFunc("/api/v1/operators/", api.Chain(
+		server.HandleGetOperator,
+		api.TimeoutMiddleware(cfg.timeout("/api/v1/operators/")),
+		api.RecoveryMiddleware,
+		api.CORSMiddleware,
 		api.LoggingMiddleware,
 	))
 
@@ -128,6 +253,8 @@ func setupRoutes(server *api.Server, authMiddleware *auth.AuthMiddleware) *http.
 		// Authenticated job routes
 		mux.HandleFunc("/api/v1/jobs", api.Chain(
 			handleJobsRoute(server),
+			api.MaxBodySizeMiddleware(cfg.MaxBodySize),
+			api.TimeoutMiddleware(cfg.timeout("/api/v1/jobs")),
 			wrapAuthMiddleware(authMiddleware),
 			api.RecoveryMiddleware,
 			api.CORSMiddleware,
@@ -137,6 +264,8 @@ func setupRoutes(server *api.Server, authMiddleware *auth.AuthMiddleware) *http.
 		// Authenticated job detail route
 		mux.HandleFunc("/api/v1/jobs/", api.Chain(
 			handleJobDetailRoute(server),
+			api.MaxBodySizeMiddleware(cfg.MaxBodySize),
+			api.TimeoutMiddleware(cfg.timeout("/api/v1/jobs/")),
 			wrapAuthMiddleware(authMiddleware),
 			api.RecoveryMiddleware,
 			api.CORSMiddleware,
@@ -146,6 +275,8 @@ func setupRoutes(server *api.Server, authMiddleware *auth.AuthMiddleware) *http.
 		// No authentication
 		mux.HandleFunc("/api/v1/jobs", api.Chain(
 			handleJobsRoute(server),
+			api.MaxBodySizeMiddleware(cfg.MaxBodySize),
+			api.TimeoutMiddleware(cfg.timeout("/api/v1/jobs")),
 			api.RecoveryMiddleware,
 			api.CORSMiddleware,
 			api.LoggingMiddleware,
@@ -153,6 +284,8 @@ func setupRoutes(server *api.Server, authMiddleware *auth.AuthMiddleware) *http.
 
 		mux.HandleFunc("/api/v1/jobs/", api.Chain(
 			handleJobDetailRoute(server),
+			api.MaxBodySizeMiddleware(cfg.MaxBodySize),
+			api.TimeoutMiddleware(cfg.timeout("/api/v1/jobs/")),
 			api.RecoveryMiddleware,
 			api.CORSMiddleware,
 			api.LoggingMiddleware,
@@ -185,9 +318,15 @@ func handleJobsRoute(server *api.Server) http.HandlerFunc {
 	}
 }
 
-// handleJobDetailRoute handles /api/v1/jobs/{id} (get and delete)
+// handleJobDetailRoute handles /api/v1/jobs/{id} (get and delete) and
+// /api/v1/jobs/{id}/clone
 func handleJobDetailRoute(server *api.Server) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/clone") {
+			server.HandleCloneJob(w, r)
+			return
+		}
+
 		switch r.Method {
 		case http.MethodGet:
 			server.HandleGetJob(w, r)
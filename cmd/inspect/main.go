@@ -0,0 +1,339 @@
+// Package main provides a CLI for inspecting job specs and processing plans
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/chicogong/media-pipeline/pkg/compiler/validator"
+	"github.com/chicogong/media-pipeline/pkg/operators"
+	_ "github.com/chicogong/media-pipeline/pkg/operators/builtin" // Register built-in operators
+	"github.com/chicogong/media-pipeline/pkg/planner"
+	"github.com/chicogong/media-pipeline/pkg/schemas"
+)
+
+const usage = `Usage: inspect <command> [arguments]
+
+Commands:
+  plan <json_file>                       Pretty-print a plan (or the plan for a job spec)
+  dot <json_file>                        Emit the plan graph in DOT format
+  validate <spec_json_file>              Run all validators against a job spec
+  estimate <spec_json_file> <probe_json> Estimate resources using probed input metadata
+  docs [operator]                        Print operator parameter docs in Markdown
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err := run(os.Args[1], os.Args[2:], os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run dispatches a subcommand
+func run(cmd string, args []string, w io.Writer) error {
+	ctx := context.Background()
+
+	switch cmd {
+	case "plan":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: inspect plan <json_file>")
+		}
+		plan, err := loadPlan(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		printPlan(w, plan)
+		return nil
+
+	case "dot":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: inspect dot <json_file>")
+		}
+		plan, err := loadPlan(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		writeDOT(w, plan)
+		return nil
+
+	case "validate":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: inspect validate <spec_json_file>")
+		}
+		spec, err := loadSpec(args[0])
+		if err != nil {
+			return err
+		}
+		if err := validateSpec(ctx, spec); err != nil {
+			return err
+		}
+		fmt.Fprintln(w, "OK")
+		return nil
+
+	case "estimate":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: inspect estimate <spec_json_file> <probe_json_file>")
+		}
+		spec, err := loadSpec(args[0])
+		if err != nil {
+			return err
+		}
+		metadata, err := loadProbe(args[1], spec)
+		if err != nil {
+			return err
+		}
+		estimates, err := estimate(ctx, spec, metadata)
+		if err != nil {
+			return err
+		}
+		printEstimates(w, estimates)
+		return nil
+
+	case "docs":
+		switch len(args) {
+		case 0:
+			fmt.Fprint(w, operators.GenerateAllDocs(operators.GlobalRegistry()))
+		case 1:
+			op, err := operators.Get(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Fprint(w, operators.GenerateMarkdown(op))
+		default:
+			return fmt.Errorf("usage: inspect docs [operator]")
+		}
+		return nil
+
+	case "help", "-h", "--help":
+		fmt.Fprint(w, usage)
+		return nil
+
+	default:
+		return fmt.Errorf("unknown command %q\n\n%s", cmd, usage)
+	}
+}
+
+// loadSpec reads a JobSpec from a JSON file
+func loadSpec(path string) (*schemas.JobSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec: %w", err)
+	}
+
+	var spec schemas.JobSpec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse spec: %w", err)
+	}
+	return &spec, nil
+}
+
+// loadPlan reads a ProcessingPlan from a JSON file. A file containing a job
+// spec instead of a plan is planned on the fly.
+func loadPlan(ctx context.Context, path string) (*schemas.ProcessingPlan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan: %w", err)
+	}
+
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse plan: %w", err)
+	}
+
+	if _, isPlan := probe["nodes"]; isPlan {
+		return planner.LoadPlan(bytes.NewReader(data))
+	}
+
+	var spec schemas.JobSpec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse spec: %w", err)
+	}
+	plan, err := planner.NewPlanner().Plan(ctx, &spec, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan spec: %w", err)
+	}
+	return plan, nil
+}
+
+// loadProbe reads input metadata from a JSON file. The file holds either a
+// single MediaInfo applied to every input, or an object mapping input IDs to
+// MediaInfo.
+func loadProbe(path string, spec *schemas.JobSpec) (map[string]*schemas.MediaInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read probe data: %w", err)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to parse probe data: %w", err)
+	}
+
+	metadata := make(map[string]*schemas.MediaInfo)
+	if _, single := fields["format"]; single {
+		var info schemas.MediaInfo
+		if err := json.Unmarshal(data, &info); err != nil {
+			return nil, fmt.Errorf("failed to parse probe data: %w", err)
+		}
+		for _, input := range spec.Inputs {
+			metadata[input.ID] = &info
+		}
+		return metadata, nil
+	}
+
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse probe data: %w", err)
+	}
+	for _, input := range spec.Inputs {
+		if metadata[input.ID] == nil {
+			return nil, fmt.Errorf("no probe data for input %s", input.ID)
+		}
+	}
+	return metadata, nil
+}
+
+// validateSpec runs the spec, operator and parameter validators and checks
+// that the spec produces a valid graph
+func validateSpec(ctx context.Context, spec *schemas.JobSpec) error {
+	if err := validator.New().Validate(spec); err != nil {
+		return fmt.Errorf("spec validation failed: %w", err)
+	}
+
+	p := planner.NewPlanner()
+	if err := p.ValidateOperators(spec); err != nil {
+		return fmt.Errorf("operator validation failed: %w", err)
+	}
+	if err := p.ValidateParameters(spec); err != nil {
+		return fmt.Errorf("parameter validation failed: %w", err)
+	}
+
+	if _, err := p.Plan(ctx, spec, &planner.PlanOptions{
+		SkipMetadataValidation: true,
+		SkipResourceEstimation: true,
+	}); err != nil {
+		return fmt.Errorf("graph validation failed: %w", err)
+	}
+	return nil
+}
+
+// estimate builds the graph for spec, seeds input metadata and runs the
+// resource estimator
+func estimate(ctx context.Context, spec *schemas.JobSpec, metadata map[string]*schemas.MediaInfo) (*schemas.ResourceEstimates, error) {
+	graph, err := planner.NewBuilder().BuildDAG(ctx, spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build DAG: %w", err)
+	}
+
+	for _, node := range graph.GetInputNodes() {
+		node.Metadata = metadata[node.InputID]
+	}
+
+	registry := operators.GlobalRegistry()
+	if err := planner.NewMetadataPropagator(registry).Propagate(ctx, graph); err != nil {
+		return nil, fmt.Errorf("metadata propagation failed: %w", err)
+	}
+
+	estimates, err := planner.NewResourceEstimator(registry).Estimate(ctx, graph)
+	if err != nil {
+		return nil, fmt.Errorf("resource estimation failed: %w", err)
+	}
+	return estimates, nil
+}
+
+// printPlan writes a human-readable summary of a plan
+func printPlan(w io.Writer, plan *schemas.ProcessingPlan) {
+	if plan.JobID != "" {
+		fmt.Fprintf(w, "Plan for job %s\n", plan.JobID)
+	} else {
+		fmt.Fprintln(w, "Plan")
+	}
+
+	fmt.Fprintf(w, "\nNodes (%d):\n", len(plan.Nodes))
+	for _, node := range plan.Nodes {
+		fmt.Fprintf(w, "  %-24s %-10s %s\n", node.ID, node.Type, describeNode(node))
+	}
+
+	fmt.Fprintf(w, "\nEdges (%d):\n", len(plan.Edges))
+	for _, edge := range plan.Edges {
+		fmt.Fprintf(w, "  %s -> %s\n", edge.From, edge.To)
+	}
+
+	if len(plan.ExecutionStages) > 0 {
+		fmt.Fprintln(w, "\nExecution stages:")
+		for i, stage := range plan.ExecutionStages {
+			fmt.Fprintf(w, "  %d: %s\n", i+1, strings.Join(stage, ", "))
+		}
+	}
+
+	if plan.ResourceEstimate != nil {
+		fmt.Fprintln(w)
+		printEstimates(w, plan.ResourceEstimate)
+	}
+}
+
+// describeNode returns the type-specific details of a node
+func describeNode(node *schemas.PlanNode) string {
+	switch node.Type {
+	case "input":
+		return node.SourceURI
+	case "output":
+		return node.DestURI
+	case "operation":
+		return node.Operator + formatParams(node.Params)
+	}
+	return ""
+}
+
+// formatParams formats params as " {k=v, ...}" in key order
+func formatParams(params map[string]interface{}) string {
+	if len(params) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s=%v", k, params[k])
+	}
+	return " {" + strings.Join(parts, ", ") + "}"
+}
+
+// printEstimates writes resource estimates
+func printEstimates(w io.Writer, estimates *schemas.ResourceEstimates) {
+	fmt.Fprintln(w, "Resource estimate:")
+	fmt.Fprintf(w, "  Total duration: %s\n", estimates.TotalDuration)
+	fmt.Fprintf(w, "  Peak memory:    %d MB\n", estimates.PeakMemoryMB)
+	fmt.Fprintf(w, "  Total disk:     %d MB\n", estimates.TotalDiskMB)
+
+	ids := make([]string, 0, len(estimates.NodeEstimates))
+	for id := range estimates.NodeEstimates {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		est := estimates.NodeEstimates[id]
+		fmt.Fprintf(w, "  %-24s %s, %d MB memory, %d MB disk\n", id, est.Duration, est.MemoryMB, est.DiskMB)
+	}
+}
+
+// writeDOT writes the plan graph in Graphviz DOT format
+func writeDOT(w io.Writer, plan *schemas.ProcessingPlan) {
+	fmt.Fprint(w, planner.ExportDOT(planner.NewGraphFromPlan(plan)))
+}
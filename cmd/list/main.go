@@ -0,0 +1,84 @@
+// Package main provides a CLI for listing media objects in storage
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/chicogong/media-pipeline/pkg/storage"
+)
+
+const usage = `Usage: list [flags] <uri>
+
+Lists the objects under a directory URI (file:// or s3://).
+
+Flags:
+`
+
+var (
+	recursive = flag.Bool("r", false, "List subdirectories recursively")
+	pattern   = flag.String("pattern", "", "Only list file names matching this glob (e.g. \"*.mp4\")")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(context.Background(), flag.Arg(0), os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run lists the objects at uri
+func run(ctx context.Context, uri string, w io.Writer) error {
+	stor, err := storageFor(ctx, uri)
+	if err != nil {
+		return err
+	}
+
+	objects, err := stor.ListObjects(ctx, uri, *recursive, *pattern)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, obj := range objects {
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", obj.Size, obj.LastModified.Format(time.RFC3339), obj.URI)
+	}
+	return tw.Flush()
+}
+
+// storageFor returns the storage backend for a URI scheme
+func storageFor(ctx context.Context, uri string) (storage.Storage, error) {
+	scheme, _, err := storage.ParseURI(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	switch scheme {
+	case "file":
+		return storage.NewLocalStorage(), nil
+	case "s3":
+		s3Storage, err := storage.NewS3Storage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return s3Storage, nil
+	default:
+		return nil, fmt.Errorf("listing is not supported for %s:// URIs", scheme)
+	}
+}
@@ -0,0 +1,91 @@
+// Package main provides the worker daemon entry point.
+//
+// The worker claims pending jobs from the API server's job snapshot file and
+// processes them independently of the API server. It loads the snapshot on
+// startup and writes it back on shutdown, so it must not run while the API
+// server is using the same file: whichever stops last would overwrite the
+// other's jobs. Typical use is draining the queue while the API is stopped.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	_ "github.com/chicogong/media-pipeline/pkg/operators/builtin" // Register built-in operators
+	"github.com/chicogong/media-pipeline/pkg/store"
+	"github.com/chicogong/media-pipeline/pkg/worker"
+)
+
+var (
+	concurrency    = flag.Int("concurrency", 1, "Number of jobs processed in parallel")
+	workerID       = flag.String("worker-id", defaultWorkerID(), "Worker identifier recorded on claimed jobs")
+	pollInterval   = flag.Duration("poll-interval", worker.DefaultPollInterval, "Initial delay before re-polling an empty queue")
+	maxAutoRetries = flag.Int("max-auto-retries", 0, "Automatic retries for retryable job failures")
+	snapshotFile   = flag.String("snapshot-file", os.Getenv("SNAPSHOT_FILE"), "Job store snapshot shared with the API server (required)")
+)
+
+// defaultWorkerID derives a worker ID from the host name and process ID
+func defaultWorkerID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "worker"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+func main() {
+	flag.Parse()
+
+	// An in-memory store of its own would never see a submitted job
+	if *snapshotFile == "" {
+		log.Fatal("A -snapshot-file (or SNAPSHOT_FILE) shared with the API server is required")
+	}
+
+	log.Println("Initializing store...")
+	s, err := store.LoadSnapshotFile(*snapshotFile)
+	if err != nil {
+		log.Fatalf("Failed to load snapshot: %v", err)
+	}
+	defer s.Close()
+	loadedVersion := s.Version()
+
+	processor := worker.NewProcessor(s, nil)
+	processor.MaxAutoRetries = *maxAutoRetries
+
+	w := worker.NewWorker(s, processor, *workerID)
+	w.Concurrency = *concurrency
+	w.PollInterval = *pollInterval
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Stop claiming jobs on interrupt; Run drains in-flight jobs
+	go func() {
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+		<-quit
+		log.Println("Shutting down worker, draining in-flight jobs...")
+		cancel()
+	}()
+
+	log.Printf("Worker %s started (concurrency %d)", *workerID, *concurrency)
+	start := time.Now()
+	if err := w.Run(ctx); err != nil {
+		log.Fatalf("Worker failed: %v", err)
+	}
+
+	if s.Version() != loadedVersion {
+		if err := s.SaveSnapshotFile(*snapshotFile); err != nil {
+			log.Printf("Failed to save snapshot: %v", err)
+		} else {
+			log.Printf("Saved job snapshot to %s", *snapshotFile)
+		}
+	}
+	log.Printf("Worker stopped after %s", time.Since(start).Round(time.Second))
+}
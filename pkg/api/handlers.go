@@ -2,41 +2,30 @@
 package api
 
 import (
-	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	"sort"
+	"strings"
 	"time"
 
-	"github.com/chicogong/media-pipeline/pkg/compiler/validator"
-	"github.com/chicogong/media-pipeline/pkg/executor"
+	"github.com/chicogong/media-pipeline/pkg/auth"
 	"github.com/chicogong/media-pipeline/pkg/operators"
-	"github.com/chicogong/media-pipeline/pkg/planner"
-	"github.com/chicogong/media-pipeline/pkg/prober"
 	"github.com/chicogong/media-pipeline/pkg/schemas"
 	"github.com/chicogong/media-pipeline/pkg/store"
 )
 
-// Server holds the API server dependencies
-type Server struct {
-	store     store.Store
-	prober    *prober.Prober
-	planner   *planner.Planner
-	executor  *executor.Executor
-	validator *validator.Validator
-}
-
-// NewServer creates a new API server
-func NewServer(s store.Store) *Server {
-	registry := operators.GlobalRegistry()
-	return &Server{
-		store:     s,
-		prober:    prober.NewProber(),
-		planner:   planner.NewPlanner(),
-		executor:  executor.NewExecutor(registry),
-		validator: &validator.Validator{},
-	}
+// activeJobStates are the non-terminal states counted against per-user limits
+var activeJobStates = []schemas.JobState{
+	schemas.JobStatePending,
+	schemas.JobStateValidating,
+	schemas.JobStatePlanning,
+	schemas.JobStateDownloadingInputs,
+	schemas.JobStateProcessing,
+	schemas.JobStateUploadingOutputs,
 }
 
 // CreateJobRequest represents the request body for creating a job
@@ -51,6 +40,15 @@ type CreateJobResponse struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// ListJobsResponse represents a page of jobs. Total counts every job
+// matching the filter, not just the ones on this page.
+type ListJobsResponse struct {
+	Items  []*schemas.JobStatus `json:"items"`
+	Total  int                  `json:"total"`
+	Limit  int                  `json:"limit"`
+	Offset int                  `json:"offset"`
+}
+
 // ErrorResponse represents an error response
 type ErrorResponse struct {
 	Error   string `json:"error"`
@@ -68,7 +66,7 @@ func (s *Server) HandleCreateJob(w http.ResponseWriter, r *http.Request) {
 	// Parse request body
 	var req CreateJobRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		s.sendError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("Invalid request body: %v", err))
+		s.sendDecodeError(w, err)
 		return
 	}
 
@@ -77,32 +75,75 @@ func (s *Server) HandleCreateJob(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	s.submitJob(w, r, req.Spec)
+}
+
+// submitJob normalizes and validates spec, stores it as a new pending job
+// and queues it, writing the CreateJobResponse or error to w
+func (s *Server) submitJob(w http.ResponseWriter, r *http.Request, spec *schemas.JobSpec) {
+	for _, warning := range spec.Normalize() {
+		log.Printf("Job spec: %s", warning)
+	}
+
 	// Validate JobSpec
-	if err := s.validator.Validate(req.Spec); err != nil {
+	if err := s.validator.Validate(spec); err != nil {
 		s.sendError(w, http.StatusBadRequest, "validation_error", fmt.Sprintf("Invalid job specification: %v", err))
 		return
 	}
 
+	// Jobs submitted by an authenticated user are owned by that user
+	if userID, ok := auth.GetUserID(r); ok && userID != "" {
+		spec.UserID = userID
+	}
+
+	ctx := r.Context()
+
 	// Generate job ID
 	jobID := fmt.Sprintf("job_%d", time.Now().UnixNano())
 
+	// Reject jobs depending on a cycle up front: they could never run
+	if err := s.processor.CheckDependencyCycle(ctx, jobID, spec.DependsOn); err != nil {
+		s.sendError(w, http.StatusBadRequest, "dependency_cycle", err.Error())
+		return
+	}
+
 	// Create job in store
 	job := &store.Job{
 		JobID:   jobID,
 		Created: time.Now(),
 		Updated: time.Now(),
 		Status:  schemas.JobStatePending,
-		Spec:    req.Spec,
-	}
-
-	ctx := r.Context()
-	if err := s.store.CreateJob(ctx, job); err != nil {
+		Spec:    spec,
+	}
+
+	// Enforce the per-user concurrent job limit as part of the insert, so
+	// concurrent submissions cannot both pass the check
+	var activeFilter *store.ListFilter
+	limit := 0
+	if s.MaxConcurrentJobsPerUser > 0 && spec.UserID != "" {
+		activeFilter = &store.ListFilter{
+			UserID: spec.UserID,
+			Status: activeJobStates,
+		}
+		limit = s.MaxConcurrentJobsPerUser
+	}
+
+	if err := s.store.CreateJobWithLimit(ctx, job, activeFilter, limit); err != nil {
+		if errors.Is(err, store.ErrJobLimitReached) {
+			s.sendError(w, http.StatusTooManyRequests, "too_many_jobs",
+				fmt.Sprintf("User %s already has the maximum of %d active jobs", spec.UserID, limit))
+			return
+		}
 		s.sendError(w, http.StatusInternalServerError, "store_error", fmt.Sprintf("Failed to create job: %v", err))
 		return
 	}
 
-	// Start job processing in background
-	go s.processJob(context.Background(), jobID)
+	// Queue job for the worker pool, shedding load when the queue is full
+	if err := s.processor.Enqueue(jobID); err != nil {
+		s.store.DeleteJob(ctx, jobID)
+		s.sendError(w, http.StatusServiceUnavailable, "queue_full", "Job queue is full, retry later")
+		return
+	}
 
 	// Send response
 	resp := CreateJobResponse{
@@ -114,6 +155,121 @@ func (s *Server) HandleCreateJob(w http.ResponseWriter, r *http.Request) {
 	s.sendJSON(w, http.StatusCreated, resp)
 }
 
+// CloneJobRequest represents the optional request body for cloning a job
+type CloneJobRequest struct {
+	// Overrides are merged into the source job's spec: objects are merged
+	// key by key, arrays element by element (so {"outputs": [{"destination":
+	// "..."}]} changes only the first output's destination) and null removes
+	// a field
+	Overrides map[string]interface{} `json:"overrides,omitempty"`
+}
+
+// HandleCloneJob handles POST /api/v1/jobs/{id}/clone, creating a new job
+// from the spec of an existing one
+func (s *Server) HandleCloneJob(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+		return
+	}
+
+	jobID, ok := strings.CutSuffix(extractJobID(r.URL.Path), "/clone")
+	if !ok || jobID == "" {
+		s.sendError(w, http.StatusBadRequest, "invalid_job_id", "Job ID is required")
+		return
+	}
+
+	// The body is optional
+	var req CloneJobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		s.sendDecodeError(w, err)
+		return
+	}
+
+	source, err := s.store.GetJob(r.Context(), jobID, requestUserID(r))
+	if err == store.ErrJobNotFound {
+		s.sendError(w, http.StatusNotFound, "job_not_found", fmt.Sprintf("Job %s not found", jobID))
+		return
+	}
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, "store_error", fmt.Sprintf("Failed to get job: %v", err))
+		return
+	}
+	if source.Spec == nil {
+		s.sendError(w, http.StatusConflict, "missing_spec", fmt.Sprintf("Job %s has no specification to clone", jobID))
+		return
+	}
+
+	spec, err := cloneSpec(source.Spec, req.Overrides)
+	if err != nil {
+		s.sendError(w, http.StatusBadRequest, "invalid_overrides", fmt.Sprintf("Invalid overrides: %v", err))
+		return
+	}
+
+	s.submitJob(w, r, spec)
+}
+
+// cloneSpec returns a deep copy of spec with overrides merged in. The copy
+// gets a new identity: job ID and creation time are cleared.
+func cloneSpec(spec *schemas.JobSpec, overrides map[string]interface{}) (*schemas.JobSpec, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(overrides) > 0 {
+		doc = mergeJSON(doc, overrides)
+	}
+	if data, err = json.Marshal(doc); err != nil {
+		return nil, err
+	}
+
+	var clone schemas.JobSpec
+	if err := json.Unmarshal(data, &clone); err != nil {
+		return nil, err
+	}
+	clone.JobID = ""
+	clone.CreatedAt = time.Time{}
+	return &clone, nil
+}
+
+// mergeJSON merges the decoded JSON value patch into base
+func mergeJSON(base, patch interface{}) interface{} {
+	switch p := patch.(type) {
+	case map[string]interface{}:
+		b, ok := base.(map[string]interface{})
+		if !ok {
+			b = make(map[string]interface{})
+		}
+		for key, value := range p {
+			if value == nil {
+				delete(b, key)
+				continue
+			}
+			b[key] = mergeJSON(b[key], value)
+		}
+		return b
+	case []interface{}:
+		b, ok := base.([]interface{})
+		if !ok {
+			return p
+		}
+		for i, value := range p {
+			if i < len(b) {
+				b[i] = mergeJSON(b[i], value)
+			} else {
+				b = append(b, value)
+			}
+		}
+		return b
+	default:
+		return patch
+	}
+}
+
 // HandleGetJob handles GET /api/v1/jobs/{id}
 func (s *Server) HandleGetJob(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -130,7 +286,7 @@ func (s *Server) HandleGetJob(w http.ResponseWriter, r *http.Request) {
 
 	// Get job from store
 	ctx := r.Context()
-	job, err := s.store.GetJob(ctx, jobID)
+	job, err := s.store.GetJob(ctx, jobID, requestUserID(r))
 	if err == store.ErrJobNotFound {
 		s.sendError(w, http.StatusNotFound, "job_not_found", fmt.Sprintf("Job %s not found", jobID))
 		return
@@ -153,6 +309,7 @@ func (s *Server) HandleListJobs(w http.ResponseWriter, r *http.Request) {
 
 	// Parse query parameters
 	filter := s.parseListFilter(r)
+	filter.UserID = requestUserID(r)
 
 	// List jobs from store
 	ctx := r.Context()
@@ -162,13 +319,24 @@ func (s *Server) HandleListJobs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	total, err := s.store.CountJobs(ctx, filter)
+	if err != nil {
+		s.sendError(w, http.StatusInternalServerError, "store_error", fmt.Sprintf("Failed to count jobs: %v", err))
+		return
+	}
+
 	// Convert to JobStatus array
 	statuses := make([]*schemas.JobStatus, len(jobs))
 	for i, job := range jobs {
 		statuses[i] = job.ToJobStatus()
 	}
 
-	s.sendJSON(w, http.StatusOK, statuses)
+	s.sendJSON(w, http.StatusOK, &ListJobsResponse{
+		Items:  statuses,
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	})
 }
 
 // HandleDeleteJob handles DELETE /api/v1/jobs/{id}
@@ -188,7 +356,7 @@ func (s *Server) HandleDeleteJob(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	// Get job to check if it exists and can be cancelled
-	job, err := s.store.GetJob(ctx, jobID)
+	job, err := s.store.GetJob(ctx, jobID, requestUserID(r))
 	if err == store.ErrJobNotFound {
 		s.sendError(w, http.StatusNotFound, "job_not_found", fmt.Sprintf("Job %s not found", jobID))
 		return
@@ -214,95 +382,115 @@ func (s *Server) HandleDeleteJob(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// HandleHealth handles GET /health
-func (s *Server) HandleHealth(w http.ResponseWriter, r *http.Request) {
+// HandleListOperators handles GET /api/v1/operators, optionally filtered
+// with ?category=
+func (s *Server) HandleListOperators(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		s.sendError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
 		return
 	}
 
-	health := map[string]interface{}{
-		"status": "healthy",
-		"time":   time.Now(),
+	var ops []operators.Operator
+	if category := r.URL.Query().Get("category"); category != "" {
+		if !s.isKnownCategory(operators.Category(category)) {
+			s.sendError(w, http.StatusBadRequest, "invalid_category", fmt.Sprintf("Unknown operator category: %s", category))
+			return
+		}
+		ops = s.registry.ListByCategory(operators.Category(category))
+	} else {
+		ops = s.registry.List()
 	}
+	sort.Slice(ops, func(i, j int) bool {
+		return ops[i].Name() < ops[j].Name()
+	})
 
-	s.sendJSON(w, http.StatusOK, health)
+	descriptors := make([]*operators.OperatorDescriptor, len(ops))
+	for i, op := range ops {
+		descriptors[i] = op.Describe()
+	}
+
+	s.sendJSON(w, http.StatusOK, descriptors)
 }
 
-// processJob processes a job in the background
-func (s *Server) processJob(ctx context.Context, jobID string) {
-	// Get job from store
-	job, err := s.store.GetJob(ctx, jobID)
-	if err != nil {
+// OperatorCategory is a category with the number of operators registered in it
+type OperatorCategory struct {
+	Name  operators.Category `json:"name"`
+	Count int                `json:"count"`
+}
+
+// HandleListOperatorCategories handles GET /api/v1/operators/categories.
+// Built-in categories are listed first, then any custom ones in name order.
+func (s *Server) HandleListOperatorCategories(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
 		return
 	}
 
-	// Update status to validating
-	s.store.UpdateJobStatus(ctx, jobID, schemas.JobStateValidating, &schemas.Progress{
-		OverallPercent: 10,
-		CurrentStep:    "validating",
-	})
+	counts := s.registry.CountByCategory()
 
-	// TODO: Validate JobSpec
+	categories := []OperatorCategory{}
+	for _, category := range operators.Categories() {
+		categories = append(categories, OperatorCategory{Name: category, Count: counts[category]})
+		delete(counts, category)
+	}
 
-	// Update status to planning
-	s.store.UpdateJobStatus(ctx, jobID, schemas.JobStatePlanning, &schemas.Progress{
-		OverallPercent: 20,
-		CurrentStep:    "planning",
+	custom := make([]OperatorCategory, 0, len(counts))
+	for category, count := range counts {
+		custom = append(custom, OperatorCategory{Name: category, Count: count})
+	}
+	sort.Slice(custom, func(i, j int) bool {
+		return custom[i].Name < custom[j].Name
 	})
 
-	// Create processing plan
-	plan, err := s.planner.Plan(ctx, job.Spec, nil)
-	if err != nil {
-		s.store.UpdateJobError(ctx, jobID, &schemas.ErrorInfo{
-			Code:      "PLANNING_ERROR",
-			Message:   fmt.Sprintf("Failed to create plan: %v", err),
-			Retryable: false,
-		})
-		s.store.UpdateJobStatus(ctx, jobID, schemas.JobStateFailed, nil)
-		return
+	s.sendJSON(w, http.StatusOK, append(categories, custom...))
+}
+
+// isKnownCategory reports whether category is built in or used by a
+// registered operator
+func (s *Server) isKnownCategory(category operators.Category) bool {
+	for _, known := range operators.Categories() {
+		if category == known {
+			return true
+		}
 	}
+	return s.registry.CountByCategory()[category] > 0
+}
 
-	// Save plan
-	job.Plan = plan
-	s.store.UpdateJob(ctx, job)
+// HandleGetOperator handles GET /api/v1/operators/{name}
+func (s *Server) HandleGetOperator(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+		return
+	}
 
-	// Update status to processing
-	s.store.UpdateJobStatus(ctx, jobID, schemas.JobStateProcessing, &schemas.Progress{
-		OverallPercent: 50,
-		CurrentStep:    "processing",
-	})
+	name := extractOperatorName(r.URL.Path)
+	if name == "" {
+		s.sendError(w, http.StatusBadRequest, "invalid_operator", "Operator name is required")
+		return
+	}
 
-	// Execute plan
-	execOpts := &executor.ExecuteOptions{
-		OnProgress: func(progress *executor.Progress) {
-			// Update progress in store (simple progress based on frame count)
-			percent := 50.0 + (float64(progress.Frame) / 1000.0) // Simplified progress
-			if percent > 90 {
-				percent = 90 // Cap at 90% until completion
-			}
-			s.store.UpdateJobStatus(ctx, jobID, schemas.JobStateProcessing, &schemas.Progress{
-				OverallPercent: percent,
-				CurrentStep:    "processing",
-			})
-		},
+	op, err := s.registry.Get(name)
+	if err != nil {
+		s.sendError(w, http.StatusNotFound, "operator_not_found", fmt.Sprintf("Operator %s not found", name))
+		return
 	}
 
-	if err := s.executor.Execute(ctx, plan, execOpts); err != nil {
-		s.store.UpdateJobError(ctx, jobID, &schemas.ErrorInfo{
-			Code:      "EXECUTION_ERROR",
-			Message:   fmt.Sprintf("Failed to execute: %v", err),
-			Retryable: true,
-		})
-		s.store.UpdateJobStatus(ctx, jobID, schemas.JobStateFailed, nil)
+	s.sendJSON(w, http.StatusOK, op.Describe())
+}
+
+// HandleHealth handles GET /health
+func (s *Server) HandleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
 		return
 	}
 
-	// Update status to completed
-	s.store.UpdateJobStatus(ctx, jobID, schemas.JobStateCompleted, &schemas.Progress{
-		OverallPercent: 100,
-		CurrentStep:    "completed",
-	})
+	health := map[string]interface{}{
+		"status": "healthy",
+		"time":   time.Now(),
+	}
+
+	s.sendJSON(w, http.StatusOK, health)
 }
 
 // Helper methods
@@ -322,6 +510,18 @@ func (s *Server) sendError(w http.ResponseWriter, status int, code, message stri
 	s.sendJSON(w, status, resp)
 }
 
+// sendDecodeError reports a request body that could not be decoded, using
+// 413 if it was cut off by MaxBodySizeMiddleware
+func (s *Server) sendDecodeError(w http.ResponseWriter, err error) {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		s.sendError(w, http.StatusRequestEntityTooLarge, "request_too_large",
+			fmt.Sprintf("Request body exceeds %d bytes", maxErr.Limit))
+		return
+	}
+	s.sendError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("Invalid request body: %v", err))
+}
+
 func (s *Server) parseListFilter(r *http.Request) *store.ListFilter {
 	q := r.URL.Query()
 	filter := &store.ListFilter{}
@@ -331,6 +531,18 @@ func (s *Server) parseListFilter(r *http.Request) *store.ListFilter {
 		filter.Status = []schemas.JobState{schemas.JobState(statusStr)}
 	}
 
+	// Parse tag filters (?tag.key=value)
+	for key, values := range q {
+		name, ok := strings.CutPrefix(key, "tag.")
+		if !ok || name == "" || len(values) == 0 {
+			continue
+		}
+		if filter.Tags == nil {
+			filter.Tags = make(map[string]string)
+		}
+		filter.Tags[name] = values[0]
+	}
+
 	// Parse limit and offset
 	if limitStr := q.Get("limit"); limitStr != "" {
 		var limit int
@@ -346,6 +558,12 @@ func (s *Server) parseListFilter(r *http.Request) *store.ListFilter {
 	return filter
 }
 
+// requestUserID returns the authenticated user ID, or "" for anonymous requests
+func requestUserID(r *http.Request) string {
+	userID, _ := auth.GetUserID(r)
+	return userID
+}
+
 // extractJobID extracts job ID from URL path like "/api/v1/jobs/{id}"
 func extractJobID(path string) string {
 	// Simple extraction: assume path is /api/v1/jobs/{id}
@@ -356,6 +574,15 @@ func extractJobID(path string) string {
 	return path[len(prefix):]
 }
 
+// extractOperatorName extracts the operator name from "/api/v1/operators/{name}"
+func extractOperatorName(path string) string {
+	const prefix = "/api/v1/operators/"
+	if len(path) <= len(prefix) {
+		return ""
+	}
+	return path[len(prefix):]
+}
+
 // Close closes the server and releases resources
 func (s *Server) Close() error {
 	if s.store != nil {
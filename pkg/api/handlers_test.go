@@ -2,12 +2,21 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/chicogong/media-pipeline/pkg/auth"
+	"github.com/chicogong/media-pipeline/pkg/operators"
+	"github.com/chicogong/media-pipeline/pkg/operators/builtin"
 	"github.com/chicogong/media-pipeline/pkg/schemas"
 	"github.com/chicogong/media-pipeline/pkg/store"
 )
@@ -16,7 +25,7 @@ func TestHandleHealth(t *testing.T) {
 	s := store.NewMemoryStore()
 	defer s.Close()
 
-	server := NewServer(s)
+	server := NewServerWithOptions(s)
 	defer server.Close()
 
 	req := httptest.NewRequest(http.MethodGet, "/health", nil)
@@ -42,7 +51,7 @@ func TestHandleCreateJob(t *testing.T) {
 	s := store.NewMemoryStore()
 	defer s.Close()
 
-	server := NewServer(s)
+	server := NewServerWithOptions(s)
 	defer server.Close()
 
 	// Create request body
@@ -86,7 +95,7 @@ func TestHandleCreateJob(t *testing.T) {
 	time.Sleep(100 * time.Millisecond)
 
 	// Verify job was created in store
-	job, err := s.GetJob(req.Context(), resp.JobID)
+	job, err := s.GetJob(req.Context(), resp.JobID, "")
 	if err != nil {
 		t.Fatalf("Failed to get job from store: %v", err)
 	}
@@ -95,11 +104,192 @@ func TestHandleCreateJob(t *testing.T) {
 	}
 }
 
+func TestHandleCreateJobStringDurations(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	server := NewServerWithOptions(s)
+	defer server.Close()
+
+	body := `{
+		"spec": {
+			"timeout": "2m30s",
+			"inputs": [{"id": "input1", "source": "file://test.mp4", "start_offset": "00:00:10"}],
+			"operations": [{"op": "trim", "input": "input1", "output": "trimmed"}],
+			"outputs": [{"id": "trimmed", "destination": "file://output.mp4"}]
+		}
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/jobs", bytes.NewReader([]byte(body)))
+	w := httptest.NewRecorder()
+
+	server.HandleCreateJob(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp CreateJobResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	job, err := s.GetJob(req.Context(), resp.JobID, "")
+	if err != nil {
+		t.Fatalf("Failed to get job from store: %v", err)
+	}
+	if job.Spec.Timeout == nil || job.Spec.Timeout.Duration != 150*time.Second {
+		t.Errorf("Expected timeout 2m30s, got %v", job.Spec.Timeout)
+	}
+	if job.Spec.Inputs[0].StartOffset == nil || job.Spec.Inputs[0].StartOffset.Duration != 10*time.Second {
+		t.Errorf("Expected start offset 10s, got %v", job.Spec.Inputs[0].StartOffset)
+	}
+}
+
+func TestHandleCreateJobNormalizesSpec(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	server := NewServerWithOptions(s)
+	defer server.Close()
+
+	body := `{"spec": {
+		"priority": -1,
+		"inputs": [{"id": "input1 ", "source": " file://test.mp4"}],
+		"operations": [{"op": "Trim", "input": "input1", "output": "trimmed"}],
+		"outputs": [{"id": "trimmed", "destination": "file://output.mp4 "}]
+	}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/jobs", bytes.NewReader([]byte(body)))
+	w := httptest.NewRecorder()
+
+	server.HandleCreateJob(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp CreateJobResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	job, err := s.GetJob(req.Context(), resp.JobID, "")
+	if err != nil {
+		t.Fatalf("Failed to get job from store: %v", err)
+	}
+	if op := job.Spec.Operations[0]; op.Op != "trim" || op.Params == nil {
+		t.Errorf("Expected normalized operation, got %+v", op)
+	}
+	if job.Spec.Inputs[0].ID != "input1" || job.Spec.Outputs[0].Destination != "file://output.mp4" {
+		t.Errorf("Expected trimmed IDs and URIs, got %+v %+v", job.Spec.Inputs[0], job.Spec.Outputs[0])
+	}
+	if job.Spec.Priority != 0 {
+		t.Errorf("Expected priority 0, got %d", job.Spec.Priority)
+	}
+}
+
+func TestMaxBodySizeMiddleware(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	server := NewServerWithOptions(s)
+	defer server.Close()
+
+	handler := Chain(server.HandleCreateJob, MaxBodySizeMiddleware(1<<20))
+
+	// A 10 MB spec, padded with a long source URI
+	reqBody := CreateJobRequest{
+		Spec: &schemas.JobSpec{
+			Inputs: []schemas.Input{
+				{ID: "input1", Source: "file://" + strings.Repeat("a", 10<<20)},
+			},
+		},
+	}
+	body, _ := json.Marshal(reqBody)
+
+	// Rejected up front when the length is declared
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/jobs", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	handler(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status 413, got %d", w.Code)
+	}
+
+	// Cut off while decoding when it is not
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/jobs", io.MultiReader(bytes.NewReader(body)))
+	req.ContentLength = -1
+	w = httptest.NewRecorder()
+	handler(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status 413 for a body without a length, got %d", w.Code)
+	}
+
+	var resp ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if resp.Error != "request_too_large" {
+		t.Errorf("Expected error request_too_large, got %s", resp.Error)
+	}
+
+	if jobs, _ := s.ListJobs(context.Background(), nil); len(jobs) != 0 {
+		t.Errorf("Expected no jobs to be created, got %d", len(jobs))
+	}
+
+	// Small bodies pass through
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/jobs", strings.NewReader(`{}`))
+	w = httptest.NewRecorder()
+	handler(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a missing spec, got %d", w.Code)
+	}
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	handlerDone := make(chan struct{})
+	slow := func(w http.ResponseWriter, r *http.Request) {
+		defer close(handlerDone)
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("late"))
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/jobs", nil)
+	w := httptest.NewRecorder()
+	Chain(slow, TimeoutMiddleware(20*time.Millisecond))(w, req)
+	<-handlerDone
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", w.Code)
+	}
+
+	var resp ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if resp.Error != "request_timeout" {
+		t.Errorf("Expected error request_timeout, got %s", resp.Error)
+	}
+
+	// Fast handlers respond normally
+	fast := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Test", "yes")
+		w.WriteHeader(http.StatusCreated)
+	}
+	w = httptest.NewRecorder()
+	Chain(fast, TimeoutMiddleware(time.Second))(w, req)
+	if w.Code != http.StatusCreated || w.Header().Get("X-Test") != "yes" {
+		t.Errorf("Expected status 201 with headers, got %d %v", w.Code, w.Header())
+	}
+}
+
 func TestHandleCreateJobInvalidRequest(t *testing.T) {
 	s := store.NewMemoryStore()
 	defer s.Close()
 
-	server := NewServer(s)
+	server := NewServerWithOptions(s)
 	defer server.Close()
 
 	// Send invalid JSON
@@ -117,7 +307,7 @@ func TestHandleGetJob(t *testing.T) {
 	s := store.NewMemoryStore()
 	defer s.Close()
 
-	server := NewServer(s)
+	server := NewServerWithOptions(s)
 	defer server.Close()
 
 	// Create a test job
@@ -160,7 +350,7 @@ func TestHandleGetJobNotFound(t *testing.T) {
 	s := store.NewMemoryStore()
 	defer s.Close()
 
-	server := NewServer(s)
+	server := NewServerWithOptions(s)
 	defer server.Close()
 
 	req := httptest.NewRequest(http.MethodGet, "/api/v1/jobs/nonexistent", nil)
@@ -173,27 +363,31 @@ func TestHandleGetJobNotFound(t *testing.T) {
 	}
 }
 
+// loadFixtureStore returns a memory store populated from testdata/jobs.json:
+// one completed, failed, processing and pending job each
+func loadFixtureStore(t *testing.T) *store.MemoryStore {
+	t.Helper()
+
+	f, err := os.Open(filepath.Join("testdata", "jobs.json"))
+	if err != nil {
+		t.Fatalf("Failed to open fixture: %v", err)
+	}
+	defer f.Close()
+
+	s, err := store.ImportFromJSON(f)
+	if err != nil {
+		t.Fatalf("Failed to load fixture: %v", err)
+	}
+	return s
+}
+
 func TestHandleListJobs(t *testing.T) {
-	s := store.NewMemoryStore()
+	s := loadFixtureStore(t)
 	defer s.Close()
 
-	server := NewServer(s)
+	server := NewServerWithOptions(s)
 	defer server.Close()
 
-	// Create test jobs
-	for i := 0; i < 3; i++ {
-		job := &store.Job{
-			JobID:   "list-job-" + string(rune(i+'0')),
-			Created: time.Now(),
-			Updated: time.Now(),
-			Status:  schemas.JobStatePending,
-			Spec:    &schemas.JobSpec{},
-		}
-		if err := s.CreateJob(nil, job); err != nil {
-			t.Fatalf("Failed to create test job: %v", err)
-		}
-	}
-
 	// List jobs via API
 	req := httptest.NewRequest(http.MethodGet, "/api/v1/jobs", nil)
 	w := httptest.NewRecorder()
@@ -204,43 +398,74 @@ func TestHandleListJobs(t *testing.T) {
 		t.Errorf("Expected status 200, got %d", w.Code)
 	}
 
-	var resp []*schemas.JobStatus
+	var resp ListJobsResponse
 	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
 		t.Fatalf("Failed to parse response: %v", err)
 	}
 
-	if len(resp) != 3 {
-		t.Errorf("Expected 3 jobs, got %d", len(resp))
+	if len(resp.Items) != 4 || resp.Total != 4 {
+		t.Fatalf("Expected 4 of 4 jobs, got %d of %d", len(resp.Items), resp.Total)
+	}
+
+	// Newest first by default
+	if resp.Items[0].JobID != "job-pending" || resp.Items[3].JobID != "job-completed" {
+		t.Errorf("Expected jobs newest first, got %s ... %s", resp.Items[0].JobID, resp.Items[3].JobID)
 	}
 }
 
-func TestHandleListJobsWithFilter(t *testing.T) {
-	s := store.NewMemoryStore()
+func TestHandleListJobsPagination(t *testing.T) {
+	s := loadFixtureStore(t)
 	defer s.Close()
 
-	server := NewServer(s)
+	server := NewServerWithOptions(s)
 	defer server.Close()
 
-	// Create jobs with different statuses
-	statuses := []schemas.JobState{
-		schemas.JobStatePending,
-		schemas.JobStateProcessing,
-		schemas.JobStateCompleted,
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/jobs?limit=2&offset=1", nil)
+	w := httptest.NewRecorder()
+
+	server.HandleListJobs(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
 	}
 
-	for i, status := range statuses {
-		job := &store.Job{
-			JobID:   "filter-job-" + string(rune(i+'0')),
-			Created: time.Now(),
-			Updated: time.Now(),
-			Status:  status,
-			Spec:    &schemas.JobSpec{},
-		}
-		if err := s.CreateJob(nil, job); err != nil {
-			t.Fatalf("Failed to create test job: %v", err)
-		}
+	var resp ListJobsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	if resp.Total != 4 {
+		t.Errorf("Expected total 4, got %d", resp.Total)
+	}
+	if resp.Limit != 2 || resp.Offset != 1 {
+		t.Errorf("Expected limit 2 and offset 1, got %d and %d", resp.Limit, resp.Offset)
+	}
+	if len(resp.Items) != 2 {
+		t.Fatalf("Expected 2 jobs on the page, got %d", len(resp.Items))
+	}
+	if resp.Items[0].JobID != "job-processing" || resp.Items[1].JobID != "job-failed" {
+		t.Errorf("Expected job-processing and job-failed, got %s and %s", resp.Items[0].JobID, resp.Items[1].JobID)
 	}
 
+	// Status filters apply to the total as well
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/jobs?status=completed&limit=1", nil)
+	w = httptest.NewRecorder()
+	server.HandleListJobs(w, req)
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if resp.Total != 1 || len(resp.Items) != 1 {
+		t.Errorf("Expected 1 of 1 completed jobs, got %d of %d", len(resp.Items), resp.Total)
+	}
+}
+
+func TestHandleListJobsWithFilter(t *testing.T) {
+	s := loadFixtureStore(t)
+	defer s.Close()
+
+	server := NewServerWithOptions(s)
+	defer server.Close()
+
 	// Filter for pending jobs only
 	req := httptest.NewRequest(http.MethodGet, "/api/v1/jobs?status=pending", nil)
 	w := httptest.NewRecorder()
@@ -251,16 +476,67 @@ func TestHandleListJobsWithFilter(t *testing.T) {
 		t.Errorf("Expected status 200, got %d", w.Code)
 	}
 
-	var resp []*schemas.JobStatus
+	var resp ListJobsResponse
 	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
 		t.Fatalf("Failed to parse response: %v", err)
 	}
 
-	if len(resp) != 1 {
-		t.Errorf("Expected 1 pending job, got %d", len(resp))
+	if len(resp.Items) != 1 || resp.Total != 1 {
+		t.Errorf("Expected 1 of 1 pending jobs, got %d of %d", len(resp.Items), resp.Total)
+	}
+	if len(resp.Items) > 0 && resp.Items[0].Status != schemas.JobStatePending {
+		t.Errorf("Expected pending status, got %s", resp.Items[0].Status)
+	}
+}
+
+func TestHandleListJobsWithTagFilter(t *testing.T) {
+	s := loadFixtureStore(t)
+	defer s.Close()
+
+	server := NewServerWithOptions(s)
+	defer server.Close()
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{query: "tag.project=promo", want: []string{"job-pending", "job-processing"}},
+		{query: "tag.project=promo&tag.env=prod", want: []string{"job-pending"}},
+		{query: "tag.project=missing", want: nil},
 	}
-	if len(resp) > 0 && resp[0].Status != schemas.JobStatePending {
-		t.Errorf("Expected pending status, got %s", resp[0].Status)
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/jobs?"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			server.HandleListJobs(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d", w.Code)
+			}
+
+			var resp ListJobsResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+
+			got := make(map[string]bool)
+			for _, status := range resp.Items {
+				got[status.JobID] = true
+				if status.Tags["project"] == "" {
+					t.Errorf("Expected tags in status for %s", status.JobID)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %d jobs, got %d", len(tt.want), len(resp.Items))
+			}
+			for _, jobID := range tt.want {
+				if !got[jobID] {
+					t.Errorf("Expected %s in results", jobID)
+				}
+			}
+		})
 	}
 }
 
@@ -268,7 +544,7 @@ func TestHandleDeleteJob(t *testing.T) {
 	s := store.NewMemoryStore()
 	defer s.Close()
 
-	server := NewServer(s)
+	server := NewServerWithOptions(s)
 	defer server.Close()
 
 	// Create a test job
@@ -295,7 +571,7 @@ func TestHandleDeleteJob(t *testing.T) {
 	}
 
 	// Verify job status was updated to cancelled
-	updated, err := s.GetJob(nil, job.JobID)
+	updated, err := s.GetJob(nil, job.JobID, "")
 	if err != nil {
 		t.Fatalf("Failed to get job: %v", err)
 	}
@@ -308,7 +584,7 @@ func TestHandleDeleteJobTerminal(t *testing.T) {
 	s := store.NewMemoryStore()
 	defer s.Close()
 
-	server := NewServer(s)
+	server := NewServerWithOptions(s)
 	defer server.Close()
 
 	// Create a completed job
@@ -334,3 +610,598 @@ func TestHandleDeleteJobTerminal(t *testing.T) {
 		t.Errorf("Expected status 400, got %d", w.Code)
 	}
 }
+
+// withUser returns a copy of r authenticated as userID
+func withUser(r *http.Request, userID string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), auth.UserIDKey, userID))
+}
+
+func TestCrossUserAccessBlocked(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	server := NewServerWithOptions(s)
+	defer server.Close()
+
+	job := &store.Job{
+		JobID:   "alice-job",
+		Created: time.Now(),
+		Updated: time.Now(),
+		Status:  schemas.JobStateProcessing,
+		Spec:    &schemas.JobSpec{UserID: "alice"},
+	}
+	if err := s.CreateJob(nil, job); err != nil {
+		t.Fatalf("Failed to create test job: %v", err)
+	}
+
+	// Another user cannot read the job
+	req := withUser(httptest.NewRequest(http.MethodGet, "/api/v1/jobs/alice-job", nil), "bob")
+	w := httptest.NewRecorder()
+	server.HandleGetJob(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("GET as other user: expected status 404, got %d", w.Code)
+	}
+
+	// Another user cannot cancel the job
+	req = withUser(httptest.NewRequest(http.MethodDelete, "/api/v1/jobs/alice-job", nil), "bob")
+	w = httptest.NewRecorder()
+	server.HandleDeleteJob(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("DELETE as other user: expected status 404, got %d", w.Code)
+	}
+
+	// Another user does not see the job in listings
+	req = withUser(httptest.NewRequest(http.MethodGet, "/api/v1/jobs", nil), "bob")
+	w = httptest.NewRecorder()
+	server.HandleListJobs(w, req)
+	var resp ListJobsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if len(resp.Items) != 0 || resp.Total != 0 {
+		t.Errorf("Expected 0 jobs for other user, got %d of %d", len(resp.Items), resp.Total)
+	}
+
+	// The owner still has access
+	req = withUser(httptest.NewRequest(http.MethodGet, "/api/v1/jobs/alice-job", nil), "alice")
+	w = httptest.NewRecorder()
+	server.HandleGetJob(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("GET as owner: expected status 200, got %d", w.Code)
+	}
+
+	updated, err := s.GetJob(nil, job.JobID, "")
+	if err != nil {
+		t.Fatalf("Failed to get job: %v", err)
+	}
+	if updated.Status != schemas.JobStateProcessing {
+		t.Errorf("Expected job to remain processing, got %s", updated.Status)
+	}
+}
+
+func TestHandleCreateJobConcurrentLimit(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	server := NewServerWithOptions(s, WithMaxConcurrentJobsPerUser(2))
+	defer server.Close()
+
+	// Seed the user's active jobs directly so background processing
+	// cannot move them to a terminal state mid-test
+	for i := 0; i < server.MaxConcurrentJobsPerUser; i++ {
+		job := &store.Job{
+			JobID:   "active-job-" + string(rune(i+'0')),
+			Created: time.Now(),
+			Updated: time.Now(),
+			Status:  schemas.JobStateProcessing,
+			Spec:    &schemas.JobSpec{UserID: "alice"},
+		}
+		if err := s.CreateJob(nil, job); err != nil {
+			t.Fatalf("Failed to create test job: %v", err)
+		}
+	}
+
+	reqBody := CreateJobRequest{
+		Spec: &schemas.JobSpec{
+			Inputs: []schemas.Input{
+				{ID: "input1", Source: "file://test.mp4"},
+			},
+			Operations: []schemas.Operation{
+				{Op: "trim", Input: "input1", Output: "trimmed"},
+			},
+			Outputs: []schemas.Output{
+				{ID: "trimmed", Destination: "file://output.mp4"},
+			},
+		},
+	}
+	body, _ := json.Marshal(reqBody)
+
+	req := withUser(httptest.NewRequest(http.MethodPost, "/api/v1/jobs", bytes.NewReader(body)), "alice")
+	w := httptest.NewRecorder()
+	server.HandleCreateJob(w, req)
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429, got %d", w.Code)
+	}
+
+	var resp ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if resp.Error != "too_many_jobs" {
+		t.Errorf("Expected error 'too_many_jobs', got %s", resp.Error)
+	}
+
+	// A different user is unaffected
+	req = withUser(httptest.NewRequest(http.MethodPost, "/api/v1/jobs", bytes.NewReader(body)), "bob")
+	w = httptest.NewRecorder()
+	server.HandleCreateJob(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status 201 for other user, got %d", w.Code)
+	}
+}
+
+// barrierStore holds every ListJobs result until all expected callers have
+// listed, so a limit checked before creating a job would let them all through
+type barrierStore struct {
+	*store.MemoryStore
+	listed sync.WaitGroup
+}
+
+func (s *barrierStore) ListJobs(ctx context.Context, filter *store.ListFilter) ([]*store.Job, error) {
+	jobs, err := s.MemoryStore.ListJobs(ctx, filter)
+	s.listed.Done()
+	s.listed.Wait()
+	return jobs, err
+}
+
+func TestHandleCreateJobConcurrentLimitRace(t *testing.T) {
+	const submissions = 20
+	s := &barrierStore{MemoryStore: store.NewMemoryStore()}
+	s.listed.Add(submissions)
+	defer s.Close()
+
+	server := NewServerWithOptions(s, WithMaxConcurrentJobsPerUser(3))
+	defer server.Close()
+
+	reqBody := CreateJobRequest{
+		Spec: &schemas.JobSpec{
+			Inputs: []schemas.Input{
+				{ID: "input1", Source: "file://test.mp4"},
+			},
+			Operations: []schemas.Operation{
+				{Op: "trim", Input: "input1", Output: "trimmed"},
+			},
+			Outputs: []schemas.Output{
+				{ID: "trimmed", Destination: "file://output.mp4"},
+			},
+		},
+	}
+	body, _ := json.Marshal(reqBody)
+
+	// Submissions racing each other must not get past the limit together
+	codes := make(chan int, submissions)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < submissions; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			req := withUser(httptest.NewRequest(http.MethodPost, "/api/v1/jobs", bytes.NewReader(body)), "alice")
+			w := httptest.NewRecorder()
+			server.HandleCreateJob(w, req)
+			codes <- w.Code
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(codes)
+
+	created := 0
+	for code := range codes {
+		switch code {
+		case http.StatusCreated:
+			created++
+		case http.StatusTooManyRequests:
+		default:
+			t.Errorf("Unexpected status %d", code)
+		}
+	}
+	if created != 3 {
+		t.Errorf("Expected 3 jobs created, got %d", created)
+	}
+	if count, _ := s.CountJobs(nil, &store.ListFilter{UserID: "alice"}); count != 3 {
+		t.Errorf("Expected 3 stored jobs for alice, got %d", count)
+	}
+}
+
+func TestHandleCreateJobDependencyCycle(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	server := NewServerWithOptions(s)
+	defer server.Close()
+
+	// cycle-a and cycle-b depend on each other, so neither can ever run
+	for _, job := range []*store.Job{
+		{JobID: "cycle-a", Status: schemas.JobStatePending, Spec: &schemas.JobSpec{DependsOn: []string{"cycle-b"}}},
+		{JobID: "cycle-b", Status: schemas.JobStatePending, Spec: &schemas.JobSpec{DependsOn: []string{"cycle-a"}}},
+	} {
+		if err := s.CreateJob(nil, job); err != nil {
+			t.Fatalf("Failed to create test job: %v", err)
+		}
+	}
+
+	reqBody := CreateJobRequest{
+		Spec: &schemas.JobSpec{
+			DependsOn: []string{"cycle-a"},
+			Inputs: []schemas.Input{
+				{ID: "input1", Source: "file://test.mp4"},
+			},
+			Operations: []schemas.Operation{
+				{Op: "trim", Input: "input1", Output: "trimmed"},
+			},
+			Outputs: []schemas.Output{
+				{ID: "trimmed", Destination: "file://output.mp4"},
+			},
+		},
+	}
+	body, _ := json.Marshal(reqBody)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/jobs", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	server.HandleCreateJob(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if resp.Error != "dependency_cycle" {
+		t.Errorf("Expected error 'dependency_cycle', got %s", resp.Error)
+	}
+
+	if count, _ := s.CountJobs(nil, &store.ListFilter{}); count != 2 {
+		t.Errorf("Expected the rejected job not to be stored, got %d jobs", count)
+	}
+}
+
+func TestHandleListOperators(t *testing.T) {
+	operators.Register(&builtin.TrimOperator{})
+	operators.Register(&builtin.ScaleOperator{})
+
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	server := NewServerWithOptions(s)
+	defer server.Close()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/operators", nil)
+	w := httptest.NewRecorder()
+
+	server.HandleListOperators(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var resp []operators.OperatorDescriptor
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	byName := make(map[string]operators.OperatorDescriptor)
+	for _, desc := range resp {
+		byName[desc.Name] = desc
+	}
+
+	trim, ok := byName["trim"]
+	if !ok {
+		t.Fatal("Expected trim operator in response")
+	}
+	if trim.Category != operators.CategoryTimeline {
+		t.Errorf("Expected trim category timeline, got %s", trim.Category)
+	}
+	if !hasParameter(trim, "start", operators.TypeTimecode) {
+		t.Errorf("Expected trim 'start' timecode parameter, got %+v", trim.Parameters)
+	}
+	if !hasParameter(trim, "duration", operators.TypeDuration) {
+		t.Errorf("Expected trim 'duration' parameter, got %+v", trim.Parameters)
+	}
+
+	scale, ok := byName["scale"]
+	if !ok {
+		t.Fatal("Expected scale operator in response")
+	}
+	if scale.Category != operators.CategoryVideo {
+		t.Errorf("Expected scale category video, got %s", scale.Category)
+	}
+	if len(scale.Parameters) == 0 {
+		t.Error("Expected scale to describe its parameters")
+	}
+}
+
+// volumeOperator is an audio operator for category filtering tests
+type volumeOperator struct {
+	builtin.ScaleOperator
+}
+
+func (o *volumeOperator) Name() string { return "volume" }
+
+func (o *volumeOperator) Category() operators.Category { return operators.CategoryAudio }
+
+func (o *volumeOperator) Describe() *operators.OperatorDescriptor {
+	desc := o.ScaleOperator.Describe()
+	desc.Name = o.Name()
+	desc.Category = o.Category()
+	return desc
+}
+
+func TestHandleListOperatorsByCategory(t *testing.T) {
+	operators.Register(&builtin.TrimOperator{})
+	operators.Register(&builtin.ScaleOperator{})
+	operators.Register(&volumeOperator{})
+
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	server := NewServerWithOptions(s)
+	defer server.Close()
+
+	for _, tt := range []struct {
+		category operators.Category
+		want     string
+	}{
+		{operators.CategoryAudio, "volume"},
+		{operators.CategoryTimeline, "trim"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/operators?category="+string(tt.category), nil)
+		w := httptest.NewRecorder()
+
+		server.HandleListOperators(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+
+		var resp []operators.OperatorDescriptor
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+
+		found := false
+		for _, desc := range resp {
+			if desc.Category != tt.category {
+				t.Errorf("category=%s: unexpected %s operator %s", tt.category, desc.Category, desc.Name)
+			}
+			if desc.Name == tt.want {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("category=%s: expected %s in response, got %+v", tt.category, tt.want, resp)
+		}
+	}
+
+	// Unknown categories are rejected
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/operators?category=bogus", nil)
+	w := httptest.NewRecorder()
+
+	server.HandleListOperators(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for unknown category, got %d", w.Code)
+	}
+}
+
+func TestHandleListOperatorCategories(t *testing.T) {
+	operators.Register(&builtin.TrimOperator{})
+	operators.Register(&volumeOperator{})
+
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	server := NewServerWithOptions(s)
+	defer server.Close()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/operators/categories", nil)
+	w := httptest.NewRecorder()
+
+	server.HandleListOperatorCategories(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var resp []OperatorCategory
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	counts := make(map[operators.Category]int)
+	for _, c := range resp {
+		counts[c.Name] = c.Count
+	}
+	for _, category := range operators.Categories() {
+		if _, ok := counts[category]; !ok {
+			t.Errorf("Expected category %s to be listed", category)
+		}
+	}
+	if counts[operators.CategoryAudio] < 1 || counts[operators.CategoryTimeline] < 1 {
+		t.Errorf("Expected audio and timeline operators to be counted, got %v", counts)
+	}
+}
+
+func TestHandleGetOperator(t *testing.T) {
+	operators.Register(&builtin.ScaleOperator{})
+
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	server := NewServerWithOptions(s)
+	defer server.Close()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/operators/scale", nil)
+	w := httptest.NewRecorder()
+
+	server.HandleGetOperator(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var desc operators.OperatorDescriptor
+	if err := json.Unmarshal(w.Body.Bytes(), &desc); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if desc.Name != "scale" {
+		t.Errorf("Expected scale descriptor, got %s", desc.Name)
+	}
+
+	// Validation rules are part of the full descriptor
+	for _, p := range desc.Parameters {
+		if p.Name != "width" {
+			continue
+		}
+		if p.Validation == nil || p.Validation.Max == nil || *p.Validation.Max != 7680 {
+			t.Errorf("Expected width validation max 7680, got %+v", p.Validation)
+		}
+	}
+}
+
+func TestHandleGetOperatorNotFound(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	server := NewServerWithOptions(s)
+	defer server.Close()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/operators/nonexistent", nil)
+	w := httptest.NewRecorder()
+
+	server.HandleGetOperator(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
+
+// hasParameter reports whether desc declares a parameter with the given name and type
+func hasParameter(desc operators.OperatorDescriptor, name string, typ operators.ParameterType) bool {
+	for _, p := range desc.Parameters {
+		if p.Name == name && p.Type == typ {
+			return true
+		}
+	}
+	return false
+}
+
+func TestHandleCloneJob(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	server := NewServerWithOptions(s)
+	defer server.Close()
+
+	source := &store.Job{
+		JobID:   "job_source",
+		Created: time.Now(),
+		Updated: time.Now(),
+		Status:  schemas.JobStateCompleted,
+		Spec: &schemas.JobSpec{
+			JobID: "job_source",
+			Tags:  map[string]string{"project": "demo"},
+			Inputs: []schemas.Input{
+				{ID: "input1", Source: "file://test.mp4"},
+			},
+			Operations: []schemas.Operation{
+				{Op: "trim", Input: "input1", Output: "trimmed",
+					Params: map[string]interface{}{"start": "00:00:05"}},
+				{Op: "scale", Input: "trimmed", Output: "scaled",
+					Params: map[string]interface{}{"width": float64(1280), "height": float64(720)}},
+			},
+			Outputs: []schemas.Output{
+				{ID: "scaled", Destination: "file://output.mp4", Format: "mp4"},
+			},
+		},
+	}
+	if err := s.CreateJob(context.Background(), source); err != nil {
+		t.Fatalf("Failed to create source job: %v", err)
+	}
+
+	body := `{"overrides": {"outputs": [{"destination": "file://output-720p.mp4"}], "tags": {"project": null}}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/jobs/job_source/clone", bytes.NewReader([]byte(body)))
+	w := httptest.NewRecorder()
+
+	server.HandleCloneJob(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp CreateJobResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if resp.JobID == "" || resp.JobID == source.JobID {
+		t.Fatalf("Expected a new job ID, got %q", resp.JobID)
+	}
+	if resp.Status != string(schemas.JobStatePending) {
+		t.Errorf("Expected status pending, got %s", resp.Status)
+	}
+
+	clone, err := s.GetJob(context.Background(), resp.JobID, "")
+	if err != nil {
+		t.Fatalf("Failed to get cloned job: %v", err)
+	}
+
+	// Operations are inherited unchanged
+	if len(clone.Spec.Operations) != 2 {
+		t.Fatalf("Expected 2 operations, got %d", len(clone.Spec.Operations))
+	}
+	if op := clone.Spec.Operations[1]; op.Op != "scale" || op.Params["width"] != float64(1280) {
+		t.Errorf("Expected inherited scale operation, got %+v", op)
+	}
+
+	// Only the overridden output field changes
+	output := clone.Spec.Outputs[0]
+	if output.Destination != "file://output-720p.mp4" {
+		t.Errorf("Expected overridden destination, got %s", output.Destination)
+	}
+	if output.ID != "scaled" || output.Format != "mp4" {
+		t.Errorf("Expected other output fields to be kept, got %+v", output)
+	}
+	if len(clone.Spec.Tags) != 0 {
+		t.Errorf("Expected null override to remove tags, got %v", clone.Spec.Tags)
+	}
+	if clone.Spec.JobID != "" {
+		t.Errorf("Expected cloned spec to drop the source job ID, got %s", clone.Spec.JobID)
+	}
+
+	// The source spec is not modified
+	if source.Spec.Outputs[0].Destination != "file://output.mp4" {
+		t.Errorf("Expected source spec to be unchanged, got %s", source.Spec.Outputs[0].Destination)
+	}
+}
+
+func TestHandleCloneJobNotFound(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	server := NewServerWithOptions(s)
+	defer server.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/jobs/missing/clone", nil)
+	w := httptest.NewRecorder()
+
+	server.HandleCloneJob(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
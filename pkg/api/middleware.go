@@ -1,11 +1,21 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"sync"
 	"time"
 )
 
+// DefaultMaxBodySize is the default request body limit for write routes
+const DefaultMaxBodySize = 1 << 20 // 1 MB
+
+// DefaultRequestTimeout is the default time a request may take
+const DefaultRequestTimeout = 30 * time.Second
+
 // LoggingMiddleware logs HTTP requests
 func LoggingMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -63,6 +73,145 @@ func RecoveryMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// MaxBodySizeMiddleware limits request bodies to maxBytes. A request whose
+// Content-Length exceeds the limit is rejected with 413 before the handler
+// runs; reading past the limit of a body without a declared length fails
+// with an *http.MaxBytesError.
+func MaxBodySizeMiddleware(maxBytes int64) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > maxBytes {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusRequestEntityTooLarge)
+				json.NewEncoder(w).Encode(ErrorResponse{
+					Error:   "request_too_large",
+					Message: fmt.Sprintf("Request body exceeds %d bytes", maxBytes),
+					Code:    http.StatusRequestEntityTooLarge,
+				})
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			next(w, r)
+		}
+	}
+}
+
+// TimeoutMiddleware cancels a request's context after d. If the handler has
+// not responded by then, the client gets 503 and anything the handler writes
+// afterwards is discarded.
+func TimeoutMiddleware(d time.Duration) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+
+			tw := &timeoutWriter{w: w, header: make(http.Header), ctx: ctx}
+			done := make(chan struct{})
+			panicked := make(chan interface{}, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				next(tw, r.WithContext(ctx))
+				close(done)
+			}()
+
+			select {
+			case <-done:
+				// A handler that returned after the deadline had its
+				// writes dropped
+				if ctx.Err() == context.DeadlineExceeded {
+					tw.timeout(d)
+				}
+			case p := <-panicked:
+				// Re-raise where RecoveryMiddleware can see it
+				panic(p)
+			case <-ctx.Done():
+				if ctx.Err() != context.DeadlineExceeded {
+					return // The client went away
+				}
+				tw.timeout(d)
+			}
+		}
+	}
+}
+
+// timeoutWriter is the ResponseWriter handed to a handler under
+// TimeoutMiddleware. Headers are kept apart from the real writer's until
+// the handler writes its status, which happens at most once. Writes made
+// once the deadline has passed are dropped.
+type timeoutWriter struct {
+	w      http.ResponseWriter
+	header http.Header
+	ctx    context.Context
+
+	mu       sync.Mutex
+	once     sync.Once
+	timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if !tw.expired() {
+		tw.writeHeader(code)
+	}
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.expired() {
+		return 0, http.ErrHandlerTimeout
+	}
+	tw.writeHeader(http.StatusOK)
+	return tw.w.Write(b)
+}
+
+// expired reports whether the handler may no longer write; must be called
+// with tw.mu held
+func (tw *timeoutWriter) expired() bool {
+	return tw.timedOut || tw.ctx.Err() == context.DeadlineExceeded
+}
+
+// writeHeader sends the handler's headers and status the first time it is
+// called; must be called with tw.mu held
+func (tw *timeoutWriter) writeHeader(code int) {
+	tw.once.Do(func() {
+		dst := tw.w.Header()
+		for k, v := range tw.header {
+			dst[k] = v
+		}
+		tw.w.WriteHeader(code)
+	})
+}
+
+// timeout responds with 503 unless the handler already wrote a status
+func (tw *timeoutWriter) timeout(d time.Duration) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	tw.timedOut = true
+	tw.once.Do(func() {
+		tw.w.Header().Set("Content-Type", "application/json")
+		tw.w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(tw.w).Encode(ErrorResponse{
+			Error:   "request_timeout",
+			Message: fmt.Sprintf("Request did not complete within %v", d),
+			Code:    http.StatusServiceUnavailable,
+		})
+	})
+}
+
 // Chain combines multiple middlewares
 func Chain(handler http.HandlerFunc, middlewares ...func(http.HandlerFunc) http.HandlerFunc) http.HandlerFunc {
 	// Apply middlewares in reverse order
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/chicogong/media-pipeline/pkg/store"
+	"github.com/chicogong/media-pipeline/pkg/worker"
+)
+
+const (
+	// DefaultPoolSize is the default number of jobs processed concurrently
+	DefaultPoolSize = 4
+
+	// DefaultQueueSize is the number of submitted jobs that may wait for a
+	// free worker before submissions are rejected
+	DefaultQueueSize = 100
+)
+
+// ErrQueueFull is returned by Enqueue when no more jobs can be accepted
+var ErrQueueFull = errors.New("job queue is full")
+
+// Processor runs submitted jobs on a fixed-size pool of goroutines.
+// Jobs wait in a bounded queue until a worker is free.
+type Processor struct {
+	*worker.Processor
+
+	poolSize int
+	queue    chan string
+}
+
+// NewProcessor creates a processor that runs up to poolSize jobs at once
+// with exec. A nil exec uses the default executor.
+func NewProcessor(s store.Store, exec worker.JobExecutor, poolSize int, opts ...worker.ProcessorOption) *Processor {
+	if poolSize < 1 {
+		poolSize = 1
+	}
+
+	p := &Processor{
+		Processor: worker.NewProcessor(s, exec, opts...),
+		poolSize:  poolSize,
+		queue:     make(chan string, DefaultQueueSize),
+	}
+
+	// Jobs waiting on dependencies go back in the queue and free their worker
+	p.Requeue = p.Enqueue
+	return p
+}
+
+// Enqueue submits a job for processing without blocking.
+// Returns ErrQueueFull if the queue has no free capacity.
+func (p *Processor) Enqueue(jobID string) error {
+	select {
+	case p.queue <- jobID:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// QueueLen returns the number of jobs waiting for a worker
+func (p *Processor) QueueLen() int {
+	return len(p.queue)
+}
+
+// Run processes queued jobs with poolSize goroutines until ctx is cancelled.
+// In-flight jobs are allowed to finish before Run returns; jobs still in the
+// queue stay pending in the store.
+func (p *Processor) Run(ctx context.Context) error {
+	// In-flight jobs are drained rather than aborted on shutdown
+	jobCtx := context.WithoutCancel(ctx)
+
+	var wg sync.WaitGroup
+	for i := 0; i < p.poolSize; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case jobID := <-p.queue:
+					p.Process(jobCtx, jobID)
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	return ctx.Err()
+}
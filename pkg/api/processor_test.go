@@ -0,0 +1,235 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chicogong/media-pipeline/pkg/executor"
+	"github.com/chicogong/media-pipeline/pkg/schemas"
+	"github.com/chicogong/media-pipeline/pkg/store"
+)
+
+// gatedExecutor blocks every execution until release is closed and tracks
+// how many executions run at once
+type gatedExecutor struct {
+	release chan struct{}
+
+	mu      sync.Mutex
+	running int
+	peak    int
+	total   int
+}
+
+func (e *gatedExecutor) Execute(ctx context.Context, plan *schemas.ProcessingPlan, opts *executor.ExecuteOptions) (executor.ExecutionResult, error) {
+	e.mu.Lock()
+	e.running++
+	e.total++
+	if e.running > e.peak {
+		e.peak = e.running
+	}
+	e.mu.Unlock()
+
+	<-e.release
+
+	e.mu.Lock()
+	e.running--
+	e.mu.Unlock()
+	return executor.ExecutionResult{}, nil
+}
+
+func (e *gatedExecutor) stats() (running, peak, total int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.running, e.peak, e.total
+}
+
+func testJob(jobID string) *store.Job {
+	return &store.Job{
+		JobID:   jobID,
+		Created: time.Now(),
+		Updated: time.Now(),
+		Status:  schemas.JobStatePending,
+		Spec: &schemas.JobSpec{
+			Inputs: []schemas.Input{
+				{ID: "input1", Source: "file://test.mp4"},
+			},
+			Operations: []schemas.Operation{
+				{Op: "trim", Input: "input1", Output: "trimmed"},
+			},
+			Outputs: []schemas.Output{
+				{ID: "trimmed", Destination: "file://output.mp4"},
+			},
+		},
+	}
+}
+
+func TestProcessorPoolSaturation(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	exec := &gatedExecutor{release: make(chan struct{})}
+	p := NewProcessor(s, exec, 2)
+
+	const jobCount = 5
+	for i := 0; i < jobCount; i++ {
+		jobID := fmt.Sprintf("pool-job-%d", i)
+		if err := s.CreateJob(context.Background(), testJob(jobID)); err != nil {
+			t.Fatalf("Failed to create test job: %v", err)
+		}
+		if err := p.Enqueue(jobID); err != nil {
+			t.Fatalf("Enqueue() failed: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- p.Run(ctx)
+	}()
+
+	// The pool fills up and the remaining jobs wait in the queue
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		running, _, _ := exec.stats()
+		if running == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected 2 running jobs, got %d", running)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if _, peak, _ := exec.stats(); peak != 2 {
+		t.Errorf("Expected at most 2 concurrent jobs, got %d", peak)
+	}
+	if p.QueueLen() != jobCount-2 {
+		t.Errorf("Expected %d queued jobs, got %d", jobCount-2, p.QueueLen())
+	}
+
+	close(exec.release)
+
+	for {
+		_, _, total := exec.stats()
+		if total == jobCount && p.QueueLen() == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d executions, got %d", jobCount, total)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run() did not return after shutdown")
+	}
+
+	if _, peak, _ := exec.stats(); peak != 2 {
+		t.Errorf("Expected at most 2 concurrent jobs, got %d", peak)
+	}
+}
+
+func TestProcessorDependentsDoNotHoldWorkers(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	exec := &gatedExecutor{release: make(chan struct{})}
+	close(exec.release)
+	p := NewProcessor(s, exec, 1)
+	p.DependencyPollInterval = 5 * time.Millisecond
+
+	// The dependent is queued first; waiting in the only worker would stop
+	// its dependency from ever running
+	upstream := testJob("upstream")
+	downstream := testJob("downstream")
+	downstream.Spec.DependsOn = []string{"upstream"}
+	for _, job := range []*store.Job{downstream, upstream} {
+		if err := s.CreateJob(context.Background(), job); err != nil {
+			t.Fatalf("Failed to create test job: %v", err)
+		}
+		if err := p.Enqueue(job.JobID); err != nil {
+			t.Fatalf("Enqueue() failed: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go p.Run(ctx)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, err := s.GetJob(ctx, "downstream", "")
+		if err != nil {
+			t.Fatalf("Failed to get job: %v", err)
+		}
+		if job.Status == schemas.JobStateCompleted {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected downstream to complete, got %s", job.Status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestProcessorEnqueueQueueFull(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	p := NewProcessor(s, nil, 1)
+
+	for i := 0; i < DefaultQueueSize; i++ {
+		if err := p.Enqueue(fmt.Sprintf("job-%d", i)); err != nil {
+			t.Fatalf("Enqueue() %d failed: %v", i, err)
+		}
+	}
+
+	if err := p.Enqueue("one-too-many"); err != ErrQueueFull {
+		t.Errorf("Expected ErrQueueFull, got %v", err)
+	}
+}
+
+func TestHandleCreateJobQueueFull(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	server := NewServerWithOptions(s)
+	defer server.Close()
+
+	// Fill the queue without a running pool to drain it
+	for i := 0; i < DefaultQueueSize; i++ {
+		if err := server.Processor().Enqueue(fmt.Sprintf("job-%d", i)); err != nil {
+			t.Fatalf("Enqueue() failed: %v", err)
+		}
+	}
+
+	body, _ := json.Marshal(CreateJobRequest{Spec: testJob("").Spec})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/jobs", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+
+	server.HandleCreateJob(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status 503, got %d", w.Code)
+	}
+
+	// The rejected job must not linger in the store
+	jobs, err := s.ListJobs(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("ListJobs() failed: %v", err)
+	}
+	if len(jobs) != 0 {
+		t.Errorf("Expected no stored jobs, got %d", len(jobs))
+	}
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/chicogong/media-pipeline/pkg/compiler/validator"
+	"github.com/chicogong/media-pipeline/pkg/executor"
+	"github.com/chicogong/media-pipeline/pkg/operators"
+	"github.com/chicogong/media-pipeline/pkg/planner"
+	"github.com/chicogong/media-pipeline/pkg/prober"
+	"github.com/chicogong/media-pipeline/pkg/store"
+	"github.com/chicogong/media-pipeline/pkg/worker"
+)
+
+// Server holds the API server dependencies
+type Server struct {
+	store     store.Store
+	registry  *operators.Registry
+	prober    *prober.Prober
+	processor *Processor
+	validator *validator.Validator
+
+	// MaxConcurrentJobsPerUser caps the number of non-terminal jobs a single
+	// user may have at once (0 = unlimited)
+	MaxConcurrentJobsPerUser int
+}
+
+// serverOptions collects the dependencies and settings a Server is built from
+type serverOptions struct {
+	prober            *prober.Prober
+	planner           *planner.Planner
+	executor          worker.JobExecutor
+	validator         *validator.Validator
+	storageConfig     executor.StorageConfig
+	maxRetries        int
+	jobTimeout        time.Duration
+	maxConcurrentJobs int
+	maxJobsPerUser    int
+	webhookSecret     string
+}
+
+// ServerOption is a functional option for NewServerWithOptions
+type ServerOption func(*serverOptions)
+
+// WithProber probes job inputs with p
+func WithProber(p *prober.Prober) ServerOption {
+	return func(o *serverOptions) {
+		o.prober = p
+	}
+}
+
+// WithPlanner plans jobs with p instead of a planner backed by the global
+// operator registry
+func WithPlanner(p *planner.Planner) ServerOption {
+	return func(o *serverOptions) {
+		o.planner = p
+	}
+}
+
+// WithExecutor runs job plans with exec. It takes precedence over
+// WithStorageConfig, which only configures the default executor.
+func WithExecutor(exec worker.JobExecutor) ServerOption {
+	return func(o *serverOptions) {
+		o.executor = exec
+	}
+}
+
+// WithValidator validates submitted job specs with v
+func WithValidator(v *validator.Validator) ServerOption {
+	return func(o *serverOptions) {
+		o.validator = v
+	}
+}
+
+// WithStorageConfig makes the default executor transfer inputs and outputs
+// according to cfg
+func WithStorageConfig(cfg executor.StorageConfig) ServerOption {
+	return func(o *serverOptions) {
+		o.storageConfig = cfg
+	}
+}
+
+// WithMaxRetries sets how many times a job that failed with a retryable
+// error is re-run automatically (default 0)
+func WithMaxRetries(n int) ServerOption {
+	return func(o *serverOptions) {
+		o.maxRetries = n
+	}
+}
+
+// WithJobTimeout limits jobs whose spec sets no timeout (default none)
+func WithJobTimeout(d time.Duration) ServerOption {
+	return func(o *serverOptions) {
+		o.jobTimeout = d
+	}
+}
+
+// WithMaxConcurrentJobs sets how many jobs are processed at once
+// (default DefaultPoolSize)
+func WithMaxConcurrentJobs(n int) ServerOption {
+	return func(o *serverOptions) {
+		o.maxConcurrentJobs = n
+	}
+}
+
+// WithMaxConcurrentJobsPerUser sets Server.MaxConcurrentJobsPerUser
+func WithMaxConcurrentJobsPerUser(n int) ServerOption {
+	return func(o *serverOptions) {
+		o.maxJobsPerUser = n
+	}
+}
+
+// WithWebhookSecret signs the webhooks sent for jobs with secret
+func WithWebhookSecret(secret string) ServerOption {
+	return func(o *serverOptions) {
+		o.webhookSecret = secret
+	}
+}
+
+// NewServerWithOptions creates a new API server. Dependencies not set by
+// opts default to ones backed by the global operator registry.
+func NewServerWithOptions(s store.Store, opts ...ServerOption) *Server {
+	registry := operators.GlobalRegistry()
+
+	o := &serverOptions{
+		maxConcurrentJobs: DefaultPoolSize,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if o.prober == nil {
+		o.prober = prober.NewProber()
+	}
+	if o.validator == nil {
+		o.validator = &validator.Validator{}
+	}
+	if o.executor == nil {
+		o.executor = executor.NewExecutorWithStorageConfig(registry, o.storageConfig)
+	}
+
+	workerOpts := []worker.ProcessorOption{worker.WithProber(o.prober)}
+	if o.planner != nil {
+		workerOpts = append(workerOpts, worker.WithPlanner(o.planner))
+	}
+	processor := NewProcessor(s, o.executor, o.maxConcurrentJobs, workerOpts...)
+	processor.MaxAutoRetries = o.maxRetries
+	processor.DefaultJobTimeout = o.jobTimeout
+	processor.WebhookSecret = o.webhookSecret
+
+	return &Server{
+		store:     s,
+		registry:  registry,
+		prober:    o.prober,
+		processor: processor,
+		validator: o.validator,
+
+		MaxConcurrentJobsPerUser: o.maxJobsPerUser,
+	}
+}
+
+// Processor returns the processor that runs submitted jobs in the background
+func (s *Server) Processor() *Processor {
+	return s.processor
+}
+
+// ListenAndProcessJobs processes submitted jobs on the server's worker pool
+// until ctx is cancelled. Jobs are only run while this is running.
+func (s *Server) ListenAndProcessJobs(ctx context.Context) error {
+	return s.processor.Run(ctx)
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chicogong/media-pipeline/pkg/compiler/validator"
+	"github.com/chicogong/media-pipeline/pkg/operators"
+	"github.com/chicogong/media-pipeline/pkg/operators/builtin"
+	"github.com/chicogong/media-pipeline/pkg/planner"
+	"github.com/chicogong/media-pipeline/pkg/schemas"
+	"github.com/chicogong/media-pipeline/pkg/store"
+)
+
+func TestNewServerWithOptions(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	registry := operators.NewRegistry()
+	registry.Register(&builtin.TrimOperator{})
+
+	exec := &gatedExecutor{release: make(chan struct{})}
+	close(exec.release)
+	v := &validator.Validator{}
+
+	server := NewServerWithOptions(s,
+		WithExecutor(exec),
+		WithPlanner(planner.NewPlannerWithRegistry(registry)),
+		WithValidator(v),
+		WithMaxRetries(3),
+		WithJobTimeout(time.Minute),
+		WithMaxConcurrentJobs(1),
+		WithMaxConcurrentJobsPerUser(2),
+		WithWebhookSecret("secret"),
+	)
+	defer server.Close()
+
+	p := server.Processor()
+	if p.poolSize != 1 {
+		t.Errorf("Expected pool size 1, got %d", p.poolSize)
+	}
+	if p.MaxAutoRetries != 3 {
+		t.Errorf("Expected 3 retries, got %d", p.MaxAutoRetries)
+	}
+	if p.DefaultJobTimeout != time.Minute {
+		t.Errorf("Expected a 1m job timeout, got %v", p.DefaultJobTimeout)
+	}
+	if p.WebhookSecret != "secret" {
+		t.Errorf("Expected the webhook secret to be set, got %q", p.WebhookSecret)
+	}
+	if server.MaxConcurrentJobsPerUser != 2 {
+		t.Errorf("Expected 2 jobs per user, got %d", server.MaxConcurrentJobsPerUser)
+	}
+	if server.validator != v {
+		t.Error("Expected the given validator to be used")
+	}
+
+	// Jobs run on the given executor
+	job := testJob("options-job")
+	if err := s.CreateJob(context.Background(), job); err != nil {
+		t.Fatalf("Failed to create test job: %v", err)
+	}
+	p.Process(context.Background(), job.JobID)
+
+	if _, _, total := exec.stats(); total != 1 {
+		t.Errorf("Expected 1 execution, got %d", total)
+	}
+	updated, err := s.GetJob(context.Background(), job.JobID, "")
+	if err != nil {
+		t.Fatalf("Failed to get job: %v", err)
+	}
+	if updated.Status != schemas.JobStateCompleted {
+		t.Errorf("Expected status completed, got %s", updated.Status)
+	}
+}
+
+func TestNewServerWithOptionsDefaults(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	server := NewServerWithOptions(s)
+	defer server.Close()
+
+	if server.Processor().poolSize != DefaultPoolSize {
+		t.Errorf("Expected pool size %d, got %d", DefaultPoolSize, server.Processor().poolSize)
+	}
+	if server.prober == nil || server.validator == nil {
+		t.Error("Expected default prober and validator")
+	}
+}
@@ -3,11 +3,21 @@ package auth
 import (
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 )
 
+// DefaultRotationGracePeriod is how long a rotated key stays valid
+// alongside its replacement
+const DefaultRotationGracePeriod = 24 * time.Hour
+
+// ErrKeyRotated is returned when verifying a rotated key whose grace
+// period has ended, or when rotating a key that was already rotated
+var ErrKeyRotated = errors.New("API key has been rotated")
+
 // APIKey represents an API key
 type APIKey struct {
 	Key       string    `json:"key"`
@@ -16,36 +26,45 @@ type APIKey struct {
 	CreatedAt time.Time `json:"created_at"`
 	ExpiresAt *time.Time `json:"expires_at,omitempty"`
 	Revoked   bool      `json:"revoked"`
+
+	// Set when the key is replaced by RotateKey; the key stays valid until
+	// GraceUntil
+	RotatedAt  *time.Time `json:"rotated_at,omitempty"`
+	GraceUntil *time.Time `json:"grace_until,omitempty"`
 }
 
 // APIKeyManager manages API keys
 type APIKeyManager struct {
 	keys map[string]*APIKey // key -> APIKey
 	mu   sync.RWMutex
+
+	// RotationGracePeriod is how long RotateKey leaves the old key valid
+	RotationGracePeriod time.Duration
+
+	now func() time.Time
 }
 
 // NewAPIKeyManager creates a new API key manager
 func NewAPIKeyManager() *APIKeyManager {
 	return &APIKeyManager{
-		keys: make(map[string]*APIKey),
+		keys:                make(map[string]*APIKey),
+		RotationGracePeriod: DefaultRotationGracePeriod,
+		now:                 time.Now,
 	}
 }
 
 // Generate creates a new API key
 func (m *APIKeyManager) Generate(userID, name string, expiresAt *time.Time) (*APIKey, error) {
-	// Generate random 32-byte key
-	keyBytes := make([]byte, 32)
-	if _, err := rand.Read(keyBytes); err != nil {
-		return nil, fmt.Errorf("failed to generate random key: %w", err)
+	key, err := newAPIKey()
+	if err != nil {
+		return nil, err
 	}
 
-	key := "sk_" + base64.URLEncoding.EncodeToString(keyBytes)
-
 	apiKey := &APIKey{
 		Key:       key,
 		UserID:    userID,
 		Name:      name,
-		CreatedAt: time.Now(),
+		CreatedAt: m.now(),
 		ExpiresAt: expiresAt,
 		Revoked:   false,
 	}
@@ -71,13 +90,80 @@ func (m *APIKeyManager) Verify(key string) (*APIKey, error) {
 		return nil, fmt.Errorf("API key has been revoked")
 	}
 
-	if apiKey.ExpiresAt != nil && time.Now().After(*apiKey.ExpiresAt) {
+	if apiKey.ExpiresAt != nil && m.now().After(*apiKey.ExpiresAt) {
 		return nil, fmt.Errorf("API key has expired")
 	}
 
+	if apiKey.GraceUntil != nil && m.now().After(*apiKey.GraceUntil) {
+		return nil, ErrKeyRotated
+	}
+
+	return apiKey, nil
+}
+
+// RotateKey replaces oldKey with a new key for the same user and name.
+// Both keys are valid during the grace period, after which Verify rejects
+// the old key with ErrKeyRotated.
+func (m *APIKeyManager) RotateKey(oldKey string) (*APIKey, error) {
+	key, err := newAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	old, exists := m.keys[oldKey]
+	if !exists {
+		return nil, fmt.Errorf("API key not found")
+	}
+	if old.Revoked {
+		return nil, fmt.Errorf("API key has been revoked")
+	}
+	if old.RotatedAt != nil {
+		return nil, ErrKeyRotated
+	}
+
+	now := m.now()
+	graceUntil := now.Add(m.RotationGracePeriod)
+	old.RotatedAt = &now
+	old.GraceUntil = &graceUntil
+
+	apiKey := &APIKey{
+		Key:       key,
+		UserID:    old.UserID,
+		Name:      old.Name,
+		CreatedAt: now,
+		ExpiresAt: old.ExpiresAt,
+	}
+	m.keys[key] = apiKey
+
 	return apiKey, nil
 }
 
+// ListExpiredKeys returns the keys that can no longer be used because they
+// expired or their rotation grace period ended, oldest first. Cleanup jobs
+// can Delete them.
+func (m *APIKeyManager) ListExpiredKeys() []*APIKey {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := m.now()
+	var keys []*APIKey
+	for _, apiKey := range m.keys {
+		expired := apiKey.ExpiresAt != nil && now.After(*apiKey.ExpiresAt)
+		rotated := apiKey.GraceUntil != nil && now.After(*apiKey.GraceUntil)
+		if expired || rotated {
+			keys = append(keys, apiKey)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+	return keys
+}
+
 // Revoke marks an API key as revoked
 func (m *APIKeyManager) Revoke(key string) error {
 	m.mu.Lock()
@@ -134,3 +220,13 @@ func (m *APIKeyManager) Count() int {
 
 	return count
 }
+
+// newAPIKey generates a random 32-byte key
+func newAPIKey() (string, error) {
+	keyBytes := make([]byte, 32)
+	if _, err := rand.Read(keyBytes); err != nil {
+		return "", fmt.Errorf("failed to generate random key: %w", err)
+	}
+
+	return "sk_" + base64.URLEncoding.EncodeToString(keyBytes), nil
+}
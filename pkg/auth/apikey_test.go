@@ -159,3 +159,105 @@ func TestAPIKeyManager_Count(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, 1, manager.Count()) // Count should decrease
 }
+
+// fakeClock is a settable time source for APIKeyManager
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func TestAPIKeyManager_RotateKey(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	manager := NewAPIKeyManager()
+	manager.now = clock.Now
+
+	oldKey, err := manager.Generate("user123", "CI Key", nil)
+	require.NoError(t, err)
+
+	newKey, err := manager.RotateKey(oldKey.Key)
+	require.NoError(t, err)
+	assert.NotEqual(t, oldKey.Key, newKey.Key)
+	assert.Equal(t, "user123", newKey.UserID)
+	assert.Equal(t, "CI Key", newKey.Name)
+	require.NotNil(t, oldKey.RotatedAt)
+	assert.Equal(t, clock.now, *oldKey.RotatedAt)
+	assert.Nil(t, newKey.RotatedAt)
+
+	// Both keys are valid during the grace period
+	clock.now = clock.now.Add(DefaultRotationGracePeriod - time.Minute)
+	_, err = manager.Verify(oldKey.Key)
+	assert.NoError(t, err)
+	_, err = manager.Verify(newKey.Key)
+	assert.NoError(t, err)
+	assert.Empty(t, manager.ListExpiredKeys())
+
+	// Afterwards only the new key is
+	clock.now = clock.now.Add(2 * time.Minute)
+	_, err = manager.Verify(oldKey.Key)
+	assert.ErrorIs(t, err, ErrKeyRotated)
+	_, err = manager.Verify(newKey.Key)
+	assert.NoError(t, err)
+
+	expired := manager.ListExpiredKeys()
+	require.Len(t, expired, 1)
+	assert.Equal(t, oldKey.Key, expired[0].Key)
+}
+
+func TestAPIKeyManager_RotateKey_CustomGracePeriod(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	manager := NewAPIKeyManager()
+	manager.now = clock.Now
+	manager.RotationGracePeriod = time.Hour
+
+	oldKey, err := manager.Generate("user123", "Test Key", nil)
+	require.NoError(t, err)
+	_, err = manager.RotateKey(oldKey.Key)
+	require.NoError(t, err)
+
+	clock.now = clock.now.Add(61 * time.Minute)
+	_, err = manager.Verify(oldKey.Key)
+	assert.ErrorIs(t, err, ErrKeyRotated)
+}
+
+func TestAPIKeyManager_RotateKey_Errors(t *testing.T) {
+	manager := NewAPIKeyManager()
+
+	_, err := manager.RotateKey("invalid-key")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+
+	revoked, err := manager.Generate("user123", "Revoked Key", nil)
+	require.NoError(t, err)
+	require.NoError(t, manager.Revoke(revoked.Key))
+	_, err = manager.RotateKey(revoked.Key)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "revoked")
+
+	// A key can only be rotated once
+	apiKey, err := manager.Generate("user123", "Test Key", nil)
+	require.NoError(t, err)
+	_, err = manager.RotateKey(apiKey.Key)
+	require.NoError(t, err)
+	_, err = manager.RotateKey(apiKey.Key)
+	assert.ErrorIs(t, err, ErrKeyRotated)
+}
+
+func TestAPIKeyManager_ListExpiredKeys(t *testing.T) {
+	manager := NewAPIKeyManager()
+
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	expired, err := manager.Generate("user123", "Expired Key", &past)
+	require.NoError(t, err)
+	_, err = manager.Generate("user123", "Valid Key", &future)
+	require.NoError(t, err)
+	_, err = manager.Generate("user123", "Forever Key", nil)
+	require.NoError(t, err)
+
+	keys := manager.ListExpiredKeys()
+	require.Len(t, keys, 1)
+	assert.Equal(t, expired.Key, keys[0].Key)
+}
@@ -56,6 +56,10 @@ func (v *Validator) Validate(spec *schemas.JobSpec) error {
 		if !storage.IsAllowedScheme(scheme) {
 			return fmt.Errorf("output %d (%s): scheme '%s' not allowed", i, output.ID, scheme)
 		}
+
+		if scheme == "data" {
+			return fmt.Errorf("output %d (%s): data: URIs are read-only", i, output.ID)
+		}
 	}
 
 	// Use JobSpec's built-in validation for dependency checking
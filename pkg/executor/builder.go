@@ -2,10 +2,21 @@ package executor
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/chicogong/media-pipeline/pkg/operators"
+	"github.com/chicogong/media-pipeline/pkg/planner"
 	"github.com/chicogong/media-pipeline/pkg/schemas"
 )
 
@@ -25,16 +36,55 @@ func NewCommandBuilder(registry *operators.Registry) *CommandBuilder {
 type Command struct {
 	Args    []string
 	WorkDir string
+
+	// Timeout bounds the command's run time (0 = none)
+	Timeout time.Duration
+
+	// TimeoutNode is the plan node whose timeout set Timeout
+	TimeoutNode string
+
+	// DependsOn lists the indexes of the commands, in the same build
+	// result, that must complete before this one can start
+	DependsOn []int
+}
+
+// BuildOptions contains options for command generation
+type BuildOptions struct {
+	// InputSeeking compiles a leading trim (one that reads directly from an
+	// input consumed by nothing else) to "-ss <start> -i input" instead of a
+	// trim filter, avoiding decoding everything before the start point
+	InputSeeking bool
+
+	// TempDir is where multi-command builds write intermediate files and
+	// two-pass logs (empty = relative to the working directory)
+	TempDir string
 }
 
 // Build generates an FFmpeg command from a processing plan
 func (cb *CommandBuilder) Build(ctx context.Context, plan *schemas.ProcessingPlan) (*Command, error) {
+	return cb.BuildWithOptions(ctx, plan, nil)
+}
+
+// BuildWithOptions generates an FFmpeg command from a processing plan using the given options
+func (cb *CommandBuilder) BuildWithOptions(ctx context.Context, plan *schemas.ProcessingPlan, opts *BuildOptions) (*Command, error) {
+	if opts == nil {
+		opts = &BuildOptions{}
+	}
+
 	// Collect input files
 	inputs := cb.collectInputs(plan)
 	if len(inputs) == 0 {
 		return nil, fmt.Errorf("no input files found in plan")
 	}
 
+	// Input-side arguments (e.g. -ss) keyed by input node ID
+	inputArgs := make(map[string][]string)
+
+	// All nodes run within one FFmpeg process, so the longest node
+	// timeout bounds the command
+	var timeout time.Duration
+	var timeoutNode string
+
 	// Build filter expressions for each operation
 	filterExprs := []string{}
 	streamLabels := make(map[string][]string) // node ID -> output labels
@@ -60,19 +110,26 @@ func (cb *CommandBuilder) Build(ctx context.Context, plan *schemas.ProcessingPla
 			continue
 		}
 
-		// Get operator
-		op, err := cb.registry.Get(node.Operator)
-		if err != nil {
-			return nil, fmt.Errorf("node %s: operator %s not found: %w", nodeID, node.Operator, err)
+		// A trim reading directly from an input can seek the input instead
+		// of filtering: when input seeking is enabled, or when it asks to
+		// and is the only operation
+		inputNodeID, seekable := cb.seekableInput(plan, node)
+		allowInputFlags := seekable && (opts.InputSeeking || cb.isOnlyOperation(plan, node))
+		if allowInputFlags && opts.InputSeeking {
+			node = withFastSeek(node)
 		}
 
-		// Build compile context
-		compileCtx := cb.buildCompileContext(plan, node, streamLabels)
-
 		// Compile operator
-		result, err := op.Compile(compileCtx)
+		result, nodeTimeout, err := cb.compileNode(plan, node, streamLabels, allowInputFlags)
 		if err != nil {
-			return nil, fmt.Errorf("node %s: compile failed: %w", nodeID, err)
+			return nil, err
+		}
+		if nodeTimeout > timeout {
+			timeout = nodeTimeout
+			timeoutNode = nodeID
+		}
+		if len(result.InputFlags) > 0 {
+			inputArgs[inputNodeID] = append(inputArgs[inputNodeID], result.InputFlags...)
 		}
 
 		// Add filter expression
@@ -91,6 +148,7 @@ func (cb *CommandBuilder) Build(ctx context.Context, plan *schemas.ProcessingPla
 
 	// Add inputs
 	for _, input := range inputs {
+		args = append(args, inputArgs[input.nodeID]...)
 		args = append(args, "-i", input.source)
 	}
 
@@ -107,10 +165,17 @@ func (cb *CommandBuilder) Build(ctx context.Context, plan *schemas.ProcessingPla
 		if labels, ok := streamLabels[output.sourceNodeID]; ok && len(labels) > 0 {
 			// Use the output labels from the last operation
 			for _, label := range labels {
-				args = append(args, "-map", label)
+				args = append(args, "-map", mapArg(label))
 			}
 		}
 
+		// Container options and metadata tags
+		containerArgs, err := containerArgs(output)
+		if err != nil {
+			return nil, fmt.Errorf("output %s: %w", output.nodeID, err)
+		}
+		args = append(args, containerArgs...)
+
 		// Output file
 		args = append(args, output.destination)
 
@@ -122,10 +187,225 @@ func (cb *CommandBuilder) Build(ctx context.Context, plan *schemas.ProcessingPla
 	}
 
 	return &Command{
-		Args: args,
+		Args:        args,
+		Timeout:     timeout,
+		TimeoutNode: timeoutNode,
 	}, nil
 }
 
+// compileNode compiles an operation node and returns its execution timeout.
+// The operations of a fused node are compiled in order, each reading the
+// output streams of the one before, and its timeout is the longest of
+// theirs.
+func (cb *CommandBuilder) compileNode(plan *schemas.ProcessingPlan, node *schemas.PlanNode, streamLabels map[string][]string, allowInputFlags bool) (*operators.CompileResult, time.Duration, error) {
+	compileCtx := cb.buildCompileContext(plan, node, streamLabels)
+	compileCtx.AllowInputFlags = allowInputFlags && len(node.Operations()) == 1
+
+	var result *operators.CompileResult
+	var filters []string
+	var timeout time.Duration
+	for i, step := range node.Operations() {
+		op, err := cb.registry.Get(step.Operator)
+		if err != nil {
+			return nil, 0, fmt.Errorf("node %s: operator %s not found: %w", node.ID, step.Operator, err)
+		}
+
+		stepTimeout, err := nodeTimeout(step.Params, op)
+		if err != nil {
+			return nil, 0, fmt.Errorf("node %s: %w", node.ID, err)
+		}
+		if stepTimeout > timeout {
+			timeout = stepTimeout
+		}
+
+		if i > 0 {
+			compileCtx = cb.chainCompileContext(compileCtx, op, result.OutputLabels)
+		}
+		compileCtx.Params = step.Params
+
+		result, err = op.Compile(compileCtx)
+		if err != nil {
+			return nil, 0, fmt.Errorf("node %s: compile failed: %w", node.ID, err)
+		}
+		if len(result.InputFlags) > 0 && !compileCtx.AllowInputFlags {
+			return nil, 0, fmt.Errorf("node %s: operator %s returned input flags that cannot be applied", node.ID, step.Operator)
+		}
+		if result.FilterExpression != "" {
+			filters = append(filters, result.FilterExpression)
+		}
+	}
+
+	if len(filters) > 1 {
+		result.FilterExpression = strings.Join(filters, ";")
+	}
+	return result, timeout, nil
+}
+
+// chainCompileContext returns the compile context for the next operation of
+// a fused node, reading the streams labeled by the previous one. Input
+// metadata is carried over when the previous operation's output metadata
+// can be computed.
+func (cb *CommandBuilder) chainCompileContext(prev *operators.CompileContext, prevOp operators.Operator, labels []string) *operators.CompileContext {
+	next := &operators.CompileContext{
+		WorkDir: prev.WorkDir,
+		TempDir: prev.TempDir,
+		Debug:   prev.Debug,
+	}
+	for i, label := range labels {
+		next.InputStreams = append(next.InputStreams, operators.StreamRef{
+			SourceID:    prevOp.Name(),
+			StreamIndex: i,
+			StreamType:  cb.inferStreamType(label),
+			Label:       label,
+		})
+	}
+	if len(prev.InputMetadata) > 0 {
+		if output, err := prevOp.ComputeOutputMetadata(prev.Params, prev.InputMetadata); err == nil {
+			next.InputMetadata = []*schemas.MediaInfo{output}
+		}
+	}
+	return next
+}
+
+// nodeTimeout returns the execution timeout for an operation: its
+// timeout_seconds param if set, otherwise the operator's default (0 = none)
+func nodeTimeout(params map[string]interface{}, op operators.Operator) (time.Duration, error) {
+	seconds := 0.0
+	if desc := op.Describe(); desc != nil {
+		seconds = float64(desc.TimeoutSeconds)
+	}
+
+	if v, ok := params["timeout_seconds"]; ok {
+		switch n := v.(type) {
+		case int:
+			seconds = float64(n)
+		case int64:
+			seconds = float64(n)
+		case float64:
+			seconds = n
+		default:
+			return 0, fmt.Errorf("timeout_seconds must be a number, got %T", v)
+		}
+		if seconds < 0 {
+			return 0, fmt.Errorf("timeout_seconds must not be negative, got %v", seconds)
+		}
+	}
+
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// seekableInput reports whether node is a trim reading directly from an input
+// node that has no other consumers, returning the input node ID
+func (cb *CommandBuilder) seekableInput(plan *schemas.ProcessingPlan, node *schemas.PlanNode) (string, bool) {
+	if node.Operator != "trim" {
+		return "", false
+	}
+
+	var incoming []*schemas.PlanEdge
+	for _, edge := range plan.Edges {
+		if edge.To == node.ID {
+			incoming = append(incoming, edge)
+		}
+	}
+	if len(incoming) != 1 {
+		return "", false
+	}
+
+	source := cb.getNode(plan, incoming[0].From)
+	if source == nil || source.Type != "input" {
+		return "", false
+	}
+
+	// Seeking the input would also affect any other consumer
+	for _, edge := range plan.Edges {
+		if edge.From == source.ID && edge.To != node.ID {
+			return "", false
+		}
+	}
+
+	return source.ID, true
+}
+
+// isOnlyOperation reports whether node is the plan's only operation node
+func (cb *CommandBuilder) isOnlyOperation(plan *schemas.ProcessingPlan, node *schemas.PlanNode) bool {
+	for _, other := range plan.Nodes {
+		if other.Type == "operation" && other.ID != node.ID {
+			return false
+		}
+	}
+	return true
+}
+
+// withFastSeek returns a copy of a trim node set to fast seek
+func withFastSeek(node *schemas.PlanNode) *schemas.PlanNode {
+	params := make(map[string]interface{}, len(node.Params)+1)
+	for k, v := range node.Params {
+		params[k] = v
+	}
+	params["seek_mode"] = "fast_seek"
+
+	copied := *node
+	copied.Params = params
+	return &copied
+}
+
+// mapArg converts a stream label into a -map argument. Filter outputs are
+// mapped by label ("[v]"), while raw input streams ("[0:v]") are mapped by
+// stream specifier and marked optional in case the input lacks that stream
+func mapArg(label string) string {
+	inner := strings.TrimSuffix(strings.TrimPrefix(label, "["), "]")
+	if parts := strings.SplitN(inner, ":", 2); len(parts) == 2 {
+		if _, err := strconv.Atoi(parts[0]); err == nil {
+			return inner + "?"
+		}
+	}
+	return label
+}
+
+// containerArgs returns the muxer options for output: -map_metadata to keep
+// or drop the first input's metadata, -movflags +faststart for MP4 files so
+// playback can start before the download finishes, and the output's tags
+func containerArgs(output outputFile) ([]string, error) {
+	var args []string
+	if output.metadataMode == schemas.MetadataModeStrip {
+		args = append(args, "-map_metadata", "-1")
+	} else {
+		args = append(args, "-map_metadata", "0")
+	}
+
+	switch strings.ToLower(filepath.Ext(output.destination)) {
+	case ".mp4", ".m4v", ".m4a":
+		args = append(args, "-movflags", "+faststart")
+	}
+
+	metadataArgs, err := metadataArgs(output.metadata)
+	if err != nil {
+		return nil, err
+	}
+	return append(args, metadataArgs...), nil
+}
+
+// metadataArgs converts output metadata into -metadata key=value arguments,
+// sorted by key for stable commands. Arguments are passed to FFmpeg without a
+// shell, so spaces and '=' in values need no quoting; FFmpeg splits on the
+// first '=', so keys must not contain one
+func metadataArgs(metadata map[string]string) ([]string, error) {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	args := make([]string, 0, len(keys)*2)
+	for _, key := range keys {
+		if key == "" || strings.Contains(key, "=") {
+			return nil, fmt.Errorf("invalid metadata key %q", key)
+		}
+		args = append(args, "-metadata", key+"="+metadata[key])
+	}
+	return args, nil
+}
+
 // inputFile represents an input file in the plan
 type inputFile struct {
 	nodeID string
@@ -137,6 +417,8 @@ type outputFile struct {
 	nodeID       string
 	sourceNodeID string // Node that produces this output
 	destination  string
+	metadata     map[string]string
+	metadataMode schemas.MetadataMode
 }
 
 // collectInputs finds all input nodes in the plan
@@ -171,6 +453,8 @@ func (cb *CommandBuilder) collectOutputs(plan *schemas.ProcessingPlan) []outputF
 				nodeID:       node.ID,
 				sourceNodeID: sourceNodeID,
 				destination:  node.DestURI,
+				metadata:     node.OutputMetadata,
+				metadataMode: node.MetadataMode,
 			})
 		}
 	}
@@ -235,3 +519,494 @@ func (cb *CommandBuilder) inferStreamType(label string) string {
 	}
 	return "both"
 }
+
+// BuildStages generates one FFmpeg command per operation node, ordered by
+// execution stage. Each operation reads the materialized outputs of its
+// predecessors and writes either directly to its output destination or to an
+// intermediate file in tempDir, so operations that need separate FFmpeg passes
+// can be chained.
+func (cb *CommandBuilder) BuildStages(ctx context.Context, plan *schemas.ProcessingPlan, tempDir string) ([]*Command, error) {
+	return cb.BuildMultiCommandWithOptions(ctx, plan, &BuildOptions{TempDir: tempDir})
+}
+
+// BuildMultiCommand generates the FFmpeg commands for a plan that cannot be
+// expressed as a single invocation, such as one using a two-pass operator.
+// Commands are returned in a valid execution order and each lists the
+// commands it waits for in DependsOn.
+func (cb *CommandBuilder) BuildMultiCommand(ctx context.Context, plan *schemas.ProcessingPlan) ([]*Command, error) {
+	return cb.BuildMultiCommandWithOptions(ctx, plan, nil)
+}
+
+// BuildMultiCommandWithOptions generates the FFmpeg commands for a plan using the given options
+func (cb *CommandBuilder) BuildMultiCommandWithOptions(ctx context.Context, plan *schemas.ProcessingPlan, opts *BuildOptions) ([]*Command, error) {
+	if opts == nil {
+		opts = &BuildOptions{}
+	}
+
+	// materialized maps node ID -> file that holds the node's output
+	materialized := make(map[string]string)
+	for _, input := range cb.collectInputs(plan) {
+		materialized[input.nodeID] = input.source
+	}
+	if len(materialized) == 0 {
+		return nil, fmt.Errorf("no input files found in plan")
+	}
+
+	// destinations maps producing node ID -> outputs it feeds
+	destinations := make(map[string][]outputFile)
+	for _, output := range cb.collectOutputs(plan) {
+		destinations[output.sourceNodeID] = append(destinations[output.sourceNodeID], output)
+	}
+
+	// producers maps node ID -> index of the command that writes its output
+	producers := make(map[string]int)
+
+	commands := []*Command{}
+	for _, stage := range plan.ExecutionStages {
+		for _, nodeID := range stage {
+			node := cb.getNode(plan, nodeID)
+			if node == nil || node.Type != "operation" {
+				continue
+			}
+
+			nodeCmds, outputPath, err := cb.buildNodeCommands(plan, node, materialized, destinations[nodeID], opts.TempDir)
+			if err != nil {
+				return nil, err
+			}
+
+			// The first command waits for the node's inputs, each later
+			// pass for the one before it
+			for i, cmd := range nodeCmds {
+				if i == 0 {
+					cmd.DependsOn = cb.commandDependencies(plan, node, producers)
+				} else {
+					cmd.DependsOn = []int{len(commands) - 1}
+				}
+				commands = append(commands, cmd)
+			}
+
+			materialized[nodeID] = outputPath
+			producers[nodeID] = len(commands) - 1
+		}
+	}
+
+	if len(commands) == 0 {
+		return nil, fmt.Errorf("no operations found in plan")
+	}
+
+	return commands, nil
+}
+
+// commandDependencies returns the sorted indexes of the commands producing
+// the inputs of node; plan inputs need no command
+func (cb *CommandBuilder) commandDependencies(plan *schemas.ProcessingPlan, node *schemas.PlanNode, producers map[string]int) []int {
+	seen := make(map[int]bool)
+	deps := []int{}
+	for _, edge := range plan.Edges {
+		if edge.To != node.ID {
+			continue
+		}
+		if index, ok := producers[edge.From]; ok && !seen[index] {
+			seen[index] = true
+			deps = append(deps, index)
+		}
+	}
+	sort.Ints(deps)
+	return deps
+}
+
+// requiresMultiCommand reports whether plan uses an operator that cannot run
+// inside a single FFmpeg invocation
+func (cb *CommandBuilder) requiresMultiCommand(plan *schemas.ProcessingPlan) bool {
+	for _, node := range plan.Nodes {
+		if node.Type == "operation" && cb.requiresTwoPass(node) {
+			return true
+		}
+	}
+	return false
+}
+
+// requiresTwoPass reports whether any of node's operators needs an analysis
+// pass before encoding
+func (cb *CommandBuilder) requiresTwoPass(node *schemas.PlanNode) bool {
+	for _, step := range node.Operations() {
+		op, err := cb.registry.Get(step.Operator)
+		if err != nil {
+			continue
+		}
+		if desc := op.Describe(); desc != nil && desc.RequiresTwoPass {
+			return true
+		}
+	}
+	return false
+}
+
+// buildNodeCommands builds the standalone FFmpeg commands for a single
+// operation node: one command, or an analysis pass followed by an encoding
+// pass for two-pass operators. Returns the commands and the path the node's
+// output is written to
+func (cb *CommandBuilder) buildNodeCommands(
+	plan *schemas.ProcessingPlan,
+	node *schemas.PlanNode,
+	materialized map[string]string,
+	dests []outputFile,
+	tempDir string,
+) ([]*Command, string, error) {
+	// Each predecessor becomes an FFmpeg input of this command
+	args := []string{"ffmpeg"}
+	streamLabels := make(map[string][]string)
+	inputIndex := 0
+	for _, edge := range plan.Edges {
+		if edge.To != node.ID {
+			continue
+		}
+		if _, seen := streamLabels[edge.From]; seen {
+			continue
+		}
+		source, ok := materialized[edge.From]
+		if !ok {
+			return nil, "", fmt.Errorf("node %s: input %s has not been materialized", node.ID, edge.From)
+		}
+
+		args = append(args, "-i", source)
+		streamLabels[edge.From] = []string{
+			fmt.Sprintf("[%d:v]", inputIndex),
+			fmt.Sprintf("[%d:a]", inputIndex),
+		}
+		inputIndex++
+	}
+
+	result, timeout, err := cb.compileNode(plan, node, streamLabels, false)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if result.FilterExpression != "" {
+		args = append(args, "-filter_complex", result.FilterExpression)
+	}
+
+	// Write to the final destinations if this node feeds outputs,
+	// otherwise to an intermediate file for later stages
+	if len(dests) == 0 {
+		dests = []outputFile{{destination: filepath.Join(tempDir, node.ID+".mkv")}}
+	}
+
+	// outputArgs maps the node's streams to every destination
+	outputArgs := func(args []string, passArgs ...string) ([]string, error) {
+		for _, dest := range dests {
+			for _, label := range result.OutputLabels {
+				args = append(args, "-map", mapArg(label))
+			}
+			args = append(args, passArgs...)
+			containerArgs, err := containerArgs(dest)
+			if err != nil {
+				return nil, fmt.Errorf("output %s: %w", dest.nodeID, err)
+			}
+			args = append(args, containerArgs...)
+			args = append(args, dest.destination)
+		}
+		return args, nil
+	}
+
+	var commands []*Command
+	if cb.requiresTwoPass(node) {
+		// The first pass only analyzes the input, writing its statistics
+		// to the pass log read by the second pass
+		passLog := filepath.Join(tempDir, node.ID+"-passlog")
+
+		firstPass := append([]string{}, args...)
+		for _, label := range result.OutputLabels {
+			firstPass = append(firstPass, "-map", mapArg(label))
+		}
+		firstPass = append(firstPass, "-pass", "1", "-passlogfile", passLog, "-f", "null", "-")
+
+		secondPass, err := outputArgs(append([]string{}, args...), "-pass", "2", "-passlogfile", passLog)
+		if err != nil {
+			return nil, "", err
+		}
+		commands = []*Command{{Args: firstPass}, {Args: secondPass}}
+	} else {
+		args, err = outputArgs(args)
+		if err != nil {
+			return nil, "", err
+		}
+		commands = []*Command{{Args: args}}
+	}
+
+	if timeout > 0 {
+		for _, cmd := range commands {
+			cmd.Timeout = timeout
+			cmd.TimeoutNode = node.ID
+		}
+	}
+	return commands, dests[0].destination, nil
+}
+
+// CommandDependencyGraph returns the dependency graph of a multi-command
+// build for visualization. Command i becomes node "cmd_<i>" carrying its
+// arguments, with an edge from each command it depends on.
+func CommandDependencyGraph(cmds []*Command) *planner.Graph {
+	graph := planner.NewGraph()
+	for i, cmd := range cmds {
+		graph.AddNode(&schemas.PlanNode{
+			ID:     commandNodeID(i),
+			Type:   "command",
+			Params: map[string]interface{}{"args": strings.Join(cmd.Args, " ")},
+		})
+	}
+	for i, cmd := range cmds {
+		for _, dep := range cmd.DependsOn {
+			graph.AddEdge(&schemas.PlanEdge{From: commandNodeID(dep), To: commandNodeID(i)})
+		}
+	}
+	return graph
+}
+
+// commandNodeID returns the graph node ID of command i
+func commandNodeID(i int) string {
+	return fmt.Sprintf("cmd_%d", i)
+}
+
+// commandOrder returns the command indexes in dependency order, keeping the
+// build order among independent commands
+func commandOrder(cmds []*Command) ([]int, error) {
+	pending := make([]int, len(cmds)) // unfinished dependencies per command
+	dependents := make(map[int][]int)
+	for i, cmd := range cmds {
+		for _, dep := range cmd.DependsOn {
+			if dep < 0 || dep >= len(cmds) || dep == i {
+				return nil, fmt.Errorf("command %d has invalid dependency %d", i, dep)
+			}
+			pending[i]++
+			dependents[dep] = append(dependents[dep], i)
+		}
+	}
+
+	order := make([]int, 0, len(cmds))
+	done := make([]bool, len(cmds))
+	for len(order) < len(cmds) {
+		next := -1
+		for i := range cmds {
+			if !done[i] && pending[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			return nil, fmt.Errorf("command dependencies contain a cycle")
+		}
+
+		done[next] = true
+		order = append(order, next)
+		for _, dependent := range dependents[next] {
+			pending[dependent]--
+		}
+	}
+	return order, nil
+}
+
+// ErrInvalidFilterGraph is returned by ValidateFilterGraph for malformed
+// filter graphs
+var ErrInvalidFilterGraph = errors.New("invalid filter graph")
+
+// validFilterGraphs caches the hashes of filter graphs that passed
+// ValidateFilterGraph
+var validFilterGraphs sync.Map
+
+var (
+	// filterLeadingLabelRegex matches a label before a filter name
+	filterLeadingLabelRegex = regexp.MustCompile(`^\s*\[([^\[\]\s]+)\]`)
+
+	// filterTrailingLabelRegex matches a label after a filter's arguments
+	filterTrailingLabelRegex = regexp.MustCompile(`\[([^\[\]\s]+)\]\s*$`)
+
+	// filterNameRegex matches a filter name, optional instance name and arguments
+	filterNameRegex = regexp.MustCompile(`(?s)^\s*[A-Za-z0-9_]+(@[A-Za-z0-9_]+)?(=.+)?\s*$`)
+
+	// inputStreamLabelRegex matches labels that select input file streams, e.g. 0:v
+	inputStreamLabelRegex = regexp.MustCompile(`^\d+(:[A-Za-z0-9_:]+)?$`)
+
+	// filterInputRegex matches the input stream references of a filter graph
+	filterInputRegex = regexp.MustCompile(`\[(\d+)(:[A-Za-z0-9_:]+)?\]`)
+)
+
+// ValidateFilterGraph checks a -filter_complex value before it is run.
+// A structural check catches unbalanced brackets and quotes, malformed
+// filters and dangling labels; if ffmpeg is installed the graph is also
+// parsed by ffmpeg against synthetic inputs. Valid graphs are cached.
+func ValidateFilterGraph(filterComplex string) error {
+	sum := sha256.Sum256([]byte(filterComplex))
+	key := hex.EncodeToString(sum[:])
+	if _, ok := validFilterGraphs.Load(key); ok {
+		return nil
+	}
+
+	if err := checkFilterGraphStructure(filterComplex); err != nil {
+		return err
+	}
+	if err := lintFilterGraph(filterComplex); err != nil {
+		return err
+	}
+
+	validFilterGraphs.Store(key, struct{}{})
+	return nil
+}
+
+// checkFilterGraphStructure is the best-effort check used without ffmpeg
+func checkFilterGraphStructure(filterComplex string) error {
+	if strings.TrimSpace(filterComplex) == "" {
+		return fmt.Errorf("%w: filter graph is empty", ErrInvalidFilterGraph)
+	}
+
+	chains, err := splitFilterGraph(filterComplex, ';')
+	if err != nil {
+		return err
+	}
+
+	// Labels are linked in order like FFmpeg does: an output label waits
+	// for the next filter reading it and vice versa, so a label can be
+	// reused once it has been linked
+	openOutputs := make(map[string]bool) // produced, not yet read
+	openInputs := make(map[string]bool)  // read, not yet produced
+	for i, chain := range chains {
+		filters, err := splitFilterGraph(chain, ',')
+		if err != nil {
+			return err
+		}
+
+		for j, filter := range filters {
+			rest := filter
+
+			var inputs []string
+			for {
+				m := filterLeadingLabelRegex.FindStringSubmatchIndex(rest)
+				if m == nil {
+					break
+				}
+				inputs = append(inputs, rest[m[2]:m[3]])
+				rest = rest[m[1]:]
+			}
+
+			var outputs []string
+			for {
+				m := filterTrailingLabelRegex.FindStringSubmatchIndex(rest)
+				if m == nil {
+					break
+				}
+				outputs = append(outputs, rest[m[2]:m[3]])
+				rest = rest[:m[0]]
+			}
+
+			if !filterNameRegex.MatchString(rest) {
+				return fmt.Errorf("%w: chain %d, filter %d: malformed filter %q", ErrInvalidFilterGraph, i, j, strings.TrimSpace(filter))
+			}
+
+			for _, label := range inputs {
+				switch {
+				case inputStreamLabelRegex.MatchString(label):
+					// Input streams may be read more than once
+				case openOutputs[label]:
+					delete(openOutputs, label)
+				case openInputs[label]:
+					return fmt.Errorf("%w: label [%s] is used as an input more than once", ErrInvalidFilterGraph, label)
+				default:
+					openInputs[label] = true
+				}
+			}
+			for _, label := range outputs {
+				switch {
+				case openInputs[label]:
+					delete(openInputs, label)
+				case openOutputs[label]:
+					return fmt.Errorf("%w: label [%s] is defined more than once", ErrInvalidFilterGraph, label)
+				default:
+					openOutputs[label] = true
+				}
+			}
+		}
+	}
+
+	for label := range openInputs {
+		return fmt.Errorf("%w: label [%s] is not defined", ErrInvalidFilterGraph, label)
+	}
+
+	return nil
+}
+
+// splitFilterGraph splits s on sep outside quotes and brackets, honoring
+// backslash escapes, and rejects empty parts and unbalanced quotes or brackets
+func splitFilterGraph(s string, sep rune) ([]string, error) {
+	var parts []string
+	var current strings.Builder
+	inQuote := false
+	escaped := false
+	depth := 0
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '\'':
+			inQuote = !inQuote
+		case inQuote:
+		case r == '[':
+			depth++
+		case r == ']':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("%w: unexpected ']'", ErrInvalidFilterGraph)
+			}
+		case r == sep && depth == 0:
+			parts = append(parts, current.String())
+			current.Reset()
+			continue
+		}
+		current.WriteRune(r)
+	}
+
+	if inQuote {
+		return nil, fmt.Errorf("%w: unterminated quote", ErrInvalidFilterGraph)
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("%w: unclosed '['", ErrInvalidFilterGraph)
+	}
+	parts = append(parts, current.String())
+
+	for _, part := range parts {
+		if strings.TrimSpace(part) == "" {
+			return nil, fmt.Errorf("%w: empty filter or chain in %q", ErrInvalidFilterGraph, s)
+		}
+	}
+	return parts, nil
+}
+
+// lintFilterGraph asks ffmpeg to parse the filter graph, feeding every
+// referenced input a short synthetic video and audio stream. It does
+// nothing if ffmpeg is not installed.
+func lintFilterGraph(filterComplex string) error {
+	ffmpegPath, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return nil
+	}
+
+	inputs := 0
+	for _, m := range filterInputRegex.FindAllStringSubmatch(filterComplex, -1) {
+		if n, err := strconv.Atoi(m[1]); err == nil && n+1 > inputs {
+			inputs = n + 1
+		}
+	}
+
+	args := []string{"-hide_banner", "-nostats", "-v", "error"}
+	for i := 0; i < inputs; i++ {
+		args = append(args, "-f", "lavfi", "-i", "testsrc=size=64x64:duration=0.1[out0];anullsrc=duration=0.1[out1]")
+	}
+	args = append(args, "-filter_complex", filterComplex, "-t", "0.1", "-f", "null", "-")
+
+	output, err := exec.Command(ffmpegPath, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidFilterGraph, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
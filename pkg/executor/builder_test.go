@@ -2,7 +2,13 @@ package executor
 
 import (
 	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/chicogong/media-pipeline/pkg/operators"
 	"github.com/chicogong/media-pipeline/pkg/operators/builtin"
@@ -219,3 +225,686 @@ func TestCommandBuilder_InvalidOperator(t *testing.T) {
 		t.Error("expected error for nonexistent operator, got nil")
 	}
 }
+
+func TestCommandBuilder_BuildStages(t *testing.T) {
+	operators.Register(&builtin.TrimOperator{})
+	operators.Register(&builtin.ScaleOperator{})
+
+	spec := &schemas.JobSpec{
+		Inputs: []schemas.Input{
+			{ID: "video", Source: "/tmp/input.mp4"},
+		},
+		Operations: []schemas.Operation{
+			{Op: "trim", Input: "video", Output: "trimmed",
+				Params: map[string]interface{}{"start": "00:00:10", "duration": "00:00:30"}},
+			{Op: "scale", Input: "trimmed", Output: "scaled",
+				Params: map[string]interface{}{"width": 1280, "height": 720}},
+		},
+		Outputs: []schemas.Output{
+			{ID: "scaled", Destination: "/tmp/output.mp4"},
+		},
+	}
+
+	p := planner.NewPlanner()
+	plan, err := p.Plan(context.Background(), spec, nil)
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+
+	tempDir := "/tmp/media-pipeline-test"
+	builder := NewCommandBuilder(operators.GlobalRegistry())
+	cmds, err := builder.BuildStages(context.Background(), plan, tempDir)
+	if err != nil {
+		t.Fatalf("BuildStages failed: %v", err)
+	}
+
+	if len(cmds) != 2 {
+		t.Fatalf("expected 2 commands, got %d", len(cmds))
+	}
+
+	intermediate := filepath.Join(tempDir, "op_0_trim.mkv")
+
+	// First pass reads the input and writes the intermediate file
+	first := cmds[0].Args
+	if first[1] != "-i" || first[2] != "/tmp/input.mp4" {
+		t.Errorf("expected first command to read /tmp/input.mp4, got %v", first)
+	}
+	if first[len(first)-1] != intermediate {
+		t.Errorf("expected first command to write %s, got %s", intermediate, first[len(first)-1])
+	}
+
+	// Second pass reads the intermediate file and writes the final output
+	second := cmds[1].Args
+	if second[1] != "-i" || second[2] != intermediate {
+		t.Errorf("expected second command to read %s, got %v", intermediate, second)
+	}
+	if second[len(second)-1] != "/tmp/output.mp4" {
+		t.Errorf("expected second command to write /tmp/output.mp4, got %s", second[len(second)-1])
+	}
+}
+
+// passthroughOperator needs no filter and hands its input streams on as is
+type passthroughOperator struct {
+	builtin.ScaleOperator
+}
+
+func (op *passthroughOperator) Name() string {
+	return "passthrough"
+}
+
+func (op *passthroughOperator) Describe() *operators.OperatorDescriptor {
+	desc := op.ScaleOperator.Describe()
+	desc.Name = op.Name()
+	return desc
+}
+
+func (op *passthroughOperator) Compile(ctx *operators.CompileContext) (*operators.CompileResult, error) {
+	labels := []string{}
+	for _, stream := range ctx.InputStreams {
+		labels = append(labels, stream.Label)
+	}
+	return &operators.CompileResult{OutputLabels: labels}, nil
+}
+
+func TestCommandBuilder_BuildStages_MapsInputStreams(t *testing.T) {
+	operators.Register(&passthroughOperator{})
+
+	spec := &schemas.JobSpec{
+		Inputs: []schemas.Input{
+			{ID: "video", Source: "/tmp/input.mp4"},
+		},
+		Operations: []schemas.Operation{
+			{Op: "passthrough", Input: "video", Output: "copied",
+				Params: map[string]interface{}{"width": 1280, "height": 720}},
+		},
+		Outputs: []schemas.Output{
+			{ID: "copied", Destination: "/tmp/output.mp4"},
+		},
+	}
+
+	plan, err := planner.NewPlanner().Plan(context.Background(), spec, nil)
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+
+	builder := NewCommandBuilder(operators.GlobalRegistry())
+	cmds, err := builder.BuildStages(context.Background(), plan, "/tmp/media-pipeline-test")
+	if err != nil {
+		t.Fatalf("BuildStages failed: %v", err)
+	}
+	if len(cmds) != 1 {
+		t.Fatalf("expected 1 command, got %d", len(cmds))
+	}
+
+	// Raw input streams are mapped by specifier, not as filter labels
+	got := strings.Join(cmds[0].Args, " ")
+	if !strings.Contains(got, "-map 0:v? -map 0:a?") || strings.Contains(got, "-map [0:") {
+		t.Errorf("expected input streams mapped by specifier, got %q", got)
+	}
+}
+
+func TestCommandBuilder_BuildStages_FusedOperations(t *testing.T) {
+	operators.Register(&builtin.TrimOperator{})
+	operators.Register(&builtin.ScaleOperator{})
+
+	spec := &schemas.JobSpec{
+		Inputs: []schemas.Input{
+			{ID: "video", Source: "/tmp/input.mp4"},
+		},
+		Operations: []schemas.Operation{
+			{Op: "trim", Input: "video", Output: "trimmed",
+				Params: map[string]interface{}{"start": "00:00:10", "duration": "00:00:30"}},
+			{Op: "scale", Input: "trimmed", Output: "scaled",
+				Params: map[string]interface{}{"width": 1280, "height": 720}},
+		},
+		Outputs: []schemas.Output{
+			{ID: "scaled", Destination: "/tmp/output.mp4"},
+		},
+	}
+
+	p := planner.NewPlanner()
+	plan, err := p.Plan(context.Background(), spec, &planner.PlanOptions{FuseOperations: true})
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+
+	builder := NewCommandBuilder(operators.GlobalRegistry())
+	cmds, err := builder.BuildStages(context.Background(), plan, "/tmp/media-pipeline-test")
+	if err != nil {
+		t.Fatalf("BuildStages failed: %v", err)
+	}
+
+	// The fused node runs as one command with no intermediate file
+	if len(cmds) != 1 {
+		t.Fatalf("expected 1 command, got %d", len(cmds))
+	}
+	args := cmds[0].Args
+	if args[2] != "/tmp/input.mp4" || args[len(args)-1] != "/tmp/output.mp4" {
+		t.Errorf("expected command to read the input and write the output, got %v", args)
+	}
+
+	filter := ""
+	for i, arg := range args {
+		if arg == "-filter_complex" && i+1 < len(args) {
+			filter = args[i+1]
+		}
+	}
+	if !strings.Contains(filter, "trim=start=10.000:duration=30.000") || !strings.Contains(filter, "[v]scale=1280:720") {
+		t.Errorf("expected trim and scale filters, got %q", filter)
+	}
+}
+
+func TestCommandBuilder_InputSeeking_LeadingTrim(t *testing.T) {
+	operators.Register(&builtin.TrimOperator{})
+	operators.Register(&builtin.ScaleOperator{})
+
+	spec := &schemas.JobSpec{
+		Inputs: []schemas.Input{
+			{ID: "video", Source: "/tmp/input.mp4"},
+		},
+		Operations: []schemas.Operation{
+			{Op: "trim", Input: "video", Output: "trimmed",
+				Params: map[string]interface{}{"start": "00:10:00", "duration": "00:00:30"}},
+			{Op: "scale", Input: "trimmed", Output: "scaled",
+				Params: map[string]interface{}{"width": 1280, "height": 720}},
+		},
+		Outputs: []schemas.Output{
+			{ID: "scaled", Destination: "/tmp/output.mp4"},
+		},
+	}
+
+	p := planner.NewPlanner()
+	plan, err := p.Plan(context.Background(), spec, nil)
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+
+	builder := NewCommandBuilder(operators.GlobalRegistry())
+	cmd, err := builder.BuildWithOptions(context.Background(), plan, &BuildOptions{InputSeeking: true})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	// Expect: ffmpeg -ss 600.000 -t 30.000 -i /tmp/input.mp4 ...
+	want := []string{"ffmpeg", "-ss", "600.000", "-t", "30.000", "-i", "/tmp/input.mp4"}
+	for i, arg := range want {
+		if i >= len(cmd.Args) || cmd.Args[i] != arg {
+			t.Fatalf("expected args to start with %v, got %v", want, cmd.Args)
+		}
+	}
+
+	// The trim filter should be gone, the scale filter should read the input directly
+	for i, arg := range cmd.Args {
+		if arg == "-filter_complex" {
+			filter := cmd.Args[i+1]
+			if strings.Contains(filter, "trim=") {
+				t.Errorf("expected no trim filter, got %s", filter)
+			}
+			if !strings.HasPrefix(filter, "[0:v]scale=") {
+				t.Errorf("expected scale to read [0:v], got %s", filter)
+			}
+		}
+	}
+}
+
+func TestCommandBuilder_InputSeeking_FilterFallback(t *testing.T) {
+	operators.Register(&builtin.TrimOperator{})
+	operators.Register(&builtin.ScaleOperator{})
+
+	spec := &schemas.JobSpec{
+		Inputs: []schemas.Input{
+			{ID: "video", Source: "/tmp/input.mp4"},
+		},
+		Operations: []schemas.Operation{
+			{Op: "scale", Input: "video", Output: "scaled",
+				Params: map[string]interface{}{"width": 1280, "height": 720}},
+			{Op: "trim", Input: "scaled", Output: "trimmed",
+				Params: map[string]interface{}{"start": "00:10:00", "duration": "00:00:30"}},
+		},
+		Outputs: []schemas.Output{
+			{ID: "trimmed", Destination: "/tmp/output.mp4"},
+		},
+	}
+
+	p := planner.NewPlanner()
+	plan, err := p.Plan(context.Background(), spec, nil)
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+
+	builder := NewCommandBuilder(operators.GlobalRegistry())
+	cmd, err := builder.BuildWithOptions(context.Background(), plan, &BuildOptions{InputSeeking: true})
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	hasTrimFilter := false
+	for i, arg := range cmd.Args {
+		if arg == "-ss" {
+			t.Errorf("expected no input seeking when trim is not first, got %v", cmd.Args)
+		}
+		if arg == "-filter_complex" && strings.Contains(cmd.Args[i+1], "trim=start=600.000") {
+			hasTrimFilter = true
+		}
+	}
+	if !hasTrimFilter {
+		t.Errorf("expected trim filter, got %v", cmd.Args)
+	}
+}
+
+func TestCommandBuilder_FastSeekTrim(t *testing.T) {
+	operators.Register(&builtin.TrimOperator{})
+	operators.Register(&builtin.ScaleOperator{})
+
+	trim := schemas.Operation{Op: "trim", Input: "video", Output: "trimmed",
+		Params: map[string]interface{}{"start": "00:10:00", "duration": "00:00:30", "seek_mode": "fast_seek"}}
+	spec := &schemas.JobSpec{
+		Inputs: []schemas.Input{
+			{ID: "video", Source: "/tmp/input.mp4"},
+		},
+		Operations: []schemas.Operation{trim},
+		Outputs: []schemas.Output{
+			{ID: "trimmed", Destination: "/tmp/output.mp4"},
+		},
+	}
+
+	plan, err := planner.NewPlanner().Plan(context.Background(), spec, nil)
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+
+	builder := NewCommandBuilder(operators.GlobalRegistry())
+	cmd, err := builder.Build(context.Background(), plan)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	want := "ffmpeg -ss 600.000 -t 30.000 -i /tmp/input.mp4 -map 0:v? -map 0:a? "
+	if got := strings.Join(cmd.Args, " "); !strings.HasPrefix(got, want) || strings.Contains(got, "-filter_complex") {
+		t.Errorf("expected %q without filters, got %q", want, got)
+	}
+
+	// With another operation the trim filter is used
+	spec.Operations = []schemas.Operation{trim,
+		{Op: "scale", Input: "trimmed", Output: "scaled",
+			Params: map[string]interface{}{"width": 1280, "height": 720}}}
+	spec.Outputs[0].ID = "scaled"
+
+	plan, err = planner.NewPlanner().Plan(context.Background(), spec, nil)
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	cmd, err = builder.Build(context.Background(), plan)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if got := strings.Join(cmd.Args, " "); strings.Contains(got, "-ss") || !strings.Contains(got, "trim=start=600.000") {
+		t.Errorf("expected a trim filter, got %q", got)
+	}
+}
+
+func TestCommandBuilder_OutputMetadata(t *testing.T) {
+	operators.Register(&builtin.ScaleOperator{})
+
+	spec := &schemas.JobSpec{
+		Inputs: []schemas.Input{
+			{ID: "video", Source: "/tmp/input.mp4"},
+		},
+		Operations: []schemas.Operation{
+			{Op: "scale", Input: "video", Output: "scaled",
+				Params: map[string]interface{}{"width": 1280, "height": 720}},
+		},
+		Outputs: []schemas.Output{
+			{
+				ID:          "scaled",
+				Destination: "/tmp/output.mp4",
+				Metadata: map[string]string{
+					"title":   "My Video",
+					"comment": "a=b c",
+					"artist":  "Someone",
+				},
+			},
+		},
+	}
+
+	p := planner.NewPlanner()
+	plan, err := p.Plan(context.Background(), spec, nil)
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+
+	builder := NewCommandBuilder(operators.GlobalRegistry())
+	cmd, err := builder.Build(context.Background(), plan)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	var metadata []string
+	for i, arg := range cmd.Args {
+		if arg == "-metadata" && i+1 < len(cmd.Args) {
+			metadata = append(metadata, cmd.Args[i+1])
+		}
+	}
+
+	want := []string{"artist=Someone", "comment=a=b c", "title=My Video"}
+	if strings.Join(metadata, "|") != strings.Join(want, "|") {
+		t.Errorf("expected metadata %v, got %v", want, metadata)
+	}
+
+	// Metadata flags must precede the output file
+	if cmd.Args[len(cmd.Args)-1] != "/tmp/output.mp4" {
+		t.Errorf("expected output file last, got %v", cmd.Args)
+	}
+}
+
+func TestCommandBuilder_OutputMetadata_InvalidKey(t *testing.T) {
+	if _, err := metadataArgs(map[string]string{"bad=key": "value"}); err == nil {
+		t.Error("expected error for metadata key containing '='")
+	}
+}
+
+func TestCommandBuilder_NodeTimeout(t *testing.T) {
+	operators.Register(&builtin.TrimOperator{})
+	operators.Register(&builtin.ScaleOperator{})
+
+	spec := &schemas.JobSpec{
+		Inputs: []schemas.Input{
+			{ID: "video", Source: "/tmp/input.mp4"},
+		},
+		Operations: []schemas.Operation{
+			{Op: "trim", Input: "video", Output: "trimmed",
+				Params: map[string]interface{}{"start": "00:00:10", "duration": "00:00:30", "timeout_seconds": 5}},
+			{Op: "scale", Input: "trimmed", Output: "scaled",
+				Params: map[string]interface{}{"width": 1280, "height": 720, "timeout_seconds": 2.5}},
+		},
+		Outputs: []schemas.Output{
+			{ID: "scaled", Destination: "/tmp/output.mp4"},
+		},
+	}
+
+	p := planner.NewPlanner()
+	plan, err := p.Plan(context.Background(), spec, nil)
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+
+	builder := NewCommandBuilder(operators.GlobalRegistry())
+
+	// A single command is bounded by the longest node timeout
+	cmd, err := builder.Build(context.Background(), plan)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if cmd.Timeout != 5*time.Second || cmd.TimeoutNode != "op_0_trim" {
+		t.Errorf("expected 5s timeout from op_0_trim, got %v from %q", cmd.Timeout, cmd.TimeoutNode)
+	}
+
+	// Staged commands carry their own node's timeout
+	cmds, err := builder.BuildStages(context.Background(), plan, t.TempDir())
+	if err != nil {
+		t.Fatalf("BuildStages failed: %v", err)
+	}
+	if len(cmds) != 2 {
+		t.Fatalf("expected 2 commands, got %d", len(cmds))
+	}
+	if cmds[0].Timeout != 5*time.Second || cmds[1].Timeout != 2500*time.Millisecond {
+		t.Errorf("expected stage timeouts 5s and 2.5s, got %v and %v", cmds[0].Timeout, cmds[1].Timeout)
+	}
+
+	// Invalid timeouts are rejected
+	plan.Nodes[1].Params["timeout_seconds"] = "soon"
+	if _, err := builder.Build(context.Background(), plan); err == nil {
+		t.Error("expected error for non-numeric timeout_seconds")
+	}
+}
+
+// twoPassScaleOperator is a scale operator that requires two encoding passes
+type twoPassScaleOperator struct {
+	builtin.ScaleOperator
+}
+
+func (op *twoPassScaleOperator) Name() string {
+	return "twopass_scale"
+}
+
+func (op *twoPassScaleOperator) Describe() *operators.OperatorDescriptor {
+	desc := op.ScaleOperator.Describe()
+	desc.Name = op.Name()
+	desc.RequiresTwoPass = true
+	return desc
+}
+
+func TestCommandBuilder_BuildMultiCommand_TwoPass(t *testing.T) {
+	operators.Register(&builtin.TrimOperator{})
+	operators.Register(&twoPassScaleOperator{})
+
+	spec := &schemas.JobSpec{
+		Inputs: []schemas.Input{
+			{ID: "video", Source: "/tmp/input.mp4"},
+		},
+		Operations: []schemas.Operation{
+			{Op: "trim", Input: "video", Output: "trimmed",
+				Params: map[string]interface{}{"start": "00:00:10", "duration": "00:00:30"}},
+			{Op: "twopass_scale", Input: "trimmed", Output: "scaled",
+				Params: map[string]interface{}{"width": 1280, "height": 720}},
+		},
+		Outputs: []schemas.Output{
+			{ID: "scaled", Destination: "/tmp/output.mp4"},
+		},
+	}
+
+	p := planner.NewPlanner()
+	plan, err := p.Plan(context.Background(), spec, nil)
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+
+	builder := NewCommandBuilder(operators.GlobalRegistry())
+	if !builder.requiresMultiCommand(plan) {
+		t.Error("expected two-pass plan to require multiple commands")
+	}
+
+	tempDir := "/tmp/media-pipeline-test"
+	cmds, err := builder.BuildMultiCommandWithOptions(context.Background(), plan, &BuildOptions{TempDir: tempDir})
+	if err != nil {
+		t.Fatalf("BuildMultiCommand failed: %v", err)
+	}
+
+	// trim, then the analysis and encoding passes of the scale
+	if len(cmds) != 3 {
+		t.Fatalf("expected 3 commands, got %d", len(cmds))
+	}
+
+	wantDeps := [][]int{{}, {0}, {1}}
+	for i, want := range wantDeps {
+		if len(cmds[i].DependsOn) != len(want) || (len(want) > 0 && cmds[i].DependsOn[0] != want[0]) {
+			t.Errorf("command %d: expected DependsOn %v, got %v", i, want, cmds[i].DependsOn)
+		}
+	}
+
+	intermediate := filepath.Join(tempDir, "op_0_trim.mkv")
+	passLog := filepath.Join(tempDir, "op_1_twopass_scale-passlog")
+
+	firstPass := strings.Join(cmds[1].Args, " ")
+	if !strings.Contains(firstPass, "-i "+intermediate) {
+		t.Errorf("expected first pass to read %s, got %s", intermediate, firstPass)
+	}
+	if !strings.HasSuffix(firstPass, "-pass 1 -passlogfile "+passLog+" -f null -") {
+		t.Errorf("expected first pass to discard its output, got %s", firstPass)
+	}
+
+	secondPass := strings.Join(cmds[2].Args, " ")
+	if !strings.Contains(secondPass, "-pass 2 -passlogfile "+passLog) || !strings.HasSuffix(secondPass, " /tmp/output.mp4") {
+		t.Errorf("expected second pass to write /tmp/output.mp4, got %s", secondPass)
+	}
+
+	graph := CommandDependencyGraph(cmds)
+	if len(graph.Nodes) != 3 || len(graph.Edges) != 2 {
+		t.Fatalf("expected 3 nodes and 2 edges, got %d and %d", len(graph.Nodes), len(graph.Edges))
+	}
+	if preds := graph.GetPredecessors("cmd_2"); len(preds) != 1 || preds[0].ID != "cmd_1" {
+		t.Errorf("expected cmd_2 to depend on cmd_1, got %v", preds)
+	}
+}
+
+func TestCommandOrder(t *testing.T) {
+	cmds := []*Command{
+		{DependsOn: []int{2}},
+		{},
+		{DependsOn: []int{1}},
+	}
+
+	order, err := commandOrder(cmds)
+	if err != nil {
+		t.Fatalf("commandOrder failed: %v", err)
+	}
+	if want := []int{1, 2, 0}; !reflect.DeepEqual(order, want) {
+		t.Errorf("expected order %v, got %v", want, order)
+	}
+
+	cmds[1].DependsOn = []int{0}
+	if _, err := commandOrder(cmds); err == nil {
+		t.Error("expected error for dependency cycle")
+	}
+
+	if _, err := commandOrder([]*Command{{DependsOn: []int{3}}}); err == nil {
+		t.Error("expected error for out of range dependency")
+	}
+}
+
+func TestCommandBuilder_ContainerArgs(t *testing.T) {
+	operators.Register(&builtin.ScaleOperator{})
+
+	spec := &schemas.JobSpec{
+		Tags: map[string]string{"project": "demo", "title": "Job Title"},
+		Inputs: []schemas.Input{
+			{ID: "video", Source: "/tmp/input.mp4"},
+		},
+		Operations: []schemas.Operation{
+			{Op: "scale", Input: "video", Output: "scaled",
+				Params: map[string]interface{}{"width": 1280, "height": 720}},
+		},
+		Outputs: []schemas.Output{
+			{ID: "scaled", Destination: "/tmp/output.MP4", Metadata: map[string]string{"title": "Output Title"}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		mode     schemas.MetadataMode
+		dest     string
+		wantArgs string
+	}{
+		{
+			name:     "merge",
+			dest:     "/tmp/output.MP4",
+			wantArgs: "-map_metadata 0 -movflags +faststart -metadata project=demo -metadata title=Output Title /tmp/output.MP4",
+		},
+		{
+			name:     "preserve",
+			mode:     schemas.MetadataModePreserve,
+			dest:     "/tmp/output.mkv",
+			wantArgs: "-map_metadata 0 -metadata title=Output Title /tmp/output.mkv",
+		},
+		{
+			name:     "strip",
+			mode:     schemas.MetadataModeStrip,
+			dest:     "/tmp/output.m4a",
+			wantArgs: "-map_metadata -1 -movflags +faststart -metadata title=Output Title /tmp/output.m4a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec.Outputs[0].MetadataMode = tt.mode
+			spec.Outputs[0].Destination = tt.dest
+
+			plan, err := planner.NewPlanner().Plan(context.Background(), spec, nil)
+			if err != nil {
+				t.Fatalf("Plan failed: %v", err)
+			}
+
+			cmd, err := NewCommandBuilder(operators.GlobalRegistry()).Build(context.Background(), plan)
+			if err != nil {
+				t.Fatalf("Build failed: %v", err)
+			}
+
+			if args := strings.Join(cmd.Args, " "); !strings.HasSuffix(args, " -map [v] "+tt.wantArgs) {
+				t.Errorf("expected args to end with %q, got %q", tt.wantArgs, args)
+			}
+		})
+	}
+}
+
+func TestValidateFilterGraph_Structure(t *testing.T) {
+	// Make sure only the structural check runs
+	t.Setenv("PATH", t.TempDir())
+
+	valid := []string{
+		"[0:v]scale=1280:720:flags=bicubic[v]",
+		"[0:v]trim=start=10:duration=30,setpts=PTS-STARTPTS[v];[0:a]atrim=start=10:duration=30,asetpts=PTS-STARTPTS[a]",
+		"[0:v][1:v]overlay=10:10[tmp];[tmp]drawtext=text='a, b; [c]'[v]",
+		"[0:v]split=2[a][b];[a]scale=640:360[small];[b]null[big]",
+		"[0:v]trim=start=10[v];[v]scale=1280:720[v]",
+		"[tmp]hflip[v];[0:v]null[tmp]",
+		"[0:v]select='eq(n\\,0)'",
+	}
+	for _, graph := range valid {
+		if err := ValidateFilterGraph(graph); err != nil {
+			t.Errorf("ValidateFilterGraph(%q) = %v, want nil", graph, err)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"[0:v]scale=1280:720[v",
+		"[0:v]scale=1280:720]v[",
+		"[0:v]drawtext=text='unterminated[v]",
+		"[0:v]scale=1280:720,,setpts=PTS[v]",
+		"[0:v]scale=1280:720[v];",
+		"[0:v]=1280:720[v]",
+		"[missing]scale=1280:720[v]",
+		"[0:v]split[a][a]",
+		"[0:v]split[a][b];[a][a]overlay[v]",
+	}
+	for _, graph := range invalid {
+		err := ValidateFilterGraph(graph)
+		if !errors.Is(err, ErrInvalidFilterGraph) {
+			t.Errorf("ValidateFilterGraph(%q) = %v, want ErrInvalidFilterGraph", graph, err)
+		}
+	}
+}
+
+func TestValidateFilterGraph_FFmpegLint(t *testing.T) {
+	// A fake ffmpeg that counts its runs and rejects unknown filters
+	dir := t.TempDir()
+	countFile := filepath.Join(dir, "runs")
+	script := "#!/bin/sh\n" +
+		"echo run >> " + countFile + "\n" +
+		"case \"$*\" in *bogus*) echo \"No such filter: 'bogus'\" >&2; exit 1;; esac\n"
+	if err := os.WriteFile(filepath.Join(dir, "ffmpeg"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake ffmpeg: %v", err)
+	}
+	t.Setenv("PATH", dir)
+
+	runs := func() int {
+		data, _ := os.ReadFile(countFile)
+		return strings.Count(string(data), "run")
+	}
+
+	err := ValidateFilterGraph("[0:v]bogus=1[v]")
+	if !errors.Is(err, ErrInvalidFilterGraph) || !strings.Contains(err.Error(), "No such filter") {
+		t.Errorf("expected ffmpeg error, got %v", err)
+	}
+
+	// Valid graphs are linted once and then served from the cache
+	graph := "[0:v][1:a]concat=n=1:v=1:a=1[v][a]; [0:v]hflip[flipped]"
+	for i := 0; i < 3; i++ {
+		if err := ValidateFilterGraph(graph); err != nil {
+			t.Fatalf("ValidateFilterGraph() failed: %v", err)
+		}
+	}
+	if n := runs(); n != 2 {
+		t.Errorf("expected 2 ffmpeg runs, got %d", n)
+	}
+}
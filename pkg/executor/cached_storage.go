@@ -0,0 +1,144 @@
+package executor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/chicogong/media-pipeline/pkg/storage"
+)
+
+// CachedStorageManager is a StorageManager that keeps remote inputs in a
+// cache directory. Repeated downloads of a URI are served from the cache,
+// and different URIs with identical content share a single cached copy.
+type CachedStorageManager struct {
+	*StorageManager
+
+	cacheDir string
+
+	mu       sync.Mutex
+	uriIndex map[string]string // URI -> cached path
+
+	// ContentHashIndex maps the SHA-256 of each cached file to its path
+	ContentHashIndex map[string]string
+}
+
+// NewCachedStorageManager wraps sm with a download cache in cacheDir.
+// Inputs prepared through sm are cached as well.
+func NewCachedStorageManager(sm *StorageManager, cacheDir string) (*CachedStorageManager, error) {
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	c := &CachedStorageManager{
+		StorageManager:   sm,
+		cacheDir:         cacheDir,
+		uriIndex:         make(map[string]string),
+		ContentHashIndex: make(map[string]string),
+	}
+	sm.downloadInput = c.DownloadInputWithProgress
+
+	return c, nil
+}
+
+// DownloadInput returns a local copy of uri in tempDir, downloading it only
+// if it is not already cached. Cached files are hard-linked into tempDir.
+func (c *CachedStorageManager) DownloadInput(ctx context.Context, uri, tempDir string) (string, error) {
+	return c.DownloadInputWithProgress(ctx, uri, tempDir, nil)
+}
+
+// DownloadInputWithProgress is DownloadInput with onProgress called while a
+// cache miss is downloaded. Cache hits report no progress.
+func (c *CachedStorageManager) DownloadInputWithProgress(ctx context.Context, uri, tempDir string, onProgress storage.ProgressFunc) (string, error) {
+	if !c.isRemote(uri) {
+		return c.StorageManager.DownloadInputWithProgress(ctx, uri, tempDir, onProgress)
+	}
+
+	cachedPath, err := c.cachedDownload(ctx, uri, onProgress)
+	if err != nil {
+		return "", err
+	}
+
+	localPath := filepath.Join(tempDir, inputFileName(uri))
+	if err := c.linkOrCopy(cachedPath, localPath); err != nil {
+		return "", fmt.Errorf("failed to link cached input: %w", err)
+	}
+	return localPath, nil
+}
+
+// cachedDownload returns the cached path for uri, downloading and indexing
+// it on a miss
+func (c *CachedStorageManager) cachedDownload(ctx context.Context, uri string, onProgress storage.ProgressFunc) (string, error) {
+	c.mu.Lock()
+	if path, ok := c.uriIndex[uri]; ok {
+		if _, err := os.Stat(path); err == nil {
+			c.mu.Unlock()
+			return path, nil
+		}
+		delete(c.uriIndex, uri)
+	}
+	c.mu.Unlock()
+
+	stagingDir, err := os.MkdirTemp(c.cacheDir, "download-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(stagingDir)
+
+	staged, err := c.StorageManager.DownloadInputWithProgress(ctx, uri, stagingDir, onProgress)
+	if err != nil {
+		return "", err
+	}
+
+	hash, err := hashFile(staged)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", uri, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Identical content is already cached under another URI
+	if path, ok := c.ContentHashIndex[hash]; ok {
+		c.uriIndex[uri] = path
+		return path, nil
+	}
+
+	cachedPath := filepath.Join(c.cacheDir, hash+filepath.Ext(staged))
+	if err := os.Rename(staged, cachedPath); err != nil {
+		return "", fmt.Errorf("failed to cache %s: %w", uri, err)
+	}
+
+	c.ContentHashIndex[hash] = cachedPath
+	c.uriIndex[uri] = cachedPath
+	return cachedPath, nil
+}
+
+// hashFile returns the hex-encoded SHA-256 of a file
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// linkOrCopy hard-links src to dst, copying if linking is not possible
+func (c *CachedStorageManager) linkOrCopy(src, dst string) error {
+	os.Remove(dst)
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+	return c.copyFile(src, dst)
+}
@@ -70,7 +70,7 @@ func ExampleExecutor() {
 	fmt.Printf("Command: %s\n", cmd.Args[0])
 
 	// Output:
-	// FFmpeg command built with 8 arguments
+	// FFmpeg command built with 12 arguments
 	// Command: ffmpeg
 }
 
@@ -171,5 +171,5 @@ func ExampleCommandBuilder() {
 
 	// Output:
 	// Command: ffmpeg
-	// Total arguments: 10
+	// Total arguments: 14
 }
@@ -3,11 +3,15 @@ package executor
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/chicogong/media-pipeline/pkg/operators"
@@ -31,6 +35,16 @@ func NewExecutor(registry *operators.Registry) *Executor {
 	}
 }
 
+// NewExecutorWithStorageConfig creates an executor whose storage manager
+// uses config for remote transfers
+func NewExecutorWithStorageConfig(registry *operators.Registry, config StorageConfig) *Executor {
+	return &Executor{
+		builder:        NewCommandBuilder(registry),
+		parser:         NewProgressParser(),
+		storageManager: NewStorageManagerWithConfig(config),
+	}
+}
+
 // ExecuteOptions contains options for execution
 type ExecuteOptions struct {
 	// WorkDir is the working directory for execution
@@ -41,18 +55,57 @@ type ExecuteOptions struct {
 
 	// OnLog is called for FFmpeg log output
 	OnLog func(string)
+
+	// OnDownloadProgress is called as remote inputs are downloaded
+	OnDownloadProgress func(*schemas.DownloadProgress)
+
+	// OnUploadProgress is called as outputs are uploaded to remote destinations
+	OnUploadProgress func(*schemas.UploadProgress)
+
+	// OnOutput is called for each output once FFmpeg has finished, with the
+	// output ID, the local file and its destination URI. The local file is
+	// removed when Execute returns.
+	OnOutput func(outputID, localPath, destURI string)
+
+	// Sequential runs each execution stage as a separate FFmpeg command,
+	// materializing intermediate results in the temp directory. Plans using
+	// two-pass operators always run this way.
+	Sequential bool
+
+	// InputSeeking compiles a leading trim to input seeking (-ss before -i)
+	InputSeeking bool
+
+	// MaxOutputBytes cancels execution once any output file exceeds this size (0 = no limit)
+	MaxOutputBytes int64
+
+	// Limits are the job's resource limits; MaxMemory bounds the total size
+	// of remote inputs, checked before they are downloaded
+	Limits *schemas.ResourceLimits
+
+	// FFmpegLogLevel is passed to FFmpeg as -loglevel. Messages at or above
+	// it are reported to Logger; empty leaves FFmpeg's default output alone.
+	FFmpegLogLevel FFmpegLogLevel
+
+	// Logger receives FFmpeg's leveled log messages (nil = slog.Default())
+	Logger *slog.Logger
 }
 
-// Execute executes a processing plan
-func (e *Executor) Execute(ctx context.Context, plan *schemas.ProcessingPlan, opts *ExecuteOptions) error {
+// Execute executes a processing plan. The result describes what was run and
+// written; when execution fails it holds whatever was known by then.
+func (e *Executor) Execute(ctx context.Context, plan *schemas.ProcessingPlan, opts *ExecuteOptions) (result ExecutionResult, err error) {
 	if opts == nil {
 		opts = &ExecuteOptions{}
 	}
 
+	start := time.Now()
+	defer func() {
+		result.WallClockDuration = time.Since(start)
+	}()
+
 	// Create temporary directory for downloaded inputs and intermediate outputs
 	tempDir, err := os.MkdirTemp("", "media-pipeline-*")
 	if err != nil {
-		return fmt.Errorf("failed to create temp directory: %w", err)
+		return result, fmt.Errorf("failed to create temp directory: %w", err)
 	}
 	defer func() {
 		// Cleanup temp directory (ignore errors)
@@ -60,14 +113,15 @@ func (e *Executor) Execute(ctx context.Context, plan *schemas.ProcessingPlan, op
 	}()
 
 	// Download remote inputs to local temp directory
-	inputMap, err := e.storageManager.PrepareInputs(ctx, plan, tempDir)
+	inputMap, err := e.storageManager.PrepareInputs(ctx, plan, tempDir, opts.Limits, opts.OnDownloadProgress)
 	if err != nil {
-		return fmt.Errorf("failed to prepare inputs: %w", err)
+		return result, fmt.Errorf("failed to prepare inputs: %w", err)
 	}
 
 	// Prepare outputs: generate local temp paths and store original destinations
 	outputFiles := make(map[string]string) // node.ID -> local temp path
 	origDestURIs := make(map[string]string) // node.ID -> original destination URI
+	outputIDs := make(map[string]string)    // node.ID -> spec output ID
 
 	for _, node := range plan.Nodes {
 		if node.Type == "output" {
@@ -75,6 +129,10 @@ func (e *Executor) Execute(ctx context.Context, plan *schemas.ProcessingPlan, op
 			// Use node ID as base name, preserve extension from original URI if possible
 			origURI := node.DestURI
 			origDestURIs[node.ID] = origURI
+			outputIDs[node.ID] = node.OutputID
+			if outputIDs[node.ID] == "" {
+				outputIDs[node.ID] = node.ID
+			}
 
 			// Try to extract filename from URI
 			var filename string
@@ -127,10 +185,10 @@ func (e *Executor) Execute(ctx context.Context, plan *schemas.ProcessingPlan, op
 		Commands:        plan.Commands,
 	}
 
-	// Build FFmpeg command using the modified plan
-	cmd, err := e.builder.Build(ctx, planCopy)
+	// Build FFmpeg commands using the modified plan
+	cmds, err := e.buildCommands(ctx, planCopy, opts, tempDir)
 	if err != nil {
-		return fmt.Errorf("failed to build command: %w", err)
+		return result, fmt.Errorf("failed to build command: %w", err)
 	}
 
 	// Set total duration for progress tracking
@@ -138,30 +196,158 @@ func (e *Executor) Execute(ctx context.Context, plan *schemas.ProcessingPlan, op
 		e.parser.SetTotalDuration(plan.ResourceEstimate.TotalDuration)
 	}
 
-	// Execute command
-	if err := e.executeCommand(ctx, cmd, opts); err != nil {
-		return fmt.Errorf("failed to execute command: %w", err)
+	// Watch output sizes while FFmpeg runs
+	execCtx := ctx
+	var watcher *OutputSizeWatcher
+	if opts.MaxOutputBytes > 0 {
+		var cancel context.CancelFunc
+		execCtx, cancel = context.WithCancel(ctx)
+		defer cancel()
+
+		paths := make([]string, 0, len(outputFiles))
+		for _, localPath := range outputFiles {
+			paths = append(paths, localPath)
+		}
+		watcher = NewOutputSizeWatcher(paths, opts.MaxOutputBytes)
+		go watcher.Watch(execCtx, cancel)
+	}
+
+	result.TotalBytesRead = localFilesSize(inputMap)
+
+	// Execute commands in dependency order
+	order, err := commandOrder(cmds)
+	if err != nil {
+		return result, fmt.Errorf("failed to order commands: %w", err)
+	}
+	commandLines := make([]string, 0, len(order))
+	for _, i := range order {
+		commandLines = append(commandLines, strings.Join(cmds[i].Args, " "))
+		result.CommandExecuted = strings.Join(commandLines, "\n")
+
+		err := e.executeCommand(execCtx, cmds[i], opts)
+		result.FFmpegExitCode = exitCode(err)
+		if err != nil {
+			if watcher != nil && watcher.Err() != nil {
+				return result, watcher.Err()
+			}
+			return result, fmt.Errorf("failed to execute command: %w", err)
+		}
+	}
+
+	// Catch outputs that outgrew the limit after the last poll
+	if watcher != nil {
+		if err := watcher.check(); err != nil {
+			return result, err
+		}
+	}
+
+	result.OutputSizes = make(map[string]int64, len(outputFiles))
+	for nodeID, localPath := range outputFiles {
+		if info, err := os.Stat(localPath); err == nil {
+			result.OutputSizes[outputIDs[nodeID]] = info.Size()
+			result.TotalBytesWritten += info.Size()
+		}
+	}
+
+	if opts.OnOutput != nil {
+		for nodeID, localPath := range outputFiles {
+			opts.OnOutput(outputIDs[nodeID], localPath, origDestURIs[nodeID])
+		}
 	}
 
 	// Upload outputs to remote destinations
+	var uploads []outputUpload
 	for nodeID, localPath := range outputFiles {
-		destURI := origDestURIs[nodeID]
-		if destURI == "" {
-			// No destination specified, output was written locally only
-			continue
+		// Without a destination the output was written locally only
+		if destURI := origDestURIs[nodeID]; destURI != "" {
+			uploads = append(uploads, outputUpload{nodeID: nodeID, localPath: localPath, destURI: destURI})
 		}
-		if err := e.storageManager.UploadOutput(ctx, localPath, destURI); err != nil {
-			return fmt.Errorf("failed to upload output %s: %w", nodeID, err)
+	}
+	sort.Slice(uploads, func(i, j int) bool { return uploads[i].nodeID < uploads[j].nodeID })
+
+	if err := e.storageManager.uploadAll(ctx, uploads, opts.OnUploadProgress); err != nil {
+		return result, fmt.Errorf("failed to upload outputs: %w", err)
+	}
+
+	return result, nil
+}
+
+// buildCommands builds the FFmpeg commands for plan: a single command, or
+// one per stage when running sequentially or the plan needs several passes
+func (e *Executor) buildCommands(ctx context.Context, plan *schemas.ProcessingPlan, opts *ExecuteOptions, tempDir string) ([]*Command, error) {
+	if opts.FFmpegLogLevel != "" {
+		if err := opts.FFmpegLogLevel.Validate(); err != nil {
+			return nil, err
 		}
 	}
 
-	return nil
+	var cmds []*Command
+	if opts.Sequential || e.builder.requiresMultiCommand(plan) {
+		var err error
+		cmds, err = e.builder.BuildMultiCommandWithOptions(ctx, plan, &BuildOptions{TempDir: tempDir})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		cmd, err := e.builder.BuildWithOptions(ctx, plan, &BuildOptions{InputSeeking: opts.InputSeeking})
+		if err != nil {
+			return nil, err
+		}
+		cmds = []*Command{cmd}
+	}
+
+	if opts.FFmpegLogLevel != "" {
+		for _, cmd := range cmds {
+			args := append([]string{cmd.Args[0]}, opts.FFmpegLogLevel.Args()...)
+			cmd.Args = append(args, cmd.Args[1:]...)
+		}
+	}
+	return cmds, nil
+}
+
+// DryRun builds the commands Execute would run for plan and validates their
+// filter graphs, without downloading inputs or running FFmpeg. Intermediate
+// files of multi-command plans are relative to the working directory.
+func (e *Executor) DryRun(ctx context.Context, plan *schemas.ProcessingPlan, opts *ExecuteOptions) ([]*Command, error) {
+	if opts == nil {
+		opts = &ExecuteOptions{}
+	}
+
+	cmds, err := e.buildCommands(ctx, plan, opts, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to build command: %w", err)
+	}
+
+	for i, cmd := range cmds {
+		for j := 1; j+1 < len(cmd.Args); j++ {
+			if cmd.Args[j] != "-filter_complex" {
+				continue
+			}
+			if err := ValidateFilterGraph(cmd.Args[j+1]); err != nil {
+				return nil, fmt.Errorf("command %d: %w", i, err)
+			}
+		}
+	}
+
+	return cmds, nil
 }
 
+// ErrNodeTimeout is returned when a command runs longer than the timeout of
+// the plan node it executes
+var ErrNodeTimeout = errors.New("node execution timed out")
+
 // executeCommand executes an FFmpeg command
 func (e *Executor) executeCommand(ctx context.Context, cmd *Command, opts *ExecuteOptions) error {
+	// A node timeout tightens the deadline of the job context
+	cmdCtx := ctx
+	if cmd.Timeout > 0 {
+		var cancel context.CancelFunc
+		cmdCtx, cancel = context.WithTimeout(ctx, cmd.Timeout)
+		defer cancel()
+	}
+
 	// Create exec.Cmd
-	execCmd := exec.CommandContext(ctx, cmd.Args[0], cmd.Args[1:]...)
+	execCmd := exec.CommandContext(cmdCtx, cmd.Args[0], cmd.Args[1:]...)
 
 	if cmd.WorkDir != "" {
 		execCmd.Dir = cmd.WorkDir
@@ -189,7 +375,7 @@ func (e *Executor) executeCommand(ctx context.Context, cmd *Command, opts *Execu
 	// Stream stderr for progress
 	stderrDone := make(chan error, 1)
 	go func() {
-		stderrDone <- e.streamStderr(stderr, opts)
+		stderrDone <- e.streamStderr(ctx, stderr, opts)
 	}()
 
 	// Stream stdout for logs
@@ -206,19 +392,39 @@ func (e *Executor) executeCommand(ctx context.Context, cmd *Command, opts *Execu
 	<-stdoutDone
 
 	if cmdErr != nil {
+		if ctx.Err() == nil && cmdCtx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("%w: node %s exceeded %v", ErrNodeTimeout, cmd.TimeoutNode, cmd.Timeout)
+		}
 		return fmt.Errorf("ffmpeg execution failed: %w", cmdErr)
 	}
 
 	return nil
 }
 
-// streamStderr reads and processes stderr output
-func (e *Executor) streamStderr(reader io.Reader, opts *ExecuteOptions) error {
+// streamStderr reads and processes stderr output. With a log level set,
+// leveled messages below it are discarded and the rest are reported to the
+// logger; progress lines carry no level and are always processed.
+func (e *Executor) streamStderr(ctx context.Context, reader io.Reader, opts *ExecuteOptions) error {
 	scanner := bufio.NewScanner(reader)
 
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
 	for scanner.Scan() {
 		line := scanner.Text()
 
+		if opts.FFmpegLogLevel != "" {
+			parsed := ParseFFmpegLogLine(line)
+			if parsed.Level != "" {
+				if !opts.FFmpegLogLevel.Allows(parsed.Level) {
+					continue
+				}
+				logFFmpegLine(ctx, logger, parsed)
+			}
+		}
+
 		// Try to parse progress
 		progress := e.parser.ParseLine(line)
 		if progress != nil && opts.OnProgress != nil {
@@ -256,8 +462,46 @@ func (e *Executor) BuildCommand(ctx context.Context, plan *schemas.ProcessingPla
 
 // ExecutionResult contains the result of executing a plan
 type ExecutionResult struct {
-	Duration   time.Duration
-	Success    bool
-	Error      error
-	FinalFrame int
+	// WallClockDuration is the time Execute took, including transfers
+	WallClockDuration time.Duration
+
+	// FFmpegExitCode is the exit code of the last FFmpeg command run, or -1
+	// if it did not exit on its own (e.g. it was killed on timeout)
+	FFmpegExitCode int
+
+	// OutputSizes maps each output ID to the size of the file written
+	OutputSizes map[string]int64
+
+	// TotalBytesRead is the size of the local copies of the inputs
+	TotalBytesRead int64
+
+	// TotalBytesWritten is the size of all outputs
+	TotalBytesWritten int64
+
+	// CommandExecuted holds the FFmpeg command lines run, one per line
+	CommandExecuted string
+}
+
+// exitCode returns the exit code of a command that finished with err
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// localFilesSize returns the total size of the files in paths, a map of
+// URI -> local path. Files that cannot be read are not counted.
+func localFilesSize(paths map[string]string) int64 {
+	var total int64
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			total += info.Size()
+		}
+	}
+	return total
 }
@@ -2,7 +2,12 @@ package executor
 
 import (
 	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/chicogong/media-pipeline/pkg/operators"
 	"github.com/chicogong/media-pipeline/pkg/operators/builtin"
@@ -202,3 +207,203 @@ func TestExecutor_WithProgressCallback(t *testing.T) {
 	// Note: progressCalled would be true if we actually executed FFmpeg
 	_ = progressCalled
 }
+
+// writeSlowFFmpeg writes a fake ffmpeg that hangs for longer than any test
+func writeSlowFFmpeg(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ffmpeg")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\nexec sleep 10\n"), 0755); err != nil {
+		t.Fatalf("failed to write fake ffmpeg: %v", err)
+	}
+	return path
+}
+
+// writeFakeFFmpeg puts an ffmpeg on PATH that writes "encoded" to its last
+// argument, the output file
+func writeFakeFFmpeg(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	script := "#!/bin/sh\nfor last; do :; done\nprintf encoded > \"$last\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "ffmpeg"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake ffmpeg: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestExecutor_ExecuteResult(t *testing.T) {
+	writeFakeFFmpeg(t)
+	operators.Register(&builtin.ScaleOperator{})
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.mp4")
+	if err := os.WriteFile(input, []byte("source media"), 0644); err != nil {
+		t.Fatalf("failed to write input: %v", err)
+	}
+	output := filepath.Join(dir, "out", "output.mp4")
+
+	spec := &schemas.JobSpec{
+		Inputs: []schemas.Input{
+			{ID: "video", Source: input},
+		},
+		Operations: []schemas.Operation{
+			{Op: "scale", Input: "video", Output: "scaled",
+				Params: map[string]interface{}{"width": 640, "height": 360}},
+		},
+		Outputs: []schemas.Output{
+			{ID: "scaled", Destination: output},
+		},
+	}
+
+	plan, err := planner.NewPlanner().Plan(context.Background(), spec, nil)
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+
+	result, err := NewExecutor(operators.GlobalRegistry()).Execute(context.Background(), plan, nil)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if result.WallClockDuration <= 0 {
+		t.Errorf("expected a wall clock duration, got %v", result.WallClockDuration)
+	}
+	if result.FFmpegExitCode != 0 {
+		t.Errorf("expected exit code 0, got %d", result.FFmpegExitCode)
+	}
+	if got := result.OutputSizes["scaled"]; got != int64(len("encoded")) {
+		t.Errorf("expected output size %d, got %d (%v)", len("encoded"), got, result.OutputSizes)
+	}
+	if result.TotalBytesRead != int64(len("source media")) {
+		t.Errorf("expected %d bytes read, got %d", len("source media"), result.TotalBytesRead)
+	}
+	if result.TotalBytesWritten != int64(len("encoded")) {
+		t.Errorf("expected %d bytes written, got %d", len("encoded"), result.TotalBytesWritten)
+	}
+	if !strings.HasPrefix(result.CommandExecuted, "ffmpeg ") || !strings.Contains(result.CommandExecuted, "scale=640:360") {
+		t.Errorf("unexpected command %q", result.CommandExecuted)
+	}
+}
+
+func TestExecutor_ExecuteResultExitCode(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "ffmpeg"), []byte("#!/bin/sh\nexit 3\n"), 0755); err != nil {
+		t.Fatalf("failed to write fake ffmpeg: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	operators.Register(&builtin.ScaleOperator{})
+
+	if err := os.WriteFile(filepath.Join(dir, "input.mp4"), []byte("source media"), 0644); err != nil {
+		t.Fatalf("failed to write input: %v", err)
+	}
+
+	spec := &schemas.JobSpec{
+		Inputs: []schemas.Input{
+			{ID: "video", Source: filepath.Join(dir, "input.mp4")},
+		},
+		Operations: []schemas.Operation{
+			{Op: "scale", Input: "video", Output: "scaled",
+				Params: map[string]interface{}{"width": 640, "height": 360}},
+		},
+		Outputs: []schemas.Output{
+			{ID: "scaled", Destination: filepath.Join(dir, "output.mp4")},
+		},
+	}
+
+	plan, err := planner.NewPlanner().Plan(context.Background(), spec, nil)
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+
+	result, err := NewExecutor(operators.GlobalRegistry()).Execute(context.Background(), plan, nil)
+	if err == nil {
+		t.Fatal("expected Execute to fail")
+	}
+	if result.FFmpegExitCode != 3 {
+		t.Errorf("expected exit code 3, got %d", result.FFmpegExitCode)
+	}
+}
+
+func TestExecuteCommand_NodeTimeout(t *testing.T) {
+	e := &Executor{parser: NewProgressParser()}
+	cmd := &Command{
+		Args:        []string{writeSlowFFmpeg(t)},
+		Timeout:     50 * time.Millisecond,
+		TimeoutNode: "stabilize_0",
+	}
+
+	start := time.Now()
+	err := e.executeCommand(context.Background(), cmd, &ExecuteOptions{})
+	if !errors.Is(err, ErrNodeTimeout) {
+		t.Fatalf("expected ErrNodeTimeout, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "stabilize_0") {
+		t.Errorf("expected error to name the node, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("node timeout was not enforced, took %v", elapsed)
+	}
+}
+
+func TestExecuteCommand_JobCancelIsNotNodeTimeout(t *testing.T) {
+	e := &Executor{parser: NewProgressParser()}
+	cmd := &Command{
+		Args:    []string{writeSlowFFmpeg(t)},
+		Timeout: time.Minute,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := e.executeCommand(ctx, cmd, &ExecuteOptions{})
+	if err == nil {
+		t.Fatal("expected command to be cancelled")
+	}
+	if errors.Is(err, ErrNodeTimeout) {
+		t.Errorf("expected job cancellation, got node timeout: %v", err)
+	}
+}
+
+func TestExecutor_DryRun(t *testing.T) {
+	t.Setenv("PATH", t.TempDir()) // Structural filter graph check only
+
+	operators.Register(&builtin.TrimOperator{})
+	operators.Register(&builtin.ScaleOperator{})
+
+	spec := &schemas.JobSpec{
+		Inputs: []schemas.Input{
+			{ID: "video", Source: "/tmp/input.mp4"},
+		},
+		Operations: []schemas.Operation{
+			{Op: "trim", Input: "video", Output: "trimmed",
+				Params: map[string]interface{}{"start": "00:00:10", "duration": "00:00:30"}},
+			{Op: "scale", Input: "trimmed", Output: "scaled",
+				Params: map[string]interface{}{"width": 1280, "height": 720}},
+		},
+		Outputs: []schemas.Output{
+			{ID: "scaled", Destination: "/tmp/output.mp4"},
+		},
+	}
+
+	plan, err := planner.NewPlanner().Plan(context.Background(), spec, nil)
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+
+	executor := NewExecutor(operators.GlobalRegistry())
+
+	cmds, err := executor.DryRun(context.Background(), plan, nil)
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+	if len(cmds) != 1 {
+		t.Errorf("expected 1 command, got %d", len(cmds))
+	}
+
+	cmds, err = executor.DryRun(context.Background(), plan, &ExecuteOptions{Sequential: true})
+	if err != nil {
+		t.Fatalf("sequential DryRun failed: %v", err)
+	}
+	if len(cmds) != 2 {
+		t.Errorf("expected 2 sequential commands, got %d", len(cmds))
+	}
+}
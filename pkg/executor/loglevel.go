@@ -0,0 +1,110 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+)
+
+// FFmpegLogLevel is an FFmpeg -loglevel value
+type FFmpegLogLevel string
+
+// FFmpeg log levels, from least to most verbose
+const (
+	FFmpegLogQuiet   FFmpegLogLevel = "quiet"
+	FFmpegLogError   FFmpegLogLevel = "error"
+	FFmpegLogWarning FFmpegLogLevel = "warning"
+	FFmpegLogInfo    FFmpegLogLevel = "info"
+	FFmpegLogVerbose FFmpegLogLevel = "verbose"
+	FFmpegLogDebug   FFmpegLogLevel = "debug"
+)
+
+// ffmpegLogSeverity ranks the levels FFmpeg can prefix a message with,
+// using FFmpeg's own numbering (lower is more severe)
+var ffmpegLogSeverity = map[FFmpegLogLevel]int{
+	FFmpegLogQuiet:   -8,
+	"panic":          0,
+	"fatal":          8,
+	FFmpegLogError:   16,
+	FFmpegLogWarning: 24,
+	FFmpegLogInfo:    32,
+	FFmpegLogVerbose: 40,
+	FFmpegLogDebug:   48,
+	"trace":          56,
+}
+
+// Validate checks that l is one of the supported log levels
+func (l FFmpegLogLevel) Validate() error {
+	switch l {
+	case FFmpegLogQuiet, FFmpegLogError, FFmpegLogWarning, FFmpegLogInfo, FFmpegLogVerbose, FFmpegLogDebug:
+		return nil
+	}
+	return fmt.Errorf("invalid FFmpeg log level %q", l)
+}
+
+// Args returns the FFmpeg arguments selecting the level. Messages are
+// prefixed with their level so they can be parsed, and progress statistics,
+// which FFmpeg prints at info level, are kept below it.
+func (l FFmpegLogLevel) Args() []string {
+	args := []string{"-loglevel", "level+" + string(l)}
+	if ffmpegLogSeverity[l] < ffmpegLogSeverity[FFmpegLogInfo] {
+		args = append(args, "-stats")
+	}
+	return args
+}
+
+// Allows reports whether a message at level passes the threshold l
+func (l FFmpegLogLevel) Allows(level FFmpegLogLevel) bool {
+	severity, ok := ffmpegLogSeverity[level]
+	return ok && severity <= ffmpegLogSeverity[l]
+}
+
+// FFmpegLogLine is a parsed FFmpeg log message
+type FFmpegLogLine struct {
+	Category string         // Component that logged the message, e.g. "h264"
+	Address  string         // Address of the component's context
+	Level    FFmpegLogLevel // Empty if the line has no level prefix
+	Message  string
+}
+
+// ffmpegLogRegex matches "[<category> @ <addr>] [<level>] <msg>", where
+// both bracketed prefixes are optional
+var ffmpegLogRegex = regexp.MustCompile(`^(?:\[(\S+) @ (0x[0-9a-fA-F]+)\] )?(?:\[(panic|fatal|error|warning|info|verbose|debug|trace)\] )?(.*)$`)
+
+// ParseFFmpegLogLine splits an FFmpeg stderr line into its parts
+func ParseFFmpegLogLine(line string) FFmpegLogLine {
+	m := ffmpegLogRegex.FindStringSubmatch(line)
+	if m == nil {
+		return FFmpegLogLine{Message: line}
+	}
+	return FFmpegLogLine{
+		Category: m[1],
+		Address:  m[2],
+		Level:    FFmpegLogLevel(m[3]),
+		Message:  m[4],
+	}
+}
+
+// slogLevel maps an FFmpeg level to the logger level it is reported at
+func (l FFmpegLogLevel) slogLevel() slog.Level {
+	switch severity := ffmpegLogSeverity[l]; {
+	case severity <= ffmpegLogSeverity[FFmpegLogError]:
+		return slog.LevelError
+	case severity <= ffmpegLogSeverity[FFmpegLogWarning]:
+		return slog.LevelWarn
+	case severity <= ffmpegLogSeverity[FFmpegLogInfo]:
+		return slog.LevelInfo
+	default:
+		return slog.LevelDebug
+	}
+}
+
+// logFFmpegLine reports a parsed line to logger at its level
+func logFFmpegLine(ctx context.Context, logger *slog.Logger, line FFmpegLogLine) {
+	attrs := []any{slog.String("ffmpeg_level", string(line.Level))}
+	if line.Category != "" {
+		attrs = append(attrs, slog.String("category", line.Category), slog.String("address", line.Address))
+	}
+	logger.Log(ctx, line.Level.slogLevel(), line.Message, attrs...)
+}
@@ -0,0 +1,111 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFFmpegLogLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want FFmpegLogLine
+	}{
+		{
+			line: "[h264 @ 0x55d0c8a3e6c0] [error] non-existing PPS 0 referenced",
+			want: FFmpegLogLine{Category: "h264", Address: "0x55d0c8a3e6c0", Level: FFmpegLogError, Message: "non-existing PPS 0 referenced"},
+		},
+		{
+			line: "[mp4 @ 0x7f00] [warning] Non-monotonous DTS",
+			want: FFmpegLogLine{Category: "mp4", Address: "0x7f00", Level: FFmpegLogWarning, Message: "Non-monotonous DTS"},
+		},
+		{
+			line: "[info] Stream mapping:",
+			want: FFmpegLogLine{Level: FFmpegLogInfo, Message: "Stream mapping:"},
+		},
+		{
+			line: "[aac @ 0xabc] Too many bits",
+			want: FFmpegLogLine{Category: "aac", Address: "0xabc", Message: "Too many bits"},
+		},
+		{
+			line: "frame=  100 fps=25 q=28.0 size=1024kB time=00:00:04.00 speed=1.0x",
+			want: FFmpegLogLine{Message: "frame=  100 fps=25 q=28.0 size=1024kB time=00:00:04.00 speed=1.0x"},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := ParseFFmpegLogLine(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseFFmpegLogLine(%q) = %+v, want %+v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestFFmpegLogLevel(t *testing.T) {
+	if !FFmpegLogWarning.Allows(FFmpegLogError) || !FFmpegLogWarning.Allows(FFmpegLogWarning) {
+		t.Error("expected warning to allow errors and warnings")
+	}
+	if FFmpegLogWarning.Allows(FFmpegLogInfo) || FFmpegLogQuiet.Allows(FFmpegLogError) {
+		t.Error("expected messages below the threshold to be discarded")
+	}
+	if FFmpegLogDebug.Allows("") {
+		t.Error("expected unleveled lines not to be treated as log messages")
+	}
+
+	if err := FFmpegLogLevel("loud").Validate(); err == nil {
+		t.Error("expected an unknown level to be rejected")
+	}
+
+	if got := FFmpegLogError.Args(); !reflect.DeepEqual(got, []string{"-loglevel", "level+error", "-stats"}) {
+		t.Errorf("unexpected args for error level: %v", got)
+	}
+	if got := FFmpegLogVerbose.Args(); !reflect.DeepEqual(got, []string{"-loglevel", "level+verbose"}) {
+		t.Errorf("unexpected args for verbose level: %v", got)
+	}
+}
+
+func TestExecutor_StreamStderrLogLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	var progress int
+	var logged []string
+	opts := &ExecuteOptions{
+		FFmpegLogLevel: FFmpegLogWarning,
+		Logger:         logger,
+		OnProgress:     func(*Progress) { progress++ },
+		OnLog:          func(line string) { logged = append(logged, line) },
+	}
+
+	stderr := strings.Join([]string{
+		"[h264 @ 0x1] [error] corrupt frame",
+		"[mp4 @ 0x2] [warning] Non-monotonous DTS",
+		"[info] Stream mapping:",
+		"frame=  100 fps=25 q=28.0 size=1024kB time=00:00:04.00 bitrate=2097.2kbits/s speed=1.0x",
+	}, "\n")
+
+	e := NewExecutor(nil)
+	if err := e.streamStderr(context.Background(), strings.NewReader(stderr), opts); err != nil {
+		t.Fatalf("streamStderr failed: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "level=ERROR msg=\"corrupt frame\"") || !strings.Contains(out, "category=h264") {
+		t.Errorf("expected the error to be logged at ERROR, got:\n%s", out)
+	}
+	if !strings.Contains(out, "level=WARN msg=\"Non-monotonous DTS\"") {
+		t.Errorf("expected the warning to be logged at WARN, got:\n%s", out)
+	}
+	if strings.Contains(out, "Stream mapping") {
+		t.Errorf("expected the info message to be discarded, got:\n%s", out)
+	}
+
+	if progress != 1 {
+		t.Errorf("expected 1 progress update, got %d", progress)
+	}
+	if len(logged) != 3 {
+		t.Errorf("expected the discarded line to be dropped from OnLog, got %v", logged)
+	}
+}
@@ -4,7 +4,10 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/chicogong/media-pipeline/pkg/schemas"
 )
 
 // Progress represents FFmpeg encoding progress
@@ -138,3 +141,213 @@ func parseFFmpegTime(timeStr string) time.Duration {
 		time.Duration(seconds)*time.Second +
 		time.Duration(centiseconds)*10*time.Millisecond
 }
+
+// DefaultProgressInterval is the minimum time between merged updates
+// emitted by a ProgressAggregator
+const DefaultProgressInterval = 250 * time.Millisecond
+
+// ProgressAggregator merges progress from FFmpeg processes running in
+// parallel into one Progress. Each worker is weighted by the media duration
+// it processes, so a long encode counts for more than a short one.
+type ProgressAggregator struct {
+	// Interval is the minimum time between updates sent to subscribers
+	// (default DefaultProgressInterval)
+	Interval time.Duration
+
+	mu          sync.Mutex
+	weights     map[string]time.Duration // worker ID -> media duration
+	latest      map[string]*Progress     // worker ID -> last reported progress
+	subscribers []chan *Progress
+	lastEmit    time.Time
+	timer       *time.Timer // Pending trailing update
+	closed      bool
+}
+
+// NewProgressAggregator creates an aggregator for workers with the given
+// duration weights. Workers without a weight still contribute frames, size
+// and throughput but not to the completed time.
+func NewProgressAggregator(weights map[string]time.Duration) *ProgressAggregator {
+	w := make(map[string]time.Duration, len(weights))
+	for id, d := range weights {
+		w[id] = d
+	}
+	return &ProgressAggregator{
+		Interval: DefaultProgressInterval,
+		weights:  w,
+		latest:   make(map[string]*Progress),
+	}
+}
+
+// NewProgressAggregatorFromEstimates creates an aggregator whose workers are
+// plan nodes, weighted by their estimated durations
+func NewProgressAggregatorFromEstimates(estimates *schemas.ResourceEstimates) *ProgressAggregator {
+	weights := make(map[string]time.Duration)
+	if estimates != nil {
+		for nodeID, est := range estimates.NodeEstimates {
+			if est != nil {
+				weights[nodeID] = est.Duration
+			}
+		}
+	}
+	return NewProgressAggregator(weights)
+}
+
+// TotalDuration returns the sum of all worker weights, the Time of the
+// merged progress once every worker has finished
+func (a *ProgressAggregator) TotalDuration() time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.totalDuration()
+}
+
+// totalDuration sums the worker weights. Callers must hold a.mu.
+func (a *ProgressAggregator) totalDuration() time.Duration {
+	var total time.Duration
+	for _, d := range a.weights {
+		total += d
+	}
+	return total
+}
+
+// Update records the latest progress of a worker and schedules a merged
+// update for subscribers
+func (a *ProgressAggregator) Update(workerID string, p *Progress) {
+	if p == nil {
+		return
+	}
+	copied := *p
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed {
+		return
+	}
+	a.latest[workerID] = &copied
+
+	if a.timer != nil {
+		return // A trailing update is already scheduled
+	}
+
+	interval := a.Interval
+	if interval <= 0 {
+		interval = DefaultProgressInterval
+	}
+	if wait := interval - time.Since(a.lastEmit); wait > 0 {
+		a.timer = time.AfterFunc(wait, a.emitPending)
+		return
+	}
+	a.emit()
+}
+
+// Progress returns the merged progress of all workers. Frames, FPS, size,
+// bitrate and speed are summed; Time is the weighted share of
+// TotalDuration completed so far.
+func (a *ProgressAggregator) Progress() *Progress {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.merge()
+}
+
+// merge combines the latest worker progress. Callers must hold a.mu.
+func (a *ProgressAggregator) merge() *Progress {
+	merged := &Progress{}
+	for id, p := range a.latest {
+		merged.Frame += p.Frame
+		merged.FPS += p.FPS
+		merged.Size += p.Size
+		merged.Bitrate += p.Bitrate
+		merged.Speed += p.Speed
+
+		weight, ok := a.weights[id]
+		switch {
+		case !ok:
+			// Unweighted workers add no completed time
+		case p.Time > weight:
+			merged.Time += weight
+		default:
+			merged.Time += p.Time
+		}
+	}
+
+	// Without any weights the elapsed media times are simply added up
+	if len(a.weights) == 0 {
+		for _, p := range a.latest {
+			merged.Time += p.Time
+		}
+	}
+	return merged
+}
+
+// Percent returns the completed share of TotalDuration (0-100)
+func (a *ProgressAggregator) Percent() float64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	total := a.totalDuration()
+	if total == 0 {
+		return 0
+	}
+	return float64(a.merge().Time) / float64(total) * 100
+}
+
+// Subscribe returns a channel receiving merged progress at most once per
+// Interval. Slow subscribers only see the most recent update; the channel
+// is closed by Close.
+func (a *ProgressAggregator) Subscribe() <-chan *Progress {
+	ch := make(chan *Progress, 1)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed {
+		close(ch)
+		return ch
+	}
+	a.subscribers = append(a.subscribers, ch)
+	return ch
+}
+
+// Close stops pending updates and closes all subscriber channels
+func (a *ProgressAggregator) Close() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed {
+		return
+	}
+	a.closed = true
+
+	if a.timer != nil {
+		a.timer.Stop()
+		a.timer = nil
+	}
+	for _, ch := range a.subscribers {
+		close(ch)
+	}
+	a.subscribers = nil
+}
+
+// emitPending sends the update scheduled by Update
+func (a *ProgressAggregator) emitPending() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed {
+		return
+	}
+	a.timer = nil
+	a.emit()
+}
+
+// emit sends the merged progress to every subscriber, replacing any update
+// it has not received yet. Callers must hold a.mu.
+func (a *ProgressAggregator) emit() {
+	a.lastEmit = time.Now()
+	merged := a.merge()
+
+	for _, ch := range a.subscribers {
+		select {
+		case <-ch:
+		default:
+		}
+		p := *merged
+		ch <- &p
+	}
+}
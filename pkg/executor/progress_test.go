@@ -1,8 +1,12 @@
 package executor
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/chicogong/media-pipeline/pkg/schemas"
 )
 
 func TestProgressParser_ParseLine(t *testing.T) {
@@ -164,3 +168,116 @@ func TestProgressParser_ComputePercentage(t *testing.T) {
 		t.Errorf("expected 0%% when total duration unknown, got %.2f%%", percentage)
 	}
 }
+
+func TestProgressAggregator_Weighted(t *testing.T) {
+	agg := NewProgressAggregatorFromEstimates(&schemas.ResourceEstimates{
+		NodeEstimates: map[string]*schemas.NodeEstimates{
+			"long":  {Duration: 30 * time.Second},
+			"short": {Duration: 10 * time.Second},
+		},
+	})
+	defer agg.Close()
+
+	if total := agg.TotalDuration(); total != 40*time.Second {
+		t.Fatalf("expected total duration 40s, got %v", total)
+	}
+
+	agg.Update("long", &Progress{Frame: 300, Time: 15 * time.Second, Size: 1000, Speed: 2})
+	agg.Update("short", &Progress{Frame: 100, Time: 20 * time.Second, Size: 500, Speed: 1})
+
+	merged := agg.Progress()
+	if merged.Frame != 400 || merged.Size != 1500 || merged.Speed != 3 {
+		t.Errorf("expected summed frame/size/speed 400/1500/3, got %d/%d/%v", merged.Frame, merged.Size, merged.Speed)
+	}
+
+	// The short worker is capped at its 10s weight: (15s + 10s) / 40s
+	if merged.Time != 25*time.Second {
+		t.Errorf("expected merged time 25s, got %v", merged.Time)
+	}
+	if percent := agg.Percent(); percent != 62.5 {
+		t.Errorf("expected 62.5%%, got %.2f%%", percent)
+	}
+}
+
+func TestProgressAggregator_Throttle(t *testing.T) {
+	agg := NewProgressAggregator(map[string]time.Duration{"w": 10 * time.Second})
+	agg.Interval = 50 * time.Millisecond
+	defer agg.Close()
+
+	updates := agg.Subscribe()
+
+	// The first update is sent immediately, the burst after it is coalesced
+	// into one trailing update carrying the latest progress
+	for i := 1; i <= 100; i++ {
+		agg.Update("w", &Progress{Frame: i, Time: time.Duration(i) * 100 * time.Millisecond})
+	}
+
+	first := <-updates
+	if first.Frame != 1 {
+		t.Errorf("expected first update for frame 1, got %d", first.Frame)
+	}
+
+	select {
+	case last := <-updates:
+		if last.Frame != 100 {
+			t.Errorf("expected trailing update for frame 100, got %d", last.Frame)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a trailing update")
+	}
+
+	select {
+	case extra := <-updates:
+		t.Errorf("expected no further updates, got %+v", extra)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestProgressAggregator_ConcurrentUpdates(t *testing.T) {
+	weights := make(map[string]time.Duration)
+	for i := 0; i < 10; i++ {
+		weights[fmt.Sprintf("worker-%d", i)] = 10 * time.Second
+	}
+
+	agg := NewProgressAggregator(weights)
+	agg.Interval = time.Millisecond
+	updates := agg.Subscribe()
+
+	received := make(chan int)
+	go func() {
+		count := 0
+		for range updates {
+			count++
+		}
+		received <- count
+	}()
+
+	var wg sync.WaitGroup
+	for id := range weights {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			for frame := 1; frame <= 100; frame++ {
+				agg.Update(id, &Progress{Frame: frame, Time: time.Duration(frame) * 100 * time.Millisecond})
+				_ = agg.Progress()
+			}
+		}(id)
+	}
+	wg.Wait()
+
+	merged := agg.Progress()
+	if merged.Frame != 1000 {
+		t.Errorf("expected 1000 frames, got %d", merged.Frame)
+	}
+	if percent := agg.Percent(); percent != 100 {
+		t.Errorf("expected 100%%, got %.2f%%", percent)
+	}
+
+	agg.Close()
+	if count := <-received; count == 0 {
+		t.Error("expected subscriber to receive updates")
+	}
+
+	// Updates after Close are ignored
+	agg.Update("worker-0", &Progress{Frame: 1})
+}
@@ -2,37 +2,149 @@ package executor
 
 import (
 	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/chicogong/media-pipeline/pkg/schemas"
 	"github.com/chicogong/media-pipeline/pkg/storage"
+	"golang.org/x/sync/errgroup"
 )
 
 // StorageManager manages file downloads and uploads for different storage backends
 type StorageManager struct {
-	local *storage.LocalStorage
-	http  *storage.HTTPStorage
-	s3    *storage.S3Storage
+	local  *storage.LocalStorage
+	http   *storage.HTTPStorage
+	s3     *storage.S3Storage
+	sftp   *storage.SFTPStorage
+	data   *storage.DataStorage
+	config StorageConfig
+
+	// backends caches instances created from the storage backend registry
+	backends   map[string]storage.Storage
+	backendsMu sync.Mutex
+
+	// downloadInput, if set, replaces DownloadInputWithProgress when preparing inputs
+	downloadInput func(ctx context.Context, uri, tempDir string, onProgress storage.ProgressFunc) (string, error)
 }
 
+// StorageConfig configures transfers made by a StorageManager
+type StorageConfig struct {
+	// DownloadBytesPerSecond limits remote input downloads (0 = unlimited)
+	DownloadBytesPerSecond int64
+
+	// UploadBytesPerSecond limits remote output uploads (0 = unlimited)
+	UploadBytesPerSecond int64
+
+	// HTTPOptions configure headers, timeouts and redirects for http(s):// URIs
+	HTTPOptions []storage.HTTPOption
+
+	// SFTPOptions configure authentication and host key checking for sftp:// URIs
+	SFTPOptions []storage.SFTPOption
+
+	// S3Endpoint overrides the S3 endpoint for S3-compatible services (e.g. MinIO, R2)
+	S3Endpoint string
+
+	// S3Region overrides the AWS region from the environment
+	S3Region string
+
+	// S3PathStyle uses path-style bucket addressing
+	S3PathStyle bool
+
+	// S3PartSize is the multipart upload part size (0 = storage.DefaultS3PartSize)
+	S3PartSize int64
+
+	// S3UploadConcurrency is the number of parts uploaded in parallel
+	// (0 = storage.DefaultS3UploadConcurrency)
+	S3UploadConcurrency int
+
+	// RetryAttempts is how many times a download or upload that failed
+	// with a transient error is retried (0 = DefaultRetryAttempts, <0 = never)
+	RetryAttempts int
+
+	// RetryBackoff is the delay before the first retry, doubling after each
+	// further attempt (0 = DefaultRetryBackoff)
+	RetryBackoff time.Duration
+
+	// VerifyUploads checks each uploaded output against the local file: its
+	// MD5 against the destination's ETag (or the copied file), falling back
+	// to the size for multipart ETags
+	VerifyUploads bool
+
+	// DownloadConcurrency is the number of inputs downloaded in parallel
+	// (0 = DefaultDownloadConcurrency)
+	DownloadConcurrency int
+
+	// UploadConcurrency is the number of outputs uploaded in parallel
+	// (0 = DefaultUploadConcurrency)
+	UploadConcurrency int
+}
+
+const (
+	// DefaultRetryAttempts is the default number of retries for transient
+	// storage failures
+	DefaultRetryAttempts = 3
+
+	// DefaultRetryBackoff is the default delay before the first retry
+	DefaultRetryBackoff = 500 * time.Millisecond
+
+	// maxRetryBackoff caps the delay between retries
+	maxRetryBackoff = 30 * time.Second
+
+	// DefaultDownloadConcurrency is the default number of inputs
+	// downloaded in parallel
+	DefaultDownloadConcurrency = 4
+
+	// DefaultUploadConcurrency is the default number of outputs uploaded
+	// in parallel
+	DefaultUploadConcurrency = 4
+)
+
 // NewStorageManager creates a new storage manager
 func NewStorageManager() *StorageManager {
+	return NewStorageManagerWithConfig(StorageConfig{})
+}
+
+// NewStorageManagerWithConfig creates a storage manager with transfer limits
+func NewStorageManagerWithConfig(config StorageConfig) *StorageManager {
 	sm := &StorageManager{
-		local: storage.NewLocalStorage(),
-		http:  storage.NewHTTPStorage(),
+		local:  storage.NewLocalStorage(),
+		http:   storage.NewHTTPStorage(config.HTTPOptions...),
+		data:   storage.NewDataStorage(),
+		config: config,
 	}
 
 	// Try to initialize S3 (may fail if no AWS credentials)
 	ctx := context.Background()
-	s3Storage, err := storage.NewS3Storage(ctx)
+	var s3Storage *storage.S3Storage
+	var err error
+	if config.S3Endpoint != "" || config.S3Region != "" || config.S3PathStyle {
+		s3Storage, err = storage.NewS3StorageWithOptions(ctx, config.S3Endpoint, config.S3Region, config.S3PathStyle)
+	} else {
+		s3Storage, err = storage.NewS3Storage(ctx)
+	}
 	if err == nil {
+		s3Storage.PartSize = config.S3PartSize
+		s3Storage.Concurrency = config.S3UploadConcurrency
 		sm.s3 = s3Storage
 	}
 
+	// SFTP may fail if the configured credentials are invalid
+	sftpStorage, err := storage.NewSFTPStorage(config.SFTPOptions...)
+	if err == nil {
+		sm.sftp = sftpStorage
+	}
+
 	return sm
 }
 
@@ -43,21 +155,53 @@ func (sm *StorageManager) getStorage(uri string) (storage.Storage, error) {
 		return nil, err
 	}
 
+	if factory, ok := storage.LookupBackend(scheme); ok {
+		return sm.registeredBackend(scheme, factory)
+	}
+
 	switch scheme {
 	case "file":
 		return sm.local, nil
 	case "http", "https":
 		return sm.http, nil
+	case "data":
+		return sm.data, nil
 	case "s3":
 		if sm.s3 == nil {
 			return nil, fmt.Errorf("S3 storage not initialized (AWS credentials may be missing)")
 		}
 		return sm.s3, nil
+	case "sftp":
+		if sm.sftp == nil {
+			return nil, fmt.Errorf("SFTP storage not initialized (SFTP options may be invalid)")
+		}
+		return sm.sftp, nil
 	default:
 		return nil, fmt.Errorf("unsupported URI scheme: %s", scheme)
 	}
 }
 
+// registeredBackend returns the cached instance of a registered backend,
+// creating it on first use
+func (sm *StorageManager) registeredBackend(scheme string, factory storage.BackendFactory) (storage.Storage, error) {
+	sm.backendsMu.Lock()
+	defer sm.backendsMu.Unlock()
+
+	if stor, ok := sm.backends[scheme]; ok {
+		return stor, nil
+	}
+
+	stor, err := factory()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize %s storage: %w", scheme, err)
+	}
+	if sm.backends == nil {
+		sm.backends = make(map[string]storage.Storage)
+	}
+	sm.backends[scheme] = stor
+	return stor, nil
+}
+
 // isRemote checks if a URI points to a remote resource
 func (sm *StorageManager) isRemote(uri string) bool {
 	scheme, _, err := storage.ParseURI(uri)
@@ -70,6 +214,12 @@ func (sm *StorageManager) isRemote(uri string) bool {
 // DownloadInput downloads a remote file to a local temporary location
 // Returns the local path if successful
 func (sm *StorageManager) DownloadInput(ctx context.Context, uri, tempDir string) (string, error) {
+	return sm.DownloadInputWithProgress(ctx, uri, tempDir, nil)
+}
+
+// DownloadInputWithProgress is DownloadInput with onProgress called as
+// bytes arrive. The total is -1 if the backend cannot report the size.
+func (sm *StorageManager) DownloadInputWithProgress(ctx context.Context, uri, tempDir string, onProgress storage.ProgressFunc) (string, error) {
 	// If it's a local file, return the path as-is
 	if !sm.isRemote(uri) {
 		scheme, path, err := storage.ParseURI(uri)
@@ -88,37 +238,163 @@ func (sm *StorageManager) DownloadInput(ctx context.Context, uri, tempDir string
 	}
 
 	// Create temp file
+	tempPath := filepath.Join(tempDir, inputFileName(uri))
+
+	// Look up the size only when someone is listening for progress
+	total := int64(-1)
+	if onProgress != nil {
+		if info, err := stor.Stat(ctx, uri); err == nil {
+			total = info.Size
+		}
+	}
+
+	// Retries continue from the bytes already written
+	resume := false
+	err = sm.withRetry(ctx, "download "+uri, func() error {
+		err := sm.download(ctx, stor, uri, tempPath, total, onProgress, resume)
+		resume = true
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return tempPath, nil
+}
+
+// inputFileName returns the local file name for a downloaded input. data:
+// URIs are named after a hash of their content and their media type. Other
+// URIs keep their base name behind a hash of the full URI, so inputs with the
+// same base name from different locations do not overwrite each other.
+func inputFileName(uri string) string {
+	sum := sha256.Sum256([]byte(uri))
+
+	if strings.HasPrefix(uri, "data:") {
+		name := "data-" + hex.EncodeToString(sum[:8])
+		if mediaType, _, err := storage.ParseDataURI(uri); err == nil {
+			name += storage.ExtensionForContentType(mediaType)
+		}
+		return name
+	}
+
 	fileName := filepath.Base(uri)
 	if fileName == "" || fileName == "." || fileName == "/" {
 		fileName = "input"
 	}
-	tempPath := filepath.Join(tempDir, fileName)
+	return hex.EncodeToString(sum[:4]) + "-" + fileName
+}
 
-	// Download file
-	reader, err := stor.Get(ctx, uri)
-	if err != nil {
-		return "", fmt.Errorf("failed to download %s: %w", uri, err)
+// download copies uri to tempPath. If resume is set and a partial file
+// from an earlier attempt exists, only the remaining bytes are fetched with
+// GetRange; otherwise the file is replaced. When the total size is known the
+// downloaded size is checked against it.
+func (sm *StorageManager) download(ctx context.Context, stor storage.Storage, uri, tempPath string, total int64, onProgress storage.ProgressFunc, resume bool) error {
+	var offset int64
+	if resume {
+		if info, err := os.Stat(tempPath); err == nil && info.Size() > 0 {
+			offset = info.Size()
+			if total < 0 {
+				if objInfo, err := stor.Stat(ctx, uri); err == nil {
+					total = objInfo.Size
+				}
+			}
+			// Start over if the size is unknown or the partial file is not a prefix
+			if total < 0 || offset >= total {
+				offset = 0
+			}
+		}
 	}
-	defer reader.Close()
 
-	// Create temp file
-	tempFile, err := os.Create(tempPath)
-	if err != nil {
-		return "", fmt.Errorf("failed to create temp file: %w", err)
+	var reader io.ReadCloser
+	var tempFile *os.File
+	var err error
+	if offset > 0 {
+		log.Printf("Resuming download of %s at byte %d of %d", uri, offset, total)
+		reader, err = stor.GetRange(ctx, uri, offset, 0)
+		if err != nil {
+			return fmt.Errorf("failed to resume download of %s: %w", uri, err)
+		}
+		defer reader.Close()
+
+		tempFile, err = os.OpenFile(tempPath, os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open temp file: %w", err)
+		}
+	} else {
+		reader, err = stor.Get(ctx, uri)
+		if err != nil {
+			return fmt.Errorf("failed to download %s: %w", uri, err)
+		}
+		defer reader.Close()
+
+		// Create temp file
+		tempFile, err = os.Create(tempPath)
+		if err != nil {
+			return fmt.Errorf("failed to create temp file: %w", err)
+		}
 	}
 	defer tempFile.Close()
 
+	// Report progress across the whole file, not just this attempt
+	progress := onProgress
+	if onProgress != nil && offset > 0 {
+		progress = func(n, t int64) { onProgress(offset+n, t) }
+	}
+
 	// Copy data
-	_, err = io.Copy(tempFile, reader)
+	limited := storage.LimitReader(ctx, reader, sm.config.DownloadBytesPerSecond)
+	written, err := io.Copy(tempFile, storage.NewProgressReader(limited, total, progress))
 	if err != nil {
-		return "", fmt.Errorf("failed to write temp file: %w", err)
+		return fmt.Errorf("failed to write temp file: %w", err)
 	}
 
-	return tempPath, nil
+	if total >= 0 && offset+written != total {
+		return fmt.Errorf("download of %s incomplete: got %d of %d bytes: %w", uri, offset+written, total, io.ErrUnexpectedEOF)
+	}
+
+	return nil
+}
+
+// withRetry runs fn, retrying with exponential backoff while it fails with
+// a transient error and attempts remain
+func (sm *StorageManager) withRetry(ctx context.Context, op string, fn func() error) error {
+	retries := sm.config.RetryAttempts
+	if retries == 0 {
+		retries = DefaultRetryAttempts
+	}
+	backoff := sm.config.RetryBackoff
+	if backoff <= 0 {
+		backoff = DefaultRetryBackoff
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= retries || !storage.IsTransient(err) {
+			return err
+		}
+
+		log.Printf("Retrying %s (attempt %d/%d) in %v: %v", op, attempt+1, retries, backoff, err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return err
+		}
+
+		backoff *= 2
+		if backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
 }
 
 // UploadOutput uploads a local file to a remote destination
 func (sm *StorageManager) UploadOutput(ctx context.Context, localPath, destURI string) error {
+	return sm.UploadOutputWithProgress(ctx, localPath, destURI, nil)
+}
+
+// UploadOutputWithProgress is UploadOutput with onProgress called as bytes
+// are sent to a remote destination
+func (sm *StorageManager) UploadOutputWithProgress(ctx context.Context, localPath, destURI string, onProgress storage.ProgressFunc) error {
 	// If destination is local, just copy/move the file
 	if !sm.isRemote(destURI) {
 		scheme, destPath, err := storage.ParseURI(destURI)
@@ -133,7 +409,10 @@ func (sm *StorageManager) UploadOutput(ctx context.Context, localPath, destURI s
 			}
 
 			// Copy file
-			return sm.copyFile(localPath, destPath)
+			if err := sm.copyFile(localPath, destPath); err != nil {
+				return err
+			}
+			return sm.verifyUpload(ctx, localPath, destURI)
 		}
 	}
 
@@ -150,15 +429,101 @@ func (sm *StorageManager) UploadOutput(ctx context.Context, localPath, destURI s
 	}
 	defer file.Close()
 
-	// Upload file
-	err = stor.Put(ctx, destURI, file)
+	total := int64(-1)
+	if info, err := file.Stat(); err == nil {
+		total = info.Size()
+	}
+
+	// Upload file, rewinding it for each attempt
+	err = sm.withRetry(ctx, "upload to "+destURI, func() error {
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("failed to rewind local file: %w", err)
+		}
+
+		limited := storage.LimitReader(ctx, file, sm.config.UploadBytesPerSecond)
+		if err := stor.Put(ctx, destURI, storage.NewProgressReader(limited, total, onProgress)); err != nil {
+			return fmt.Errorf("failed to upload to %s: %w", destURI, err)
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to upload to %s: %w", destURI, err)
+		return err
+	}
+
+	return sm.verifyUpload(ctx, localPath, destURI)
+}
+
+// ErrChecksumMismatch is returned when an uploaded output does not match the local file
+var ErrChecksumMismatch = errors.New("upload checksum mismatch")
+
+// verifyUpload compares the uploaded destURI with localPath if
+// StorageConfig.VerifyUploads is set
+func (sm *StorageManager) verifyUpload(ctx context.Context, localPath, destURI string) error {
+	if !sm.config.VerifyUploads {
+		return nil
 	}
 
+	localMD5, localSize, err := fileMD5(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to checksum %s: %w", localPath, err)
+	}
+
+	// Local copies are hashed directly
+	if !sm.isRemote(destURI) {
+		_, destPath, err := storage.ParseURI(destURI)
+		if err != nil {
+			return err
+		}
+		destMD5, _, err := fileMD5(destPath)
+		if err != nil {
+			return fmt.Errorf("failed to checksum %s: %w", destURI, err)
+		}
+		if destMD5 != localMD5 {
+			return fmt.Errorf("%w: %s has MD5 %s, expected %s", ErrChecksumMismatch, destURI, destMD5, localMD5)
+		}
+		return nil
+	}
+
+	stor, err := sm.getStorage(destURI)
+	if err != nil {
+		return err
+	}
+	info, err := stor.Stat(ctx, destURI)
+	if err != nil {
+		return fmt.Errorf("failed to verify %s: %w", destURI, err)
+	}
+
+	// Single-part S3 ETags are the object's MD5; multipart ETags ("<hash>-<parts>")
+	// and other backends only allow a size check
+	etag := strings.ToLower(strings.Trim(info.ETag, `"`))
+	if len(etag) == md5.Size*2 && !strings.Contains(etag, "-") {
+		if etag != localMD5 {
+			return fmt.Errorf("%w: %s has ETag %s, expected MD5 %s", ErrChecksumMismatch, destURI, etag, localMD5)
+		}
+		return nil
+	}
+	if info.Size >= 0 && info.Size != localSize {
+		return fmt.Errorf("%w: %s is %d bytes, expected %d", ErrChecksumMismatch, destURI, info.Size, localSize)
+	}
 	return nil
 }
 
+// fileMD5 returns the hex MD5 digest and size of the file at path
+func fileMD5(path string) (string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+
+	hash := md5.New()
+	n, err := io.Copy(hash, file)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), n, nil
+}
+
 // copyFile copies a file from src to dst
 func (sm *StorageManager) copyFile(src, dst string) error {
 	sourceFile, err := os.Open(src)
@@ -177,40 +542,234 @@ func (sm *StorageManager) copyFile(src, dst string) error {
 	return err
 }
 
-// PrepareInputs downloads all remote inputs and returns a map of original URI -> local path
-func (sm *StorageManager) PrepareInputs(ctx context.Context, plan *schemas.ProcessingPlan, tempDir string) (map[string]string, error) {
-	inputMap := make(map[string]string)
+// ErrInputSizeLimitExceeded is returned when the remote inputs of a plan are
+// larger in total than ResourceLimits.MaxMemory
+var ErrInputSizeLimitExceeded = errors.New("input size limit exceeded")
+
+// PrepareInputs downloads all remote inputs and returns a map of original URI -> local path.
+// Up to StorageConfig.DownloadConcurrency inputs are downloaded at once; if
+// one fails, the others are cancelled. If limits sets MaxMemory, the total
+// size of the remote inputs is checked before anything is downloaded.
+// onProgress, if non-nil, receives download progress for each file being
+// fetched; calls are serialized.
+func (sm *StorageManager) PrepareInputs(ctx context.Context, plan *schemas.ProcessingPlan, tempDir string, limits *schemas.ResourceLimits, onProgress func(*schemas.DownloadProgress)) (map[string]string, error) {
+	if limits != nil && limits.MaxMemory > 0 {
+		if err := sm.checkInputSize(ctx, plan, limits.MaxMemory); err != nil {
+			return nil, err
+		}
+	}
 
+	// Inputs sharing a URI are downloaded once
+	var inputs []string
+	seen := make(map[string]bool)
 	for _, node := range plan.Nodes {
-		if node.Type == "input" {
-			originalURI := node.SourceURI
-			localPath, err := sm.DownloadInput(ctx, originalURI, tempDir)
+		if node.Type == "input" && !seen[node.SourceURI] {
+			seen[node.SourceURI] = true
+			inputs = append(inputs, node.SourceURI)
+		}
+	}
+
+	download := sm.DownloadInputWithProgress
+	if sm.downloadInput != nil {
+		download = sm.downloadInput
+	}
+
+	concurrency := sm.config.DownloadConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultDownloadConcurrency
+	}
+
+	var mu sync.Mutex // Guards inputMap, completed and onProgress calls
+	inputMap := make(map[string]string)
+	completed := 0
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for _, originalURI := range inputs {
+		g.Go(func() error {
+			var progressFunc storage.ProgressFunc
+			if onProgress != nil {
+				progressFunc = func(bytesTransferred, total int64) {
+					mu.Lock()
+					defer mu.Unlock()
+					onProgress(&schemas.DownloadProgress{
+						TotalFiles:      len(inputs),
+						CompletedFiles:  completed,
+						CurrentFile:     originalURI,
+						BytesDownloaded: bytesTransferred,
+						TotalBytes:      total,
+					})
+				}
+			}
+
+			localPath, err := download(gctx, originalURI, tempDir, progressFunc)
 			if err != nil {
-				return nil, fmt.Errorf("failed to prepare input %s: %w", originalURI, err)
+				return fmt.Errorf("failed to download input %s: %w", originalURI, err)
 			}
+
+			mu.Lock()
+			defer mu.Unlock()
 			inputMap[originalURI] = localPath
-		}
+			completed++
+			return nil
+		})
 	}
 
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
 	return inputMap, nil
 }
 
-// UploadOutputs uploads all outputs to their destination URIs
-func (sm *StorageManager) UploadOutputs(ctx context.Context, plan *schemas.ProcessingPlan, outputFiles map[string]string) error {
+// checkInputSize stats every remote input and fails if their combined size
+// exceeds maxBytes. Inputs of unknown size are not counted.
+func (sm *StorageManager) checkInputSize(ctx context.Context, plan *schemas.ProcessingPlan, maxBytes int64) error {
+	var total int64
+	seen := make(map[string]bool)
+
+	for _, node := range plan.Nodes {
+		if node.Type != "input" || seen[node.SourceURI] || !sm.isRemote(node.SourceURI) {
+			continue
+		}
+		seen[node.SourceURI] = true
+
+		stor, err := sm.getStorage(node.SourceURI)
+		if err != nil {
+			return err
+		}
+
+		info, err := stor.Stat(ctx, node.SourceURI)
+		if err != nil {
+			return fmt.Errorf("failed to stat input %s: %w", node.SourceURI, err)
+		}
+		if info.Size > 0 {
+			total += info.Size
+		}
+	}
+
+	if total > maxBytes {
+		return fmt.Errorf("%w: inputs total %d bytes, limit is %d bytes", ErrInputSizeLimitExceeded, total, maxBytes)
+	}
+	return nil
+}
+
+// MultiUploadError reports the outputs that failed to upload
+type MultiUploadError struct {
+	Errors map[string]error // Output node ID -> upload error
+	Total  int              // Number of uploads attempted
+}
+
+func (e *MultiUploadError) Error() string {
+	nodeIDs := make([]string, 0, len(e.Errors))
+	for nodeID := range e.Errors {
+		nodeIDs = append(nodeIDs, nodeID)
+	}
+	sort.Strings(nodeIDs)
+
+	msgs := make([]string, len(nodeIDs))
+	for i, nodeID := range nodeIDs {
+		msgs[i] = fmt.Sprintf("output %s: %v", nodeID, e.Errors[nodeID])
+	}
+	return fmt.Sprintf("%d of %d uploads failed: %s", len(e.Errors), e.Total, strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the per-output errors
+func (e *MultiUploadError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// outputUpload is an output file and where to upload it
+type outputUpload struct {
+	nodeID    string
+	localPath string
+	destURI   string
+}
+
+// UploadOutputs uploads all outputs to their destination URIs. Up to
+// StorageConfig.UploadConcurrency outputs are uploaded at once; every upload
+// is attempted and the failures are returned together as a
+// *MultiUploadError. onProgress, if non-nil, receives the combined progress
+// of all uploads; calls are serialized.
+func (sm *StorageManager) UploadOutputs(ctx context.Context, plan *schemas.ProcessingPlan, outputFiles map[string]string, onProgress func(*schemas.UploadProgress)) error {
+	var uploads []outputUpload
 	for _, node := range plan.Nodes {
 		if node.Type == "output" {
 			localPath, ok := outputFiles[node.ID]
 			if !ok {
 				return fmt.Errorf("output file not found for node %s", node.ID)
 			}
+			uploads = append(uploads, outputUpload{nodeID: node.ID, localPath: localPath, destURI: node.DestURI})
+		}
+	}
+
+	return sm.uploadAll(ctx, uploads, onProgress)
+}
+
+// uploadAll uploads files concurrently, reporting their combined progress
+func (sm *StorageManager) uploadAll(ctx context.Context, uploads []outputUpload, onProgress func(*schemas.UploadProgress)) error {
+	concurrency := sm.config.UploadConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultUploadConcurrency
+	}
+
+	// Files whose size cannot be read don't count towards the total
+	sizes := make([]int64, len(uploads))
+	var totalBytes int64
+	for i, upload := range uploads {
+		if info, err := os.Stat(upload.localPath); err == nil {
+			sizes[i] = info.Size()
+			totalBytes += sizes[i]
+		}
+	}
+
+	var mu sync.Mutex // Guards the fields below and onProgress calls
+	transferred := make([]int64, len(uploads))
+	var uploaded int64
+	completed := 0
+	errs := make(map[string]error)
+
+	var g errgroup.Group
+	g.SetLimit(concurrency)
+	for i, upload := range uploads {
+		g.Go(func() error {
+			var progressFunc storage.ProgressFunc
+			if onProgress != nil {
+				progressFunc = func(bytesTransferred, total int64) {
+					mu.Lock()
+					defer mu.Unlock()
+					uploaded += bytesTransferred - transferred[i]
+					transferred[i] = bytesTransferred
+					onProgress(&schemas.UploadProgress{
+						TotalFiles:     len(uploads),
+						CompletedFiles: completed,
+						CurrentFile:    upload.destURI,
+						BytesUploaded:  uploaded,
+						TotalBytes:     totalBytes,
+					})
+				}
+			}
 
-			err := sm.UploadOutput(ctx, localPath, node.DestURI)
+			err := sm.UploadOutputWithProgress(ctx, upload.localPath, upload.destURI, progressFunc)
+
+			mu.Lock()
+			defer mu.Unlock()
 			if err != nil {
-				return fmt.Errorf("failed to upload output %s: %w", node.ID, err)
+				errs[upload.nodeID] = err
+				return nil
 			}
-		}
+			completed++
+			return nil
+		})
 	}
+	g.Wait()
 
+	if len(errs) > 0 {
+		return &MultiUploadError{Errors: errs, Total: len(uploads)}
+	}
 	return nil
 }
 
@@ -0,0 +1,777 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/chicogong/media-pipeline/pkg/schemas"
+	"github.com/chicogong/media-pipeline/pkg/storage"
+)
+
+func TestPrepareInputs_InputSizeLimit(t *testing.T) {
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			downloads++
+		}
+		w.Header().Set("Content-Length", "2048")
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			w.Write(make([]byte, 2048))
+		}
+	}))
+	defer server.Close()
+
+	plan := &schemas.ProcessingPlan{
+		Nodes: []*schemas.PlanNode{
+			{ID: "input_a", Type: "input", SourceURI: server.URL + "/a.mp4"},
+			{ID: "input_b", Type: "input", SourceURI: server.URL + "/b.mp4"},
+		},
+	}
+
+	sm := NewStorageManager()
+	ctx := context.Background()
+
+	// 2 x 2048 bytes exceeds the limit before anything is downloaded
+	_, err := sm.PrepareInputs(ctx, plan, t.TempDir(), &schemas.ResourceLimits{MaxMemory: 4000}, nil)
+	if !errors.Is(err, ErrInputSizeLimitExceeded) {
+		t.Fatalf("Expected ErrInputSizeLimitExceeded, got %v", err)
+	}
+	if downloads != 0 {
+		t.Errorf("Expected no downloads, got %d", downloads)
+	}
+
+	// Within the limit
+	inputs, err := sm.PrepareInputs(ctx, plan, t.TempDir(), &schemas.ResourceLimits{MaxMemory: 4096}, nil)
+	if err != nil {
+		t.Fatalf("PrepareInputs() failed: %v", err)
+	}
+	if len(inputs) != 2 {
+		t.Errorf("Expected 2 prepared inputs, got %d", len(inputs))
+	}
+}
+
+func TestCachedStorageManager_Deduplicates(t *testing.T) {
+	var gets int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			gets++
+		}
+		w.Write([]byte("identical media content"))
+	}))
+	defer server.Close()
+
+	c, err := NewCachedStorageManager(NewStorageManager(), t.TempDir())
+	if err != nil {
+		t.Fatalf("NewCachedStorageManager() failed: %v", err)
+	}
+	ctx := context.Background()
+
+	// The same URI twice is downloaded once
+	first, err := c.DownloadInput(ctx, server.URL+"/video.mp4", t.TempDir())
+	if err != nil {
+		t.Fatalf("DownloadInput() failed: %v", err)
+	}
+	second, err := c.DownloadInput(ctx, server.URL+"/video.mp4", t.TempDir())
+	if err != nil {
+		t.Fatalf("DownloadInput() failed: %v", err)
+	}
+	if gets != 1 {
+		t.Errorf("Expected 1 GET request, got %d", gets)
+	}
+
+	data, err := os.ReadFile(second)
+	if err != nil {
+		t.Fatalf("Failed to read cached input: %v", err)
+	}
+	if string(data) != "identical media content" {
+		t.Errorf("Unexpected cached content: %q", data)
+	}
+	if first == second {
+		t.Error("Expected each download to get its own local path")
+	}
+
+	// A different URI with the same content shares the cached copy
+	if _, err := c.DownloadInput(ctx, server.URL+"/mirror/video.mp4", t.TempDir()); err != nil {
+		t.Fatalf("DownloadInput() failed: %v", err)
+	}
+	if len(c.ContentHashIndex) != 1 {
+		t.Errorf("Expected 1 cached content hash, got %d", len(c.ContentHashIndex))
+	}
+}
+
+func TestPrepareInputs_DownloadProgress(t *testing.T) {
+	const size = 256 * 1024
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(size))
+		w.WriteHeader(http.StatusOK)
+		if r.Method != http.MethodGet {
+			return
+		}
+		// Stream in chunks so the download is read in several pieces
+		chunk := make([]byte, 16*1024)
+		for written := 0; written < size; written += len(chunk) {
+			w.Write(chunk)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer server.Close()
+
+	plan := &schemas.ProcessingPlan{
+		Nodes: []*schemas.PlanNode{
+			{ID: "input_a", Type: "input", SourceURI: server.URL + "/a.mp4"},
+		},
+	}
+
+	var reports []schemas.DownloadProgress
+	sm := NewStorageManager()
+	_, err := sm.PrepareInputs(context.Background(), plan, t.TempDir(), nil, func(p *schemas.DownloadProgress) {
+		reports = append(reports, *p)
+	})
+	if err != nil {
+		t.Fatalf("PrepareInputs() failed: %v", err)
+	}
+
+	if len(reports) < 2 {
+		t.Fatalf("Expected several progress reports, got %d", len(reports))
+	}
+	for i, report := range reports {
+		if report.TotalBytes != size || report.TotalFiles != 1 || report.CurrentFile != server.URL+"/a.mp4" {
+			t.Errorf("Unexpected progress report: %+v", report)
+		}
+		if i > 0 && report.BytesDownloaded <= reports[i-1].BytesDownloaded {
+			t.Errorf("Expected increasing byte counts, got %d after %d", report.BytesDownloaded, reports[i-1].BytesDownloaded)
+		}
+	}
+	if last := reports[len(reports)-1]; last.BytesDownloaded != size {
+		t.Errorf("Expected final report of %d bytes, got %d", size, last.BytesDownloaded)
+	}
+}
+
+func TestPrepareInputs_SameBaseName(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("content of " + r.URL.Path))
+	}))
+	defer server.Close()
+
+	uris := []string{server.URL + "/a/clip.mp4", server.URL + "/b/clip.mp4"}
+	plan := &schemas.ProcessingPlan{
+		Nodes: []*schemas.PlanNode{
+			{ID: "input_a", Type: "input", SourceURI: uris[0]},
+			{ID: "input_b", Type: "input", SourceURI: uris[1]},
+		},
+	}
+
+	sm := NewStorageManager()
+	inputs, err := sm.PrepareInputs(context.Background(), plan, t.TempDir(), nil, nil)
+	if err != nil {
+		t.Fatalf("PrepareInputs() failed: %v", err)
+	}
+
+	if inputs[uris[0]] == inputs[uris[1]] {
+		t.Fatalf("Expected distinct local paths, both are %s", inputs[uris[0]])
+	}
+	for _, uri := range uris {
+		data, err := os.ReadFile(inputs[uri])
+		if err != nil {
+			t.Fatalf("Failed to read local copy of %s: %v", uri, err)
+		}
+		if want := "content of " + strings.TrimPrefix(uri, server.URL); string(data) != want {
+			t.Errorf("Local copy of %s contains %q, want %q", uri, data, want)
+		}
+		if !strings.HasSuffix(inputs[uri], "clip.mp4") {
+			t.Errorf("Expected local path to keep the file name, got %s", inputs[uri])
+		}
+	}
+}
+
+// multiInputPlan returns a plan with n remote inputs
+func multiInputPlan(n int) *schemas.ProcessingPlan {
+	plan := &schemas.ProcessingPlan{}
+	for i := 0; i < n; i++ {
+		plan.Nodes = append(plan.Nodes, &schemas.PlanNode{
+			ID:        fmt.Sprintf("input_%d", i),
+			Type:      "input",
+			SourceURI: fmt.Sprintf("s3://bucket/%d.mp4", i),
+		})
+	}
+	return plan
+}
+
+// sleepDownload returns a download hook taking d per input, failing fail
+func sleepDownload(d time.Duration, fail string) func(context.Context, string, string, storage.ProgressFunc) (string, error) {
+	return func(ctx context.Context, uri, tempDir string, _ storage.ProgressFunc) (string, error) {
+		if uri == fail {
+			return "", errors.New("connection reset")
+		}
+		select {
+		case <-time.After(d):
+			return filepath.Join(tempDir, filepath.Base(uri)), nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+}
+
+func TestPrepareInputs_Concurrent(t *testing.T) {
+	sm := NewStorageManagerWithConfig(StorageConfig{DownloadConcurrency: 4})
+	sm.downloadInput = sleepDownload(100*time.Millisecond, "")
+
+	start := time.Now()
+	inputs, err := sm.PrepareInputs(context.Background(), multiInputPlan(8), t.TempDir(), nil, nil)
+	if err != nil {
+		t.Fatalf("PrepareInputs() failed: %v", err)
+	}
+	if len(inputs) != 8 {
+		t.Errorf("Expected 8 inputs, got %d", len(inputs))
+	}
+	// Two rounds of four downloads, not eight sequential ones
+	if elapsed := time.Since(start); elapsed > 600*time.Millisecond {
+		t.Errorf("Expected parallel downloads, took %v", elapsed)
+	}
+}
+
+func TestPrepareInputs_CancelsOnFailure(t *testing.T) {
+	sm := NewStorageManager()
+	sm.downloadInput = sleepDownload(10*time.Second, "s3://bucket/2.mp4")
+
+	start := time.Now()
+	_, err := sm.PrepareInputs(context.Background(), multiInputPlan(4), t.TempDir(), nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "s3://bucket/2.mp4") {
+		t.Fatalf("Expected an error naming the failed input, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected in-flight downloads to be cancelled, took %v", elapsed)
+	}
+}
+
+func BenchmarkPrepareInputs(b *testing.B) {
+	plan := multiInputPlan(8)
+	for _, concurrency := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("concurrency=%d", concurrency), func(b *testing.B) {
+			sm := NewStorageManagerWithConfig(StorageConfig{DownloadConcurrency: concurrency})
+			sm.downloadInput = sleepDownload(100*time.Millisecond, "")
+			tempDir := b.TempDir()
+
+			for i := 0; i < b.N; i++ {
+				if _, err := sm.PrepareInputs(context.Background(), plan, tempDir, nil, nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestDownloadInput_RetriesTransientFailures(t *testing.T) {
+	var gets int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gets++
+		if gets <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("video data"))
+	}))
+	defer server.Close()
+
+	sm := NewStorageManagerWithConfig(StorageConfig{RetryBackoff: time.Millisecond})
+	localPath, err := sm.DownloadInput(context.Background(), server.URL+"/in.mp4", t.TempDir())
+	if err != nil {
+		t.Fatalf("DownloadInput() failed: %v", err)
+	}
+	if gets != 3 {
+		t.Errorf("Expected 3 attempts, got %d", gets)
+	}
+
+	data, err := os.ReadFile(localPath)
+	if err != nil {
+		t.Fatalf("Failed to read download: %v", err)
+	}
+	if string(data) != "video data" {
+		t.Errorf("Unexpected download content: %q", data)
+	}
+}
+
+func TestDownloadInput_DoesNotRetryPermanentFailures(t *testing.T) {
+	var gets int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gets++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	sm := NewStorageManagerWithConfig(StorageConfig{RetryBackoff: time.Millisecond})
+	if _, err := sm.DownloadInput(context.Background(), server.URL+"/missing.mp4", t.TempDir()); err == nil {
+		t.Fatal("Expected error for missing input")
+	}
+	if gets != 1 {
+		t.Errorf("Expected a single attempt, got %d", gets)
+	}
+
+	// Retries can be disabled entirely
+	gets = 0
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gets++
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	sm = NewStorageManagerWithConfig(StorageConfig{RetryAttempts: -1})
+	if _, err := sm.DownloadInput(context.Background(), server.URL+"/in.mp4", t.TempDir()); err == nil {
+		t.Fatal("Expected error with retries disabled")
+	}
+	if gets != 1 {
+		t.Errorf("Expected a single attempt with retries disabled, got %d", gets)
+	}
+}
+
+func TestUploadOutput_RetriesTransientFailures(t *testing.T) {
+	var puts int
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		puts++
+		if puts <= 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sm := NewStorageManagerWithConfig(StorageConfig{RetryBackoff: time.Millisecond})
+	// Let the storage manager do the retrying rather than the SDK
+	sm.s3 = storage.NewS3StorageWithClient(s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(server.URL),
+		UsePathStyle: true,
+		Credentials:  aws.AnonymousCredentials{},
+		Retryer:      aws.NopRetryer{},
+	}))
+
+	localPath := filepath.Join(t.TempDir(), "out.mp4")
+	if err := os.WriteFile(localPath, []byte("encoded"), 0644); err != nil {
+		t.Fatalf("Failed to write output: %v", err)
+	}
+
+	if err := sm.UploadOutput(context.Background(), localPath, "s3://bucket/out.mp4"); err != nil {
+		t.Fatalf("UploadOutput() failed: %v", err)
+	}
+	if puts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", puts)
+	}
+	// Each attempt re-sends the whole file
+	if !strings.Contains(string(body), "encoded") {
+		t.Errorf("Expected final attempt to carry the file, got %q", body)
+	}
+}
+
+func TestUploadOutput_VerifyLocalCopy(t *testing.T) {
+	dir := t.TempDir()
+	localPath := filepath.Join(dir, "out.mp4")
+	if err := os.WriteFile(localPath, []byte("encoded"), 0644); err != nil {
+		t.Fatalf("Failed to write output: %v", err)
+	}
+	destPath := filepath.Join(dir, "dest", "out.mp4")
+
+	sm := NewStorageManagerWithConfig(StorageConfig{VerifyUploads: true})
+	if err := sm.UploadOutput(context.Background(), localPath, "file://"+destPath); err != nil {
+		t.Fatalf("UploadOutput() failed: %v", err)
+	}
+
+	// A destination that differs from the local file is detected
+	if err := os.WriteFile(destPath, []byte("corrupt"), 0644); err != nil {
+		t.Fatalf("Failed to overwrite destination: %v", err)
+	}
+	err := sm.verifyUpload(context.Background(), localPath, "file://"+destPath)
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Expected ErrChecksumMismatch, got %v", err)
+	}
+}
+
+func TestUploadOutput_VerifyS3ETag(t *testing.T) {
+	var etag string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.Header().Set("ETag", `"`+etag+`"`)
+			w.Header().Set("Content-Length", "7")
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sm := NewStorageManagerWithConfig(StorageConfig{VerifyUploads: true})
+	sm.s3 = storage.NewS3StorageWithClient(s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(server.URL),
+		UsePathStyle: true,
+		Credentials:  aws.AnonymousCredentials{},
+	}))
+
+	localPath := filepath.Join(t.TempDir(), "out.mp4")
+	if err := os.WriteFile(localPath, []byte("encoded"), 0644); err != nil {
+		t.Fatalf("Failed to write output: %v", err)
+	}
+	localMD5, _, err := fileMD5(localPath)
+	if err != nil {
+		t.Fatalf("fileMD5() failed: %v", err)
+	}
+	etag = localMD5
+
+	if err := sm.UploadOutput(context.Background(), localPath, "s3://bucket/out.mp4"); err != nil {
+		t.Fatalf("UploadOutput() failed: %v", err)
+	}
+
+	etag = "00000000000000000000000000000000"
+	err = sm.UploadOutput(context.Background(), localPath, "s3://bucket/out.mp4")
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Expected ErrChecksumMismatch, got %v", err)
+	}
+
+	// Multipart ETags fall back to comparing sizes
+	etag = "d41d8cd98f00b204e9800998ecf8427e-2"
+	if err := sm.UploadOutput(context.Background(), localPath, "s3://bucket/out.mp4"); err != nil {
+		t.Errorf("Expected size check to pass for multipart ETag, got %v", err)
+	}
+}
+
+func TestDownloadInput_LocalPaths(t *testing.T) {
+	sm := NewStorageManager()
+
+	tests := map[string]string{
+		"/tmp/in.mp4":              "/tmp/in.mp4",
+		"/tmp/my video.mp4":        "/tmp/my video.mp4",
+		"file:///tmp/in.mp4":       "/tmp/in.mp4",
+		"file:///tmp/my%20vid.mp4": "/tmp/my vid.mp4",
+		"file://in.mp4":            "in.mp4",
+	}
+	for uri, want := range tests {
+		got, err := sm.DownloadInput(context.Background(), uri, t.TempDir())
+		if err != nil {
+			t.Errorf("DownloadInput(%q) failed: %v", uri, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("DownloadInput(%q) = %q, want %q", uri, got, want)
+		}
+	}
+}
+
+// memoryStorage is an in-memory storage backend for registry tests.
+// Methods it does not override panic via the nil embedded interface.
+type memoryStorage struct {
+	storage.Storage
+	files map[string]string
+}
+
+func (m *memoryStorage) Get(ctx context.Context, uri string) (io.ReadCloser, error) {
+	data, ok := m.files[uri]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return io.NopCloser(strings.NewReader(data)), nil
+}
+
+func (m *memoryStorage) Put(ctx context.Context, uri string, data io.Reader) error {
+	b, err := io.ReadAll(data)
+	if err != nil {
+		return err
+	}
+	m.files[uri] = string(b)
+	return nil
+}
+
+func TestStorageManager_RegisteredBackend(t *testing.T) {
+	mem := &memoryStorage{files: map[string]string{"test://bucket/in.mp4": "video data"}}
+	var created int
+	storage.RegisterBackend("test", func() (storage.Storage, error) {
+		created++
+		return mem, nil
+	})
+	defer storage.UnregisterBackend("test")
+
+	if !storage.IsAllowedScheme("test") {
+		t.Error("Expected registered scheme to be allowed")
+	}
+
+	sm := NewStorageManager()
+	tempDir := t.TempDir()
+
+	localPath, err := sm.DownloadInput(context.Background(), "test://bucket/in.mp4", tempDir)
+	if err != nil {
+		t.Fatalf("DownloadInput() failed: %v", err)
+	}
+	data, err := os.ReadFile(localPath)
+	if err != nil {
+		t.Fatalf("Failed to read download: %v", err)
+	}
+	if string(data) != "video data" {
+		t.Errorf("Unexpected download content: %q", data)
+	}
+
+	if err := sm.UploadOutput(context.Background(), localPath, "test://bucket/out.mp4"); err != nil {
+		t.Fatalf("UploadOutput() failed: %v", err)
+	}
+	if mem.files["test://bucket/out.mp4"] != "video data" {
+		t.Errorf("Expected upload to reach the registered backend, got %v", mem.files)
+	}
+
+	// The backend is created once per storage manager
+	if created != 1 {
+		t.Errorf("Expected factory to be called once, got %d", created)
+	}
+}
+
+func TestStorageManager_RegisteredBackendError(t *testing.T) {
+	storage.RegisterBackend("broken", func() (storage.Storage, error) {
+		return nil, errors.New("no credentials")
+	})
+	defer storage.UnregisterBackend("broken")
+
+	sm := NewStorageManager()
+	if _, err := sm.DownloadInput(context.Background(), "broken://bucket/in.mp4", t.TempDir()); err == nil {
+		t.Fatal("Expected factory error")
+	}
+}
+
+func TestDownloadInput_DataURI(t *testing.T) {
+	// 1x1 transparent PNG
+	const pngBase64 = "iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNkYPhfDwAChwGA60e6kgAAAABJRU5ErkJggg=="
+	uri := "data:image/png;base64," + pngBase64
+
+	sm := NewStorageManager()
+	tempDir := t.TempDir()
+
+	localPath, err := sm.DownloadInput(context.Background(), uri, tempDir)
+	if err != nil {
+		t.Fatalf("DownloadInput() failed: %v", err)
+	}
+	if filepath.Dir(localPath) != tempDir {
+		t.Errorf("Expected file in %s, got %s", tempDir, localPath)
+	}
+	if filepath.Ext(localPath) != ".png" {
+		t.Errorf("Expected .png extension, got %s", localPath)
+	}
+
+	data, err := os.ReadFile(localPath)
+	if err != nil {
+		t.Fatalf("Failed to read decoded file: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "\x89PNG\r\n\x1a\n") {
+		t.Errorf("Expected PNG signature, got %q", data[:8])
+	}
+
+	if _, err := sm.DownloadInput(context.Background(), "data:image/png;base64,!!!", tempDir); err == nil {
+		t.Error("Expected error for invalid data URI")
+	}
+}
+
+func TestCachedStorageManager_DataURI(t *testing.T) {
+	// A payload long enough that naming the file after the URI would
+	// exceed the file name limit
+	payload := bytes.Repeat([]byte("\x89PNG logo bytes "), 32)
+	uri := "data:image/png;base64," + base64.StdEncoding.EncodeToString(payload)
+
+	c, err := NewCachedStorageManager(NewStorageManager(), t.TempDir())
+	if err != nil {
+		t.Fatalf("NewCachedStorageManager() failed: %v", err)
+	}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		tempDir := t.TempDir()
+		localPath, err := c.DownloadInput(ctx, uri, tempDir)
+		if err != nil {
+			t.Fatalf("DownloadInput() failed: %v", err)
+		}
+		if filepath.Dir(localPath) != tempDir {
+			t.Errorf("Expected file in %s, got %s", tempDir, localPath)
+		}
+		if filepath.Ext(localPath) != ".png" {
+			t.Errorf("Expected .png extension, got %s", localPath)
+		}
+
+		data, err := os.ReadFile(localPath)
+		if err != nil {
+			t.Fatalf("Failed to read decoded file: %v", err)
+		}
+		if !bytes.Equal(data, payload) {
+			t.Errorf("Unexpected decoded content: %q", data)
+		}
+	}
+
+	if len(c.ContentHashIndex) != 1 {
+		t.Errorf("Expected 1 cached content hash, got %d", len(c.ContentHashIndex))
+	}
+}
+
+// interruptedStorage serves a file whose first Get fails partway through
+type interruptedStorage struct {
+	storage.Storage
+	data        string
+	failAfter   int
+	gets        int
+	rangeOffset int64
+}
+
+func (s *interruptedStorage) Get(ctx context.Context, uri string) (io.ReadCloser, error) {
+	s.gets++
+	if s.gets == 1 {
+		reader := io.MultiReader(strings.NewReader(s.data[:s.failAfter]), iotest.ErrReader(io.ErrUnexpectedEOF))
+		return io.NopCloser(reader), nil
+	}
+	return io.NopCloser(strings.NewReader(s.data)), nil
+}
+
+func (s *interruptedStorage) GetRange(ctx context.Context, uri string, offset, length int64) (io.ReadCloser, error) {
+	s.rangeOffset = offset
+	return io.NopCloser(strings.NewReader(s.data[offset:])), nil
+}
+
+func (s *interruptedStorage) Stat(ctx context.Context, uri string) (*storage.ObjectInfo, error) {
+	return &storage.ObjectInfo{Size: int64(len(s.data))}, nil
+}
+
+func TestDownloadInput_ResumesPartialDownload(t *testing.T) {
+	stor := &interruptedStorage{data: "0123456789abcdefghij", failAfter: 8}
+	storage.RegisterBackend("resumable", func() (storage.Storage, error) { return stor, nil })
+	defer storage.UnregisterBackend("resumable")
+
+	sm := NewStorageManagerWithConfig(StorageConfig{RetryBackoff: time.Millisecond})
+
+	var lastProgress int64
+	localPath, err := sm.DownloadInputWithProgress(context.Background(), "resumable://bucket/in.mp4", t.TempDir(),
+		func(n, total int64) { lastProgress = n })
+	if err != nil {
+		t.Fatalf("DownloadInput() failed: %v", err)
+	}
+
+	// The second attempt continues where the first stopped
+	if stor.gets != 1 {
+		t.Errorf("Expected a single full Get, got %d", stor.gets)
+	}
+	if stor.rangeOffset != 8 {
+		t.Errorf("Expected resume at byte 8, got %d", stor.rangeOffset)
+	}
+	if lastProgress != int64(len(stor.data)) {
+		t.Errorf("Expected progress to reach %d, got %d", len(stor.data), lastProgress)
+	}
+
+	data, err := os.ReadFile(localPath)
+	if err != nil {
+		t.Fatalf("Failed to read download: %v", err)
+	}
+	if string(data) != stor.data {
+		t.Errorf("Unexpected download content: %q", data)
+	}
+}
+
+// slowStorage is a storage backend whose uploads take a varying time and
+// fail for some URIs
+type slowStorage struct {
+	storage.Storage
+	latency func(uri string) time.Duration
+	fail    map[string]bool
+
+	mu       sync.Mutex
+	files    map[string]string
+	inFlight int
+	peak     int
+}
+
+func (s *slowStorage) Put(ctx context.Context, uri string, data io.Reader) error {
+	s.mu.Lock()
+	s.inFlight++
+	if s.inFlight > s.peak {
+		s.peak = s.inFlight
+	}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.inFlight--
+		s.mu.Unlock()
+	}()
+
+	time.Sleep(s.latency(uri))
+	if s.fail[uri] {
+		return errors.New("service unavailable")
+	}
+
+	b, err := io.ReadAll(data)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.files[uri] = string(b)
+	s.mu.Unlock()
+	return nil
+}
+
+func TestUploadOutputs_Concurrent(t *testing.T) {
+	stor := &slowStorage{
+		latency: func(uri string) time.Duration { return time.Duration(10+len(uri)%4*15) * time.Millisecond },
+		fail:    map[string]bool{"slow://bucket/1.mp4": true, "slow://bucket/4.mp4": true},
+		files:   make(map[string]string),
+	}
+	storage.RegisterBackend("slow", func() (storage.Storage, error) { return stor, nil })
+	defer storage.UnregisterBackend("slow")
+
+	dir := t.TempDir()
+	plan := &schemas.ProcessingPlan{}
+	outputFiles := make(map[string]string)
+	for i := 0; i < 6; i++ {
+		nodeID := fmt.Sprintf("output_%d", i)
+		localPath := filepath.Join(dir, fmt.Sprintf("%d.mp4", i))
+		if err := os.WriteFile(localPath, []byte("encoded"), 0644); err != nil {
+			t.Fatalf("Failed to write output: %v", err)
+		}
+		plan.Nodes = append(plan.Nodes, &schemas.PlanNode{
+			ID: nodeID, Type: "output", DestURI: fmt.Sprintf("slow://bucket/%d.mp4", i),
+		})
+		outputFiles[nodeID] = localPath
+	}
+
+	sm := NewStorageManagerWithConfig(StorageConfig{UploadConcurrency: 3, RetryAttempts: -1})
+	var last schemas.UploadProgress
+	err := sm.UploadOutputs(context.Background(), plan, outputFiles, func(p *schemas.UploadProgress) {
+		last = *p
+	})
+
+	// Every upload is attempted and both failures are reported
+	var multiErr *MultiUploadError
+	if !errors.As(err, &multiErr) {
+		t.Fatalf("Expected a MultiUploadError, got %v", err)
+	}
+	if len(multiErr.Errors) != 2 || multiErr.Errors["output_1"] == nil || multiErr.Errors["output_4"] == nil || multiErr.Total != 6 {
+		t.Errorf("Unexpected upload errors: %v", err)
+	}
+	if len(stor.files) != 4 {
+		t.Errorf("Expected the other 4 outputs to be uploaded, got %d", len(stor.files))
+	}
+
+	if stor.peak < 2 || stor.peak > 3 {
+		t.Errorf("Expected up to 3 concurrent uploads, peak was %d", stor.peak)
+	}
+
+	// Progress is aggregated over all files
+	if last.TotalFiles != 6 || last.TotalBytes != 6*int64(len("encoded")) {
+		t.Errorf("Unexpected progress totals: %+v", last)
+	}
+	if last.BytesUploaded != 4*int64(len("encoded")) {
+		t.Errorf("Expected %d bytes uploaded, got %d", 4*len("encoded"), last.BytesUploaded)
+	}
+}
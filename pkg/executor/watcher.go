@@ -0,0 +1,77 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrOutputSizeLimitExceeded is returned when an output file grows past ExecuteOptions.MaxOutputBytes
+var ErrOutputSizeLimitExceeded = errors.New("output size limit exceeded")
+
+// OutputSizeWatcher polls output files and cancels execution when they exceed a size limit
+type OutputSizeWatcher struct {
+	paths    []string
+	maxBytes int64
+
+	// PollInterval is how often output files are checked (default 1s)
+	PollInterval time.Duration
+
+	mu  sync.Mutex
+	err error
+}
+
+// NewOutputSizeWatcher creates a watcher for the given output paths
+func NewOutputSizeWatcher(paths []string, maxBytes int64) *OutputSizeWatcher {
+	return &OutputSizeWatcher{
+		paths:        paths,
+		maxBytes:     maxBytes,
+		PollInterval: time.Second,
+	}
+}
+
+// Watch polls the output files until ctx is done, calling cancel if the limit is exceeded
+func (w *OutputSizeWatcher) Watch(ctx context.Context, cancel context.CancelFunc) {
+	ticker := time.NewTicker(w.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.check(); err != nil {
+				w.mu.Lock()
+				w.err = err
+				w.mu.Unlock()
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+// Err returns the limit error if the watcher cancelled execution
+func (w *OutputSizeWatcher) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// check stats every output file and returns an error if any exceeds the limit
+func (w *OutputSizeWatcher) check() error {
+	for _, path := range w.paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			// File may not have been created yet
+			continue
+		}
+		if info.Size() > w.maxBytes {
+			return fmt.Errorf("%w: %s is %d bytes (limit %d)", ErrOutputSizeLimitExceeded, path, info.Size(), w.maxBytes)
+		}
+	}
+	return nil
+}
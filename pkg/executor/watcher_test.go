@@ -0,0 +1,93 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOutputSizeWatcher_CancelsOnLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "output.raw")
+	if err := os.WriteFile(path, make([]byte, 2048), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	watcher := NewOutputSizeWatcher([]string{path}, 1024)
+	watcher.PollInterval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		watcher.Watch(ctx, cancel)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("watcher did not stop after limit was exceeded")
+	}
+
+	if ctx.Err() == nil {
+		t.Error("expected context to be cancelled")
+	}
+	err := watcher.Err()
+	if !errors.Is(err, ErrOutputSizeLimitExceeded) {
+		t.Fatalf("expected ErrOutputSizeLimitExceeded, got %v", err)
+	}
+	t.Logf("watcher error: %v", err)
+}
+
+func TestOutputSizeWatcher_UnderLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "output.raw")
+	if err := os.WriteFile(path, make([]byte, 512), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	watcher := NewOutputSizeWatcher([]string{path}, 1024)
+	watcher.PollInterval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	watcher.Watch(ctx, cancel)
+
+	if err := watcher.Err(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestOutputSizeWatcher_FFmpegZeros(t *testing.T) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		t.Skip("ffmpeg not available")
+	}
+
+	path := filepath.Join(t.TempDir(), "zeros.raw")
+	cmd := &Command{Args: []string{
+		"ffmpeg", "-y",
+		"-f", "lavfi", "-i", "anullsrc=r=48000:cl=stereo",
+		"-f", "s16le", path,
+	}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	watcher := NewOutputSizeWatcher([]string{path}, 64*1024)
+	watcher.PollInterval = 50 * time.Millisecond
+	go watcher.Watch(ctx, cancel)
+
+	e := &Executor{parser: NewProgressParser()}
+	if err := e.executeCommand(ctx, cmd, &ExecuteOptions{}); err == nil {
+		t.Fatal("expected ffmpeg to be cancelled")
+	}
+
+	if !errors.Is(watcher.Err(), ErrOutputSizeLimitExceeded) {
+		t.Fatalf("expected ErrOutputSizeLimitExceeded, got %v", watcher.Err())
+	}
+}
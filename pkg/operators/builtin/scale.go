@@ -64,6 +64,7 @@ func (o *ScaleOperator) Describe() *operators.OperatorDescriptor {
 		InputTypes:        []operators.MediaType{operators.MediaTypeVideo, operators.MediaTypeVideoAudio},
 		OutputTypes:       []operators.MediaType{operators.MediaTypeVideo},
 		SupportsStreaming: true,
+		Fusable:           true,
 	}
 }
 
@@ -92,6 +93,9 @@ func (o *ScaleOperator) ComputeOutputMetadata(
 	}
 
 	input := inputs[0]
+	if !input.HasVideo() {
+		return nil, fmt.Errorf("scale requires an input with a video stream")
+	}
 	output := *input
 	output.VideoStreams = append([]schemas.VideoStream(nil), input.VideoStreams...)
 	output.AudioStreams = append([]schemas.AudioStream(nil), input.AudioStreams...)
@@ -103,20 +107,20 @@ func (o *ScaleOperator) ComputeOutputMetadata(
 	widthInt := width.(int)
 	heightInt := height.(int)
 
-	if len(output.VideoStreams) > 0 {
-		inputWidth := output.VideoStreams[0].Width
-		inputHeight := output.VideoStreams[0].Height
-
-		// Calculate actual dimensions
-		if widthInt == -1 {
-			widthInt = inputWidth * heightInt / inputHeight
-		} else if heightInt == -1 {
-			heightInt = inputHeight * widthInt / inputWidth
-		}
+	video := output.PrimaryVideoStream()
 
-		output.VideoStreams[0].Width = widthInt
-		output.VideoStreams[0].Height = heightInt
+	// Calculate actual dimensions, keeping the aspect ratio for -1
+	if (widthInt == -1 || heightInt == -1) && input.AspectRatio() == 0 {
+		return nil, fmt.Errorf("cannot keep the aspect ratio of a video of unknown size")
 	}
+	if widthInt == -1 {
+		widthInt = video.Width * heightInt / video.Height
+	} else if heightInt == -1 {
+		heightInt = video.Height * widthInt / video.Width
+	}
+
+	video.Width = widthInt
+	video.Height = heightInt
 
 	return &output, nil
 }
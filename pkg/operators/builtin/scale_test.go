@@ -51,6 +51,39 @@ func TestScaleOperator_ComputeOutputMetadata_DoesNotMutateInput(t *testing.T) {
 	}
 }
 
+func TestScaleOperator_ComputeOutputMetadata_RequiresVideo(t *testing.T) {
+	op := &ScaleOperator{}
+
+	tests := []struct {
+		name   string
+		input  *schemas.MediaInfo
+		params map[string]interface{}
+	}{
+		{"nil input", nil, map[string]interface{}{"width": 1280, "height": 720}},
+		{"audio only", &schemas.MediaInfo{AudioStreams: []schemas.AudioStream{{Channels: 2}}},
+			map[string]interface{}{"width": 1280, "height": 720}},
+		{"unknown size", &schemas.MediaInfo{VideoStreams: []schemas.VideoStream{{Codec: "h264"}}},
+			map[string]interface{}{"width": 1280, "height": -1}},
+	}
+
+	for _, tt := range tests {
+		if _, err := op.ComputeOutputMetadata(tt.params, []*schemas.MediaInfo{tt.input}); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+
+	out, err := op.ComputeOutputMetadata(
+		map[string]interface{}{"width": 1280, "height": -1},
+		[]*schemas.MediaInfo{{VideoStreams: []schemas.VideoStream{{Width: 1920, Height: 1080}}}},
+	)
+	if err != nil {
+		t.Fatalf("ComputeOutputMetadata failed: %v", err)
+	}
+	if video := out.PrimaryVideoStream(); video.Width != 1280 || video.Height != 720 {
+		t.Errorf("expected 1280x720, got %dx%d", video.Width, video.Height)
+	}
+}
+
 func TestScaleOperator_Compile_UsesLabelsDirectly(t *testing.T) {
 	op := &ScaleOperator{}
 
@@ -75,3 +108,25 @@ func TestScaleOperator_Compile_UsesLabelsDirectly(t *testing.T) {
 	}
 }
 
+func TestJobSpec_ValidateWithRegistry_Scale(t *testing.T) {
+	registry := operators.NewRegistry()
+	registry.Register(&ScaleOperator{})
+
+	spec := &schemas.JobSpec{
+		Inputs: []schemas.Input{{ID: "video", Source: "s3://bucket/in.mp4"}},
+		Operations: []schemas.Operation{
+			{Op: "scale", Input: "video", Output: "scaled", Params: map[string]interface{}{"height": 720}},
+		},
+		Outputs: []schemas.Output{{ID: "scaled", Destination: "s3://bucket/out.mp4"}},
+	}
+
+	err := spec.ValidateWithRegistry(registry)
+	if err == nil || !strings.Contains(err.Error(), "operation 0 (scale)") {
+		t.Fatalf("expected the missing width to be reported for operation 0, got: %v", err)
+	}
+
+	spec.Operations[0].Params["width"] = 1280
+	if err := spec.ValidateWithRegistry(registry); err != nil {
+		t.Errorf("expected a valid spec, got: %v", err)
+	}
+}
@@ -11,6 +11,20 @@ import (
 // TrimOperator implements the trim operation
 type TrimOperator struct{}
 
+// SeekMode selects how a trim is compiled
+type SeekMode string
+
+const (
+	// SeekModeFilter trims with the trim/atrim filters, after decoding
+	SeekModeFilter SeekMode = "filter"
+
+	// SeekModeFastSeek trims with -ss/-t input options, seeking to the
+	// nearest keyframe without decoding what comes before. It applies when
+	// the trim reads directly from an input and is the plan's only
+	// operation; otherwise the filter is used.
+	SeekModeFastSeek SeekMode = "fast_seek"
+)
+
 func init() {
 	operators.Register(&TrimOperator{})
 }
@@ -50,12 +64,23 @@ func (o *TrimOperator) Describe() *operators.OperatorDescriptor {
 				Required:    false,
 				Description: "End time (alternative to duration)",
 			},
+			{
+				Name:        "seek_mode",
+				Type:        operators.TypeEnum,
+				Required:    false,
+				Default:     string(SeekModeFilter),
+				Description: "Trim with a filter or by fast seeking the input",
+				Validation: &operators.ValidationRules{
+					Enum: []interface{}{string(SeekModeFilter), string(SeekModeFastSeek)},
+				},
+			},
 		},
 		MinInputs:         1,
 		MaxInputs:         1,
 		InputTypes:        []operators.MediaType{operators.MediaTypeVideoAudio, operators.MediaTypeVideo, operators.MediaTypeAudio},
 		OutputTypes:       []operators.MediaType{operators.MediaTypeVideoAudio},
 		SupportsStreaming: true,
+		Fusable:           true,
 	}
 }
 
@@ -172,6 +197,10 @@ func (o *TrimOperator) Compile(ctx *operators.CompileContext) (*operators.Compil
 		return nil, fmt.Errorf("trim requires at least one input stream")
 	}
 
+	if mode, _ := ctx.Params["seek_mode"].(string); SeekMode(mode) == SeekModeFastSeek && ctx.AllowInputFlags {
+		return o.compileFastSeek(ctx, startDuration)
+	}
+
 	var filterVideo, filterAudio string
 
 	if duration, ok := ctx.Params["duration"]; ok {
@@ -220,3 +249,34 @@ func (o *TrimOperator) Compile(ctx *operators.CompileContext) (*operators.Compil
 		OutputLabels:     outputLabels,
 	}, nil
 }
+
+// compileFastSeek compiles the trim to -ss/-t input options; the input's
+// streams pass through unfiltered
+func (o *TrimOperator) compileFastSeek(ctx *operators.CompileContext, start time.Duration) (*operators.CompileResult, error) {
+	converter := operators.NewTypeConverter()
+	flags := []string{"-ss", fmt.Sprintf("%.3f", start.Seconds())}
+
+	if duration, ok := ctx.Params["duration"]; ok {
+		d, err := converter.Convert(duration, operators.TypeDuration)
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration: %w", err)
+		}
+		flags = append(flags, "-t", fmt.Sprintf("%.3f", d.(time.Duration).Seconds()))
+	} else if end, ok := ctx.Params["end"]; ok {
+		e, err := converter.Convert(end, operators.TypeDuration)
+		if err != nil {
+			return nil, fmt.Errorf("invalid end: %w", err)
+		}
+		flags = append(flags, "-t", fmt.Sprintf("%.3f", (e.(time.Duration)-start).Seconds()))
+	}
+
+	labels := []string{}
+	for _, stream := range ctx.InputStreams {
+		labels = append(labels, stream.Label)
+	}
+
+	return &operators.CompileResult{
+		InputFlags:   flags,
+		OutputLabels: labels,
+	}, nil
+}
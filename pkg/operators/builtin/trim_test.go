@@ -78,3 +78,46 @@ func TestTrimOperator_Compile_UsesSeparateVideoAndAudioInputs(t *testing.T) {
 	}
 }
 
+func TestTrimOperator_Compile_FastSeek(t *testing.T) {
+	op := &TrimOperator{}
+	ctx := &operators.CompileContext{
+		InputStreams: []operators.StreamRef{
+			{Label: "[0:v]", StreamType: "video"},
+			{Label: "[0:a]", StreamType: "audio"},
+		},
+		Params: map[string]interface{}{
+			"start":     "00:00:10",
+			"end":       "00:00:40",
+			"seek_mode": string(SeekModeFastSeek),
+		},
+		AllowInputFlags: true,
+	}
+
+	res, err := op.Compile(ctx)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	if res.FilterExpression != "" {
+		t.Errorf("expected no filter, got %q", res.FilterExpression)
+	}
+	if got := strings.Join(res.InputFlags, " "); got != "-ss 10.000 -t 30.000" {
+		t.Errorf("unexpected input flags %q", got)
+	}
+	if len(res.OutputLabels) != 2 || res.OutputLabels[0] != "[0:v]" || res.OutputLabels[1] != "[0:a]" {
+		t.Errorf("expected the input streams to pass through, got %v", res.OutputLabels)
+	}
+
+	// Falls back to the filter where input flags cannot be applied
+	ctx.AllowInputFlags = false
+	res, err = op.Compile(ctx)
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	if len(res.InputFlags) != 0 || !strings.Contains(res.FilterExpression, "[0:v]trim=") {
+		t.Errorf("expected a trim filter, got %+v", res)
+	}
+
+	if err := op.ValidateParams(map[string]interface{}{"seek_mode": "slow"}); err == nil {
+		t.Error("expected an unknown seek mode to be rejected")
+	}
+}
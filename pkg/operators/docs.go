@@ -0,0 +1,162 @@
+package operators
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// GenerateMarkdown renders the operator's descriptor as a Markdown section
+// with a table of its parameters
+func GenerateMarkdown(op Operator) string {
+	desc := op.Describe()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "### %s\n\n", desc.Name)
+	if desc.Description != "" {
+		fmt.Fprintf(&b, "%s\n\n", desc.Description)
+	}
+
+	fmt.Fprintf(&b, "- Category: %s\n", desc.Category)
+	fmt.Fprintf(&b, "- Inputs: %s\n", inputRange(desc.MinInputs, desc.MaxInputs))
+	if len(desc.OutputTypes) > 0 {
+		fmt.Fprintf(&b, "- Outputs: %s\n", joinMediaTypes(desc.OutputTypes))
+	}
+	if desc.RequiresTwoPass {
+		b.WriteString("- Requires two passes\n")
+	}
+	b.WriteString("\n")
+
+	if len(desc.Parameters) == 0 {
+		b.WriteString("This operator has no parameters.\n")
+		return b.String()
+	}
+
+	b.WriteString("| Parameter | Type | Required | Default | Description | Examples |\n")
+	b.WriteString("|-----------|------|----------|---------|-------------|----------|\n")
+	for _, p := range desc.Parameters {
+		required := "no"
+		if p.Required {
+			required = "yes"
+		}
+
+		description := p.Description
+		if p.Validation != nil && len(p.Validation.Enum) > 0 {
+			description = strings.TrimSpace(description + " One of: " + joinValues(p.Validation.Enum) + ".")
+		}
+
+		fmt.Fprintf(&b, "| `%s` | %s | %s | %s | %s | %s |\n",
+			p.Name,
+			p.Type,
+			required,
+			formatValue(p.Default),
+			escapeCell(description),
+			joinValues(p.Examples),
+		)
+	}
+
+	return b.String()
+}
+
+// GenerateAllDocs renders every operator in registry as Markdown, with one
+// section per category. Built-in categories come first, then custom ones in
+// name order; operators are sorted by name within a category.
+func GenerateAllDocs(registry *Registry) string {
+	byCategory := make(map[Category][]Operator)
+	for _, op := range registry.List() {
+		byCategory[op.Category()] = append(byCategory[op.Category()], op)
+	}
+
+	categories := []Category{}
+	for _, category := range Categories() {
+		if len(byCategory[category]) > 0 {
+			categories = append(categories, category)
+		}
+	}
+	custom := []Category{}
+	for category := range byCategory {
+		if !isBuiltinCategory(category) {
+			custom = append(custom, category)
+		}
+	}
+	sort.Slice(custom, func(i, j int) bool { return custom[i] < custom[j] })
+	categories = append(categories, custom...)
+
+	var b strings.Builder
+	b.WriteString("# Operators\n")
+	for _, category := range categories {
+		ops := byCategory[category]
+		sort.Slice(ops, func(i, j int) bool { return ops[i].Name() < ops[j].Name() })
+
+		fmt.Fprintf(&b, "\n## %s\n", categoryTitle(category))
+		for _, op := range ops {
+			b.WriteString("\n")
+			b.WriteString(GenerateMarkdown(op))
+		}
+	}
+
+	return b.String()
+}
+
+// isBuiltinCategory reports whether category is one of Categories()
+func isBuiltinCategory(category Category) bool {
+	for _, builtin := range Categories() {
+		if category == builtin {
+			return true
+		}
+	}
+	return false
+}
+
+// categoryTitle capitalizes a category name for use as a heading
+func categoryTitle(category Category) string {
+	name := string(category)
+	if name == "" {
+		return "Uncategorized"
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// inputRange describes the number of inputs an operator accepts
+func inputRange(min, max int) string {
+	switch {
+	case max < 0 || max < min:
+		return fmt.Sprintf("%d or more", min)
+	case min == max:
+		return fmt.Sprintf("%d", min)
+	default:
+		return fmt.Sprintf("%d-%d", min, max)
+	}
+}
+
+// joinMediaTypes joins media types with commas
+func joinMediaTypes(types []MediaType) string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = string(t)
+	}
+	return strings.Join(names, ", ")
+}
+
+// joinValues formats values as comma-separated code spans
+func joinValues(values []interface{}) string {
+	formatted := make([]string, len(values))
+	for i, v := range values {
+		formatted[i] = formatValue(v)
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// formatValue formats a parameter value as a code span, or "" if it is nil
+func formatValue(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return "`" + escapeCell(fmt.Sprint(v)) + "`"
+}
+
+// escapeCell escapes characters that would break a Markdown table cell
+func escapeCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", " ")
+}
@@ -0,0 +1,52 @@
+package operators_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chicogong/media-pipeline/pkg/operators"
+	"github.com/chicogong/media-pipeline/pkg/operators/builtin"
+)
+
+func TestGenerateMarkdown(t *testing.T) {
+	doc := operators.GenerateMarkdown(&builtin.TrimOperator{})
+
+	for _, want := range []string{
+		"### trim",
+		"| Parameter | Type | Required | Default | Description | Examples |",
+		"| `start` | timecode | no | `00:00:00` | Start time | `00:00:10`, `10s`, `00:00:10.500` |",
+		"| `duration` | duration | no |",
+		"| `end` | timecode | no |",
+		"- Category: timeline",
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("expected markdown to contain %q, got:\n%s", want, doc)
+		}
+	}
+}
+
+func TestGenerateAllDocs(t *testing.T) {
+	registry := operators.NewRegistry()
+	registry.Register(&builtin.ScaleOperator{})
+	registry.Register(&builtin.TrimOperator{})
+
+	doc := operators.GenerateAllDocs(registry)
+
+	timeline := strings.Index(doc, "## Timeline")
+	video := strings.Index(doc, "## Video")
+	if timeline < 0 || video < 0 {
+		t.Fatalf("expected timeline and video sections, got:\n%s", doc)
+	}
+	if timeline > video {
+		t.Error("expected built-in categories in declaration order")
+	}
+	if trim := strings.Index(doc, "### trim"); trim < timeline || trim > video {
+		t.Error("expected trim under the timeline section")
+	}
+	if scale := strings.Index(doc, "### scale"); scale < video {
+		t.Error("expected scale under the video section")
+	}
+	if strings.Contains(doc, "## Audio") {
+		t.Error("expected empty categories to be omitted")
+	}
+}
@@ -40,26 +40,46 @@ const (
 	CategoryAdvanced Category = "advanced" // custom filters
 )
 
+// Categories returns the built-in operator categories
+func Categories() []Category {
+	return []Category{
+		CategoryTimeline,
+		CategoryAudio,
+		CategoryVideo,
+		CategoryGraphics,
+		CategoryOutput,
+		CategoryAdvanced,
+	}
+}
+
 // OperatorDescriptor describes an operator
 type OperatorDescriptor struct {
-	Name        string
-	Category    Category
-	Description string
+	Name        string   `json:"name"`
+	Category    Category `json:"category"`
+	Description string   `json:"description"`
 
 	// Parameter schema
-	Parameters []ParameterDescriptor
+	Parameters []ParameterDescriptor `json:"parameters"`
 
 	// Input requirements
-	MinInputs   int
-	MaxInputs   int
-	InputTypes  []MediaType
+	MinInputs  int         `json:"min_inputs"`
+	MaxInputs  int         `json:"max_inputs"`
+	InputTypes []MediaType `json:"input_types,omitempty"`
 
 	// Output types
-	OutputTypes []MediaType
+	OutputTypes []MediaType `json:"output_types,omitempty"`
 
 	// Special requirements
-	RequiresTwoPass   bool
-	SupportsStreaming bool
+	RequiresTwoPass   bool `json:"requires_two_pass"`
+	SupportsStreaming bool `json:"supports_streaming"`
+
+	// Fusable operators take a single input and compile to plain filters,
+	// so the planner may merge adjacent fusable operations into one node
+	Fusable bool `json:"fusable,omitempty"`
+
+	// TimeoutSeconds is the default execution timeout for nodes using this
+	// operator (0 = none). A node's "timeout_seconds" param overrides it.
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
 }
 
 // MediaType represents media type
@@ -88,6 +108,10 @@ type CompileContext struct {
 
 	// Options
 	Debug bool
+
+	// AllowInputFlags is set when the node reads a single input that nothing
+	// else consumes, so CompileResult.InputFlags can be applied to it
+	AllowInputFlags bool
 }
 
 // StreamRef references an input stream
@@ -106,6 +130,11 @@ type CompileResult struct {
 	// Or complete command
 	Command *Command
 
+	// Or options placed before the input's -i (e.g. -ss/-t to seek), used
+	// in place of a filter; only returned when CompileContext.AllowInputFlags
+	// is set
+	InputFlags []string
+
 	// Output stream labels
 	OutputLabels []string
 
@@ -2,17 +2,17 @@ package operators
 
 // ParameterDescriptor describes an operator parameter
 type ParameterDescriptor struct {
-	Name        string
-	Type        ParameterType
-	Required    bool
-	Default     interface{}
-	Description string
+	Name        string        `json:"name"`
+	Type        ParameterType `json:"type"`
+	Required    bool          `json:"required"`
+	Default     interface{}   `json:"default,omitempty"`
+	Description string        `json:"description,omitempty"`
 
 	// Validation rules
-	Validation *ValidationRules
+	Validation *ValidationRules `json:"validation,omitempty"`
 
 	// Examples
-	Examples []interface{}
+	Examples []interface{} `json:"examples,omitempty"`
 }
 
 // ParameterType represents parameter type
@@ -34,25 +34,25 @@ const (
 // ValidationRules defines parameter validation rules
 type ValidationRules struct {
 	// Numeric constraints
-	Min        *float64
-	Max        *float64
-	MultipleOf *float64
+	Min        *float64 `json:"min,omitempty"`
+	Max        *float64 `json:"max,omitempty"`
+	MultipleOf *float64 `json:"multiple_of,omitempty"`
 
 	// String constraints
-	MinLength *int
-	MaxLength *int
-	Pattern   *string
+	MinLength *int    `json:"min_length,omitempty"`
+	MaxLength *int    `json:"max_length,omitempty"`
+	Pattern   *string `json:"pattern,omitempty"`
 
 	// Enum values
-	Enum []interface{}
+	Enum []interface{} `json:"enum,omitempty"`
 
 	// Array constraints
-	MinItems *int
-	MaxItems *int
-	ItemType ParameterType
+	MinItems *int          `json:"min_items,omitempty"`
+	MaxItems *int          `json:"max_items,omitempty"`
+	ItemType ParameterType `json:"item_type,omitempty"`
 
-	// Custom validator
-	CustomValidator func(interface{}) error
+	// Custom validator (not serializable)
+	CustomValidator func(interface{}) error `json:"-"`
 }
 
 // Resolution represents video resolution
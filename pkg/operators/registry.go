@@ -16,6 +16,13 @@ var globalRegistry = &Registry{
 	operators: make(map[string]Operator),
 }
 
+// NewRegistry creates an empty operator registry
+func NewRegistry() *Registry {
+	return &Registry{
+		operators: make(map[string]Operator),
+	}
+}
+
 // GlobalRegistry returns the global operator registry
 func GlobalRegistry() *Registry {
 	return globalRegistry
@@ -71,6 +78,15 @@ func (r *Registry) Get(name string) (Operator, error) {
 	return op, nil
 }
 
+// ValidateParams validates params against the named operator
+func (r *Registry) ValidateParams(name string, params map[string]interface{}) error {
+	op, err := r.Get(name)
+	if err != nil {
+		return err
+	}
+	return op.ValidateParams(params)
+}
+
 // List returns all registered operators
 func (r *Registry) List() []Operator {
 	r.mu.RLock()
@@ -97,3 +113,15 @@ func (r *Registry) ListByCategory(category Category) []Operator {
 
 	return result
 }
+
+// CountByCategory returns the number of registered operators in each category
+func (r *Registry) CountByCategory() map[Category]int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := make(map[Category]int)
+	for _, op := range r.operators {
+		counts[op.Category()]++
+	}
+	return counts
+}
@@ -0,0 +1,170 @@
+package planner
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/chicogong/media-pipeline/pkg/schemas"
+)
+
+// AppendOperation returns a copy of plan with op added, without rebuilding
+// the graph. The operation's inputs may reference the plan's inputs or the
+// outputs it writes. Since nothing in the plan can consume the new node, it
+// is a leaf: it is appended to the execution order and placed in the stage
+// after its latest input, leaving the other stages as they are.
+//
+// The node's metadata is computed if its inputs have metadata, and the
+// plan's resource estimates, if any, are recomputed.
+func (p *Planner) AppendOperation(plan *schemas.ProcessingPlan, op schemas.Operation) (*schemas.ProcessingPlan, error) {
+	operator, err := p.registry.Get(op.Op)
+	if err != nil {
+		return nil, fmt.Errorf("operator '%s' not found", op.Op)
+	}
+	if err := operator.ValidateParams(op.Params); err != nil {
+		return nil, fmt.Errorf("operation %s: %w", op.Op, err)
+	}
+	if op.Output == "" {
+		return nil, fmt.Errorf("operation %s: output is required", op.Op)
+	}
+	if err := op.ValidateInputs(); err != nil {
+		return nil, fmt.Errorf("operation %s: %w", op.Op, err)
+	}
+
+	appended := clonePlan(plan)
+	graph := NewGraphFromPlan(appended)
+
+	sources := planReferences(graph)
+	if _, ok := sources[op.Output]; ok {
+		return nil, fmt.Errorf("operation %s: output '%s' is already defined", op.Op, op.Output)
+	}
+
+	node := &schemas.PlanNode{
+		ID:       appendedNodeID(graph, op.Op),
+		Type:     "operation",
+		Operator: op.Op,
+		Params:   op.Params,
+	}
+	graph.AddNode(node)
+
+	for i, ref := range op.InputRefs() {
+		sourceID, ok := sources[ref]
+		if !ok {
+			return nil, fmt.Errorf("operation %s: reference '%s' not found", op.Op, ref)
+		}
+		graph.AddEdge(&schemas.PlanEdge{
+			From:       sourceID,
+			To:         node.ID,
+			StreamType: "both",
+			InputIndex: i,
+		})
+	}
+
+	if err := graph.DetectCycles(); err != nil {
+		return nil, fmt.Errorf("graph validation failed: %w", err)
+	}
+	if err := ValidateMediaTypes(graph, p.registry); err != nil {
+		return nil, fmt.Errorf("graph validation failed: %w", err)
+	}
+
+	appended.Nodes = graph.Nodes
+	appended.Edges = graph.Edges
+	appended.ExecutionOrder = append(appended.ExecutionOrder, node.ID)
+	appended.ExecutionStages = appendToStages(appended.ExecutionStages, graph, node.ID)
+
+	metadata, err := p.appendedMetadata(graph, node)
+	if err != nil {
+		return nil, fmt.Errorf("metadata propagation failed: %w", err)
+	}
+	if metadata != nil {
+		node.Metadata = metadata
+		if appended.ResourceEstimate != nil {
+			estimates, err := p.estimator.Estimate(context.Background(), graph)
+			if err != nil {
+				return nil, fmt.Errorf("resource estimation failed: %w", err)
+			}
+			appended.ResourceEstimate = estimates
+		}
+	}
+
+	appended.Warnings = deadOperationWarnings(graph)
+	return appended, nil
+}
+
+// planReferences maps the IDs an operation can reference to the node
+// producing them: inputs to their input node, and written outputs to the
+// node feeding the output
+func planReferences(graph *Graph) map[string]string {
+	sources := make(map[string]string)
+	for _, node := range graph.GetInputNodes() {
+		sources[node.InputID] = node.ID
+	}
+	for _, node := range graph.GetOutputNodes() {
+		if preds := graph.GetPredecessors(node.ID); len(preds) == 1 {
+			sources[node.OutputID] = preds[0].ID
+		}
+	}
+	return sources
+}
+
+// appendedNodeID returns an unused ID for a new operation node, numbered
+// after the graph's operations as the builder does
+func appendedNodeID(graph *Graph, op string) string {
+	n := 0
+	for _, node := range graph.Nodes {
+		if node.Type == "operation" {
+			n++
+		}
+	}
+	for {
+		id := fmt.Sprintf("op_%d_%s", n, op)
+		if graph.GetNode(id) == nil {
+			return id
+		}
+		n++
+	}
+}
+
+// appendToStages places a new leaf node in the stage after the latest stage
+// of its predecessors, adding a stage if needed
+func appendToStages(stages [][]string, graph *Graph, nodeID string) [][]string {
+	stageOf := make(map[string]int)
+	for i, stage := range stages {
+		for _, id := range stage {
+			stageOf[id] = i
+		}
+	}
+
+	level := 0
+	for _, pred := range graph.GetPredecessors(nodeID) {
+		if stageOf[pred.ID]+1 > level {
+			level = stageOf[pred.ID] + 1
+		}
+	}
+
+	if level == len(stages) {
+		return append(stages, []string{nodeID})
+	}
+	stages[level] = append(stages[level], nodeID)
+	sort.Strings(stages[level])
+	return stages
+}
+
+// appendedMetadata computes the output metadata of an appended node, or
+// returns nil if any of its inputs has none
+func (p *Planner) appendedMetadata(graph *Graph, node *schemas.PlanNode) (*schemas.MediaInfo, error) {
+	inputs, err := p.propagator.collectInputMetadata(graph, node)
+	if err != nil {
+		return nil, nil
+	}
+
+	op, err := p.registry.Get(node.Operator)
+	if err != nil {
+		return nil, fmt.Errorf("node %s: operator %s not found: %w", node.ID, node.Operator, err)
+	}
+	metadata, err := op.ComputeOutputMetadata(node.Params, inputs)
+	if err != nil {
+		return nil, fmt.Errorf("node %s: failed to compute output metadata: %w", node.ID, err)
+	}
+	return metadata, nil
+}
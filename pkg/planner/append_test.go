@@ -0,0 +1,135 @@
+package planner
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/chicogong/media-pipeline/pkg/operators"
+	"github.com/chicogong/media-pipeline/pkg/operators/builtin"
+	"github.com/chicogong/media-pipeline/pkg/schemas"
+)
+
+// trimPlan plans video -> trim -> output trimmed
+func trimPlan(t *testing.T, planner *Planner) *schemas.ProcessingPlan {
+	t.Helper()
+
+	spec := &schemas.JobSpec{
+		Inputs: []schemas.Input{
+			{ID: "video", Source: "s3://bucket/input.mp4"},
+		},
+		Operations: []schemas.Operation{
+			{Op: "trim", Input: "video", Output: "trimmed",
+				Params: map[string]interface{}{"start": "00:00:10", "duration": "00:00:30"}},
+		},
+		Outputs: []schemas.Output{
+			{ID: "trimmed", Destination: "s3://bucket/output.mp4"},
+		},
+	}
+
+	plan, err := planner.Plan(context.Background(), spec, nil)
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	return plan
+}
+
+func TestPlanner_AppendOperation(t *testing.T) {
+	registry := operators.NewRegistry()
+	registry.Register(&builtin.TrimOperator{})
+	registry.Register(&builtin.ScaleOperator{})
+	planner := NewPlannerWithRegistry(registry)
+
+	plan := trimPlan(t, planner)
+	if len(plan.ExecutionStages) != 3 {
+		t.Fatalf("expected 3 stages, got %d", len(plan.ExecutionStages))
+	}
+
+	appended, err := planner.AppendOperation(plan, schemas.Operation{
+		Op: "scale", Input: "trimmed", Output: "scaled",
+		Params: map[string]interface{}{"width": 1280, "height": 720},
+	})
+	if err != nil {
+		t.Fatalf("AppendOperation failed: %v", err)
+	}
+
+	if len(plan.Nodes) != 3 || len(plan.ExecutionOrder) != 3 {
+		t.Error("expected the original plan to be left unchanged")
+	}
+
+	node := NewGraphFromPlan(appended).GetNode("op_1_scale")
+	if node == nil {
+		t.Fatal("appended node not found")
+	}
+	if preds := NewGraphFromPlan(appended).GetPredecessors(node.ID); len(preds) != 1 || preds[0].ID != "op_0_trim" {
+		t.Errorf("expected the scale to read op_0_trim, got %v", preds)
+	}
+
+	wantOrder := []string{"input_video", "op_0_trim", "output_trimmed", "op_1_scale"}
+	if !reflect.DeepEqual(appended.ExecutionOrder, wantOrder) {
+		t.Errorf("execution order = %v, want %v", appended.ExecutionOrder, wantOrder)
+	}
+
+	// The scale runs alongside the trim's output, so no stage is added
+	if len(appended.ExecutionStages) != 3 {
+		t.Fatalf("expected 3 stages, got %d", len(appended.ExecutionStages))
+	}
+	wantStages, err := NewGraphFromPlan(appended).ComputeExecutionStages()
+	if err != nil {
+		t.Fatalf("ComputeExecutionStages failed: %v", err)
+	}
+	if !reflect.DeepEqual(appended.ExecutionStages, wantStages) {
+		t.Errorf("stages = %v, want %v", appended.ExecutionStages, wantStages)
+	}
+
+	if len(appended.Warnings) != 1 {
+		t.Errorf("expected a warning for the unused scale, got %v", appended.Warnings)
+	}
+}
+
+func TestPlanner_AppendOperationOnInput(t *testing.T) {
+	registry := operators.NewRegistry()
+	registry.Register(&builtin.TrimOperator{})
+	registry.Register(&builtin.ScaleOperator{})
+	planner := NewPlannerWithRegistry(registry)
+
+	// An operation on the input runs alongside the trim
+	plan := trimPlan(t, planner)
+
+	appended, err := planner.AppendOperation(plan, schemas.Operation{
+		Op: "scale", Input: "video", Output: "small",
+		Params: map[string]interface{}{"width": 640, "height": 360},
+	})
+	if err != nil {
+		t.Fatalf("AppendOperation failed: %v", err)
+	}
+	want := [][]string{{"input_video"}, {"op_0_trim", "op_1_scale"}, {"output_trimmed"}}
+	if !reflect.DeepEqual(appended.ExecutionStages, want) {
+		t.Errorf("stages = %v, want %v", appended.ExecutionStages, want)
+	}
+}
+
+func TestPlanner_AppendOperationErrors(t *testing.T) {
+	registry := operators.NewRegistry()
+	registry.Register(&builtin.TrimOperator{})
+	registry.Register(&builtin.ScaleOperator{})
+	planner := NewPlannerWithRegistry(registry)
+	plan := trimPlan(t, planner)
+
+	scaleParams := map[string]interface{}{"width": 1280, "height": 720}
+	tests := []struct {
+		name string
+		op   schemas.Operation
+	}{
+		{"unknown operator", schemas.Operation{Op: "blur", Input: "video", Output: "blurred"}},
+		{"invalid params", schemas.Operation{Op: "scale", Input: "video", Output: "scaled"}},
+		{"unknown reference", schemas.Operation{Op: "scale", Input: "missing", Output: "scaled", Params: scaleParams}},
+		{"duplicate output", schemas.Operation{Op: "scale", Input: "video", Output: "trimmed", Params: scaleParams}},
+	}
+
+	for _, tt := range tests {
+		if _, err := planner.AppendOperation(plan, tt.op); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
@@ -3,13 +3,17 @@ package planner
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 
+	"github.com/chicogong/media-pipeline/pkg/operators"
 	"github.com/chicogong/media-pipeline/pkg/schemas"
 )
 
 // Builder builds a processing plan from a JobSpec
 type Builder struct {
-	outputMap map[string]string // Maps output ID to node ID
+	outputMap map[string]string   // Maps output ID to node ID
+	registry  *operators.Registry // Used to rank candidates for unresolved references
 }
 
 // NewBuilder creates a new plan builder
@@ -19,6 +23,14 @@ func NewBuilder() *Builder {
 	}
 }
 
+// NewBuilderWithRegistry creates a plan builder that uses registry to
+// suggest candidates for unresolved references
+func NewBuilderWithRegistry(registry *operators.Registry) *Builder {
+	b := NewBuilder()
+	b.registry = registry
+	return b
+}
+
 // BuildDAG builds a directed acyclic graph from a JobSpec
 func (b *Builder) BuildDAG(ctx context.Context, spec *schemas.JobSpec) (*Graph, error) {
 	graph := NewGraph()
@@ -40,6 +52,10 @@ func (b *Builder) BuildDAG(ctx context.Context, spec *schemas.JobSpec) (*Graph,
 
 	// Step 2: Create operation nodes and edges
 	for i, op := range spec.Operations {
+		if err := op.ValidateInputs(); err != nil {
+			return nil, fmt.Errorf("operation %d (%s): %w", i, op.Op, err)
+		}
+
 		nodeID := fmt.Sprintf("op_%d_%s", i, op.Op)
 		node := &schemas.PlanNode{
 			ID:       nodeID,
@@ -52,10 +68,9 @@ func (b *Builder) BuildDAG(ctx context.Context, spec *schemas.JobSpec) (*Graph,
 		// Map output ID to node ID
 		b.outputMap[op.Output] = nodeID
 
-		// Create edges from inputs
-		if op.Input != "" {
-			// Single input
-			sourceID, err := b.resolveReference(op.Input)
+		// Create one edge per input, in order
+		for j, inputRef := range op.InputRefs() {
+			sourceID, err := b.resolveReference(inputRef)
 			if err != nil {
 				return nil, fmt.Errorf("operation %d (%s): %w", i, op.Op, err)
 			}
@@ -64,35 +79,21 @@ func (b *Builder) BuildDAG(ctx context.Context, spec *schemas.JobSpec) (*Graph,
 				From:       sourceID,
 				To:         nodeID,
 				StreamType: "both", // Default to both video and audio
+				InputIndex: j,
 			}
 			graph.AddEdge(edge)
 		}
-
-		if len(op.Inputs) > 0 {
-			// Multiple inputs
-			for _, inputRef := range op.Inputs {
-				sourceID, err := b.resolveReference(inputRef)
-				if err != nil {
-					return nil, fmt.Errorf("operation %d (%s): %w", i, op.Op, err)
-				}
-
-				edge := &schemas.PlanEdge{
-					From:       sourceID,
-					To:         nodeID,
-					StreamType: "both",
-				}
-				graph.AddEdge(edge)
-			}
-		}
 	}
 
 	// Step 3: Create output nodes and edges
 	for _, output := range spec.Outputs {
 		node := &schemas.PlanNode{
-			ID:       "output_" + output.ID,
-			Type:     "output",
-			OutputID: output.ID,
-			DestURI:  output.Destination,
+			ID:             "output_" + output.ID,
+			Type:           "output",
+			OutputID:       output.ID,
+			DestURI:        output.Destination,
+			OutputMetadata: outputMetadata(spec, output),
+			MetadataMode:   output.MetadataMode,
 		}
 		graph.AddNode(node)
 
@@ -118,6 +119,24 @@ func (b *Builder) BuildDAG(ctx context.Context, spec *schemas.JobSpec) (*Graph,
 	return graph, nil
 }
 
+// outputMetadata returns the container tags to write for output. In merge
+// mode the job's tags are included, overridden by the output's own metadata
+func outputMetadata(spec *schemas.JobSpec, output schemas.Output) map[string]string {
+	mode := output.MetadataMode
+	if (mode != "" && mode != schemas.MetadataModeMerge) || len(spec.Tags) == 0 {
+		return output.Metadata
+	}
+
+	merged := make(map[string]string, len(spec.Tags)+len(output.Metadata))
+	for key, value := range spec.Tags {
+		merged[key] = value
+	}
+	for key, value := range output.Metadata {
+		merged[key] = value
+	}
+	return merged
+}
+
 // resolveReference resolves an input/output reference to a node ID
 func (b *Builder) resolveReference(ref string) (string, error) {
 	nodeID, ok := b.outputMap[ref]
@@ -126,3 +145,136 @@ func (b *Builder) resolveReference(ref string) (string, error) {
 	}
 	return nodeID, nil
 }
+
+// InputCandidate is a possible source for an operation's input reference
+type InputCandidate struct {
+	// Ref is the input or operation output ID
+	Ref string
+
+	// Confidence ranks the candidate from 0 (unlikely) to 1 (exact match)
+	Confidence float64
+}
+
+// AmbiguityError is returned when an operation's input reference does not
+// name an available input or output but several candidates resemble it
+type AmbiguityError struct {
+	Operation  int
+	Ref        string
+	Candidates []InputCandidate // Best candidate first
+}
+
+// Error implements the error interface
+func (e *AmbiguityError) Error() string {
+	refs := make([]string, len(e.Candidates))
+	for i, c := range e.Candidates {
+		refs[i] = fmt.Sprintf("'%s' (%.2f)", c.Ref, c.Confidence)
+	}
+	return fmt.Sprintf("reference '%s' is ambiguous, candidates: %s", e.Ref, strings.Join(refs, ", "))
+}
+
+// Confidence weights for InferOperatorInputs
+const (
+	nameSimilarityWeight = 0.7
+	streamTypeWeight     = 0.3
+
+	// minSharedPrefix is how many leading characters a candidate must share
+	// with a reference to be considered at all
+	minSharedPrefix = 3
+)
+
+// InferOperatorInputs ranks the inputs and earlier operation outputs of spec
+// that operation opIndex's Input could refer to. An exact match is returned
+// alone with confidence 1. Otherwise candidates sharing a name prefix with
+// the reference are scored by name similarity and, if registry is non-nil,
+// stream type compatibility. More than one candidate yields an
+// *AmbiguityError listing them; none yields a not-found error.
+func InferOperatorInputs(spec *schemas.JobSpec, opIndex int, registry *operators.Registry) ([]InputCandidate, error) {
+	if opIndex < 0 || opIndex >= len(spec.Operations) {
+		return nil, fmt.Errorf("operation index %d out of range", opIndex)
+	}
+	op := spec.Operations[opIndex]
+	ref := op.Input
+
+	// Types the consuming operator accepts (nil = unknown)
+	var accepted []operators.MediaType
+	if registry != nil {
+		if consumer, err := registry.Get(op.Op); err == nil {
+			accepted = consumer.Describe().InputTypes
+		}
+	}
+
+	// Everything the operation could refer to, with the types it produces
+	available := make(map[string][]operators.MediaType)
+	for _, input := range spec.Inputs {
+		var types []operators.MediaType
+		if input.Type != "" {
+			types = []operators.MediaType{operators.MediaType(input.Type)}
+		}
+		available[input.ID] = types
+	}
+	for _, prev := range spec.Operations[:opIndex] {
+		var types []operators.MediaType
+		if registry != nil {
+			if producer, err := registry.Get(prev.Op); err == nil {
+				types = producer.Describe().OutputTypes
+			}
+		}
+		available[prev.Output] = types
+	}
+
+	if _, ok := available[ref]; ok {
+		return []InputCandidate{{Ref: ref, Confidence: 1}}, nil
+	}
+
+	var candidates []InputCandidate
+	for id, types := range available {
+		shared := sharedPrefixLen(ref, id)
+		if shared < minSharedPrefix && shared < len(ref) {
+			continue
+		}
+		if shared == 0 {
+			continue
+		}
+
+		similarity := float64(shared) / float64(max(len(ref), len(id)))
+		confidence := nameSimilarityWeight*similarity + streamTypeWeight*streamTypeScore(types, accepted)
+		candidates = append(candidates, InputCandidate{Ref: id, Confidence: confidence})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Confidence != candidates[j].Confidence {
+			return candidates[i].Confidence > candidates[j].Confidence
+		}
+		return candidates[i].Ref < candidates[j].Ref
+	})
+
+	switch len(candidates) {
+	case 0:
+		return nil, fmt.Errorf("reference '%s' not found", ref)
+	case 1:
+		return candidates, fmt.Errorf("reference '%s' not found (did you mean '%s'?)", ref, candidates[0].Ref)
+	default:
+		return candidates, &AmbiguityError{Operation: opIndex, Ref: ref, Candidates: candidates}
+	}
+}
+
+// sharedPrefixLen returns the length of the longest common prefix of a and b
+func sharedPrefixLen(a, b string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+// streamTypeScore rates how well produced stream types fit accepted ones:
+// 1 if they overlap, 0 if they cannot, 0.5 if either side is unknown
+func streamTypeScore(produced, accepted []operators.MediaType) float64 {
+	if len(produced) == 0 || len(accepted) == 0 {
+		return 0.5
+	}
+	if mediaTypesOverlap(produced, accepted) {
+		return 1
+	}
+	return 0
+}
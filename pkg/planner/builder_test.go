@@ -2,8 +2,12 @@ package planner
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
 
+	"github.com/chicogong/media-pipeline/pkg/operators"
+	"github.com/chicogong/media-pipeline/pkg/operators/builtin"
 	"github.com/chicogong/media-pipeline/pkg/schemas"
 )
 
@@ -147,6 +151,56 @@ func TestBuilder_BuildDAG_MultipleInputs(t *testing.T) {
 	if len(graph.Edges) != 3 {
 		t.Errorf("expected 3 edges, got %d", len(graph.Edges))
 	}
+
+	// One incoming edge per input, in order
+	incoming := graph.GetIncomingEdges("op_0_concat")
+	if len(incoming) != 2 {
+		t.Fatalf("expected 2 incoming edges, got %d", len(incoming))
+	}
+	for i, want := range []string{"input_video1", "input_video2"} {
+		if incoming[i].From != want || incoming[i].InputIndex != i {
+			t.Errorf("edge %d: expected from %s at index %d, got %s at %d", i, want, i, incoming[i].From, incoming[i].InputIndex)
+		}
+	}
+}
+
+func TestBuilder_BuildDAG_InputAndInputs(t *testing.T) {
+	spec := &schemas.JobSpec{
+		Inputs: []schemas.Input{
+			{ID: "video", Source: "s3://bucket/video.mp4"},
+			{ID: "logo", Source: "s3://bucket/logo.png"},
+		},
+		Operations: []schemas.Operation{
+			{Op: "overlay", Input: "video", Inputs: []string{"logo"}, Output: "branded"},
+		},
+		Outputs: []schemas.Output{
+			{ID: "branded", Destination: "s3://bucket/output.mp4"},
+		},
+	}
+
+	_, err := NewBuilder().BuildDAG(context.Background(), spec)
+	if err == nil || !strings.Contains(err.Error(), "input and inputs cannot both be set") {
+		t.Errorf("expected an error for an operation setting both input and inputs, got: %v", err)
+	}
+}
+
+func TestBuilder_BuildDAG_NoInputs(t *testing.T) {
+	spec := &schemas.JobSpec{
+		Inputs: []schemas.Input{
+			{ID: "video", Source: "s3://bucket/video.mp4"},
+		},
+		Operations: []schemas.Operation{
+			{Op: "trim", Output: "trimmed"},
+		},
+		Outputs: []schemas.Output{
+			{ID: "trimmed", Destination: "s3://bucket/output.mp4"},
+		},
+	}
+
+	_, err := NewBuilder().BuildDAG(context.Background(), spec)
+	if err == nil || !strings.Contains(err.Error(), "input or inputs is required") {
+		t.Errorf("expected an error for an operation without inputs, got: %v", err)
+	}
 }
 
 func TestBuilder_BuildDAG_InvalidReference(t *testing.T) {
@@ -199,3 +253,111 @@ func TestBuilder_BuildDAG_CyclicDependency(t *testing.T) {
 		t.Errorf("unexpected cycle: %v", err)
 	}
 }
+
+// ambiguousSpec has two trims whose outputs share the "clip_" prefix, an
+// audio-only input with the same prefix, and a scale referencing "clip_in"
+func ambiguousSpec() *schemas.JobSpec {
+	return &schemas.JobSpec{
+		Inputs: []schemas.Input{
+			{ID: "video", Source: "s3://bucket/input.mp4", Type: "video"},
+			{ID: "clip_audio", Source: "s3://bucket/music.mp3", Type: "audio"},
+		},
+		Operations: []schemas.Operation{
+			{Op: "trim", Input: "video", Output: "clip_intro",
+				Params: map[string]interface{}{"start": "0s", "duration": "5s"}},
+			{Op: "trim", Input: "video", Output: "clip_outro",
+				Params: map[string]interface{}{"start": "55s", "duration": "5s"}},
+			{Op: "scale", Input: "clip_in", Output: "scaled",
+				Params: map[string]interface{}{"width": 1280, "height": 720}},
+		},
+		Outputs: []schemas.Output{
+			{ID: "scaled", Destination: "s3://bucket/output.mp4"},
+		},
+	}
+}
+
+func TestInferOperatorInputs_Ranking(t *testing.T) {
+	operators.Register(&builtin.TrimOperator{})
+	operators.Register(&builtin.ScaleOperator{})
+
+	candidates, err := InferOperatorInputs(ambiguousSpec(), 2, operators.GlobalRegistry())
+
+	var ambiguity *AmbiguityError
+	if !errors.As(err, &ambiguity) {
+		t.Fatalf("expected AmbiguityError, got %v", err)
+	}
+	if ambiguity.Ref != "clip_in" || ambiguity.Operation != 2 {
+		t.Errorf("unexpected ambiguity details: %+v", ambiguity)
+	}
+
+	// Closest name first; the audio-only input ranks last because scale
+	// cannot consume it
+	want := []string{"clip_intro", "clip_outro", "clip_audio"}
+	if len(candidates) != len(want) {
+		t.Fatalf("expected %d candidates, got %+v", len(want), candidates)
+	}
+	for i, ref := range want {
+		if candidates[i].Ref != ref {
+			t.Errorf("candidate %d: expected %s, got %s", i, ref, candidates[i].Ref)
+		}
+		if i > 0 && candidates[i].Confidence >= candidates[i-1].Confidence {
+			t.Errorf("expected decreasing confidence, got %+v", candidates)
+		}
+	}
+	if len(ambiguity.Candidates) != len(want) {
+		t.Errorf("expected error to carry the candidates, got %+v", ambiguity.Candidates)
+	}
+}
+
+func TestInferOperatorInputs_WithoutRegistry(t *testing.T) {
+	candidates, err := InferOperatorInputs(ambiguousSpec(), 2, nil)
+	var ambiguity *AmbiguityError
+	if !errors.As(err, &ambiguity) {
+		t.Fatalf("expected AmbiguityError, got %v", err)
+	}
+
+	// Without operator types only input types are known, so every
+	// candidate gets the neutral type score
+	if candidates[0].Ref != "clip_intro" {
+		t.Errorf("expected clip_intro first, got %+v", candidates)
+	}
+	if candidates[1].Confidence != candidates[2].Confidence {
+		t.Errorf("expected equal scores for equally similar names, got %+v", candidates)
+	}
+}
+
+func TestInferOperatorInputs_ExactAndMissing(t *testing.T) {
+	spec := ambiguousSpec()
+
+	// Exact match
+	spec.Operations[2].Input = "clip_outro"
+	candidates, err := InferOperatorInputs(spec, 2, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(candidates) != 1 || candidates[0].Ref != "clip_outro" || candidates[0].Confidence != 1 {
+		t.Errorf("expected exact match, got %+v", candidates)
+	}
+
+	// Single similar candidate is suggested, not used
+	spec.Operations[2].Input = "vid"
+	candidates, err = InferOperatorInputs(spec, 2, nil)
+	if err == nil || !strings.Contains(err.Error(), "did you mean 'video'") {
+		t.Errorf("expected suggestion for video, got %v", err)
+	}
+	if len(candidates) != 1 {
+		t.Errorf("expected 1 candidate, got %+v", candidates)
+	}
+
+	// Outputs of later operations are not candidates
+	spec.Operations[0].Input = "clip_outr"
+	if _, err := InferOperatorInputs(spec, 0, nil); err == nil || !strings.Contains(err.Error(), "clip_audio") {
+		t.Errorf("expected only earlier outputs and inputs as candidates, got %v", err)
+	}
+
+	// Nothing similar
+	spec.Operations[2].Input = "xyz"
+	if _, err := InferOperatorInputs(spec, 2, nil); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
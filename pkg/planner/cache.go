@@ -0,0 +1,222 @@
+package planner
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/chicogong/media-pipeline/pkg/schemas"
+)
+
+// DefaultPlanCacheMaxEntries is the default number of cached plans
+const DefaultPlanCacheMaxEntries = 256
+
+// CachingPlanner memoizes the plans of a Planner, keyed by SpecHash and the
+// plan options. Plans are cached without the job they were created for; a
+// hit returns a copy for the requesting job.
+type CachingPlanner struct {
+	planner    *Planner
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // Front is most recently used
+	hits    int
+	misses  int
+}
+
+// planCacheEntry is a cached plan
+type planCacheEntry struct {
+	key  string
+	plan *schemas.ProcessingPlan
+}
+
+// PlanCacheStats reports how a CachingPlanner has been used
+type PlanCacheStats struct {
+	Hits    int
+	Misses  int
+	Entries int
+}
+
+// PlanCacheOption is a functional option for CachingPlanner
+type PlanCacheOption func(*CachingPlanner)
+
+// WithPlanCacheMaxEntries bounds the number of cached plans; the least
+// recently used plan is evicted when the cache is full
+func WithPlanCacheMaxEntries(n int) PlanCacheOption {
+	return func(c *CachingPlanner) {
+		c.maxEntries = n
+	}
+}
+
+// NewCachingPlanner creates a CachingPlanner wrapping p
+func NewCachingPlanner(p *Planner, opts ...PlanCacheOption) *CachingPlanner {
+	c := &CachingPlanner{
+		planner:    p,
+		maxEntries: DefaultPlanCacheMaxEntries,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Plan returns the plan for spec, reusing the cached plan of an identical
+// spec planned with the same options
+func (c *CachingPlanner) Plan(ctx context.Context, spec *schemas.JobSpec, opts *PlanOptions) (*schemas.ProcessingPlan, error) {
+	key, err := planCacheKey(spec, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if elem, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(elem)
+		c.hits++
+		plan := clonePlan(elem.Value.(*planCacheEntry).plan)
+		c.mu.Unlock()
+
+		plan.JobID = spec.JobID
+		return plan, nil
+	}
+	c.misses++
+	c.mu.Unlock()
+
+	plan, err := c.planner.Plan(ctx, spec, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	cached := clonePlan(plan)
+	cached.PlanID = ""
+	cached.JobID = ""
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.removeElement(elem)
+	}
+	c.entries[key] = c.lru.PushFront(&planCacheEntry{key: key, plan: cached})
+
+	for c.maxEntries > 0 && c.lru.Len() > c.maxEntries {
+		c.removeElement(c.lru.Back())
+	}
+
+	return plan, nil
+}
+
+// InvalidateAll drops every cached plan, e.g. after operators change
+func (c *CachingPlanner) InvalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+}
+
+// Stats returns the cache's hit and miss counts and its size
+func (c *CachingPlanner) Stats() PlanCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return PlanCacheStats{
+		Hits:    c.hits,
+		Misses:  c.misses,
+		Entries: c.lru.Len(),
+	}
+}
+
+// removeElement removes an entry; must be called with c.mu held
+func (c *CachingPlanner) removeElement(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*planCacheEntry)
+	delete(c.entries, entry.key)
+}
+
+// SpecHash returns a hex SHA-256 of the parts of spec that determine its
+// plan: the inputs, operations, outputs and tags of its normalized form.
+// Specs that differ only in job ID, owner, priority or similar settings
+// hash the same.
+func SpecHash(spec *schemas.JobSpec) (string, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode spec: %w", err)
+	}
+
+	// Normalize a copy so the caller's spec is left alone
+	var normalized schemas.JobSpec
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return "", fmt.Errorf("failed to copy spec: %w", err)
+	}
+	normalized.Normalize()
+
+	data, err = json.Marshal(struct {
+		Inputs     []schemas.Input     `json:"inputs"`
+		Operations []schemas.Operation `json:"operations"`
+		Outputs    []schemas.Output    `json:"outputs"`
+		Tags       map[string]string   `json:"tags,omitempty"`
+	}{normalized.Inputs, normalized.Operations, normalized.Outputs, normalized.Tags})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode spec: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// planCacheKey combines the spec hash with the options that change the plan
+func planCacheKey(spec *schemas.JobSpec, opts *PlanOptions) (string, error) {
+	hash, err := SpecHash(spec)
+	if err != nil {
+		return "", err
+	}
+	if opts == nil {
+		opts = &PlanOptions{}
+	}
+	return fmt.Sprintf("%s/%t/%t/%t/%t", hash,
+		opts.SkipMetadataValidation, opts.SkipResourceEstimation, opts.DeduplicateOperations, opts.FuseOperations), nil
+}
+
+// clonePlan returns a deep copy of plan
+func clonePlan(plan *schemas.ProcessingPlan) *schemas.ProcessingPlan {
+	copied := *plan
+
+	copied.Nodes = make([]*schemas.PlanNode, len(plan.Nodes))
+	for i, node := range plan.Nodes {
+		copied.Nodes[i] = cloneNode(node)
+	}
+
+	copied.Edges = make([]*schemas.PlanEdge, len(plan.Edges))
+	for i, edge := range plan.Edges {
+		e := *edge
+		copied.Edges[i] = &e
+	}
+
+	copied.ExecutionOrder = append([]string(nil), plan.ExecutionOrder...)
+	copied.ExecutionStages = make([][]string, len(plan.ExecutionStages))
+	for i, stage := range plan.ExecutionStages {
+		copied.ExecutionStages[i] = append([]string(nil), stage...)
+	}
+
+	if plan.ResourceEstimate != nil {
+		estimate := *plan.ResourceEstimate
+		estimate.NodeEstimates = make(map[string]*schemas.NodeEstimates, len(plan.ResourceEstimate.NodeEstimates))
+		for id, e := range plan.ResourceEstimate.NodeEstimates {
+			ne := *e
+			estimate.NodeEstimates[id] = &ne
+		}
+		copied.ResourceEstimate = &estimate
+	}
+
+	copied.Warnings = append([]string(nil), plan.Warnings...)
+	copied.Commands = append([]schemas.FFmpegCommand(nil), plan.Commands...)
+	return &copied
+}
@@ -0,0 +1,146 @@
+package planner
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/chicogong/media-pipeline/pkg/operators"
+	"github.com/chicogong/media-pipeline/pkg/operators/builtin"
+	"github.com/chicogong/media-pipeline/pkg/schemas"
+)
+
+// cacheSpec returns a trim -> scale spec for jobID
+func cacheSpec(jobID string) *schemas.JobSpec {
+	return &schemas.JobSpec{
+		JobID: jobID,
+		Inputs: []schemas.Input{
+			{ID: "video", Source: "s3://bucket/input.mp4"},
+		},
+		Operations: []schemas.Operation{
+			{Op: "trim", Input: "video", Output: "trimmed",
+				Params: map[string]interface{}{"start": "00:00:10", "duration": "00:00:30"}},
+			{Op: "scale", Input: "trimmed", Output: "scaled",
+				Params: map[string]interface{}{"width": 1280, "height": 720}},
+		},
+		Outputs: []schemas.Output{
+			{ID: "scaled", Destination: "s3://bucket/output.mp4"},
+		},
+	}
+}
+
+func TestCachingPlanner_HitAndMiss(t *testing.T) {
+	operators.Register(&builtin.TrimOperator{})
+	operators.Register(&builtin.ScaleOperator{})
+
+	c := NewCachingPlanner(NewPlanner())
+	ctx := context.Background()
+
+	first, err := c.Plan(ctx, cacheSpec("job-1"), nil)
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+
+	// An identical spec for another job hits the cache; surrounding
+	// whitespace is normalized away
+	spec := cacheSpec("job-2")
+	spec.Inputs[0].Source = "  s3://bucket/input.mp4 "
+	second, err := c.Plan(ctx, spec, nil)
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+
+	if stats := c.Stats(); stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("expected 1 hit and 1 miss, got %+v", stats)
+	}
+	if second.JobID != "job-2" {
+		t.Errorf("expected cached plan for job-2, got %s", second.JobID)
+	}
+	if !reflect.DeepEqual(second.ExecutionOrder, first.ExecutionOrder) || len(second.Nodes) != len(first.Nodes) {
+		t.Errorf("cached plan differs from the original")
+	}
+
+	// Hits return copies
+	second.Nodes[0].SourceURI = "changed"
+	third, err := c.Plan(ctx, cacheSpec("job-3"), nil)
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	if third.Nodes[0].SourceURI == "changed" {
+		t.Error("cached plan was modified through a returned copy")
+	}
+
+	// A modified spec misses
+	modified := cacheSpec("job-4")
+	modified.Operations[1].Params["width"] = 640
+	if _, err := c.Plan(ctx, modified, nil); err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	if stats := c.Stats(); stats.Hits != 2 || stats.Misses != 2 || stats.Entries != 2 {
+		t.Errorf("expected 2 hits, 2 misses and 2 entries, got %+v", stats)
+	}
+
+	// Different options miss too
+	if _, err := c.Plan(ctx, cacheSpec("job-5"), &PlanOptions{FuseOperations: true}); err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	if stats := c.Stats(); stats.Misses != 3 {
+		t.Errorf("expected a miss for different options, got %+v", stats)
+	}
+}
+
+func TestCachingPlanner_InvalidateAll(t *testing.T) {
+	operators.Register(&builtin.TrimOperator{})
+	operators.Register(&builtin.ScaleOperator{})
+
+	c := NewCachingPlanner(NewPlanner())
+	ctx := context.Background()
+
+	c.Plan(ctx, cacheSpec("job-1"), nil)
+	c.InvalidateAll()
+	c.Plan(ctx, cacheSpec("job-2"), nil)
+
+	if stats := c.Stats(); stats.Hits != 0 || stats.Misses != 2 || stats.Entries != 1 {
+		t.Errorf("expected 2 misses after invalidation, got %+v", stats)
+	}
+}
+
+func TestCachingPlanner_MaxEntries(t *testing.T) {
+	operators.Register(&builtin.TrimOperator{})
+	operators.Register(&builtin.ScaleOperator{})
+
+	c := NewCachingPlanner(NewPlanner(), WithPlanCacheMaxEntries(1))
+	ctx := context.Background()
+
+	other := cacheSpec("job-2")
+	other.Outputs[0].Destination = "s3://bucket/other.mp4"
+
+	c.Plan(ctx, cacheSpec("job-1"), nil)
+	c.Plan(ctx, other, nil)
+	c.Plan(ctx, cacheSpec("job-3"), nil) // Evicted by other
+
+	if stats := c.Stats(); stats.Hits != 0 || stats.Entries != 1 {
+		t.Errorf("expected the least recently used plan to be evicted, got %+v", stats)
+	}
+}
+
+func TestSpecHash(t *testing.T) {
+	a, err := SpecHash(cacheSpec("job-1"))
+	if err != nil {
+		t.Fatalf("SpecHash failed: %v", err)
+	}
+
+	spec := cacheSpec("job-2")
+	spec.Priority = 5
+	spec.UserID = "alice"
+	b, _ := SpecHash(spec)
+	if a != b {
+		t.Error("expected job settings not to change the hash")
+	}
+
+	spec.Tags = map[string]string{"project": "promo"}
+	c, _ := SpecHash(spec)
+	if a == c {
+		t.Error("expected tags to change the hash")
+	}
+}
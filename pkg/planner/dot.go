@@ -0,0 +1,77 @@
+package planner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/chicogong/media-pipeline/pkg/schemas"
+)
+
+// dotNodeStyles maps node types to their Graphviz shape and fill color
+var dotNodeStyles = map[string][2]string{
+	"input":     {"invhouse", "lightblue"},
+	"operation": {"box", "lightyellow"},
+	"output":    {"house", "palegreen"},
+}
+
+// ExportDOT renders the graph in Graphviz DOT format. Nodes are shaped and
+// colored by type and labeled with their operator and parameters or URI;
+// edges are labeled with their stream type.
+func ExportDOT(graph *Graph) string {
+	var b strings.Builder
+	b.WriteString("digraph plan {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [style=filled];\n")
+
+	for _, node := range graph.Nodes {
+		label := node.ID
+		if detail := dotNodeDetail(node); detail != "" {
+			label += "\n" + detail
+		}
+
+		style, ok := dotNodeStyles[node.Type]
+		if !ok {
+			style = [2]string{"ellipse", "white"}
+		}
+		fmt.Fprintf(&b, "  %q [label=%q, shape=%s, fillcolor=%s];\n", node.ID, label, style[0], style[1])
+	}
+
+	for _, edge := range graph.Edges {
+		if edge.StreamType != "" {
+			fmt.Fprintf(&b, "  %q -> %q [label=%q];\n", edge.From, edge.To, edge.StreamType)
+		} else {
+			fmt.Fprintf(&b, "  %q -> %q;\n", edge.From, edge.To)
+		}
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// dotNodeDetail returns the type-specific details shown under a node's ID
+func dotNodeDetail(node *schemas.PlanNode) string {
+	switch node.Type {
+	case "input":
+		return node.SourceURI
+	case "output":
+		return node.DestURI
+	case "operation":
+		if len(node.Params) == 0 {
+			return node.Operator
+		}
+
+		keys := make([]string, 0, len(node.Params))
+		for k := range node.Params {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		parts := make([]string, len(keys))
+		for i, k := range keys {
+			parts[i] = fmt.Sprintf("%s=%v", k, node.Params[k])
+		}
+		return node.Operator + " {" + strings.Join(parts, ", ") + "}"
+	}
+	return ""
+}
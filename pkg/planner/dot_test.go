@@ -0,0 +1,64 @@
+package planner
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/chicogong/media-pipeline/pkg/schemas"
+)
+
+func TestExportDOT(t *testing.T) {
+	spec := &schemas.JobSpec{
+		Inputs: []schemas.Input{
+			{ID: "video", Source: "s3://bucket/input.mp4"},
+		},
+		Operations: []schemas.Operation{
+			{Op: "trim", Input: "video", Output: "trimmed",
+				Params: map[string]interface{}{"start": "00:00:10", "duration": "5s"}},
+			{Op: "scale", Input: "trimmed", Output: "scaled",
+				Params: map[string]interface{}{"width": 1280, "height": 720}},
+		},
+		Outputs: []schemas.Output{
+			{ID: "scaled", Destination: "s3://bucket/output.mp4"},
+		},
+	}
+
+	graph, err := NewBuilder().BuildDAG(context.Background(), spec)
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+
+	dot := ExportDOT(graph)
+
+	want := []string{
+		"digraph plan {\n",
+		`  "input_video" [label="input_video\ns3://bucket/input.mp4", shape=invhouse, fillcolor=lightblue];`,
+		`  "op_0_trim" [label="op_0_trim\ntrim {duration=5s, start=00:00:10}", shape=box, fillcolor=lightyellow];`,
+		`  "op_1_scale" [label="op_1_scale\nscale {height=720, width=1280}", shape=box, fillcolor=lightyellow];`,
+		`  "output_scaled" [label="output_scaled\ns3://bucket/output.mp4", shape=house, fillcolor=palegreen];`,
+		`  "input_video" -> "op_0_trim" [label="both"];`,
+		`  "op_0_trim" -> "op_1_scale" [label="both"];`,
+		`  "op_1_scale" -> "output_scaled" [label="both"];`,
+	}
+	for _, line := range want {
+		if !strings.Contains(dot, line) {
+			t.Errorf("DOT output missing %q\ngot:\n%s", line, dot)
+		}
+	}
+	if !strings.HasSuffix(dot, "}\n") {
+		t.Errorf("DOT output should end with a closing brace, got:\n%s", dot)
+	}
+}
+
+func TestExportDOT_UnlabeledEdge(t *testing.T) {
+	graph := NewGraph()
+	graph.AddNode(&schemas.PlanNode{ID: "a", Type: "input"})
+	graph.AddNode(&schemas.PlanNode{ID: "b", Type: "output"})
+	graph.AddEdge(&schemas.PlanEdge{From: "a", To: "b"})
+
+	dot := ExportDOT(graph)
+	if !strings.Contains(dot, "  \"a\" -> \"b\";\n") {
+		t.Errorf("expected edge without label, got:\n%s", dot)
+	}
+}
@@ -2,23 +2,50 @@ package planner
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/chicogong/media-pipeline/pkg/operators"
 	"github.com/chicogong/media-pipeline/pkg/schemas"
 )
 
+// ErrResourceLimitExceeded is returned when a plan's estimates exceed the
+// job's resource limits
+var ErrResourceLimitExceeded = errors.New("resource limit exceeded")
+
 // ResourceEstimator estimates resource requirements for a processing plan
 type ResourceEstimator struct {
-	registry *operators.Registry
+	registry       *operators.Registry
+	maxConcurrency int
+}
+
+// EstimatorOption is a functional option for ResourceEstimator
+type EstimatorOption func(*ResourceEstimator)
+
+// WithMaxConcurrency models running at most n operations at once (0 =
+// unlimited). A stage with more operations runs in waves of up to n, in
+// stage order: waves add up in duration, and peak memory is that of the
+// largest wave rather than the whole stage.
+func WithMaxConcurrency(n int) EstimatorOption {
+	return func(re *ResourceEstimator) {
+		re.maxConcurrency = n
+	}
 }
 
 // NewResourceEstimator creates a new resource estimator
-func NewResourceEstimator(registry *operators.Registry) *ResourceEstimator {
-	return &ResourceEstimator{
+func NewResourceEstimator(registry *operators.Registry, opts ...EstimatorOption) *ResourceEstimator {
+	re := &ResourceEstimator{
 		registry: registry,
 	}
+
+	for _, opt := range opts {
+		opt(re)
+	}
+
+	return re
 }
 
 // Estimate computes resource estimates for all nodes in the graph
@@ -36,8 +63,7 @@ func (re *ResourceEstimator) Estimate(ctx context.Context, graph *Graph) (*schem
 
 	// Process each stage
 	for _, stage := range stages {
-		var stageMaxDuration time.Duration
-		var stageMemoryMB int64
+		var stageEstimates []*schemas.NodeEstimates
 
 		// Process nodes in the stage
 		for _, nodeID := range stage {
@@ -56,12 +82,6 @@ func (re *ResourceEstimator) Estimate(ctx context.Context, graph *Graph) (*schem
 				return nil, fmt.Errorf("node %s has no metadata (run metadata propagation first)", nodeID)
 			}
 
-			// Get the operator
-			op, err := re.registry.Get(node.Operator)
-			if err != nil {
-				return nil, fmt.Errorf("node %s: operator %s not found: %w", nodeID, node.Operator, err)
-			}
-
 			// Collect input metadata
 			inputMetadata, err := re.collectInputMetadata(graph, node)
 			if err != nil {
@@ -69,26 +89,22 @@ func (re *ResourceEstimator) Estimate(ctx context.Context, graph *Graph) (*schem
 			}
 
 			// Estimate resources for this node
-			estimate, err := op.EstimateResources(node.Params, inputMetadata)
+			estimate, err := re.estimateNode(node, inputMetadata)
 			if err != nil {
-				return nil, fmt.Errorf("node %s: failed to estimate resources: %w", nodeID, err)
+				return nil, fmt.Errorf("node %s: %w", nodeID, err)
 			}
 
 			// Store node estimate
 			nodeEstimates[nodeID] = estimate
-
-			// Update stage estimates (for parallel operations)
-			if estimate.Duration > stageMaxDuration {
-				stageMaxDuration = estimate.Duration
-			}
-			stageMemoryMB += estimate.MemoryMB
+			stageEstimates = append(stageEstimates, estimate)
 
 			// Update total disk usage
 			totalDiskMB += estimate.DiskMB
 		}
 
 		// Add stage duration to total (stages run sequentially)
-		totalDuration += stageMaxDuration
+		stageDuration, stageMemoryMB := re.estimateStage(stageEstimates)
+		totalDuration += stageDuration
 
 		// Update peak memory (max across all stages)
 		if stageMemoryMB > peakMemoryMB {
@@ -104,6 +120,225 @@ func (re *ResourceEstimator) Estimate(ctx context.Context, graph *Graph) (*schem
 	}, nil
 }
 
+// estimateStage returns the duration and peak memory of a stage's
+// operations, run in waves of at most maxConcurrency. Operations in a wave
+// run in parallel: the wave lasts as long as its longest operation and
+// needs the memory of all of them.
+func (re *ResourceEstimator) estimateStage(estimates []*schemas.NodeEstimates) (time.Duration, int64) {
+	waveSize := len(estimates)
+	if re.maxConcurrency > 0 && re.maxConcurrency < waveSize {
+		waveSize = re.maxConcurrency
+	}
+
+	var duration time.Duration
+	var peakMemoryMB int64
+	for start := 0; start < len(estimates); start += waveSize {
+		end := min(start+waveSize, len(estimates))
+
+		var waveDuration time.Duration
+		var waveMemoryMB int64
+		for _, e := range estimates[start:end] {
+			waveDuration = max(waveDuration, e.Duration)
+			waveMemoryMB += e.MemoryMB
+		}
+
+		duration += waveDuration
+		peakMemoryMB = max(peakMemoryMB, waveMemoryMB)
+	}
+	return duration, peakMemoryMB
+}
+
+// WallClockEstimate is how long a graph takes to run on a number of workers
+type WallClockEstimate struct {
+	// WallClock is the time to run every operation, each on one worker
+	WallClock time.Duration
+
+	// CriticalPath is the longest chain of dependent operations: the wall
+	// clock time with unlimited workers
+	CriticalPath time.Duration
+
+	// PeakMemoryMB is the most memory needed by operations running at the
+	// same time
+	PeakMemoryMB int64
+}
+
+// EstimateWallClock estimates the resources of graph and how long it takes
+// to run on workers workers (0 = unlimited). Operations are list scheduled:
+// whenever a worker is free it takes the longest ready operation. Unlike
+// ResourceEstimates.TotalDuration, an operation can start as soon as its
+// own inputs are done rather than waiting for its whole stage.
+func (re *ResourceEstimator) EstimateWallClock(ctx context.Context, graph *Graph, workers int) (*WallClockEstimate, error) {
+	estimates, err := re.Estimate(ctx, graph)
+	if err != nil {
+		return nil, err
+	}
+	return scheduleWallClock(graph, estimates.NodeEstimates, workers)
+}
+
+// EstimateParallel is Estimate for operations run on concurrency workers
+// (0 = unlimited) as soon as their inputs are ready, rather than stage by
+// stage: TotalDuration is the scheduled wall clock time (see
+// EstimateWallClock) and PeakMemoryMB the most memory needed at any one
+// time. TotalDiskMB is unchanged.
+func (re *ResourceEstimator) EstimateParallel(ctx context.Context, graph *Graph, concurrency int) (*schemas.ResourceEstimates, error) {
+	estimates, err := re.Estimate(ctx, graph)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule, err := scheduleWallClock(graph, estimates.NodeEstimates, concurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	estimates.TotalDuration = schedule.WallClock
+	estimates.PeakMemoryMB = schedule.PeakMemoryMB
+	return estimates, nil
+}
+
+// scheduleWallClock simulates running graph on workers workers. Nodes
+// without an estimate (inputs and outputs) take no time and no worker.
+func scheduleWallClock(graph *Graph, estimates map[string]*schemas.NodeEstimates, workers int) (*WallClockEstimate, error) {
+	order, err := graph.TopologicalSort()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get topological order: %w", err)
+	}
+
+	duration := func(nodeID string) time.Duration {
+		if e := estimates[nodeID]; e != nil {
+			return e.Duration
+		}
+		return 0
+	}
+
+	// Critical path: the latest finish of each node with no waiting
+	finish := make(map[string]time.Duration, len(order))
+	var criticalPath time.Duration
+	for _, nodeID := range order {
+		var start time.Duration
+		for _, edge := range graph.GetIncomingEdges(nodeID) {
+			start = max(start, finish[edge.From])
+		}
+		finish[nodeID] = start + duration(nodeID)
+		criticalPath = max(criticalPath, finish[nodeID])
+	}
+
+	if workers <= 0 {
+		workers = len(order)
+	}
+
+	type running struct {
+		nodeID string
+		finish time.Duration
+	}
+
+	remaining := make(map[string]int, len(order))
+	var ready []string
+	for _, nodeID := range order {
+		remaining[nodeID] = len(graph.GetIncomingEdges(nodeID))
+		if remaining[nodeID] == 0 {
+			ready = append(ready, nodeID)
+		}
+	}
+
+	memory := func(nodeID string) int64 {
+		if e := estimates[nodeID]; e != nil {
+			return e.MemoryMB
+		}
+		return 0
+	}
+
+	var now time.Duration
+	var peakMemoryMB int64
+	var active []running
+	for len(ready) > 0 || len(active) > 0 {
+		// Start the longest ready operations on the free workers; nodes
+		// that take no time finish at once
+		sort.SliceStable(ready, func(i, j int) bool { return duration(ready[i]) > duration(ready[j]) })
+		var waiting, done []string
+		for _, nodeID := range ready {
+			switch {
+			case duration(nodeID) == 0:
+				done = append(done, nodeID)
+			case len(active) < workers:
+				active = append(active, running{nodeID: nodeID, finish: now + duration(nodeID)})
+			default:
+				waiting = append(waiting, nodeID)
+			}
+		}
+		ready = waiting
+
+		var memoryMB int64
+		for _, r := range active {
+			memoryMB += memory(r.nodeID)
+		}
+		peakMemoryMB = max(peakMemoryMB, memoryMB)
+
+		// Otherwise advance to the next operations to finish
+		if len(done) == 0 {
+			if len(active) == 0 {
+				break
+			}
+			now = active[0].finish
+			for _, r := range active[1:] {
+				now = min(now, r.finish)
+			}
+
+			var still []running
+			for _, r := range active {
+				if r.finish == now {
+					done = append(done, r.nodeID)
+				} else {
+					still = append(still, r)
+				}
+			}
+			active = still
+		}
+
+		for _, nodeID := range done {
+			for _, edge := range graph.GetOutgoingEdges(nodeID) {
+				remaining[edge.To]--
+				if remaining[edge.To] == 0 {
+					ready = append(ready, edge.To)
+				}
+			}
+		}
+	}
+
+	return &WallClockEstimate{WallClock: now, CriticalPath: criticalPath, PeakMemoryMB: peakMemoryMB}, nil
+}
+
+// estimateNode estimates the resources of an operation node. The operations
+// of a fused node are estimated in turn and combined.
+func (re *ResourceEstimator) estimateNode(node *schemas.PlanNode, inputMetadata []*schemas.MediaInfo) (*schemas.NodeEstimates, error) {
+	steps := node.Operations()
+	estimates := make([]*schemas.NodeEstimates, len(steps))
+	for i, step := range steps {
+		op, err := re.registry.Get(step.Operator)
+		if err != nil {
+			return nil, fmt.Errorf("operator %s not found: %w", step.Operator, err)
+		}
+
+		estimates[i], err = op.EstimateResources(step.Params, inputMetadata)
+		if err != nil {
+			return nil, fmt.Errorf("failed to estimate resources: %w", err)
+		}
+
+		if i < len(steps)-1 {
+			output, err := op.ComputeOutputMetadata(step.Params, inputMetadata)
+			if err != nil {
+				return nil, fmt.Errorf("failed to compute output metadata: %w", err)
+			}
+			inputMetadata = []*schemas.MediaInfo{output}
+		}
+	}
+
+	if len(estimates) == 1 {
+		return estimates[0], nil
+	}
+	return combineEstimates(estimates), nil
+}
+
 // collectInputMetadata collects metadata from all predecessor nodes
 func (re *ResourceEstimator) collectInputMetadata(graph *Graph, node *schemas.PlanNode) ([]*schemas.MediaInfo, error) {
 	predecessors := graph.GetPredecessors(node.ID)
@@ -121,3 +356,45 @@ func (re *ResourceEstimator) collectInputMetadata(graph *Graph, node *schemas.Pl
 
 	return inputs, nil
 }
+
+// CheckResourceLimits rejects a plan whose estimates exceed limits: peak
+// memory against MaxMemory, disk usage against MaxOutputSize and the total
+// duration of the outputs against MaxDuration. Every exceeded limit is
+// listed in the returned error, which wraps ErrResourceLimitExceeded.
+// Output durations are only known once metadata has been propagated.
+func CheckResourceLimits(graph *Graph, estimates *schemas.ResourceEstimates, limits *schemas.ResourceLimits) error {
+	if estimates == nil || limits == nil {
+		return nil
+	}
+
+	const mb = 1024 * 1024
+	var problems []string
+
+	if limits.MaxMemory > 0 && estimates.PeakMemoryMB*mb > limits.MaxMemory {
+		problems = append(problems, fmt.Sprintf("estimated peak memory %d MB exceeds max_memory of %d MB",
+			estimates.PeakMemoryMB, limits.MaxMemory/mb))
+	}
+
+	if limits.MaxOutputSize > 0 && estimates.TotalDiskMB*mb > limits.MaxOutputSize {
+		problems = append(problems, fmt.Sprintf("estimated disk usage %d MB exceeds max_output_size of %d MB",
+			estimates.TotalDiskMB, limits.MaxOutputSize/mb))
+	}
+
+	if limits.MaxDuration != nil && limits.MaxDuration.Duration > 0 {
+		var total time.Duration
+		for _, node := range graph.GetOutputNodes() {
+			if node.Metadata != nil {
+				total += node.Metadata.Format.Duration
+			}
+		}
+		if total > limits.MaxDuration.Duration {
+			problems = append(problems, fmt.Sprintf("total output duration %v exceeds max_duration of %v",
+				total, limits.MaxDuration.Duration))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrResourceLimitExceeded, strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -2,6 +2,9 @@ package planner
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -297,3 +300,256 @@ func TestResourceEstimator_InvalidOperator(t *testing.T) {
 		t.Error("expected error for nonexistent operator, got nil")
 	}
 }
+
+func TestCheckResourceLimits(t *testing.T) {
+	registry := operators.NewRegistry()
+	registry.Register(&builtin.TrimOperator{})
+	registry.Register(&builtin.ScaleOperator{})
+
+	ctx := context.Background()
+	graph := fusionGraph(t)
+	if err := NewMetadataPropagator(registry).Propagate(ctx, graph); err != nil {
+		t.Fatalf("Propagate failed: %v", err)
+	}
+	estimates, err := NewResourceEstimator(registry).Estimate(ctx, graph)
+	if err != nil {
+		t.Fatalf("Estimate failed: %v", err)
+	}
+
+	// A tight memory limit rejects the plan
+	err = CheckResourceLimits(graph, estimates, &schemas.ResourceLimits{MaxMemory: 1024 * 1024})
+	if !errors.Is(err, ErrResourceLimitExceeded) {
+		t.Fatalf("expected ErrResourceLimitExceeded, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "peak memory") {
+		t.Errorf("expected the error to name peak memory, got %v", err)
+	}
+
+	// The trimmed output is 30s long
+	err = CheckResourceLimits(graph, estimates, &schemas.ResourceLimits{
+		MaxDuration: &schemas.Duration{Duration: 10 * time.Second},
+	})
+	if !errors.Is(err, ErrResourceLimitExceeded) || !strings.Contains(err.Error(), "output duration") {
+		t.Errorf("expected the output duration to exceed the limit, got %v", err)
+	}
+
+	// Generous limits pass
+	err = CheckResourceLimits(graph, estimates, &schemas.ResourceLimits{
+		MaxMemory:     1 << 40,
+		MaxOutputSize: 1 << 40,
+		MaxDuration:   &schemas.Duration{Duration: time.Hour},
+	})
+	if err != nil {
+		t.Errorf("expected plan within limits, got %v", err)
+	}
+}
+
+func TestResourceEstimator_MaxConcurrency(t *testing.T) {
+	registry := operators.NewRegistry()
+	registry.Register(&builtin.TrimOperator{})
+
+	// Three independent trims make up a single stage
+	spec := &schemas.JobSpec{}
+	for i := 1; i <= 3; i++ {
+		id := fmt.Sprintf("video%d", i)
+		spec.Inputs = append(spec.Inputs, schemas.Input{ID: id, Source: "s3://bucket/" + id + ".mp4"})
+		spec.Operations = append(spec.Operations, schemas.Operation{
+			Op: "trim", Input: id, Output: "trimmed" + id,
+			Params: map[string]interface{}{"start": "00:00:00", "duration": "00:00:30"},
+		})
+		spec.Outputs = append(spec.Outputs, schemas.Output{ID: "trimmed" + id, Destination: "s3://bucket/out" + id + ".mp4"})
+	}
+
+	ctx := context.Background()
+	graph, err := NewBuilderWithRegistry(registry).BuildDAG(ctx, spec)
+	if err != nil {
+		t.Fatalf("BuildDAG failed: %v", err)
+	}
+	for _, node := range graph.GetInputNodes() {
+		node.Metadata = &schemas.MediaInfo{
+			Format:       schemas.FormatInfo{Duration: 60 * time.Second, Size: 50 * 1024 * 1024},
+			VideoStreams: []schemas.VideoStream{{Width: 1920, Height: 1080, FrameRate: 30}},
+		}
+	}
+	if err := NewMetadataPropagator(registry).Propagate(ctx, graph); err != nil {
+		t.Fatalf("Propagate failed: %v", err)
+	}
+
+	unlimited, err := NewResourceEstimator(registry).Estimate(ctx, graph)
+	if err != nil {
+		t.Fatalf("Estimate failed: %v", err)
+	}
+	serial, err := NewResourceEstimator(registry, WithMaxConcurrency(1)).Estimate(ctx, graph)
+	if err != nil {
+		t.Fatalf("Estimate failed: %v", err)
+	}
+
+	node := unlimited.NodeEstimates["op_0_trim"]
+	if node == nil {
+		t.Fatal("missing estimate for op_0_trim")
+	}
+
+	// Unlimited: all three at once
+	if unlimited.TotalDuration != node.Duration || unlimited.PeakMemoryMB != 3*node.MemoryMB {
+		t.Errorf("unlimited: expected %v and %d MB, got %v and %d MB",
+			node.Duration, 3*node.MemoryMB, unlimited.TotalDuration, unlimited.PeakMemoryMB)
+	}
+
+	// One at a time: three times as long, a third of the memory
+	if serial.TotalDuration != 3*node.Duration || serial.PeakMemoryMB != node.MemoryMB {
+		t.Errorf("concurrency 1: expected %v and %d MB, got %v and %d MB",
+			3*node.Duration, node.MemoryMB, serial.TotalDuration, serial.PeakMemoryMB)
+	}
+	if serial.TotalDiskMB != unlimited.TotalDiskMB {
+		t.Errorf("expected disk usage not to depend on concurrency, got %d and %d", serial.TotalDiskMB, unlimited.TotalDiskMB)
+	}
+}
+
+// parallelTrims returns a graph of n independent trims, with metadata
+// propagated
+func parallelTrims(tb testing.TB, registry *operators.Registry, n int) *Graph {
+	tb.Helper()
+
+	spec := &schemas.JobSpec{}
+	for i := 1; i <= n; i++ {
+		id := fmt.Sprintf("video%d", i)
+		spec.Inputs = append(spec.Inputs, schemas.Input{ID: id, Source: "s3://bucket/" + id + ".mp4"})
+		spec.Operations = append(spec.Operations, schemas.Operation{
+			Op: "trim", Input: id, Output: "trimmed" + id,
+			Params: map[string]interface{}{"start": "00:00:00", "duration": "00:00:30"},
+		})
+		spec.Outputs = append(spec.Outputs, schemas.Output{ID: "trimmed" + id, Destination: "s3://bucket/out" + id + ".mp4"})
+	}
+
+	ctx := context.Background()
+	graph, err := NewBuilderWithRegistry(registry).BuildDAG(ctx, spec)
+	if err != nil {
+		tb.Fatalf("BuildDAG failed: %v", err)
+	}
+	for _, node := range graph.GetInputNodes() {
+		node.Metadata = &schemas.MediaInfo{
+			Format:       schemas.FormatInfo{Duration: 60 * time.Second, Size: 50 * 1024 * 1024},
+			VideoStreams: []schemas.VideoStream{{Width: 1920, Height: 1080, FrameRate: 30}},
+		}
+	}
+	if err := NewMetadataPropagator(registry).Propagate(ctx, graph); err != nil {
+		tb.Fatalf("Propagate failed: %v", err)
+	}
+	return graph
+}
+
+func TestResourceEstimator_EstimateWallClock(t *testing.T) {
+	registry := operators.NewRegistry()
+	registry.Register(&builtin.TrimOperator{})
+
+	ctx := context.Background()
+	graph := parallelTrims(t, registry, 4)
+
+	estimator := NewResourceEstimator(registry)
+	one, err := estimator.EstimateWallClock(ctx, graph, 1)
+	if err != nil {
+		t.Fatalf("EstimateWallClock failed: %v", err)
+	}
+	four, err := estimator.EstimateWallClock(ctx, graph, 4)
+	if err != nil {
+		t.Fatalf("EstimateWallClock failed: %v", err)
+	}
+
+	trim := 3 * time.Second // 10% of the 30s trimmed
+	if one.WallClock != 4*trim {
+		t.Errorf("1 worker: expected %v, got %v", 4*trim, one.WallClock)
+	}
+	if four.WallClock != trim {
+		t.Errorf("4 workers: expected %v, got %v", trim, four.WallClock)
+	}
+	if one.CriticalPath != trim || four.CriticalPath != trim {
+		t.Errorf("expected a critical path of %v, got %v and %v", trim, one.CriticalPath, four.CriticalPath)
+	}
+}
+
+func TestScheduleWallClock_Dependencies(t *testing.T) {
+	// a -> c, with b and d independent
+	graph := NewGraph()
+	for _, id := range []string{"a", "b", "c", "d"} {
+		graph.AddNode(&schemas.PlanNode{ID: id, Type: "operation"})
+	}
+	graph.AddEdge(&schemas.PlanEdge{From: "a", To: "c"})
+
+	estimates := map[string]*schemas.NodeEstimates{
+		"a": {Duration: 3 * time.Second},
+		"b": {Duration: 2 * time.Second},
+		"c": {Duration: 1 * time.Second},
+		"d": {Duration: 2 * time.Second},
+	}
+
+	tests := []struct {
+		workers int
+		want    time.Duration
+	}{
+		{workers: 1, want: 8 * time.Second},
+		{workers: 2, want: 4 * time.Second}, // a and b, then d, then c after a
+		{workers: 0, want: 4 * time.Second},
+	}
+	for _, tt := range tests {
+		got, err := scheduleWallClock(graph, estimates, tt.workers)
+		if err != nil {
+			t.Fatalf("scheduleWallClock failed: %v", err)
+		}
+		if got.WallClock != tt.want || got.CriticalPath != 4*time.Second {
+			t.Errorf("%d workers: expected %v (critical path 4s), got %+v", tt.workers, tt.want, got)
+		}
+	}
+}
+
+func TestResourceEstimator_EstimateParallel(t *testing.T) {
+	registry := operators.NewRegistry()
+	registry.Register(&builtin.TrimOperator{})
+
+	ctx := context.Background()
+	graph := parallelTrims(t, registry, 8)
+	estimator := NewResourceEstimator(registry)
+
+	sequential, err := estimator.Estimate(ctx, graph)
+	if err != nil {
+		t.Fatalf("Estimate failed: %v", err)
+	}
+	parallel, err := estimator.EstimateParallel(ctx, graph, 3)
+	if err != nil {
+		t.Fatalf("EstimateParallel failed: %v", err)
+	}
+
+	// Eight trims on three workers take three rounds, holding the memory
+	// of three at a time
+	node := parallel.NodeEstimates["op_0_trim"]
+	if parallel.TotalDuration != 3*node.Duration {
+		t.Errorf("expected %v, got %v", 3*node.Duration, parallel.TotalDuration)
+	}
+	if parallel.PeakMemoryMB != 3*node.MemoryMB {
+		t.Errorf("expected peak memory %d MB, got %d MB", 3*node.MemoryMB, parallel.PeakMemoryMB)
+	}
+	if parallel.TotalDiskMB != sequential.TotalDiskMB {
+		t.Errorf("expected disk usage %d MB, got %d MB", sequential.TotalDiskMB, parallel.TotalDiskMB)
+	}
+}
+
+func BenchmarkResourceEstimator_EstimateParallel(b *testing.B) {
+	registry := operators.NewRegistry()
+	registry.Register(&builtin.TrimOperator{})
+
+	ctx := context.Background()
+	graph := parallelTrims(b, registry, 8)
+	estimator := NewResourceEstimator(registry)
+
+	for _, concurrency := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("concurrency=%d", concurrency), func(b *testing.B) {
+			var estimates *schemas.ResourceEstimates
+			for i := 0; i < b.N; i++ {
+				var err error
+				if estimates, err = estimator.EstimateParallel(ctx, graph, concurrency); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(estimates.TotalDuration.Seconds(), "est-s")
+		})
+	}
+}
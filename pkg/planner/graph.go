@@ -28,6 +28,19 @@ func NewGraph() *Graph {
 	}
 }
 
+// NewGraphFromPlan creates a graph of the plan's nodes and edges. The nodes
+// and edges are shared with the plan, not copied.
+func NewGraphFromPlan(plan *schemas.ProcessingPlan) *Graph {
+	g := NewGraph()
+	for _, node := range plan.Nodes {
+		g.AddNode(node)
+	}
+	for _, edge := range plan.Edges {
+		g.AddEdge(edge)
+	}
+	return g
+}
+
 // AddNode adds a node to the graph
 func (g *Graph) AddNode(node *schemas.PlanNode) {
 	g.Nodes = append(g.Nodes, node)
@@ -149,3 +162,145 @@ func (g *Graph) GetOutputNodes() []*schemas.PlanNode {
 	}
 	return outputs
 }
+
+// Merge returns a new graph containing the nodes and edges of g and other.
+// Nodes from other whose IDs are already used in g are renamed with a
+// "merged_" prefix. Neither g nor other is modified.
+func (g *Graph) Merge(other *Graph) (*Graph, error) {
+	merged := NewGraph()
+
+	for _, node := range g.Nodes {
+		merged.AddNode(cloneNode(node))
+	}
+	for _, edge := range g.Edges {
+		copied := *edge
+		merged.AddEdge(&copied)
+	}
+
+	// Rename conflicting node IDs from other
+	renamed := make(map[string]string, len(other.Nodes))
+	for _, node := range other.Nodes {
+		id := node.ID
+		for prefix := 1; merged.GetNode(id) != nil; prefix++ {
+			if prefix == 1 {
+				id = "merged_" + node.ID
+			} else {
+				id = fmt.Sprintf("merged%d_%s", prefix, node.ID)
+			}
+		}
+		renamed[node.ID] = id
+
+		copied := cloneNode(node)
+		copied.ID = id
+		merged.AddNode(copied)
+	}
+	for _, edge := range other.Edges {
+		copied := *edge
+		if id, ok := renamed[edge.From]; ok {
+			copied.From = id
+		}
+		if id, ok := renamed[edge.To]; ok {
+			copied.To = id
+		}
+		merged.AddEdge(&copied)
+	}
+
+	if err := merged.DetectCycles(); err != nil {
+		return nil, fmt.Errorf("merged graph is not acyclic: %w", err)
+	}
+
+	return merged, nil
+}
+
+// DetectSharedInputs returns the source URIs read by input nodes in both
+// g1 and g2, in the order they appear in g1
+func DetectSharedInputs(g1, g2 *Graph) []string {
+	sources := make(map[string]bool)
+	for _, node := range g2.GetInputNodes() {
+		if node.SourceURI != "" {
+			sources[node.SourceURI] = true
+		}
+	}
+
+	shared := []string{}
+	for _, node := range g1.GetInputNodes() {
+		if sources[node.SourceURI] {
+			shared = append(shared, node.SourceURI)
+			delete(sources, node.SourceURI) // Report each URI once
+		}
+	}
+	return shared
+}
+
+// Clone returns a deep copy of the graph. Nodes, edges, parameters and
+// computed metadata are copied, so the clone can be modified without
+// affecting g.
+func (g *Graph) Clone() *Graph {
+	clone := NewGraph()
+	for _, node := range g.Nodes {
+		clone.AddNode(cloneNode(node))
+	}
+	for _, edge := range g.Edges {
+		copied := *edge
+		clone.AddEdge(&copied)
+	}
+	return clone
+}
+
+// cloneNode returns a deep copy of node
+func cloneNode(node *schemas.PlanNode) *schemas.PlanNode {
+	copied := *node
+	if node.Params != nil {
+		copied.Params = cloneValue(node.Params).(map[string]interface{})
+	}
+	if node.Fused != nil {
+		copied.Fused = make([]schemas.FusedOperation, len(node.Fused))
+		for i, op := range node.Fused {
+			copied.Fused[i] = op
+			if op.Params != nil {
+				copied.Fused[i].Params = cloneValue(op.Params).(map[string]interface{})
+			}
+		}
+	}
+	copied.OutputMetadata = cloneTags(node.OutputMetadata)
+	copied.Metadata = cloneMediaInfo(node.Metadata)
+	if node.Estimates != nil {
+		estimates := *node.Estimates
+		copied.Estimates = &estimates
+	}
+	return &copied
+}
+
+// cloneValue deep-copies the maps and slices of a decoded parameter value
+func cloneValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			copied[key] = cloneValue(value)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, value := range v {
+			copied[i] = cloneValue(value)
+		}
+		return copied
+	case []string:
+		return append([]string(nil), v...)
+	default:
+		return v
+	}
+}
+
+// cloneTags returns a copy of tags, or nil if tags is nil
+func cloneTags(tags map[string]string) map[string]string {
+	if tags == nil {
+		return nil
+	}
+	copied := make(map[string]string, len(tags))
+	for key, value := range tags {
+		copied[key] = value
+	}
+	return copied
+}
@@ -1,7 +1,13 @@
 package planner
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"reflect"
 	"testing"
+	"testing/quick"
 
 	"github.com/chicogong/media-pipeline/pkg/schemas"
 )
@@ -175,3 +181,199 @@ func TestGraph_GetSuccessors(t *testing.T) {
 		t.Errorf("expected 2 successors, got %d", len(successors))
 	}
 }
+
+// trimGraph builds the plan for a single trim of source into dest
+func trimGraph(t *testing.T, source, dest string) *Graph {
+	t.Helper()
+
+	spec := &schemas.JobSpec{
+		Inputs: []schemas.Input{
+			{ID: "video", Source: source},
+		},
+		Operations: []schemas.Operation{
+			{Op: "trim", Input: "video", Output: "trimmed",
+				Params: map[string]interface{}{"start": "00:00:10", "duration": "5s"}},
+		},
+		Outputs: []schemas.Output{
+			{ID: "trimmed", Destination: dest},
+		},
+	}
+
+	graph, err := NewBuilder().BuildDAG(context.Background(), spec)
+	if err != nil {
+		t.Fatalf("failed to build graph: %v", err)
+	}
+	return graph
+}
+
+func TestGraph_Merge(t *testing.T) {
+	g1 := trimGraph(t, "s3://bucket/input.mp4", "s3://bucket/clip1.mp4")
+	g2 := trimGraph(t, "s3://bucket/input.mp4", "s3://bucket/clip2.mp4")
+
+	merged, err := g1.Merge(g2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(merged.Nodes) != 6 {
+		t.Errorf("expected 6 nodes, got %d", len(merged.Nodes))
+	}
+	if len(merged.Edges) != 4 {
+		t.Errorf("expected 4 edges, got %d", len(merged.Edges))
+	}
+
+	// Nodes from the first graph keep their IDs
+	if node := merged.GetNode("output_trimmed"); node == nil || node.DestURI != "s3://bucket/clip1.mp4" {
+		t.Errorf("expected output_trimmed to write clip1, got %+v", node)
+	}
+
+	// Conflicting nodes from the second graph are prefixed and stay connected
+	node := merged.GetNode("merged_output_trimmed")
+	if node == nil || node.DestURI != "s3://bucket/clip2.mp4" {
+		t.Fatalf("expected merged_output_trimmed to write clip2, got %+v", node)
+	}
+	preds := merged.GetPredecessors("merged_output_trimmed")
+	if len(preds) != 1 || preds[0].ID != "merged_op_0_trim" {
+		t.Errorf("expected merged_op_0_trim as predecessor, got %v", preds)
+	}
+	preds = merged.GetPredecessors("merged_op_0_trim")
+	if len(preds) != 1 || preds[0].ID != "merged_input_video" {
+		t.Errorf("expected merged_input_video as predecessor, got %v", preds)
+	}
+
+	// The source graphs are untouched
+	if g2.GetNode("output_trimmed") == nil || len(g2.Nodes) != 3 {
+		t.Error("expected second graph to be unchanged")
+	}
+
+	stages, err := merged.ComputeExecutionStages()
+	if err != nil {
+		t.Fatalf("failed to compute stages: %v", err)
+	}
+	if len(stages) != 3 {
+		t.Errorf("expected 3 stages, got %d", len(stages))
+	}
+}
+
+func TestGraph_MergeCycle(t *testing.T) {
+	g1 := NewGraph()
+	g1.AddNode(&schemas.PlanNode{ID: "a", Type: "operation"})
+	g1.AddEdge(&schemas.PlanEdge{From: "a", To: "b"})
+
+	g2 := NewGraph()
+	g2.AddNode(&schemas.PlanNode{ID: "b", Type: "operation"})
+	g2.AddEdge(&schemas.PlanEdge{From: "b", To: "a"})
+
+	if _, err := g1.Merge(g2); err == nil {
+		t.Error("expected cycle error")
+	}
+}
+
+func TestDetectSharedInputs(t *testing.T) {
+	g1 := trimGraph(t, "s3://bucket/input.mp4", "s3://bucket/clip1.mp4")
+	g2 := trimGraph(t, "s3://bucket/input.mp4", "s3://bucket/clip2.mp4")
+	g3 := trimGraph(t, "s3://bucket/other.mp4", "s3://bucket/clip3.mp4")
+
+	shared := DetectSharedInputs(g1, g2)
+	if len(shared) != 1 || shared[0] != "s3://bucket/input.mp4" {
+		t.Errorf("expected [s3://bucket/input.mp4], got %v", shared)
+	}
+
+	if shared := DetectSharedInputs(g1, g3); len(shared) != 0 {
+		t.Errorf("expected no shared inputs, got %v", shared)
+	}
+}
+
+// randomGraph is an acyclic graph generated by testing/quick
+type randomGraph struct {
+	*Graph
+}
+
+// Generate implements quick.Generator. Edges only point from earlier to
+// later nodes so the graph stays acyclic.
+func (randomGraph) Generate(r *rand.Rand, size int) reflect.Value {
+	g := NewGraph()
+	n := r.Intn(size + 1)
+	for i := 0; i < n; i++ {
+		node := &schemas.PlanNode{
+			ID:       fmt.Sprintf("node_%d", i),
+			Type:     "operation",
+			Operator: "scale",
+			Params: map[string]interface{}{
+				"width":  float64(r.Intn(4096)),
+				"filter": fmt.Sprintf("f%d", r.Intn(10)),
+				"nested": map[string]interface{}{"values": []interface{}{float64(r.Intn(100)), "x"}},
+			},
+			OutputMetadata: map[string]string{"title": fmt.Sprintf("t%d", i)},
+		}
+		if r.Intn(2) == 0 {
+			node.Metadata = &schemas.MediaInfo{
+				Format:       schemas.FormatInfo{Format: "mp4", Tags: map[string]string{"encoder": "x"}},
+				VideoStreams: []schemas.VideoStream{{Width: r.Intn(4096), Tags: map[string]string{"lang": "en"}}},
+			}
+			node.Estimates = &schemas.NodeEstimates{MemoryMB: int64(r.Intn(1024))}
+		}
+		g.AddNode(node)
+
+		if i > 0 {
+			g.AddEdge(&schemas.PlanEdge{From: fmt.Sprintf("node_%d", r.Intn(i)), To: node.ID, StreamType: "both"})
+		}
+	}
+	return reflect.ValueOf(randomGraph{g})
+}
+
+func TestGraph_Clone(t *testing.T) {
+	property := func(rg randomGraph) bool {
+		original := rg.Graph
+		before, err := json.Marshal(original)
+		if err != nil {
+			t.Fatalf("failed to marshal graph: %v", err)
+		}
+
+		clone := original.Clone()
+
+		// Equal but distinct
+		if !reflect.DeepEqual(original.Nodes, clone.Nodes) || !reflect.DeepEqual(original.Edges, clone.Edges) {
+			return false
+		}
+		for i, node := range clone.Nodes {
+			if node == original.Nodes[i] || clone.GetNode(node.ID) != node {
+				return false
+			}
+			if len(clone.GetIncomingEdges(node.ID)) != len(original.GetIncomingEdges(node.ID)) ||
+				len(clone.GetOutgoingEdges(node.ID)) != len(original.GetOutgoingEdges(node.ID)) {
+				return false
+			}
+		}
+
+		// Mutating the clone leaves the original untouched
+		for _, node := range clone.Nodes {
+			node.Params["width"] = float64(-1)
+			node.Params["nested"].(map[string]interface{})["values"].([]interface{})[0] = "changed"
+			node.OutputMetadata["title"] = "changed"
+			if node.Metadata != nil {
+				node.Metadata.Format.Tags["encoder"] = "changed"
+				node.Metadata.VideoStreams[0].Tags["lang"] = "changed"
+				node.Metadata.VideoStreams[0].Width = -1
+				node.Estimates.MemoryMB = -1
+			}
+		}
+		for _, edge := range clone.Edges {
+			edge.StreamType = "changed"
+		}
+		clone.AddNode(&schemas.PlanNode{ID: "extra", Type: "output"})
+		if len(clone.Nodes) > 1 {
+			clone.AddEdge(&schemas.PlanEdge{From: clone.Nodes[0].ID, To: "extra"})
+		}
+
+		after, err := json.Marshal(original)
+		if err != nil {
+			t.Fatalf("failed to marshal graph: %v", err)
+		}
+		return string(before) == string(after) && original.GetNode("extra") == nil
+	}
+
+	if err := quick.Check(property, nil); err != nil {
+		t.Error(err)
+	}
+}
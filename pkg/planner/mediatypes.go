@@ -0,0 +1,103 @@
+package planner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chicogong/media-pipeline/pkg/operators"
+	"github.com/chicogong/media-pipeline/pkg/schemas"
+)
+
+// MediaTypeError is returned when an operation is fed a stream type it does
+// not accept
+type MediaTypeError struct {
+	From     string
+	To       string
+	Produced []operators.MediaType
+	Accepted []operators.MediaType
+}
+
+// Error implements the error interface
+func (e *MediaTypeError) Error() string {
+	return fmt.Sprintf("media type mismatch on edge %s -> %s: produces %s, accepts %s",
+		e.From, e.To, joinMediaTypes(e.Produced), joinMediaTypes(e.Accepted))
+}
+
+// ValidateMediaTypes checks that every edge between two operations connects
+// compatible streams: some type in the producer's OutputTypes must match one
+// of the consumer's InputTypes ("any" matches everything). Edges from input
+// nodes and into output nodes, and operators that declare no types, are not
+// checked.
+func ValidateMediaTypes(graph *Graph, registry *operators.Registry) error {
+	for _, edge := range graph.Edges {
+		from := graph.GetNode(edge.From)
+		to := graph.GetNode(edge.To)
+		if from == nil || to == nil {
+			return fmt.Errorf("edge %s -> %s references a missing node", edge.From, edge.To)
+		}
+		if from.Type != "operation" || to.Type != "operation" {
+			continue
+		}
+
+		produced, err := nodeMediaTypes(registry, from, false)
+		if err != nil {
+			return err
+		}
+		accepted, err := nodeMediaTypes(registry, to, true)
+		if err != nil {
+			return err
+		}
+		if len(produced) == 0 || len(accepted) == 0 {
+			continue
+		}
+
+		if !mediaTypesOverlap(produced, accepted) {
+			return &MediaTypeError{From: from.ID, To: to.ID, Produced: produced, Accepted: accepted}
+		}
+	}
+	return nil
+}
+
+// nodeMediaTypes returns the types an operation node accepts, from its first
+// operator, or produces, from its last
+func nodeMediaTypes(registry *operators.Registry, node *schemas.PlanNode, input bool) ([]operators.MediaType, error) {
+	steps := node.Operations()
+	step := steps[len(steps)-1]
+	if input {
+		step = steps[0]
+	}
+
+	op, err := registry.Get(step.Operator)
+	if err != nil {
+		return nil, fmt.Errorf("node %s: %w", node.ID, err)
+	}
+	desc := op.Describe()
+	if desc == nil {
+		return nil, nil
+	}
+	if input {
+		return desc.InputTypes, nil
+	}
+	return desc.OutputTypes, nil
+}
+
+// mediaTypesOverlap reports whether any produced type is accepted
+func mediaTypesOverlap(produced, accepted []operators.MediaType) bool {
+	for _, p := range produced {
+		for _, a := range accepted {
+			if p == a || p == operators.MediaTypeAny || a == operators.MediaTypeAny {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// joinMediaTypes formats media types as a bracketed list
+func joinMediaTypes(types []operators.MediaType) string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = string(t)
+	}
+	return "[" + strings.Join(names, ", ") + "]"
+}
@@ -0,0 +1,88 @@
+package planner
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/chicogong/media-pipeline/pkg/operators"
+	"github.com/chicogong/media-pipeline/pkg/operators/builtin"
+	"github.com/chicogong/media-pipeline/pkg/schemas"
+)
+
+// extractAudio is an audio-only operator for type checks
+type extractAudio struct {
+	builtin.TrimOperator
+}
+
+func (o *extractAudio) Name() string { return "extract_audio" }
+
+func (o *extractAudio) Category() operators.Category { return operators.CategoryAudio }
+
+func (o *extractAudio) Describe() *operators.OperatorDescriptor {
+	return &operators.OperatorDescriptor{
+		Name:        "extract_audio",
+		Category:    operators.CategoryAudio,
+		MinInputs:   1,
+		MaxInputs:   1,
+		InputTypes:  []operators.MediaType{operators.MediaTypeVideoAudio, operators.MediaTypeAudio},
+		OutputTypes: []operators.MediaType{operators.MediaTypeAudio},
+	}
+}
+
+func (o *extractAudio) ValidateParams(params map[string]interface{}) error { return nil }
+
+func mediaTypeSpec(first, second string) *schemas.JobSpec {
+	return &schemas.JobSpec{
+		Inputs: []schemas.Input{
+			{ID: "video", Source: "s3://bucket/input.mp4"},
+		},
+		Operations: []schemas.Operation{
+			{Op: first, Input: "video", Output: "first"},
+			{Op: second, Input: "first", Output: "second",
+				Params: map[string]interface{}{"width": 1280, "height": 720}},
+		},
+		Outputs: []schemas.Output{
+			{ID: "second", Destination: "s3://bucket/output.mp4"},
+		},
+	}
+}
+
+func TestValidateMediaTypes_Mismatch(t *testing.T) {
+	registry := operators.NewRegistry()
+	registry.Register(&extractAudio{})
+	registry.Register(&builtin.ScaleOperator{})
+
+	graph, err := NewBuilderWithRegistry(registry).BuildDAG(context.Background(), mediaTypeSpec("extract_audio", "scale"))
+	if err != nil {
+		t.Fatalf("BuildDAG failed: %v", err)
+	}
+
+	err = ValidateMediaTypes(graph, registry)
+	var typeErr *MediaTypeError
+	if !errors.As(err, &typeErr) {
+		t.Fatalf("expected a MediaTypeError, got %v", err)
+	}
+	if typeErr.From != "op_0_extract_audio" || typeErr.To != "op_1_scale" {
+		t.Errorf("unexpected edge %s -> %s", typeErr.From, typeErr.To)
+	}
+
+	// The planner rejects the spec too
+	if _, err := NewPlannerWithRegistry(registry).Plan(context.Background(), mediaTypeSpec("extract_audio", "scale"), nil); !errors.As(err, &typeErr) {
+		t.Errorf("expected Plan to fail with a MediaTypeError, got %v", err)
+	}
+}
+
+func TestValidateMediaTypes_Compatible(t *testing.T) {
+	registry := operators.NewRegistry()
+	registry.Register(&builtin.TrimOperator{})
+	registry.Register(&builtin.ScaleOperator{})
+
+	graph, err := NewBuilderWithRegistry(registry).BuildDAG(context.Background(), mediaTypeSpec("trim", "scale"))
+	if err != nil {
+		t.Fatalf("BuildDAG failed: %v", err)
+	}
+	if err := ValidateMediaTypes(graph, registry); err != nil {
+		t.Errorf("expected trim -> scale to be valid, got %v", err)
+	}
+}
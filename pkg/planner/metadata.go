@@ -3,6 +3,7 @@ package planner
 import (
 	"context"
 	"fmt"
+	"sort"
 
 	"github.com/chicogong/media-pipeline/pkg/operators"
 	"github.com/chicogong/media-pipeline/pkg/schemas"
@@ -19,8 +20,17 @@ func cloneMediaInfo(mi *schemas.MediaInfo) *schemas.MediaInfo {
 	}
 
 	clone := *mi
+	clone.Format.Tags = cloneTags(mi.Format.Tags)
 	clone.VideoStreams = append([]schemas.VideoStream(nil), mi.VideoStreams...)
+	for i := range clone.VideoStreams {
+		clone.VideoStreams[i].Tags = cloneTags(clone.VideoStreams[i].Tags)
+	}
 	clone.AudioStreams = append([]schemas.AudioStream(nil), mi.AudioStreams...)
+	for i := range clone.AudioStreams {
+		clone.AudioStreams[i].Tags = cloneTags(clone.AudioStreams[i].Tags)
+	}
+	clone.SubtitleStreams = append([]schemas.SubtitleStream(nil), mi.SubtitleStreams...)
+	clone.Chapters = append([]schemas.Chapter(nil), mi.Chapters...)
 	return &clone
 }
 
@@ -54,22 +64,26 @@ func (mp *MetadataPropagator) Propagate(ctx context.Context, graph *Graph) error
 			}
 
 		case "operation":
-			// Get the operator
-			op, err := mp.registry.Get(node.Operator)
-			if err != nil {
-				return fmt.Errorf("node %s: operator %s not found: %w", nodeID, node.Operator, err)
-			}
-
 			// Collect input metadata from predecessors
 			inputMetadata, err := mp.collectInputMetadata(graph, node)
 			if err != nil {
 				return fmt.Errorf("node %s: failed to collect input metadata: %w", nodeID, err)
 			}
 
-			// Compute output metadata
-			outputMetadata, err := op.ComputeOutputMetadata(node.Params, inputMetadata)
-			if err != nil {
-				return fmt.Errorf("node %s: failed to compute output metadata: %w", nodeID, err)
+			// Compute output metadata, passing each fused operation's
+			// output on to the next
+			var outputMetadata *schemas.MediaInfo
+			for _, step := range node.Operations() {
+				op, err := mp.registry.Get(step.Operator)
+				if err != nil {
+					return fmt.Errorf("node %s: operator %s not found: %w", nodeID, step.Operator, err)
+				}
+
+				outputMetadata, err = op.ComputeOutputMetadata(step.Params, inputMetadata)
+				if err != nil {
+					return fmt.Errorf("node %s: failed to compute output metadata: %w", nodeID, err)
+				}
+				inputMetadata = []*schemas.MediaInfo{cloneMediaInfo(outputMetadata)}
 			}
 
 			// Store metadata in node
@@ -101,15 +115,23 @@ func (mp *MetadataPropagator) Propagate(ctx context.Context, graph *Graph) error
 	return nil
 }
 
-// collectInputMetadata collects metadata from all predecessor nodes
+// collectInputMetadata collects metadata from all predecessor nodes, in the
+// order the operation declares its inputs
 func (mp *MetadataPropagator) collectInputMetadata(graph *Graph, node *schemas.PlanNode) ([]*schemas.MediaInfo, error) {
-	predecessors := graph.GetPredecessors(node.ID)
-	if len(predecessors) == 0 {
+	incoming := append([]*schemas.PlanEdge(nil), graph.GetIncomingEdges(node.ID)...)
+	if len(incoming) == 0 {
 		return nil, fmt.Errorf("operation node %s has no inputs", node.ID)
 	}
-
-	inputs := make([]*schemas.MediaInfo, 0, len(predecessors))
-	for _, pred := range predecessors {
+	sort.SliceStable(incoming, func(i, j int) bool {
+		return incoming[i].InputIndex < incoming[j].InputIndex
+	})
+
+	inputs := make([]*schemas.MediaInfo, 0, len(incoming))
+	for _, edge := range incoming {
+		pred := graph.GetNode(edge.From)
+		if pred == nil {
+			return nil, fmt.Errorf("predecessor %s not found", edge.From)
+		}
 		if pred.Metadata == nil {
 			return nil, fmt.Errorf("predecessor %s has no metadata", pred.ID)
 		}
@@ -277,6 +277,75 @@ func TestMetadataPropagator_MultipleInputs(t *testing.T) {
 	}
 }
 
+// concatStub joins its inputs: durations add up and the video format is
+// the first input's
+type concatStub struct {
+	builtin.TrimOperator
+}
+
+func (o *concatStub) Name() string { return "concat" }
+
+func (o *concatStub) ComputeOutputMetadata(params map[string]interface{}, inputs []*schemas.MediaInfo) (*schemas.MediaInfo, error) {
+	output := *inputs[0]
+	output.Format.Duration = 0
+	for _, input := range inputs {
+		output.Format.Duration += input.Format.Duration
+	}
+	return &output, nil
+}
+
+func TestMetadataPropagator_InputOrder(t *testing.T) {
+	registry := operators.NewRegistry()
+	registry.Register(&concatStub{})
+
+	spec := &schemas.JobSpec{
+		Inputs: []schemas.Input{
+			{ID: "intro", Source: "s3://bucket/intro.mp4"},
+			{ID: "main", Source: "s3://bucket/main.mp4"},
+			{ID: "outro", Source: "s3://bucket/outro.mp4"},
+		},
+		Operations: []schemas.Operation{
+			{Op: "concat", Inputs: []string{"main", "intro", "outro"}, Output: "joined"},
+		},
+		Outputs: []schemas.Output{
+			{ID: "joined", Destination: "s3://bucket/output.mp4"},
+		},
+	}
+
+	built, err := NewBuilderWithRegistry(registry).BuildDAG(context.Background(), spec)
+	if err != nil {
+		t.Fatalf("BuildDAG failed: %v", err)
+	}
+
+	// Rebuild the graph with the concat's incoming edges reversed
+	graph := NewGraph()
+	for _, node := range built.Nodes {
+		graph.AddNode(node)
+	}
+	for i := len(built.Edges) - 1; i >= 0; i-- {
+		graph.AddEdge(built.Edges[i])
+	}
+
+	for id, width := range map[string]int{"intro": 1280, "main": 1920, "outro": 640} {
+		graph.GetNode("input_" + id).Metadata = &schemas.MediaInfo{
+			Format:       schemas.FormatInfo{Duration: 10 * time.Second},
+			VideoStreams: []schemas.VideoStream{{Codec: "h264", Width: width, Height: 720}},
+		}
+	}
+
+	if err := NewMetadataPropagator(registry).Propagate(context.Background(), graph); err != nil {
+		t.Fatalf("Propagate failed: %v", err)
+	}
+
+	joined := graph.GetNode("op_0_concat").Metadata
+	if joined.Format.Duration != 30*time.Second {
+		t.Errorf("expected the durations to add up to 30s, got %v", joined.Format.Duration)
+	}
+	if joined.VideoStreams[0].Width != 1920 {
+		t.Errorf("expected the first declared input first, got width %d", joined.VideoStreams[0].Width)
+	}
+}
+
 func TestMetadataPropagator_InvalidOperator(t *testing.T) {
 	spec := &schemas.JobSpec{
 		Inputs: []schemas.Input{
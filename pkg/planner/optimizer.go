@@ -0,0 +1,282 @@
+package planner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/chicogong/media-pipeline/pkg/operators"
+	"github.com/chicogong/media-pipeline/pkg/schemas"
+)
+
+// Optimizer rewrites a graph into an equivalent one that is cheaper to run
+type Optimizer struct {
+	registry *operators.Registry
+}
+
+// NewOptimizer creates a new optimizer
+func NewOptimizer(registry *operators.Registry) *Optimizer {
+	return &Optimizer{
+		registry: registry,
+	}
+}
+
+// Optimize fuses chains of fusable operations in place. An operation is
+// fused with its successor when both operators are declared fusable, the
+// operation's only consumer is the successor and the successor's only input
+// is the operation. The fused node applies the operations in order (see
+// schemas.PlanNode.Fused), so it compiles to one filter chain and, when
+// commands are built per node, needs no intermediate file.
+//
+// Metadata and estimates already computed for the graph are kept: a fused
+// node takes the metadata of the last operation in its chain. Returns the
+// number of nodes removed.
+func (o *Optimizer) Optimize(ctx context.Context, graph *Graph) (int, error) {
+	order, err := graph.TopologicalSort()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get topological order: %w", err)
+	}
+
+	// chainOf maps each node to the head of the chain it is fused into
+	chainOf := make(map[string]string)
+	chains := make(map[string][]*schemas.PlanNode)
+	for _, nodeID := range order {
+		node := graph.GetNode(nodeID)
+		if node == nil {
+			return 0, fmt.Errorf("node %s not found", nodeID)
+		}
+
+		head := nodeID
+		if pred, ok := o.fusablePredecessor(graph, node); ok {
+			head = chainOf[pred.ID]
+		}
+		chainOf[nodeID] = head
+		chains[head] = append(chains[head], node)
+	}
+
+	removed := 0
+	fusedID := make(map[string]string)
+	optimized := NewGraph()
+	for _, node := range graph.Nodes {
+		chain := chains[chainOf[node.ID]]
+		if len(chain) == 1 {
+			optimized.AddNode(node)
+			continue
+		}
+		if node != chain[0] {
+			continue
+		}
+
+		fused := fuseNodes(chain)
+		for _, n := range chain {
+			fusedID[n.ID] = fused.ID
+		}
+		optimized.AddNode(fused)
+		removed += len(chain) - 1
+	}
+
+	for _, edge := range graph.Edges {
+		from, to := edge.From, edge.To
+		if id, ok := fusedID[from]; ok {
+			from = id
+		}
+		if id, ok := fusedID[to]; ok {
+			to = id
+		}
+
+		// Edges inside a chain disappear with it
+		if from == to {
+			continue
+		}
+		if from != edge.From || to != edge.To {
+			rewired := *edge
+			rewired.From, rewired.To = from, to
+			edge = &rewired
+		}
+		optimized.AddEdge(edge)
+	}
+
+	*graph = *optimized
+	return removed, nil
+}
+
+// Deduplicate collapses structurally identical operations in place. Two
+// operation nodes are identical when they apply the same operators with the
+// same params to the same inputs, in the same order; since nodes are visited
+// in topological order, whole identical subtrees collapse. The first node of
+// each group is kept and takes over the successors of the others. Returns
+// the number of nodes removed.
+func (o *Optimizer) Deduplicate(ctx context.Context, graph *Graph) (int, error) {
+	order, err := graph.TopologicalSort()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get topological order: %w", err)
+	}
+
+	// replacedBy maps each removed node to the node kept in its place
+	replacedBy := make(map[string]string)
+	kept := make(map[string]string)
+	for _, nodeID := range order {
+		node := graph.GetNode(nodeID)
+		if node == nil {
+			return 0, fmt.Errorf("node %s not found", nodeID)
+		}
+		if node.Type != "operation" {
+			continue
+		}
+
+		key, err := operationKey(graph, node, replacedBy)
+		if err != nil {
+			return 0, err
+		}
+		if keptID, ok := kept[key]; ok {
+			replacedBy[nodeID] = keptID
+			continue
+		}
+		kept[key] = nodeID
+	}
+
+	if len(replacedBy) == 0 {
+		return 0, nil
+	}
+
+	deduplicated := NewGraph()
+	for _, node := range graph.Nodes {
+		if _, ok := replacedBy[node.ID]; !ok {
+			deduplicated.AddNode(node)
+		}
+	}
+	for _, edge := range graph.Edges {
+		// The kept node already has the removed node's inputs
+		if _, ok := replacedBy[edge.To]; ok {
+			continue
+		}
+		if id, ok := replacedBy[edge.From]; ok {
+			rewired := *edge
+			rewired.From = id
+			edge = &rewired
+		}
+		deduplicated.AddEdge(edge)
+	}
+
+	*graph = *deduplicated
+	return len(replacedBy), nil
+}
+
+// operationKey identifies what an operation node computes: its operations
+// and params, and its inputs after deduplication
+func operationKey(graph *Graph, node *schemas.PlanNode, replacedBy map[string]string) (string, error) {
+	inputs := []string{}
+	for _, edge := range graph.GetIncomingEdges(node.ID) {
+		from := edge.From
+		if id, ok := replacedBy[from]; ok {
+			from = id
+		}
+		inputs = append(inputs, from+"/"+edge.StreamType)
+	}
+
+	data, err := json.Marshal(struct {
+		Operations []schemas.FusedOperation `json:"operations"`
+		Inputs     []string                 `json:"inputs"`
+	}{node.Operations(), inputs})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode node %s: %w", node.ID, err)
+	}
+	return string(data), nil
+}
+
+// fusablePredecessor returns node's single input if the two can be fused
+func (o *Optimizer) fusablePredecessor(graph *Graph, node *schemas.PlanNode) (*schemas.PlanNode, bool) {
+	if !o.isFusable(node) {
+		return nil, false
+	}
+
+	incoming := graph.GetIncomingEdges(node.ID)
+	if len(incoming) != 1 {
+		return nil, false
+	}
+
+	pred := graph.GetNode(incoming[0].From)
+	if pred == nil || !o.isFusable(pred) || len(graph.GetIncomingEdges(pred.ID)) != 1 {
+		return nil, false
+	}
+	if len(graph.GetOutgoingEdges(pred.ID)) != 1 {
+		return nil, false
+	}
+	return pred, true
+}
+
+// isFusable reports whether node is an operation whose operators are all
+// fusable
+func (o *Optimizer) isFusable(node *schemas.PlanNode) bool {
+	if node.Type != "operation" {
+		return false
+	}
+
+	for _, step := range node.Operations() {
+		op, err := o.registry.Get(step.Operator)
+		if err != nil {
+			return false
+		}
+		if desc := op.Describe(); desc == nil || !desc.Fusable || desc.RequiresTwoPass {
+			return false
+		}
+	}
+	return true
+}
+
+// fuseNodes merges a chain of operation nodes into one. Its ID and operator
+// join those of the chain with "+".
+func fuseNodes(chain []*schemas.PlanNode) *schemas.PlanNode {
+	ids := make([]string, len(chain))
+	names := []string{}
+	steps := []schemas.FusedOperation{}
+	for i, node := range chain {
+		ids[i] = node.ID
+		for _, step := range node.Operations() {
+			names = append(names, step.Operator)
+			steps = append(steps, step)
+		}
+	}
+
+	last := chain[len(chain)-1]
+	return &schemas.PlanNode{
+		ID:        strings.Join(ids, "+"),
+		Type:      "operation",
+		Operator:  strings.Join(names, "+"),
+		Fused:     steps,
+		Metadata:  last.Metadata,
+		Estimates: fuseEstimates(chain),
+	}
+}
+
+// fuseEstimates combines the estimates of a chain's nodes, or returns nil if
+// any node has none
+func fuseEstimates(chain []*schemas.PlanNode) *schemas.NodeEstimates {
+	estimates := make([]*schemas.NodeEstimates, len(chain))
+	for i, node := range chain {
+		if node.Estimates == nil {
+			return nil
+		}
+		estimates[i] = node.Estimates
+	}
+	return combineEstimates(estimates)
+}
+
+// combineEstimates combines the estimates of operations run as one chain:
+// durations add up, while memory and CPU are the peak of any operation.
+// Only the last operation's output is written to disk.
+func combineEstimates(estimates []*schemas.NodeEstimates) *schemas.NodeEstimates {
+	fused := &schemas.NodeEstimates{}
+	for _, e := range estimates {
+		fused.Duration += e.Duration
+		if e.MemoryMB > fused.MemoryMB {
+			fused.MemoryMB = e.MemoryMB
+		}
+		if e.CPUCores > fused.CPUCores {
+			fused.CPUCores = e.CPUCores
+		}
+		fused.DiskMB = e.DiskMB
+	}
+	return fused
+}
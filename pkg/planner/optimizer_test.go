@@ -0,0 +1,276 @@
+package planner
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/chicogong/media-pipeline/pkg/operators"
+	"github.com/chicogong/media-pipeline/pkg/operators/builtin"
+	"github.com/chicogong/media-pipeline/pkg/schemas"
+)
+
+// fusionGraph builds video -> trim -> scale -> output with input metadata set
+func fusionGraph(t *testing.T) *Graph {
+	t.Helper()
+
+	spec := &schemas.JobSpec{
+		Inputs: []schemas.Input{
+			{ID: "video", Source: "s3://bucket/input.mp4"},
+		},
+		Operations: []schemas.Operation{
+			{Op: "trim", Input: "video", Output: "trimmed",
+				Params: map[string]interface{}{"start": "00:00:10", "duration": "00:00:30"}},
+			{Op: "scale", Input: "trimmed", Output: "scaled",
+				Params: map[string]interface{}{"width": 1280, "height": 720}},
+		},
+		Outputs: []schemas.Output{
+			{ID: "scaled", Destination: "s3://bucket/output.mp4"},
+		},
+	}
+
+	graph, err := NewBuilder().BuildDAG(context.Background(), spec)
+	if err != nil {
+		t.Fatalf("BuildDAG failed: %v", err)
+	}
+
+	graph.GetNode("input_video").Metadata = &schemas.MediaInfo{
+		Format: schemas.FormatInfo{Format: "mp4", Duration: 60 * time.Second, BitRate: 5000000},
+		VideoStreams: []schemas.VideoStream{
+			{Codec: "h264", Width: 1920, Height: 1080, FrameRate: 30},
+		},
+		AudioStreams: []schemas.AudioStream{
+			{Codec: "aac", SampleRate: 48000, Channels: 2},
+		},
+	}
+	return graph
+}
+
+func TestOptimizer_FusesChain(t *testing.T) {
+	registry := operators.NewRegistry()
+	registry.Register(&builtin.TrimOperator{})
+	registry.Register(&builtin.ScaleOperator{})
+
+	propagator := NewMetadataPropagator(registry)
+	ctx := context.Background()
+
+	// Reference: propagate without fusion
+	unfused := fusionGraph(t)
+	if err := propagator.Propagate(ctx, unfused); err != nil {
+		t.Fatalf("Propagate failed: %v", err)
+	}
+	want := unfused.GetNode("output_scaled").Metadata
+
+	graph := fusionGraph(t)
+	removed, err := NewOptimizer(registry).Optimize(ctx, graph)
+	if err != nil {
+		t.Fatalf("Optimize failed: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("expected 1 node removed, got %d", removed)
+	}
+
+	ops := operationNodes(graph)
+	if len(ops) != 1 {
+		t.Fatalf("expected 1 operation node, got %d", len(ops))
+	}
+	fused := ops[0]
+	if fused.ID != "op_0_trim+op_1_scale" || fused.Operator != "trim+scale" {
+		t.Errorf("unexpected fused node %s (%s)", fused.ID, fused.Operator)
+	}
+	if len(fused.Fused) != 2 || fused.Fused[0].Operator != "trim" || fused.Fused[1].Operator != "scale" {
+		t.Errorf("expected fused operations [trim scale], got %+v", fused.Fused)
+	}
+
+	if len(graph.Edges) != 2 {
+		t.Fatalf("expected 2 edges, got %d", len(graph.Edges))
+	}
+	if preds := graph.GetPredecessors(fused.ID); len(preds) != 1 || preds[0].ID != "input_video" {
+		t.Errorf("expected fused node to read input_video, got %v", preds)
+	}
+	if preds := graph.GetPredecessors("output_scaled"); len(preds) != 1 || preds[0] != fused {
+		t.Errorf("expected output_scaled to read the fused node, got %v", preds)
+	}
+
+	// Propagating through the fused node gives the same output metadata
+	if err := propagator.Propagate(ctx, graph); err != nil {
+		t.Fatalf("Propagate after fusion failed: %v", err)
+	}
+	if got := graph.GetNode("output_scaled").Metadata; !reflect.DeepEqual(got, want) {
+		t.Errorf("output metadata changed by fusion:\n got: %+v\nwant: %+v", got, want)
+	}
+}
+
+func TestOptimizer_KeepsPropagatedMetadata(t *testing.T) {
+	registry := operators.NewRegistry()
+	registry.Register(&builtin.TrimOperator{})
+	registry.Register(&builtin.ScaleOperator{})
+
+	ctx := context.Background()
+	graph := fusionGraph(t)
+	if err := NewMetadataPropagator(registry).Propagate(ctx, graph); err != nil {
+		t.Fatalf("Propagate failed: %v", err)
+	}
+	want := cloneMediaInfo(graph.GetNode("op_1_scale").Metadata)
+
+	if _, err := NewOptimizer(registry).Optimize(ctx, graph); err != nil {
+		t.Fatalf("Optimize failed: %v", err)
+	}
+
+	fused := graph.GetNode("op_0_trim+op_1_scale")
+	if fused == nil {
+		t.Fatal("fused node not found")
+	}
+	if !reflect.DeepEqual(fused.Metadata, want) {
+		t.Errorf("fused metadata = %+v, want %+v", fused.Metadata, want)
+	}
+	if got := graph.GetNode("output_scaled").Metadata; !reflect.DeepEqual(got, want) {
+		t.Errorf("output metadata = %+v, want %+v", got, want)
+	}
+}
+
+func TestOptimizer_SkipsUnfusable(t *testing.T) {
+	// Without the fusable flag nothing is merged
+	registry := operators.NewRegistry()
+	registry.Register(&builtin.TrimOperator{})
+	registry.Register(&unfusableScale{})
+
+	graph := fusionGraph(t)
+	removed, err := NewOptimizer(registry).Optimize(context.Background(), graph)
+	if err != nil {
+		t.Fatalf("Optimize failed: %v", err)
+	}
+	if removed != 0 || len(operationNodes(graph)) != 2 {
+		t.Errorf("expected no fusion, removed %d", removed)
+	}
+}
+
+func TestOptimizer_SkipsBranches(t *testing.T) {
+	registry := operators.NewRegistry()
+	registry.Register(&builtin.TrimOperator{})
+	registry.Register(&builtin.ScaleOperator{})
+
+	// trimmed feeds both the scale and an output of its own
+	graph := fusionGraph(t)
+	graph.AddNode(&schemas.PlanNode{ID: "output_trimmed", Type: "output", OutputID: "trimmed"})
+	graph.AddEdge(&schemas.PlanEdge{From: "op_0_trim", To: "output_trimmed", StreamType: "both"})
+
+	removed, err := NewOptimizer(registry).Optimize(context.Background(), graph)
+	if err != nil {
+		t.Fatalf("Optimize failed: %v", err)
+	}
+	if removed != 0 {
+		t.Errorf("expected no fusion across a branch, removed %d", removed)
+	}
+}
+
+// operationNodes returns the operation nodes of graph
+func operationNodes(graph *Graph) []*schemas.PlanNode {
+	ops := []*schemas.PlanNode{}
+	for _, node := range graph.Nodes {
+		if node.Type == "operation" {
+			ops = append(ops, node)
+		}
+	}
+	return ops
+}
+
+// unfusableScale is a scale operator without the fusable flag
+type unfusableScale struct {
+	builtin.ScaleOperator
+}
+
+func (o *unfusableScale) Describe() *operators.OperatorDescriptor {
+	desc := o.ScaleOperator.Describe()
+	desc.Fusable = false
+	return desc
+}
+
+// duplicateSpec trims the same input twice, identically, and scales both
+// trims the same way
+func duplicateSpec() *schemas.JobSpec {
+	trimParams := func() map[string]interface{} {
+		return map[string]interface{}{"start": "00:00:10", "duration": "00:00:30"}
+	}
+	scaleParams := func() map[string]interface{} {
+		return map[string]interface{}{"width": 1280, "height": 720}
+	}
+
+	return &schemas.JobSpec{
+		Inputs: []schemas.Input{
+			{ID: "video", Source: "s3://bucket/input.mp4"},
+		},
+		Operations: []schemas.Operation{
+			{Op: "trim", Input: "video", Output: "clip_a", Params: trimParams()},
+			{Op: "trim", Input: "video", Output: "clip_b", Params: trimParams()},
+			{Op: "scale", Input: "clip_a", Output: "small_a", Params: scaleParams()},
+			{Op: "scale", Input: "clip_b", Output: "small_b", Params: scaleParams()},
+		},
+		Outputs: []schemas.Output{
+			{ID: "clip_a", Destination: "s3://bucket/a.mp4"},
+			{ID: "small_b", Destination: "s3://bucket/b.mp4"},
+		},
+	}
+}
+
+func TestOptimizer_DeduplicatesIdenticalOperations(t *testing.T) {
+	registry := operators.NewRegistry()
+	registry.Register(&builtin.TrimOperator{})
+	registry.Register(&builtin.ScaleOperator{})
+
+	graph, err := NewBuilderWithRegistry(registry).BuildDAG(context.Background(), duplicateSpec())
+	if err != nil {
+		t.Fatalf("BuildDAG failed: %v", err)
+	}
+
+	removed, err := NewOptimizer(registry).Deduplicate(context.Background(), graph)
+	if err != nil {
+		t.Fatalf("Deduplicate failed: %v", err)
+	}
+	// The second trim and, with it, the second scale
+	if removed != 2 {
+		t.Errorf("expected 2 nodes removed, got %d", removed)
+	}
+
+	ops := operationNodes(graph)
+	if len(ops) != 2 {
+		t.Fatalf("expected 2 operation nodes, got %d", len(ops))
+	}
+	if graph.GetNode("op_1_trim") != nil || graph.GetNode("op_3_scale") != nil {
+		t.Error("expected the duplicate trim and scale to be removed")
+	}
+
+	successors := graph.GetSuccessors("op_0_trim")
+	if len(successors) != 2 {
+		t.Fatalf("expected the kept trim to have 2 successors, got %d", len(successors))
+	}
+	if preds := graph.GetPredecessors("output_small_b"); len(preds) != 1 || preds[0].ID != "op_2_scale" {
+		t.Errorf("expected output_small_b to read op_2_scale, got %v", preds)
+	}
+	if _, err := graph.TopologicalSort(); err != nil {
+		t.Errorf("deduplicated graph is invalid: %v", err)
+	}
+}
+
+func TestOptimizer_DeduplicateKeepsDifferentParams(t *testing.T) {
+	registry := operators.NewRegistry()
+	registry.Register(&builtin.TrimOperator{})
+	registry.Register(&builtin.ScaleOperator{})
+
+	spec := duplicateSpec()
+	spec.Operations[1].Params["start"] = "00:00:20"
+
+	graph, err := NewBuilderWithRegistry(registry).BuildDAG(context.Background(), spec)
+	if err != nil {
+		t.Fatalf("BuildDAG failed: %v", err)
+	}
+
+	removed, err := NewOptimizer(registry).Deduplicate(context.Background(), graph)
+	if err != nil {
+		t.Fatalf("Deduplicate failed: %v", err)
+	}
+	if removed != 0 || len(operationNodes(graph)) != 4 {
+		t.Errorf("expected no deduplication, removed %d", removed)
+	}
+}
@@ -0,0 +1,284 @@
+package planner
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/chicogong/media-pipeline/pkg/schemas"
+)
+
+// PartitionLink connects a boundary output of one partition to the boundary
+// input of another that consumes it
+type PartitionLink struct {
+	SourceNode    string // Node in the original graph whose output crosses the cut
+	FromPartition int    // Partition producing SourceNode
+	FromNode      string // Boundary output node in FromPartition
+	ToPartition   int    // Partition consuming SourceNode
+	ToNode        string // Boundary input node in ToPartition
+}
+
+// PartitionMap describes how a graph was split into partitions
+type PartitionMap struct {
+	Partitions []*Graph
+	Links      []PartitionLink
+}
+
+// Dependencies returns the partitions whose outputs partition i consumes
+func (m *PartitionMap) Dependencies(i int) []int {
+	seen := make(map[int]bool)
+	deps := []int{}
+	for _, link := range m.Links {
+		if link.ToPartition == i && !seen[link.FromPartition] {
+			seen[link.FromPartition] = true
+			deps = append(deps, link.FromPartition)
+		}
+	}
+	sort.Ints(deps)
+	return deps
+}
+
+// PartitionGraph splits g into at most maxPartitions standalone subgraphs.
+// See Partition for how the graph is cut.
+func PartitionGraph(g *Graph, maxPartitions int) ([]*Graph, error) {
+	m, err := Partition(g, maxPartitions)
+	if err != nil {
+		return nil, err
+	}
+	return m.Partitions, nil
+}
+
+// Partition splits g into at most maxPartitions balanced partitions.
+//
+// Independent subgraphs (weakly connected components) are natural cuts and
+// are packed into partitions by node count. A graph with no natural cuts is
+// bisected repeatedly along its topological order at the point crossed by
+// the fewest edges, so partitions only depend on earlier ones.
+//
+// Each cut edge is replaced by a boundary output node ("boundary_out_<id>")
+// in the producing partition and a boundary input node ("boundary_in_<id>")
+// in the consuming one; the returned map's Links connect them.
+func Partition(g *Graph, maxPartitions int) (*PartitionMap, error) {
+	if maxPartitions < 1 {
+		return nil, fmt.Errorf("maxPartitions must be at least 1, got %d", maxPartitions)
+	}
+
+	order, err := g.TopologicalSort()
+	if err != nil {
+		return nil, fmt.Errorf("cannot partition graph: %w", err)
+	}
+
+	var groups [][]string
+	components := g.connectedComponents()
+	if len(components) > 1 || maxPartitions == 1 {
+		groups = packComponents(components, maxPartitions)
+	} else {
+		groups = bisectUntil(g, [][]string{order}, maxPartitions)
+	}
+
+	return buildPartitions(g, groups), nil
+}
+
+// connectedComponents returns the node IDs of each weakly connected
+// component, in the order the components' first nodes appear in g.Nodes
+func (g *Graph) connectedComponents() [][]string {
+	visited := make(map[string]bool)
+	components := [][]string{}
+
+	for _, node := range g.Nodes {
+		if visited[node.ID] {
+			continue
+		}
+
+		component := []string{}
+		stack := []string{node.ID}
+		visited[node.ID] = true
+		for len(stack) > 0 {
+			id := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			component = append(component, id)
+
+			neighbours := []string{}
+			for _, edge := range g.GetOutgoingEdges(id) {
+				neighbours = append(neighbours, edge.To)
+			}
+			for _, edge := range g.GetIncomingEdges(id) {
+				neighbours = append(neighbours, edge.From)
+			}
+			for _, next := range neighbours {
+				if !visited[next] && g.GetNode(next) != nil {
+					visited[next] = true
+					stack = append(stack, next)
+				}
+			}
+		}
+		components = append(components, component)
+	}
+
+	return components
+}
+
+// packComponents assigns components to at most maxPartitions groups,
+// placing the largest components first into the smallest group
+func packComponents(components [][]string, maxPartitions int) [][]string {
+	bySize := make([]int, len(components))
+	for i := range bySize {
+		bySize[i] = i
+	}
+	sort.SliceStable(bySize, func(a, b int) bool {
+		return len(components[bySize[a]]) > len(components[bySize[b]])
+	})
+
+	n := maxPartitions
+	if len(components) < n {
+		n = len(components)
+	}
+	groups := make([][]string, n)
+	for _, i := range bySize {
+		smallest := 0
+		for j := range groups {
+			if len(groups[j]) < len(groups[smallest]) {
+				smallest = j
+			}
+		}
+		groups[smallest] = append(groups[smallest], components[i]...)
+	}
+	return groups
+}
+
+// bisectUntil splits the largest group in two until there are maxPartitions
+// groups or no group can be split. Each group is in topological order.
+func bisectUntil(g *Graph, groups [][]string, maxPartitions int) [][]string {
+	for len(groups) < maxPartitions {
+		largest := 0
+		for i := range groups {
+			if len(groups[i]) > len(groups[largest]) {
+				largest = i
+			}
+		}
+		if len(groups[largest]) < 2 {
+			break
+		}
+
+		left, right := bisect(g, groups[largest])
+		groups = append(groups[:largest+1], groups[largest:]...)
+		groups[largest] = left
+		groups[largest+1] = right
+	}
+	return groups
+}
+
+// bisect splits nodes, given in topological order, at the index between a
+// quarter and three quarters of the way through that the fewest edges cross
+func bisect(g *Graph, nodes []string) ([]string, []string) {
+	n := len(nodes)
+	position := make(map[string]int, n)
+	for i, id := range nodes {
+		position[id] = i
+	}
+
+	lo, hi := n/4, (3*n)/4
+	if lo < 1 {
+		lo = 1
+	}
+	if hi < lo {
+		hi = lo
+	}
+
+	best, bestCut := lo, -1
+	for split := lo; split <= hi; split++ {
+		cut := 0
+		for _, edge := range g.Edges {
+			from, ok1 := position[edge.From]
+			to, ok2 := position[edge.To]
+			if ok1 && ok2 && from < split && to >= split {
+				cut++
+			}
+		}
+
+		// Prefer the most balanced split among equal cuts
+		if bestCut < 0 || cut < bestCut || (cut == bestCut && abs(split-n/2) < abs(best-n/2)) {
+			best, bestCut = split, cut
+		}
+	}
+
+	left := append([]string(nil), nodes[:best]...)
+	right := append([]string(nil), nodes[best:]...)
+	return left, right
+}
+
+// buildPartitions creates a standalone graph for each group, with boundary
+// nodes at the cut edges
+func buildPartitions(g *Graph, groups [][]string) *PartitionMap {
+	partitionOf := make(map[string]int)
+	for i, group := range groups {
+		for _, id := range group {
+			partitionOf[id] = i
+		}
+	}
+
+	m := &PartitionMap{Partitions: make([]*Graph, len(groups))}
+	for i := range groups {
+		m.Partitions[i] = NewGraph()
+	}
+
+	// Keep the original node order within each partition
+	for _, node := range g.Nodes {
+		m.Partitions[partitionOf[node.ID]].AddNode(cloneNode(node))
+	}
+
+	for _, edge := range g.Edges {
+		from, ok1 := partitionOf[edge.From]
+		to, ok2 := partitionOf[edge.To]
+		if !ok1 || !ok2 {
+			continue
+		}
+		if from == to {
+			copied := *edge
+			m.Partitions[from].AddEdge(&copied)
+			continue
+		}
+
+		source := g.GetNode(edge.From)
+		outID := "boundary_out_" + edge.From
+		inID := "boundary_in_" + edge.From
+
+		producer := m.Partitions[from]
+		if producer.GetNode(outID) == nil {
+			producer.AddNode(&schemas.PlanNode{
+				ID:       outID,
+				Type:     "output",
+				OutputID: edge.From,
+				Metadata: cloneMediaInfo(source.Metadata),
+			})
+			producer.AddEdge(&schemas.PlanEdge{From: edge.From, To: outID, StreamType: edge.StreamType})
+		}
+
+		consumer := m.Partitions[to]
+		if consumer.GetNode(inID) == nil {
+			consumer.AddNode(&schemas.PlanNode{
+				ID:       inID,
+				Type:     "input",
+				InputID:  edge.From,
+				Metadata: cloneMediaInfo(source.Metadata),
+			})
+			m.Links = append(m.Links, PartitionLink{
+				SourceNode:    edge.From,
+				FromPartition: from,
+				FromNode:      outID,
+				ToPartition:   to,
+				ToNode:        inID,
+			})
+		}
+		consumer.AddEdge(&schemas.PlanEdge{From: inID, To: edge.To, StreamType: edge.StreamType, InputIndex: edge.InputIndex})
+	}
+
+	return m
+}
+
+// abs returns the absolute value of x
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,187 @@
+package planner
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/chicogong/media-pipeline/pkg/schemas"
+)
+
+// branchingGraph builds a 10-node graph: one input feeding a decode node
+// that fans out into three branches, two of them with two operations
+//
+//	input -> decode -> scale_a -> encode_a -> output_a
+//	                -> scale_b -> encode_b -> output_b
+//	                -> thumbnail -> output_c
+func branchingGraph() *Graph {
+	g := NewGraph()
+	g.AddNode(&schemas.PlanNode{ID: "input", Type: "input", SourceURI: "s3://bucket/in.mp4"})
+	for _, id := range []string{"decode", "scale_a", "encode_a", "scale_b", "encode_b", "thumbnail"} {
+		g.AddNode(&schemas.PlanNode{ID: id, Type: "operation", Operator: id})
+	}
+	for _, id := range []string{"output_a", "output_b", "output_c"} {
+		g.AddNode(&schemas.PlanNode{ID: id, Type: "output", DestURI: "s3://bucket/" + id})
+	}
+
+	edges := [][2]string{
+		{"input", "decode"},
+		{"decode", "scale_a"}, {"scale_a", "encode_a"}, {"encode_a", "output_a"},
+		{"decode", "scale_b"}, {"scale_b", "encode_b"}, {"encode_b", "output_b"},
+		{"decode", "thumbnail"}, {"thumbnail", "output_c"},
+	}
+	for _, e := range edges {
+		g.AddEdge(&schemas.PlanEdge{From: e[0], To: e[1], StreamType: "both"})
+	}
+	return g
+}
+
+// checkPartitions verifies that every original node is in exactly one
+// partition, each partition is acyclic and every link has its boundary nodes
+func checkPartitions(t *testing.T, g *Graph, m *PartitionMap) {
+	t.Helper()
+
+	owner := make(map[string]int)
+	for i, p := range m.Partitions {
+		if err := p.DetectCycles(); err != nil {
+			t.Errorf("partition %d has a cycle: %v", i, err)
+		}
+		for _, node := range p.Nodes {
+			if g.GetNode(node.ID) == nil {
+				continue // boundary node
+			}
+			if prev, ok := owner[node.ID]; ok {
+				t.Errorf("node %s is in partitions %d and %d", node.ID, prev, i)
+			}
+			owner[node.ID] = i
+		}
+		for _, edge := range p.Edges {
+			if p.GetNode(edge.From) == nil || p.GetNode(edge.To) == nil {
+				t.Errorf("partition %d has dangling edge %s -> %s", i, edge.From, edge.To)
+			}
+		}
+	}
+	if len(owner) != len(g.Nodes) {
+		t.Errorf("expected %d nodes across partitions, got %d", len(g.Nodes), len(owner))
+	}
+
+	for _, link := range m.Links {
+		out := m.Partitions[link.FromPartition].GetNode(link.FromNode)
+		if out == nil || out.Type != "output" {
+			t.Errorf("link %+v: missing boundary output", link)
+		}
+		in := m.Partitions[link.ToPartition].GetNode(link.ToNode)
+		if in == nil || in.Type != "input" {
+			t.Errorf("link %+v: missing boundary input", link)
+		}
+		if owner[link.SourceNode] != link.FromPartition {
+			t.Errorf("link %+v: source node is in partition %d", link, owner[link.SourceNode])
+		}
+		if link.FromPartition >= link.ToPartition {
+			t.Errorf("link %+v: partitions should only feed later partitions", link)
+		}
+	}
+}
+
+func TestPartitionGraph_Branching(t *testing.T) {
+	g := branchingGraph()
+
+	for _, maxPartitions := range []int{1, 2, 3, 4} {
+		t.Run(fmt.Sprintf("max=%d", maxPartitions), func(t *testing.T) {
+			m, err := Partition(g, maxPartitions)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(m.Partitions) != maxPartitions {
+				t.Fatalf("expected %d partitions, got %d", maxPartitions, len(m.Partitions))
+			}
+			checkPartitions(t, g, m)
+
+			if maxPartitions == 1 && len(m.Links) != 0 {
+				t.Errorf("expected no links for a single partition, got %v", m.Links)
+			}
+			if maxPartitions > 1 && len(m.Links) == 0 {
+				t.Error("expected cut edges to be linked")
+			}
+		})
+	}
+
+	// Bisection keeps partitions balanced
+	partitions, err := PartitionGraph(g, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, p := range partitions {
+		original := 0
+		for _, node := range p.Nodes {
+			if g.GetNode(node.ID) != nil {
+				original++
+			}
+		}
+		if original < 3 || original > 7 {
+			t.Errorf("partition %d has %d nodes, expected a balanced split of 10", i, original)
+		}
+	}
+
+	// The input graph is not modified
+	if len(g.Nodes) != 10 || len(g.Edges) != 9 {
+		t.Errorf("expected original graph to be unchanged, got %d nodes and %d edges", len(g.Nodes), len(g.Edges))
+	}
+}
+
+func TestPartitionGraph_NaturalCuts(t *testing.T) {
+	g1 := trimGraph(t, "s3://bucket/a.mp4", "s3://bucket/a-clip.mp4")
+	g2 := trimGraph(t, "s3://bucket/b.mp4", "s3://bucket/b-clip.mp4")
+	g, err := g1.Merge(g2)
+	if err != nil {
+		t.Fatalf("failed to merge graphs: %v", err)
+	}
+
+	m, err := Partition(g, 4)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(m.Partitions) != 2 {
+		t.Fatalf("expected one partition per independent subgraph, got %d", len(m.Partitions))
+	}
+	if len(m.Links) != 0 {
+		t.Errorf("expected no links between independent subgraphs, got %v", m.Links)
+	}
+	checkPartitions(t, g, m)
+	for i, p := range m.Partitions {
+		if len(p.Nodes) != 3 {
+			t.Errorf("partition %d: expected 3 nodes, got %d", i, len(p.Nodes))
+		}
+	}
+}
+
+func TestPartitionGraph_Errors(t *testing.T) {
+	if _, err := PartitionGraph(branchingGraph(), 0); err == nil {
+		t.Error("expected error for maxPartitions < 1")
+	}
+
+	g := NewGraph()
+	g.AddNode(&schemas.PlanNode{ID: "a", Type: "operation"})
+	g.AddNode(&schemas.PlanNode{ID: "b", Type: "operation"})
+	g.AddEdge(&schemas.PlanEdge{From: "a", To: "b"})
+	g.AddEdge(&schemas.PlanEdge{From: "b", To: "a"})
+	if _, err := PartitionGraph(g, 2); err == nil {
+		t.Error("expected error for cyclic graph")
+	}
+}
+
+func TestPartitionMap_Dependencies(t *testing.T) {
+	m, err := Partition(branchingGraph(), 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if deps := m.Dependencies(0); len(deps) != 0 {
+		t.Errorf("expected first partition to have no dependencies, got %v", deps)
+	}
+	for i := 1; i < len(m.Partitions); i++ {
+		for _, dep := range m.Dependencies(i) {
+			if dep >= i {
+				t.Errorf("partition %d depends on later partition %d", i, dep)
+			}
+		}
+	}
+}
@@ -3,6 +3,7 @@ package planner
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"github.com/chicogong/media-pipeline/pkg/operators"
 	"github.com/chicogong/media-pipeline/pkg/schemas"
@@ -13,6 +14,7 @@ type Planner struct {
 	builder    *Builder
 	propagator *MetadataPropagator
 	estimator  *ResourceEstimator
+	optimizer  *Optimizer
 	registry   *operators.Registry
 }
 
@@ -20,9 +22,10 @@ type Planner struct {
 func NewPlanner() *Planner {
 	registry := operators.GlobalRegistry()
 	return &Planner{
-		builder:    NewBuilder(),
+		builder:    NewBuilderWithRegistry(registry),
 		propagator: NewMetadataPropagator(registry),
 		estimator:  NewResourceEstimator(registry),
+		optimizer:  NewOptimizer(registry),
 		registry:   registry,
 	}
 }
@@ -30,9 +33,10 @@ func NewPlanner() *Planner {
 // NewPlannerWithRegistry creates a new planner with a custom operator registry
 func NewPlannerWithRegistry(registry *operators.Registry) *Planner {
 	return &Planner{
-		builder:    NewBuilder(),
+		builder:    NewBuilderWithRegistry(registry),
 		propagator: NewMetadataPropagator(registry),
 		estimator:  NewResourceEstimator(registry),
+		optimizer:  NewOptimizer(registry),
 		registry:   registry,
 	}
 }
@@ -44,6 +48,13 @@ type PlanOptions struct {
 
 	// SkipResourceEstimation skips resource estimation (for testing)
 	SkipResourceEstimation bool
+
+	// DeduplicateOperations collapses identical operations on the same
+	// inputs into single nodes
+	DeduplicateOperations bool
+
+	// FuseOperations merges chains of fusable operations into single nodes
+	FuseOperations bool
 }
 
 // Plan generates a complete processing plan from a JobSpec
@@ -62,6 +73,21 @@ func (p *Planner) Plan(ctx context.Context, spec *schemas.JobSpec, opts *PlanOpt
 	if err := graph.DetectCycles(); err != nil {
 		return nil, fmt.Errorf("graph validation failed: %w", err)
 	}
+	if err := ValidateMediaTypes(graph, p.registry); err != nil {
+		return nil, fmt.Errorf("graph validation failed: %w", err)
+	}
+
+	// Optimize before anything is computed from the node layout
+	if opts.DeduplicateOperations {
+		if _, err := p.optimizer.Deduplicate(ctx, graph); err != nil {
+			return nil, fmt.Errorf("optimization failed: %w", err)
+		}
+	}
+	if opts.FuseOperations {
+		if _, err := p.optimizer.Optimize(ctx, graph); err != nil {
+			return nil, fmt.Errorf("optimization failed: %w", err)
+		}
+	}
 
 	// Step 3: Get execution order
 	order, err := graph.TopologicalSort()
@@ -111,6 +137,9 @@ func (p *Planner) Plan(ctx context.Context, spec *schemas.JobSpec, opts *PlanOpt
 			if err != nil {
 				return nil, fmt.Errorf("resource estimation failed: %w", err)
 			}
+			if err := CheckResourceLimits(graph, estimates, spec.Limits); err != nil {
+				return nil, err
+			}
 		}
 	}
 
@@ -122,11 +151,25 @@ func (p *Planner) Plan(ctx context.Context, spec *schemas.JobSpec, opts *PlanOpt
 		ExecutionOrder:   order,
 		ExecutionStages:  stages,
 		ResourceEstimate: estimates,
+		Warnings:         deadOperationWarnings(graph),
 	}
 
 	return plan, nil
 }
 
+// deadOperationWarnings reports operation nodes whose output nothing reads:
+// they are either wasted work or a mistake in the spec
+func deadOperationWarnings(graph *Graph) []string {
+	var warnings []string
+	for _, node := range graph.Nodes {
+		if node.Type != "operation" || len(graph.GetOutgoingEdges(node.ID)) > 0 {
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("operation %s (%s) output is never used", node.ID, node.Operator))
+	}
+	return warnings
+}
+
 // ValidateOperators validates that all operators in the spec are registered
 func (p *Planner) ValidateOperators(spec *schemas.JobSpec) error {
 	for i, op := range spec.Operations {
@@ -152,3 +195,48 @@ func (p *Planner) ValidateParameters(spec *schemas.JobSpec) error {
 	}
 	return nil
 }
+
+// PlanCache holds plans by their fingerprint, so a plan that has already
+// been built, e.g. for an earlier run of the same spec, can be found again.
+// Plans are stored and returned as copies.
+type PlanCache struct {
+	mu    sync.RWMutex
+	plans map[string]*schemas.ProcessingPlan
+}
+
+// NewPlanCache creates an empty plan cache
+func NewPlanCache() *PlanCache {
+	return &PlanCache{
+		plans: make(map[string]*schemas.ProcessingPlan),
+	}
+}
+
+// Put stores plan and returns its fingerprint. A plan with the same
+// fingerprint replaces the one already stored.
+func (c *PlanCache) Put(plan *schemas.ProcessingPlan) string {
+	fingerprint := plan.Fingerprint()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.plans[fingerprint] = clonePlan(plan)
+	return fingerprint
+}
+
+// Get returns the plan stored under fingerprint
+func (c *PlanCache) Get(fingerprint string) (*schemas.ProcessingPlan, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	plan, ok := c.plans[fingerprint]
+	if !ok {
+		return nil, false
+	}
+	return clonePlan(plan), true
+}
+
+// Len returns the number of stored plans
+func (c *PlanCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.plans)
+}
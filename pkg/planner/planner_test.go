@@ -2,6 +2,7 @@ package planner
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -295,3 +296,89 @@ func TestPlanner_PlanParallelOperations(t *testing.T) {
 		t.Errorf("expected 2 operations in stage 1, got %d", len(stage1))
 	}
 }
+
+func TestPlanner_PlanWarnsOnDeadOperations(t *testing.T) {
+	operators.Register(&builtin.TrimOperator{})
+	operators.Register(&builtin.ScaleOperator{})
+
+	spec := &schemas.JobSpec{
+		JobID: "test-job-dead",
+		Inputs: []schemas.Input{
+			{ID: "video", Source: "s3://bucket/input.mp4"},
+		},
+		Operations: []schemas.Operation{
+			{Op: "trim", Input: "video", Output: "trimmed",
+				Params: map[string]interface{}{"start": "00:00:10", "duration": "00:00:30"}},
+			// Nothing reads "thumbnail"
+			{Op: "scale", Input: "video", Output: "thumbnail",
+				Params: map[string]interface{}{"width": 320, "height": 180}},
+		},
+		Outputs: []schemas.Output{
+			{ID: "trimmed", Destination: "s3://bucket/output.mp4"},
+		},
+	}
+
+	plan, err := NewPlanner().Plan(context.Background(), spec, nil)
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+
+	if len(plan.Warnings) != 1 {
+		t.Fatalf("expected 1 warning, got %v", plan.Warnings)
+	}
+	if !strings.Contains(plan.Warnings[0], "op_1_scale") {
+		t.Errorf("expected a warning about op_1_scale, got %q", plan.Warnings[0])
+	}
+}
+
+func TestPlanCache(t *testing.T) {
+	registry := operators.NewRegistry()
+	registry.Register(&builtin.TrimOperator{})
+
+	spec := &schemas.JobSpec{
+		Inputs: []schemas.Input{
+			{ID: "video", Source: "s3://bucket/input.mp4"},
+		},
+		Operations: []schemas.Operation{
+			{Op: "trim", Input: "video", Output: "trimmed",
+				Params: map[string]interface{}{"start": "00:00:10", "duration": "00:00:30"}},
+		},
+		Outputs: []schemas.Output{
+			{ID: "trimmed", Destination: "s3://bucket/output.mp4"},
+		},
+	}
+
+	planner := NewPlannerWithRegistry(registry)
+	opts := &PlanOptions{SkipMetadataValidation: true, SkipResourceEstimation: true}
+
+	first, err := planner.Plan(context.Background(), spec, opts)
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	spec.JobID = "another-job"
+	second, err := planner.Plan(context.Background(), spec, opts)
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+
+	cache := NewPlanCache()
+	fingerprint := cache.Put(first)
+	if fingerprint != second.Fingerprint() {
+		t.Errorf("expected the same spec to produce the same fingerprint")
+	}
+
+	cached, ok := cache.Get(second.Fingerprint())
+	if !ok {
+		t.Fatal("expected the plan to be found by fingerprint")
+	}
+	if cached == first || len(cached.Nodes) != len(first.Nodes) {
+		t.Errorf("expected a copy of the stored plan, got %+v", cached)
+	}
+
+	if _, ok := cache.Get("unknown"); ok {
+		t.Error("expected no plan for an unknown fingerprint")
+	}
+	if cache.Len() != 1 {
+		t.Errorf("expected 1 cached plan, got %d", cache.Len())
+	}
+}
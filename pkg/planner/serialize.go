@@ -0,0 +1,77 @@
+package planner
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/chicogong/media-pipeline/pkg/schemas"
+)
+
+// SavePlan writes plan to w as indented JSON
+func SavePlan(w io.Writer, plan *schemas.ProcessingPlan) error {
+	if plan == nil {
+		return fmt.Errorf("plan is nil")
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(plan); err != nil {
+		return fmt.Errorf("failed to encode plan: %w", err)
+	}
+	return nil
+}
+
+// LoadPlan reads a plan written by SavePlan and checks that its edges,
+// execution order and stages only reference nodes in the plan
+func LoadPlan(r io.Reader) (*schemas.ProcessingPlan, error) {
+	var plan schemas.ProcessingPlan
+	if err := json.NewDecoder(r).Decode(&plan); err != nil {
+		return nil, fmt.Errorf("failed to decode plan: %w", err)
+	}
+
+	if err := checkPlanReferences(&plan); err != nil {
+		return nil, fmt.Errorf("invalid plan: %w", err)
+	}
+	return &plan, nil
+}
+
+// checkPlanReferences verifies that every node ID used by plan is defined
+// and that the graph is acyclic
+func checkPlanReferences(plan *schemas.ProcessingPlan) error {
+	graph := NewGraph()
+	for _, node := range plan.Nodes {
+		if node == nil || node.ID == "" {
+			return fmt.Errorf("node without an ID")
+		}
+		if graph.GetNode(node.ID) != nil {
+			return fmt.Errorf("duplicate node %s", node.ID)
+		}
+		graph.AddNode(node)
+	}
+
+	for _, edge := range plan.Edges {
+		if edge == nil {
+			return fmt.Errorf("empty edge")
+		}
+		if graph.GetNode(edge.From) == nil || graph.GetNode(edge.To) == nil {
+			return fmt.Errorf("edge %s -> %s references an unknown node", edge.From, edge.To)
+		}
+		graph.AddEdge(edge)
+	}
+
+	for _, id := range plan.ExecutionOrder {
+		if graph.GetNode(id) == nil {
+			return fmt.Errorf("execution order references unknown node %s", id)
+		}
+	}
+	for i, stage := range plan.ExecutionStages {
+		for _, id := range stage {
+			if graph.GetNode(id) == nil {
+				return fmt.Errorf("stage %d references unknown node %s", i, id)
+			}
+		}
+	}
+
+	return graph.DetectCycles()
+}
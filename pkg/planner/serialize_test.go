@@ -0,0 +1,107 @@
+package planner
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chicogong/media-pipeline/pkg/operators"
+	"github.com/chicogong/media-pipeline/pkg/operators/builtin"
+	"github.com/chicogong/media-pipeline/pkg/schemas"
+)
+
+func TestSavePlanLoadPlan_RoundTrip(t *testing.T) {
+	operators.Register(&builtin.TrimOperator{})
+	operators.Register(&builtin.ScaleOperator{})
+
+	spec := &schemas.JobSpec{
+		JobID: "job_roundtrip",
+		Inputs: []schemas.Input{
+			{ID: "video", Source: "s3://bucket/input.mp4"},
+		},
+		Operations: []schemas.Operation{
+			{Op: "trim", Input: "video", Output: "trimmed",
+				Params: map[string]interface{}{"start": "00:00:10", "duration": "00:00:30"}},
+			{Op: "scale", Input: "trimmed", Output: "scaled",
+				Params: map[string]interface{}{"width": float64(1280), "height": float64(720)}},
+		},
+		Outputs: []schemas.Output{
+			{ID: "scaled", Destination: "s3://bucket/output.mp4", Metadata: map[string]string{"title": "Clip"}},
+		},
+	}
+
+	p := NewPlanner()
+	graph, err := p.builder.BuildDAG(context.Background(), spec)
+	if err != nil {
+		t.Fatalf("BuildDAG failed: %v", err)
+	}
+	graph.GetNode("input_video").Metadata = &schemas.MediaInfo{
+		Format:       schemas.FormatInfo{Format: "mp4", Duration: time.Minute, Size: 100 << 20},
+		VideoStreams: []schemas.VideoStream{{Index: 0, Codec: "h264", Width: 1920, Height: 1080, FrameRate: 30}},
+		AudioStreams: []schemas.AudioStream{{Index: 1, Codec: "aac", SampleRate: 48000, Channels: 2}},
+	}
+	if err := p.propagator.Propagate(context.Background(), graph); err != nil {
+		t.Fatalf("Propagate failed: %v", err)
+	}
+	estimates, err := p.estimator.Estimate(context.Background(), graph)
+	if err != nil {
+		t.Fatalf("Estimate failed: %v", err)
+	}
+	order, err := graph.TopologicalSort()
+	if err != nil {
+		t.Fatalf("TopologicalSort failed: %v", err)
+	}
+	stages, err := graph.ComputeExecutionStages()
+	if err != nil {
+		t.Fatalf("ComputeExecutionStages failed: %v", err)
+	}
+
+	plan := &schemas.ProcessingPlan{
+		PlanID:           "plan_1",
+		JobID:            spec.JobID,
+		CreatedAt:        time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Nodes:            graph.Nodes,
+		Edges:            graph.Edges,
+		ExecutionOrder:   order,
+		ExecutionStages:  stages,
+		ResourceEstimate: estimates,
+		FFmpegVersion:    "6.1",
+		Commands: []schemas.FFmpegCommand{
+			{Args: []string{"-i", "input.mp4", "output.mp4"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := SavePlan(&buf, plan); err != nil {
+		t.Fatalf("SavePlan failed: %v", err)
+	}
+
+	loaded, err := LoadPlan(&buf)
+	if err != nil {
+		t.Fatalf("LoadPlan failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(plan, loaded) {
+		t.Errorf("reloaded plan differs from original\noriginal: %+v\nloaded:   %+v", plan, loaded)
+	}
+}
+
+func TestLoadPlan_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"malformed":      `{"nodes": [`,
+		"unknown edge":   `{"nodes": [{"id": "a", "type": "input"}], "edges": [{"from": "a", "to": "b"}]}`,
+		"duplicate node": `{"nodes": [{"id": "a"}, {"id": "a"}]}`,
+		"unknown stage":  `{"nodes": [{"id": "a"}], "execution_stages": [["a", "b"]]}`,
+		"cycle": `{"nodes": [{"id": "a"}, {"id": "b"}],
+			"edges": [{"from": "a", "to": "b"}, {"from": "b", "to": "a"}]}`,
+	}
+
+	for name, data := range tests {
+		if _, err := LoadPlan(strings.NewReader(data)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
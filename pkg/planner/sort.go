@@ -1,9 +1,15 @@
 package planner
 
-import "fmt"
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+)
 
 // TopologicalSort performs topological sort using Kahn's algorithm
-// Returns a list of node IDs in topological order
+// Returns a list of node IDs in topological order. The order is
+// deterministic: of the nodes ready to run, the one with the smallest ID
+// is always taken next.
 func (g *Graph) TopologicalSort() ([]string, error) {
 	// Count incoming edges for each node
 	inDegree := make(map[string]int)
@@ -11,20 +17,19 @@ func (g *Graph) TopologicalSort() ([]string, error) {
 		inDegree[node.ID] = len(g.GetIncomingEdges(node.ID))
 	}
 
-	// Queue of nodes with no incoming edges
-	queue := []string{}
-	for nodeID, degree := range inDegree {
-		if degree == 0 {
-			queue = append(queue, nodeID)
+	// Min-heap of nodes with no incoming edges
+	ready := &idHeap{}
+	for _, node := range g.Nodes {
+		if inDegree[node.ID] == 0 {
+			*ready = append(*ready, node.ID)
 		}
 	}
+	heap.Init(ready)
 
 	// Process nodes
 	result := []string{}
-	for len(queue) > 0 {
-		// Dequeue
-		nodeID := queue[0]
-		queue = queue[1:]
+	for ready.Len() > 0 {
+		nodeID := heap.Pop(ready).(string)
 		result = append(result, nodeID)
 
 		// Reduce in-degree of successors
@@ -33,7 +38,7 @@ func (g *Graph) TopologicalSort() ([]string, error) {
 			inDegree[successor]--
 
 			if inDegree[successor] == 0 {
-				queue = append(queue, successor)
+				heap.Push(ready, successor)
 			}
 		}
 	}
@@ -46,8 +51,27 @@ func (g *Graph) TopologicalSort() ([]string, error) {
 	return result, nil
 }
 
+// idHeap is a min-heap of node IDs
+type idHeap []string
+
+func (h idHeap) Len() int           { return len(h) }
+func (h idHeap) Less(i, j int) bool { return h[i] < h[j] }
+func (h idHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *idHeap) Push(x any) {
+	*h = append(*h, x.(string))
+}
+
+func (h *idHeap) Pop() any {
+	old := *h
+	id := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return id
+}
+
 // ComputeExecutionStages groups nodes into stages for parallel execution
-// Nodes in the same stage have no dependencies on each other
+// Nodes in the same stage have no dependencies on each other; each stage is
+// sorted by node ID
 func (g *Graph) ComputeExecutionStages() ([][]string, error) {
 	// Count incoming edges for each node
 	inDegree := make(map[string]int)
@@ -71,6 +95,7 @@ func (g *Graph) ComputeExecutionStages() ([][]string, error) {
 		if len(stage) == 0 {
 			return nil, fmt.Errorf("cannot compute stages (possible cycle)")
 		}
+		sort.Strings(stage)
 
 		stages = append(stages, stage)
 
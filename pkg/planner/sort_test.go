@@ -1,6 +1,7 @@
 package planner
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/chicogong/media-pipeline/pkg/schemas"
@@ -26,21 +27,9 @@ func TestTopologicalSort_LinearGraph(t *testing.T) {
 		t.Fatalf("expected 3 nodes, got %d", len(order))
 	}
 
-	// Verify order: A must come before B, B before C
-	posA, posB, posC := -1, -1, -1
-	for i, id := range order {
-		switch id {
-		case "A":
-			posA = i
-		case "B":
-			posB = i
-		case "C":
-			posC = i
-		}
-	}
-
-	if posA >= posB || posB >= posC {
-		t.Errorf("invalid order: %v (expected A < B < C)", order)
+	want := []string{"A", "B", "C"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("expected order %v, got %v", want, order)
 	}
 }
 
@@ -232,3 +221,67 @@ func TestComputeExecutionStages_Diamond(t *testing.T) {
 		t.Errorf("expected stage 2 to be [D], got %v", stages[2])
 	}
 }
+
+func TestTopologicalSort_Deterministic(t *testing.T) {
+	// Several independent sources fanning into one sink, with nodes added
+	// out of ID order
+	build := func(ids []string) *Graph {
+		graph := NewGraph()
+		for _, id := range ids {
+			graph.AddNode(&schemas.PlanNode{ID: id})
+		}
+		graph.AddNode(&schemas.PlanNode{ID: "sink"})
+		for _, id := range ids {
+			graph.AddEdge(&schemas.PlanEdge{From: id, To: "sink"})
+		}
+		return graph
+	}
+
+	wantOrder := []string{"a", "b", "c", "d", "e", "sink"}
+	wantStages := [][]string{{"a", "b", "c", "d", "e"}, {"sink"}}
+	for i := 0; i < 50; i++ {
+		graph := build([]string{"d", "a", "e", "c", "b"})
+		if i%2 == 1 {
+			graph = build([]string{"b", "e", "a", "c", "d"})
+		}
+
+		order, err := graph.TopologicalSort()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(order, wantOrder) {
+			t.Fatalf("run %d: expected order %v, got %v", i, wantOrder, order)
+		}
+
+		stages, err := graph.ComputeExecutionStages()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(stages, wantStages) {
+			t.Fatalf("run %d: expected stages %v, got %v", i, wantStages, stages)
+		}
+	}
+}
+
+func TestTopologicalSort_ParallelRootsAlphabetical(t *testing.T) {
+	// Two chains, z -> a and b -> y, added out of ID order. Whenever
+	// several nodes are ready the smallest ID runs first, across chains.
+	graph := NewGraph()
+	for _, id := range []string{"z", "y", "b", "a"} {
+		graph.AddNode(&schemas.PlanNode{ID: id})
+	}
+	graph.AddEdge(&schemas.PlanEdge{From: "z", To: "a"})
+	graph.AddEdge(&schemas.PlanEdge{From: "b", To: "y"})
+
+	order, err := graph.TopologicalSort()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// b, then y (ready after b and smaller than z), then z and the a it
+	// unlocks
+	want := []string{"b", "y", "z", "a"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("expected order %v, got %v", want, order)
+	}
+}
@@ -0,0 +1,171 @@
+package prober
+
+import (
+	"container/list"
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chicogong/media-pipeline/pkg/schemas"
+)
+
+// MediaProber is implemented by anything that can probe a media URI
+type MediaProber interface {
+	Probe(ctx context.Context, uri string) (*schemas.MediaInfo, error)
+}
+
+// Ensure Prober implements MediaProber
+var _ MediaProber = (*Prober)(nil)
+
+const (
+	// DefaultCacheTTL is how long probe results are cached by default
+	DefaultCacheTTL = 10 * time.Minute
+
+	// DefaultCacheMaxEntries is the default number of cached probe results
+	DefaultCacheMaxEntries = 1000
+)
+
+// CachingProber memoizes probe results in front of another MediaProber.
+// Local files are keyed by path plus size and modification time, so a
+// rewritten file is probed again; other URIs are cached by URI alone until
+// the TTL expires or they are invalidated.
+type CachingProber struct {
+	prober     MediaProber
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // Front is most recently used
+}
+
+// cacheEntry is a cached probe result
+type cacheEntry struct {
+	uri     string
+	info    *schemas.MediaInfo
+	size    int64
+	modTime time.Time
+	expires time.Time
+}
+
+// CacheOption is a functional option for CachingProber
+type CacheOption func(*CachingProber)
+
+// WithCacheTTL sets how long probe results stay valid
+func WithCacheTTL(ttl time.Duration) CacheOption {
+	return func(c *CachingProber) {
+		c.ttl = ttl
+	}
+}
+
+// WithCacheMaxEntries bounds the number of cached results; the least
+// recently used entry is evicted when the cache is full
+func WithCacheMaxEntries(n int) CacheOption {
+	return func(c *CachingProber) {
+		c.maxEntries = n
+	}
+}
+
+// NewCachingProber creates a CachingProber wrapping p
+func NewCachingProber(p MediaProber, opts ...CacheOption) *CachingProber {
+	c := &CachingProber{
+		prober:     p,
+		ttl:        DefaultCacheTTL,
+		maxEntries: DefaultCacheMaxEntries,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Probe returns cached metadata for uri, probing it on a miss.
+// The returned MediaInfo is shared between callers and must not be modified.
+func (c *CachingProber) Probe(ctx context.Context, uri string) (*schemas.MediaInfo, error) {
+	size, modTime, local := statLocal(uri)
+
+	c.mu.Lock()
+	if elem, ok := c.entries[uri]; ok {
+		entry := elem.Value.(*cacheEntry)
+		fresh := time.Now().Before(entry.expires)
+		unchanged := !local || (entry.size == size && entry.modTime.Equal(modTime))
+		if fresh && unchanged {
+			c.lru.MoveToFront(elem)
+			c.mu.Unlock()
+			return entry.info, nil
+		}
+		c.removeElement(elem)
+	}
+	c.mu.Unlock()
+
+	info, err := c.prober.Probe(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[uri]; ok {
+		c.removeElement(elem)
+	}
+	c.entries[uri] = c.lru.PushFront(&cacheEntry{
+		uri:     uri,
+		info:    info,
+		size:    size,
+		modTime: modTime,
+		expires: time.Now().Add(c.ttl),
+	})
+
+	for c.maxEntries > 0 && c.lru.Len() > c.maxEntries {
+		c.removeElement(c.lru.Back())
+	}
+
+	return info, nil
+}
+
+// Invalidate drops the cached result for uri
+func (c *CachingProber) Invalidate(uri string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[uri]; ok {
+		c.removeElement(elem)
+	}
+}
+
+// Len returns the number of cached results
+func (c *CachingProber) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// removeElement removes an entry; must be called with c.mu held
+func (c *CachingProber) removeElement(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*cacheEntry)
+	delete(c.entries, entry.uri)
+}
+
+// statLocal returns the size and modification time of a local file URI.
+// ok is false for remote URIs and files that cannot be stat'ed.
+func statLocal(uri string) (size int64, modTime time.Time, ok bool) {
+	path := uri
+	if strings.HasPrefix(path, "file://") {
+		path = strings.TrimPrefix(path, "file://")
+	} else if strings.Contains(path, "://") {
+		return 0, time.Time{}, false
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return 0, time.Time{}, false
+	}
+	return fi.Size(), fi.ModTime(), true
+}
@@ -0,0 +1,131 @@
+package prober
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chicogong/media-pipeline/pkg/schemas"
+)
+
+// countingProber counts Probe calls
+type countingProber struct {
+	calls map[string]int
+}
+
+func newCountingProber() *countingProber {
+	return &countingProber{calls: make(map[string]int)}
+}
+
+func (p *countingProber) Probe(ctx context.Context, uri string) (*schemas.MediaInfo, error) {
+	p.calls[uri]++
+	return &schemas.MediaInfo{Format: schemas.FormatInfo{Filename: uri}}, nil
+}
+
+// TestCachingProber_Memoizes tests that identical probes hit the cache
+func TestCachingProber_Memoizes(t *testing.T) {
+	inner := newCountingProber()
+	c := NewCachingProber(inner)
+	ctx := context.Background()
+
+	first, err := c.Probe(ctx, "s3://bucket/video.mp4")
+	if err != nil {
+		t.Fatalf("Probe() failed: %v", err)
+	}
+	second, err := c.Probe(ctx, "s3://bucket/video.mp4")
+	if err != nil {
+		t.Fatalf("Probe() failed: %v", err)
+	}
+
+	if inner.calls["s3://bucket/video.mp4"] != 1 {
+		t.Errorf("Expected 1 underlying probe, got %d", inner.calls["s3://bucket/video.mp4"])
+	}
+	if first != second {
+		t.Error("Expected the cached MediaInfo to be returned")
+	}
+}
+
+// TestCachingProber_Invalidate tests that Invalidate forces a re-probe
+func TestCachingProber_Invalidate(t *testing.T) {
+	inner := newCountingProber()
+	c := NewCachingProber(inner)
+	ctx := context.Background()
+
+	c.Probe(ctx, "s3://bucket/video.mp4")
+	c.Invalidate("s3://bucket/video.mp4")
+	c.Probe(ctx, "s3://bucket/video.mp4")
+
+	if inner.calls["s3://bucket/video.mp4"] != 2 {
+		t.Errorf("Expected 2 underlying probes, got %d", inner.calls["s3://bucket/video.mp4"])
+	}
+}
+
+// TestCachingProber_TTL tests that expired entries are re-probed
+func TestCachingProber_TTL(t *testing.T) {
+	inner := newCountingProber()
+	c := NewCachingProber(inner, WithCacheTTL(10*time.Millisecond))
+	ctx := context.Background()
+
+	c.Probe(ctx, "s3://bucket/video.mp4")
+	time.Sleep(20 * time.Millisecond)
+	c.Probe(ctx, "s3://bucket/video.mp4")
+
+	if inner.calls["s3://bucket/video.mp4"] != 2 {
+		t.Errorf("Expected 2 underlying probes after expiry, got %d", inner.calls["s3://bucket/video.mp4"])
+	}
+}
+
+// TestCachingProber_MaxEntries tests LRU eviction
+func TestCachingProber_MaxEntries(t *testing.T) {
+	inner := newCountingProber()
+	c := NewCachingProber(inner, WithCacheMaxEntries(2))
+	ctx := context.Background()
+
+	c.Probe(ctx, "s3://bucket/a.mp4")
+	c.Probe(ctx, "s3://bucket/b.mp4")
+	c.Probe(ctx, "s3://bucket/a.mp4") // a is now most recently used
+	c.Probe(ctx, "s3://bucket/c.mp4") // evicts b
+
+	if c.Len() != 2 {
+		t.Errorf("Expected 2 cached entries, got %d", c.Len())
+	}
+
+	c.Probe(ctx, "s3://bucket/a.mp4")
+	c.Probe(ctx, "s3://bucket/b.mp4")
+
+	if inner.calls["s3://bucket/a.mp4"] != 1 {
+		t.Errorf("Expected a.mp4 to stay cached, got %d probes", inner.calls["s3://bucket/a.mp4"])
+	}
+	if inner.calls["s3://bucket/b.mp4"] != 2 {
+		t.Errorf("Expected b.mp4 to be evicted, got %d probes", inner.calls["s3://bucket/b.mp4"])
+	}
+}
+
+// TestCachingProber_LocalFileChanged tests that modified local files are re-probed
+func TestCachingProber_LocalFileChanged(t *testing.T) {
+	inner := newCountingProber()
+	c := NewCachingProber(inner)
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "video.mp4")
+	if err := os.WriteFile(path, []byte("first"), 0o644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	c.Probe(ctx, path)
+	c.Probe(ctx, path)
+	if inner.calls[path] != 1 {
+		t.Fatalf("Expected 1 underlying probe, got %d", inner.calls[path])
+	}
+
+	if err := os.WriteFile(path, []byte("rewritten"), 0o644); err != nil {
+		t.Fatalf("Failed to rewrite file: %v", err)
+	}
+
+	c.Probe(ctx, path)
+	if inner.calls[path] != 2 {
+		t.Errorf("Expected re-probe after file change, got %d probes", inner.calls[path])
+	}
+}
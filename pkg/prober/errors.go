@@ -0,0 +1,126 @@
+package prober
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"strings"
+)
+
+// ProbeErrorKind classifies why a probe failed
+type ProbeErrorKind string
+
+const (
+	// ProbeErrorUnknown is any failure that could not be classified
+	ProbeErrorUnknown ProbeErrorKind = "unknown"
+
+	// ProbeErrorNotFound means the input file or URL does not exist
+	ProbeErrorNotFound ProbeErrorKind = "not_found"
+
+	// ProbeErrorUnsupported means ffprobe could not understand the input
+	ProbeErrorUnsupported ProbeErrorKind = "unsupported"
+
+	// ProbeErrorTimeout means the probe did not finish before its deadline
+	ProbeErrorTimeout ProbeErrorKind = "timeout"
+
+	// ProbeErrorBinaryMissing means no ffprobe binary could be run
+	ProbeErrorBinaryMissing ProbeErrorKind = "binary_missing"
+)
+
+// ProbeError is returned when ffprobe fails
+type ProbeError struct {
+	Kind     ProbeErrorKind
+	Stderr   string // ffprobe's stderr output, if it ran
+	ExitCode int    // ffprobe's exit code, or -1 if it did not exit normally
+	Err      error  // Underlying error
+}
+
+// Error implements the error interface
+func (e *ProbeError) Error() string {
+	switch e.Kind {
+	case ProbeErrorBinaryMissing:
+		if e.Err != nil {
+			return fmt.Sprintf("ffprobe not found: %v", e.Err)
+		}
+		return "ffprobe not found in PATH"
+	case ProbeErrorTimeout:
+		return fmt.Sprintf("ffprobe timed out: %v", e.Err)
+	}
+
+	if stderr := strings.TrimSpace(e.Stderr); stderr != "" {
+		return fmt.Sprintf("ffprobe failed (%s, exit code %d): %s", e.Kind, e.ExitCode, stderr)
+	}
+	return fmt.Sprintf("ffprobe execution error: %v", e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *ProbeError) Unwrap() error {
+	return e.Err
+}
+
+// IsProbeErrorKind reports whether err is a ProbeError of the given kind
+func IsProbeErrorKind(err error, kind ProbeErrorKind) bool {
+	var probeErr *ProbeError
+	return errors.As(err, &probeErr) && probeErr.Kind == kind
+}
+
+// errBinaryMissing is returned when no ffprobe binary is configured
+func errBinaryMissing() error {
+	return &ProbeError{Kind: ProbeErrorBinaryMissing, ExitCode: -1}
+}
+
+// newProbeError builds a ProbeError from a failed ffprobe run
+func newProbeError(ctx context.Context, err error) *ProbeError {
+	if ctx.Err() == context.DeadlineExceeded {
+		return &ProbeError{Kind: ProbeErrorTimeout, ExitCode: -1, Err: ctx.Err()}
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		stderr := string(exitErr.Stderr)
+		return &ProbeError{
+			Kind:     classifyStderr(stderr),
+			Stderr:   stderr,
+			ExitCode: exitErr.ExitCode(),
+			Err:      err,
+		}
+	}
+
+	// The binary could not be started
+	if errors.Is(err, exec.ErrNotFound) || errors.Is(err, fs.ErrNotExist) {
+		return &ProbeError{Kind: ProbeErrorBinaryMissing, ExitCode: -1, Err: err}
+	}
+
+	return &ProbeError{Kind: ProbeErrorUnknown, ExitCode: -1, Err: err}
+}
+
+// stderrPatterns maps ffprobe stderr fragments to error kinds, checked in order
+var stderrPatterns = []struct {
+	pattern string
+	kind    ProbeErrorKind
+}{
+	{"no such file or directory", ProbeErrorNotFound},
+	{"404 not found", ProbeErrorNotFound},
+	{"server returned 404", ProbeErrorNotFound},
+	{"connection timed out", ProbeErrorTimeout},
+	{"operation timed out", ProbeErrorTimeout},
+	{"invalid data found when processing input", ProbeErrorUnsupported},
+	{"could not find codec parameters", ProbeErrorUnsupported},
+	{"unknown format", ProbeErrorUnsupported},
+	{"decoder not found", ProbeErrorUnsupported},
+	{"protocol not found", ProbeErrorUnsupported},
+	{"not supported", ProbeErrorUnsupported},
+}
+
+// classifyStderr classifies an ffprobe failure from its stderr output
+func classifyStderr(stderr string) ProbeErrorKind {
+	lower := strings.ToLower(stderr)
+	for _, p := range stderrPatterns {
+		if strings.Contains(lower, p.pattern) {
+			return p.kind
+		}
+	}
+	return ProbeErrorUnknown
+}
@@ -0,0 +1,129 @@
+package prober
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestClassifyStderr tests classification of sample ffprobe stderr output
+func TestClassifyStderr(t *testing.T) {
+	tests := []struct {
+		name   string
+		stderr string
+		want   ProbeErrorKind
+	}{
+		{
+			name:   "missing local file",
+			stderr: "missing.mp4: No such file or directory\n",
+			want:   ProbeErrorNotFound,
+		},
+		{
+			name:   "missing remote file",
+			stderr: "[https @ 0x55d0c8a3c2c0] HTTP error 404 Not Found\nhttps://example.com/missing.mp4: Server returned 404 Not Found\n",
+			want:   ProbeErrorNotFound,
+		},
+		{
+			name:   "not media",
+			stderr: "notes.txt: Invalid data found when processing input\n",
+			want:   ProbeErrorUnsupported,
+		},
+		{
+			name:   "unknown codec",
+			stderr: "[mov,mp4,m4a,3gp,3g2,mj2 @ 0x7f8] Could not find codec parameters for stream 0 (Video: none): unknown codec\n",
+			want:   ProbeErrorUnsupported,
+		},
+		{
+			name:   "unsupported protocol",
+			stderr: "ftp://host/video.mp4: Protocol not found\n",
+			want:   ProbeErrorUnsupported,
+		},
+		{
+			name:   "network timeout",
+			stderr: "[tcp @ 0x5600] Connection to tcp://10.0.0.1:80 failed: Connection timed out\n",
+			want:   ProbeErrorTimeout,
+		},
+		{
+			name:   "unrecognized",
+			stderr: "something unexpected happened\n",
+			want:   ProbeErrorUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyStderr(tt.stderr); got != tt.want {
+				t.Errorf("classifyStderr() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// failingFFprobe writes a fake ffprobe that prints stderr and exits with code
+func failingFFprobe(t *testing.T, stderr string, code int) string {
+	t.Helper()
+
+	script := fmt.Sprintf("#!/bin/sh\nprintf '%%s' '%s' >&2\nexit %d\n", stderr, code)
+	path := filepath.Join(t.TempDir(), "ffprobe")
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatalf("Failed to write fake ffprobe: %v", err)
+	}
+	return path
+}
+
+// TestProbeError_FromFFprobe tests that a failed ffprobe run yields a classified ProbeError
+func TestProbeError_FromFFprobe(t *testing.T) {
+	p := NewProber(WithFFprobePath(failingFFprobe(t, "missing.mp4: No such file or directory", 1)))
+
+	_, err := p.Probe(context.Background(), "missing.mp4")
+
+	var probeErr *ProbeError
+	if !errors.As(err, &probeErr) {
+		t.Fatalf("Expected *ProbeError, got %T: %v", err, err)
+	}
+	if probeErr.Kind != ProbeErrorNotFound {
+		t.Errorf("Expected kind %s, got %s", ProbeErrorNotFound, probeErr.Kind)
+	}
+	if probeErr.ExitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", probeErr.ExitCode)
+	}
+	if probeErr.Stderr != "missing.mp4: No such file or directory" {
+		t.Errorf("Unexpected stderr: %q", probeErr.Stderr)
+	}
+}
+
+// TestProbeError_BinaryMissing tests the error when ffprobe cannot be run
+func TestProbeError_BinaryMissing(t *testing.T) {
+	ctx := context.Background()
+
+	// No ffprobe configured
+	p := &Prober{}
+	if _, err := p.Probe(ctx, "input.mp4"); !IsProbeErrorKind(err, ProbeErrorBinaryMissing) {
+		t.Errorf("Expected binary_missing, got %v", err)
+	}
+
+	// Configured path does not exist
+	p = NewProber(WithFFprobePath(filepath.Join(t.TempDir(), "ffprobe")))
+	if _, err := p.Probe(ctx, "input.mp4"); !IsProbeErrorKind(err, ProbeErrorBinaryMissing) {
+		t.Errorf("Expected binary_missing, got %v", err)
+	}
+}
+
+// TestProbeError_Timeout tests that a probe past its deadline is classified as a timeout
+func TestProbeError_Timeout(t *testing.T) {
+	slow := filepath.Join(t.TempDir(), "ffprobe")
+	if err := os.WriteFile(slow, []byte("#!/bin/sh\nexec sleep 10\n"), 0o755); err != nil {
+		t.Fatalf("Failed to write fake ffprobe: %v", err)
+	}
+
+	p := NewProber(WithFFprobePath(slow), WithTimeout(50*time.Millisecond))
+
+	_, err := p.Probe(context.Background(), "input.mp4")
+	if !IsProbeErrorKind(err, ProbeErrorTimeout) {
+		t.Errorf("Expected timeout, got %v", err)
+	}
+}
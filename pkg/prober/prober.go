@@ -5,9 +5,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/chicogong/media-pipeline/pkg/schemas"
@@ -16,6 +20,10 @@ import (
 // Prober probes media files using ffprobe
 type Prober struct {
 	ffprobePath string
+	timeout     time.Duration
+
+	downloader     Downloader
+	downloaderOnce sync.Once
 }
 
 // ProberOption is a functional option for Prober
@@ -28,6 +36,13 @@ func WithFFprobePath(path string) ProberOption {
 	}
 }
 
+// WithTimeout bounds each probe to d when the caller's context has no deadline
+func WithTimeout(d time.Duration) ProberOption {
+	return func(p *Prober) {
+		p.timeout = d
+	}
+}
+
 // NewProber creates a new Prober instance
 func NewProber(opts ...ProberOption) *Prober {
 	p := &Prober{
@@ -44,31 +59,154 @@ func NewProber(opts ...ProberOption) *Prober {
 // Probe probes a media file and returns its metadata
 func (p *Prober) Probe(ctx context.Context, filePath string) (*schemas.MediaInfo, error) {
 	if p.ffprobePath == "" {
-		return nil, fmt.Errorf("ffprobe not found in PATH")
+		return nil, errBinaryMissing()
+	}
+
+	ctx, cancel := p.withDeadline(ctx)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, p.ffprobePath, probeArgs(filePath)...)
+	return runFFprobe(ctx, cmd)
+}
+
+// ProbeRaw probes a media file and returns the unmodified ffprobe JSON
+// alongside the parsed metadata, for callers that need fields MediaInfo
+// does not model
+func (p *Prober) ProbeRaw(ctx context.Context, filePath string) ([]byte, *schemas.MediaInfo, error) {
+	if p.ffprobePath == "" {
+		return nil, nil, errBinaryMissing()
 	}
 
-	// Build ffprobe command
-	args := []string{
+	ctx, cancel := p.withDeadline(ctx)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, p.ffprobePath, probeArgs(filePath)...)
+	raw, err := runFFprobeRaw(ctx, cmd)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info, err := parseFFprobeOutput(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	return raw, info, nil
+}
+
+// ProbeChapters returns the chapter markers of a media file, without
+// probing its format and streams
+func (p *Prober) ProbeChapters(ctx context.Context, filePath string) ([]schemas.Chapter, error) {
+	if p.ffprobePath == "" {
+		return nil, errBinaryMissing()
+	}
+
+	ctx, cancel := p.withDeadline(ctx)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, p.ffprobePath,
+		"-v", "quiet",
+		"-print_format", "json",
+		"-show_chapters",
+		"-i", filePath,
+	)
+	raw, err := runFFprobeRaw(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	return parseChaptersOutput(raw)
+}
+
+// ProbeReader probes media read from r by piping it to ffprobe's stdin.
+//
+// Formats that need a seekable input (e.g. MP4 files with the moov atom at
+// the end) cannot be probed from a pipe. Everything read from r is therefore
+// spooled to a temporary file, and if probing the pipe fails the rest of r is
+// copied there and the file is probed instead. The fallback reads r to EOF.
+func (p *Prober) ProbeReader(ctx context.Context, r io.Reader) (*schemas.MediaInfo, error) {
+	if p.ffprobePath == "" {
+		return nil, errBinaryMissing()
+	}
+
+	ctx, cancel := p.withDeadline(ctx)
+	defer cancel()
+
+	spool, err := os.CreateTemp("", "probe-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create spool file: %w", err)
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	cmd := exec.CommandContext(ctx, p.ffprobePath, probeArgs("pipe:0")...)
+	cmd.Stdin = io.TeeReader(r, spool)
+
+	info, pipeErr := runFFprobe(ctx, cmd)
+	if pipeErr == nil {
+		return info, nil
+	}
+	if ctx.Err() != nil {
+		return nil, pipeErr
+	}
+
+	// Fall back to probing the spooled file
+	if _, err := io.Copy(spool, r); err != nil {
+		return nil, fmt.Errorf("failed to spool input: %w", err)
+	}
+	if err := spool.Sync(); err != nil {
+		return nil, fmt.Errorf("failed to spool input: %w", err)
+	}
+
+	info, err = p.Probe(ctx, spool.Name())
+	if err != nil {
+		return nil, fmt.Errorf("%w (pipe probe: %v)", err, pipeErr)
+	}
+	return info, nil
+}
+
+// withDeadline applies the configured timeout unless ctx already has a deadline
+func (p *Prober) withDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	if p.timeout <= 0 {
+		return ctx, func() {}
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, p.timeout)
+}
+
+// probeArgs builds the ffprobe arguments for an input path or URL
+func probeArgs(input string) []string {
+	return []string{
 		"-v", "quiet",                    // Suppress logs
 		"-print_format", "json",          // Output JSON
 		"-show_format",                   // Show format info
 		"-show_streams",                  // Show stream info
-		filePath,
+		"-show_chapters",                 // Show chapter markers
+		"-i", input,
 	}
+}
 
-	cmd := exec.CommandContext(ctx, p.ffprobePath, args...)
+// runFFprobe executes an ffprobe command and parses its JSON output
+func runFFprobe(ctx context.Context, cmd *exec.Cmd) (*schemas.MediaInfo, error) {
+	output, err := runFFprobeRaw(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
 
+	// Parse output
+	return parseFFprobeOutput(output)
+}
+
+// runFFprobeRaw executes an ffprobe command and returns its JSON output.
+// Failures are returned as *ProbeError.
+func runFFprobeRaw(ctx context.Context, cmd *exec.Cmd) ([]byte, error) {
 	// Execute command
 	output, err := cmd.Output()
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			return nil, fmt.Errorf("ffprobe failed: %s", string(exitErr.Stderr))
-		}
-		return nil, fmt.Errorf("ffprobe execution error: %w", err)
+		return nil, newProbeError(ctx, err)
 	}
 
-	// Parse output
-	return parseFFprobeOutput(output)
+	return output, nil
 }
 
 // findFFprobe locates ffprobe in PATH
@@ -92,8 +230,9 @@ func findFFprobe() string {
 
 // ffprobeOutput represents the raw JSON output from ffprobe
 type ffprobeOutput struct {
-	Format  ffprobeFormat   `json:"format"`
-	Streams []ffprobeStream `json:"streams"`
+	Format   ffprobeFormat    `json:"format"`
+	Streams  []ffprobeStream  `json:"streams"`
+	Chapters []ffprobeChapter `json:"chapters"`
 }
 
 type ffprobeFormat struct {
@@ -103,18 +242,25 @@ type ffprobeFormat struct {
 	Size       string `json:"size"`
 	BitRate    string `json:"bit_rate"`
 	StartTime  string `json:"start_time"`
+	Tags       map[string]string `json:"tags"`
 }
 
 type ffprobeStream struct {
 	Index       int    `json:"index"`
 	CodecType   string `json:"codec_type"`
 	CodecName   string `json:"codec_name"`
+	Profile     string `json:"profile"`
 
 	// Video fields
-	Width       int    `json:"width"`
-	Height      int    `json:"height"`
-	RFrameRate  string `json:"r_frame_rate"`
-	PixelFormat string `json:"pix_fmt"`
+	Width            int    `json:"width"`
+	Height           int    `json:"height"`
+	RFrameRate       string `json:"r_frame_rate"`
+	PixelFormat      string `json:"pix_fmt"`
+	NbFrames         string `json:"nb_frames"`
+	SAR              string `json:"sample_aspect_ratio"`
+	DAR              string `json:"display_aspect_ratio"`
+	Level            int    `json:"level"`
+	BitsPerRawSample string `json:"bits_per_raw_sample"`
 
 	// Audio fields
 	SampleRate  string `json:"sample_rate"`
@@ -123,6 +269,20 @@ type ffprobeStream struct {
 	// Common fields
 	BitRate     string `json:"bit_rate"`
 	Duration    string `json:"duration"`
+	Tags        map[string]string `json:"tags"`
+	SideData    []ffprobeSideData `json:"side_data_list"`
+}
+
+type ffprobeSideData struct {
+	SideDataType string  `json:"side_data_type"`
+	Rotation     float64 `json:"rotation"`
+}
+
+type ffprobeChapter struct {
+	ID        int               `json:"id"`
+	StartTime string            `json:"start_time"`
+	EndTime   string            `json:"end_time"`
+	Tags      map[string]string `json:"tags"`
 }
 
 // parseFFprobeOutput parses ffprobe JSON output into MediaInfo
@@ -142,6 +302,7 @@ func parseFFprobeOutput(data []byte) (*schemas.MediaInfo, error) {
 		Size:     parseInt64(output.Format.Size),
 		BitRate:  parseInt64(output.Format.BitRate),
 		StartTime: parseDuration(output.Format.StartTime),
+		Tags:      output.Format.Tags,
 	}
 
 	// Parse streams
@@ -157,6 +318,14 @@ func parseFFprobeOutput(data []byte) (*schemas.MediaInfo, error) {
 				PixelFormat: stream.PixelFormat,
 				BitRate:     parseInt64(stream.BitRate),
 				Duration:    parseDuration(stream.Duration),
+				Rotation:    parseRotation(stream),
+				FrameCount:  parseInt64(stream.NbFrames),
+				SAR:         parseAspectRatio(stream.SAR),
+				DAR:         parseAspectRatio(stream.DAR),
+				Profile:     stream.Profile,
+				Level:       parseLevel(stream.CodecName, stream.Level),
+				BitDepth:    parseBitDepth(stream.BitsPerRawSample, stream.PixelFormat),
+				Tags:        stream.Tags,
 			})
 		case "audio":
 			info.AudioStreams = append(info.AudioStreams, schemas.AudioStream{
@@ -166,13 +335,48 @@ func parseFFprobeOutput(data []byte) (*schemas.MediaInfo, error) {
 				Channels:   stream.Channels,
 				BitRate:    parseInt64(stream.BitRate),
 				Duration:   parseDuration(stream.Duration),
+				Profile:    stream.Profile,
+				Tags:       stream.Tags,
+			})
+		case "subtitle":
+			info.SubtitleStreams = append(info.SubtitleStreams, schemas.SubtitleStream{
+				Index:    stream.Index,
+				Codec:    stream.CodecName,
+				Language: stream.Tags["language"],
 			})
 		}
 	}
 
+	info.Chapters = parseChapters(output.Chapters)
+
 	return info, nil
 }
 
+// parseChaptersOutput parses the JSON output of ffprobe -show_chapters
+func parseChaptersOutput(data []byte) ([]schemas.Chapter, error) {
+	var output struct {
+		Chapters []ffprobeChapter `json:"chapters"`
+	}
+	if err := json.Unmarshal(data, &output); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %w", err)
+	}
+	return parseChapters(output.Chapters), nil
+}
+
+// parseChapters converts ffprobe chapters, or returns nil if there are none
+func parseChapters(chapters []ffprobeChapter) []schemas.Chapter {
+	var parsed []schemas.Chapter
+	for _, chapter := range chapters {
+		parsed = append(parsed, schemas.Chapter{
+			ID:    chapter.ID,
+			Start: parseDuration(chapter.StartTime),
+			End:   parseDuration(chapter.EndTime),
+			Title: chapter.Tags["title"],
+		})
+	}
+	return parsed
+}
+
 // parseDuration parses a duration string from ffprobe (seconds as float)
 func parseDuration(s string) time.Duration {
 	if s == "" {
@@ -215,6 +419,87 @@ func parseInt(s string) int {
 	return v
 }
 
+// parseRotation returns the clockwise display rotation of a video stream,
+// normalized to [0, 360). Older files carry a "rotate" tag; newer ffprobe
+// versions report a display matrix whose rotation is counter-clockwise.
+func parseRotation(stream ffprobeStream) int {
+	if rotate, ok := stream.Tags["rotate"]; ok {
+		return normalizeRotation(parseInt(rotate))
+	}
+
+	for _, sd := range stream.SideData {
+		if sd.SideDataType == "Display Matrix" {
+			return normalizeRotation(-int(sd.Rotation))
+		}
+	}
+
+	return 0
+}
+
+// normalizeRotation maps any angle in degrees to [0, 360)
+func normalizeRotation(degrees int) int {
+	degrees %= 360
+	if degrees < 0 {
+		degrees += 360
+	}
+	return degrees
+}
+
+// parseAspectRatio normalizes an ffprobe aspect ratio such as "16:9".
+// "N/A" and "0:1" (unknown) become "".
+func parseAspectRatio(s string) string {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return ""
+	}
+
+	num, err1 := strconv.Atoi(parts[0])
+	den, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil || num <= 0 || den <= 0 {
+		return ""
+	}
+
+	return s
+}
+
+// parseLevel formats an ffprobe codec level as a dotted version.
+// H.264 reports 40 for level 4.0 and HEVC reports 120 (level x 30);
+// other codecs are returned as-is. Unknown levels become "".
+func parseLevel(codec string, level int) string {
+	if level <= 0 {
+		return ""
+	}
+
+	switch codec {
+	case "h264":
+		return fmt.Sprintf("%.1f", float64(level)/10)
+	case "hevc":
+		return fmt.Sprintf("%.1f", float64(level)/30)
+	}
+	return strconv.Itoa(level)
+}
+
+// pixFmtDepthPattern matches the bit depth suffix of high bit depth pixel
+// formats such as yuv420p10le
+var pixFmtDepthPattern = regexp.MustCompile(`p(\d+)(le|be)$`)
+
+// parseBitDepth returns the bits per sample of a video stream, preferring
+// bits_per_raw_sample and falling back to the pixel format
+func parseBitDepth(bitsPerRawSample, pixFmt string) int {
+	if bits := parseInt(bitsPerRawSample); bits > 0 {
+		return bits
+	}
+
+	if m := pixFmtDepthPattern.FindStringSubmatch(pixFmt); m != nil {
+		return parseInt(m[1])
+	}
+
+	if strings.HasPrefix(pixFmt, "yuv") || strings.HasPrefix(pixFmt, "nv") {
+		return 8
+	}
+	return 0
+}
+
 // parseFrameRate parses a frame rate from ffprobe format (e.g., "30/1" or "30000/1001")
 func parseFrameRate(s string) float64 {
 	if s == "" {
@@ -1,12 +1,16 @@
 package prober
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"testing"
 	"time"
+
+	"github.com/chicogong/media-pipeline/pkg/schemas"
 )
 
 // TestProbeLocalFile tests probing a local file
@@ -76,6 +80,42 @@ func TestProbeWithContext(t *testing.T) {
 	}
 }
 
+// TestProbeReader tests probing media piped through stdin
+func TestProbeReader(t *testing.T) {
+	if !isFFprobeAvailable() {
+		t.Skip("ffprobe not available")
+	}
+
+	testFile := createTestVideoFile(t)
+	data, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+
+	p := NewProber()
+	info, err := p.ProbeReader(context.Background(), bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ProbeReader() failed: %v", err)
+	}
+
+	if len(info.VideoStreams) == 0 {
+		t.Error("Expected at least one video stream")
+	}
+	if len(info.AudioStreams) == 0 {
+		t.Error("Expected at least one audio stream")
+	}
+}
+
+// TestProbeReaderWithoutFFprobe tests the error when ffprobe is missing
+func TestProbeReaderWithoutFFprobe(t *testing.T) {
+	p := NewProber(WithFFprobePath(""))
+
+	_, err := p.ProbeReader(context.Background(), bytes.NewReader(nil))
+	if err == nil {
+		t.Error("Expected error when ffprobe is not available")
+	}
+}
+
 // TestProbeWithOptions tests prober with custom options
 func TestProbeWithOptions(t *testing.T) {
 	if !isFFprobeAvailable() {
@@ -88,6 +128,50 @@ func TestProbeWithOptions(t *testing.T) {
 	}
 }
 
+// TestProbeWithTimeout tests that WithTimeout stops a hung ffprobe
+func TestProbeWithTimeout(t *testing.T) {
+	// Stand-in for an ffprobe that hangs on a bad input
+	slow := filepath.Join(t.TempDir(), "ffprobe")
+	if err := os.WriteFile(slow, []byte("#!/bin/sh\nexec sleep 10\n"), 0o755); err != nil {
+		t.Fatalf("Failed to write fake ffprobe: %v", err)
+	}
+
+	p := NewProber(WithFFprobePath(slow), WithTimeout(50*time.Millisecond))
+
+	start := time.Now()
+	_, err := p.Probe(context.Background(), "input.mp4")
+	if err == nil {
+		t.Fatal("Expected timeout error")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Probe did not honour the timeout, took %v", elapsed)
+	}
+}
+
+// TestProbeRaw tests that the original ffprobe JSON is returned unmodified
+func TestProbeRaw(t *testing.T) {
+	p := NewProber(WithFFprobePath(echoFFprobe(t)))
+
+	raw, info, err := p.ProbeRaw(context.Background(), "/media/input.mp4")
+	if err != nil {
+		t.Fatalf("ProbeRaw() failed: %v", err)
+	}
+
+	want := `{"format":{"filename":"/media/input.mp4","format_name":"mp4"}}`
+	if string(raw) != want {
+		t.Errorf("Expected raw output %s, got %s", want, raw)
+	}
+	if info.Format.Filename != "/media/input.mp4" {
+		t.Errorf("Expected parsed filename /media/input.mp4, got %s", info.Format.Filename)
+	}
+	if info.Format.Format != "mp4" {
+		t.Errorf("Expected parsed format mp4, got %s", info.Format.Format)
+	}
+}
+
 // TestParseFFprobeOutput tests parsing ffprobe JSON output
 func TestParseFFprobeOutput(t *testing.T) {
 	// Sample ffprobe JSON output
@@ -172,6 +256,434 @@ func TestParseFFprobeOutput(t *testing.T) {
 	}
 }
 
+// TestParseFFprobeOutput_SubtitleStreams tests parsing subtitle streams
+func TestParseFFprobeOutput_SubtitleStreams(t *testing.T) {
+	jsonOutput := `{
+		"format": {
+			"filename": "movie.mkv",
+			"format_name": "matroska,webm",
+			"duration": "60.000000"
+		},
+		"streams": [
+			{
+				"index": 0,
+				"codec_type": "video",
+				"codec_name": "h264",
+				"width": 1280,
+				"height": 720,
+				"r_frame_rate": "24/1"
+			},
+			{
+				"index": 1,
+				"codec_type": "subtitle",
+				"codec_name": "subrip",
+				"tags": {
+					"language": "eng",
+					"title": "English"
+				}
+			},
+			{
+				"index": 2,
+				"codec_type": "subtitle",
+				"codec_name": "ass"
+			}
+		]
+	}`
+
+	info, err := parseFFprobeOutput([]byte(jsonOutput))
+	if err != nil {
+		t.Fatalf("parseFFprobeOutput() failed: %v", err)
+	}
+
+	if len(info.SubtitleStreams) != 2 {
+		t.Fatalf("Expected 2 subtitle streams, got %d", len(info.SubtitleStreams))
+	}
+
+	sub := info.SubtitleStreams[0]
+	if sub.Index != 1 {
+		t.Errorf("Expected index 1, got %d", sub.Index)
+	}
+	if sub.Codec != "subrip" {
+		t.Errorf("Expected codec 'subrip', got '%s'", sub.Codec)
+	}
+	if sub.Language != "eng" {
+		t.Errorf("Expected language 'eng', got '%s'", sub.Language)
+	}
+
+	if info.SubtitleStreams[1].Language != "" {
+		t.Errorf("Expected empty language for untagged stream, got '%s'", info.SubtitleStreams[1].Language)
+	}
+}
+
+// TestParseFFprobeOutput_Chapters tests parsing chapter markers
+func TestParseFFprobeOutput_Chapters(t *testing.T) {
+	jsonOutput := `{
+		"format": {
+			"filename": "audiobook.m4b",
+			"format_name": "mov,mp4,m4a,3gp,3g2,mj2",
+			"duration": "125.500000"
+		},
+		"streams": [],
+		"chapters": [
+			{
+				"id": 0,
+				"time_base": "1/1000",
+				"start": 0,
+				"start_time": "0.000000",
+				"end": 60000,
+				"end_time": "60.000000",
+				"tags": {
+					"title": "Introduction"
+				}
+			},
+			{
+				"id": 1,
+				"time_base": "1/1000",
+				"start": 60000,
+				"start_time": "60.000000",
+				"end": 125500,
+				"end_time": "125.500000"
+			}
+		]
+	}`
+
+	info, err := parseFFprobeOutput([]byte(jsonOutput))
+	if err != nil {
+		t.Fatalf("parseFFprobeOutput() failed: %v", err)
+	}
+
+	if len(info.Chapters) != 2 {
+		t.Fatalf("Expected 2 chapters, got %d", len(info.Chapters))
+	}
+
+	first := info.Chapters[0]
+	if first.Start != 0 || first.End != 60*time.Second {
+		t.Errorf("Expected chapter 0-60s, got %v-%v", first.Start, first.End)
+	}
+	if first.Title != "Introduction" {
+		t.Errorf("Expected title 'Introduction', got '%s'", first.Title)
+	}
+
+	second := info.Chapters[1]
+	if second.ID != 1 {
+		t.Errorf("Expected chapter ID 1, got %d", second.ID)
+	}
+	if second.Start != 60*time.Second || second.End != 125500*time.Millisecond {
+		t.Errorf("Expected chapter 60s-2m5.5s, got %v-%v", second.Start, second.End)
+	}
+	if second.Title != "" {
+		t.Errorf("Expected empty title, got '%s'", second.Title)
+	}
+}
+
+// TestParseChaptersOutput tests parsing the output of ffprobe -show_chapters
+func TestParseChaptersOutput(t *testing.T) {
+	jsonOutput := `{
+		"chapters": [
+			{
+				"id": 7,
+				"time_base": "1/1000000000",
+				"start": 0,
+				"start_time": "0.000000",
+				"end": 90500000000,
+				"end_time": "90.500000",
+				"tags": {
+					"title": "Opening"
+				}
+			}
+		]
+	}`
+
+	chapters, err := parseChaptersOutput([]byte(jsonOutput))
+	if err != nil {
+		t.Fatalf("parseChaptersOutput() failed: %v", err)
+	}
+
+	if len(chapters) != 1 {
+		t.Fatalf("Expected 1 chapter, got %d", len(chapters))
+	}
+	want := schemas.Chapter{ID: 7, Start: 0, End: 90500 * time.Millisecond, Title: "Opening"}
+	if chapters[0] != want {
+		t.Errorf("Expected %+v, got %+v", want, chapters[0])
+	}
+
+	// Files without chapters
+	chapters, err = parseChaptersOutput([]byte(`{"chapters": []}`))
+	if err != nil || chapters != nil {
+		t.Errorf("Expected no chapters, got %v (%v)", chapters, err)
+	}
+
+	if _, err := parseChaptersOutput([]byte("not json")); err == nil {
+		t.Error("Expected an error for invalid output")
+	}
+}
+
+// TestParseFFprobeOutput_Tags tests that format and stream tags are preserved
+func TestParseFFprobeOutput_Tags(t *testing.T) {
+	jsonOutput := `{
+		"format": {
+			"filename": "test.mp4",
+			"format_name": "mov,mp4,m4a,3gp,3g2,mj2",
+			"duration": "10.000000",
+			"tags": {
+				"title": "Sample",
+				"encoder": "Lavf60.3.100",
+				"creation_time": "2024-01-01T00:00:00.000000Z"
+			}
+		},
+		"streams": [
+			{
+				"index": 0,
+				"codec_type": "video",
+				"codec_name": "h264",
+				"width": 640,
+				"height": 360,
+				"r_frame_rate": "25/1",
+				"tags": {
+					"handler_name": "VideoHandler"
+				}
+			},
+			{
+				"index": 1,
+				"codec_type": "audio",
+				"codec_name": "aac",
+				"sample_rate": "44100",
+				"channels": 2,
+				"tags": {
+					"language": "jpn"
+				}
+			}
+		]
+	}`
+
+	info, err := parseFFprobeOutput([]byte(jsonOutput))
+	if err != nil {
+		t.Fatalf("parseFFprobeOutput() failed: %v", err)
+	}
+
+	if info.Format.Tags["title"] != "Sample" {
+		t.Errorf("Expected format title 'Sample', got '%s'", info.Format.Tags["title"])
+	}
+	if info.Format.Tags["encoder"] != "Lavf60.3.100" {
+		t.Errorf("Expected format encoder 'Lavf60.3.100', got '%s'", info.Format.Tags["encoder"])
+	}
+
+	if len(info.VideoStreams) != 1 || info.VideoStreams[0].Tags["handler_name"] != "VideoHandler" {
+		t.Errorf("Expected video handler_name tag, got %v", info.VideoStreams)
+	}
+
+	if len(info.AudioStreams) != 1 {
+		t.Fatalf("Expected 1 audio stream, got %d", len(info.AudioStreams))
+	}
+	if lang := info.AudioStreams[0].Tags["language"]; lang != "jpn" {
+		t.Errorf("Expected audio language 'jpn', got '%s'", lang)
+	}
+}
+
+// TestParseFFprobeOutput_Rotation tests reading rotation from side data and tags
+func TestParseFFprobeOutput_Rotation(t *testing.T) {
+	jsonOutput := `{
+		"format": {
+			"filename": "phone.mov",
+			"format_name": "mov,mp4,m4a,3gp,3g2,mj2",
+			"duration": "5.000000"
+		},
+		"streams": [
+			{
+				"index": 0,
+				"codec_type": "video",
+				"codec_name": "hevc",
+				"width": 1920,
+				"height": 1080,
+				"r_frame_rate": "30/1",
+				"side_data_list": [
+					{
+						"side_data_type": "Display Matrix",
+						"displaymatrix": "...",
+						"rotation": -90
+					}
+				]
+			},
+			{
+				"index": 1,
+				"codec_type": "video",
+				"codec_name": "h264",
+				"width": 1280,
+				"height": 720,
+				"r_frame_rate": "30/1",
+				"tags": {
+					"rotate": "90"
+				}
+			},
+			{
+				"index": 2,
+				"codec_type": "video",
+				"codec_name": "h264",
+				"width": 1280,
+				"height": 720,
+				"r_frame_rate": "30/1"
+			}
+		]
+	}`
+
+	info, err := parseFFprobeOutput([]byte(jsonOutput))
+	if err != nil {
+		t.Fatalf("parseFFprobeOutput() failed: %v", err)
+	}
+
+	if len(info.VideoStreams) != 3 {
+		t.Fatalf("Expected 3 video streams, got %d", len(info.VideoStreams))
+	}
+
+	expected := []int{90, 90, 0}
+	for i, want := range expected {
+		if got := info.VideoStreams[i].Rotation; got != want {
+			t.Errorf("Stream %d: expected rotation %d, got %d", i, want, got)
+		}
+	}
+}
+
+// TestParseFFprobeOutput_AspectRatioAndFrames tests frame count and SAR/DAR parsing
+func TestParseFFprobeOutput_AspectRatioAndFrames(t *testing.T) {
+	jsonOutput := `{
+		"format": {
+			"filename": "hdv.m2ts",
+			"format_name": "mpegts",
+			"duration": "10.000000"
+		},
+		"streams": [
+			{
+				"index": 0,
+				"codec_type": "video",
+				"codec_name": "h264",
+				"width": 1440,
+				"height": 1080,
+				"r_frame_rate": "25/1",
+				"nb_frames": "250",
+				"sample_aspect_ratio": "4:3",
+				"display_aspect_ratio": "16:9"
+			},
+			{
+				"index": 1,
+				"codec_type": "video",
+				"codec_name": "mjpeg",
+				"width": 640,
+				"height": 480,
+				"r_frame_rate": "0/0",
+				"nb_frames": "N/A",
+				"sample_aspect_ratio": "0:1",
+				"display_aspect_ratio": "N/A"
+			}
+		]
+	}`
+
+	info, err := parseFFprobeOutput([]byte(jsonOutput))
+	if err != nil {
+		t.Fatalf("parseFFprobeOutput() failed: %v", err)
+	}
+
+	if len(info.VideoStreams) != 2 {
+		t.Fatalf("Expected 2 video streams, got %d", len(info.VideoStreams))
+	}
+
+	anamorphic := info.VideoStreams[0]
+	if anamorphic.FrameCount != 250 {
+		t.Errorf("Expected 250 frames, got %d", anamorphic.FrameCount)
+	}
+	if anamorphic.SAR != "4:3" {
+		t.Errorf("Expected SAR '4:3', got '%s'", anamorphic.SAR)
+	}
+	if anamorphic.DAR != "16:9" {
+		t.Errorf("Expected DAR '16:9', got '%s'", anamorphic.DAR)
+	}
+
+	unknown := info.VideoStreams[1]
+	if unknown.FrameCount != 0 || unknown.SAR != "" || unknown.DAR != "" {
+		t.Errorf("Expected N/A values to be empty, got frames=%d sar=%q dar=%q",
+			unknown.FrameCount, unknown.SAR, unknown.DAR)
+	}
+}
+
+// TestParseFFprobeOutput_ProfileLevelBitDepth tests codec profile, level and bit depth parsing
+func TestParseFFprobeOutput_ProfileLevelBitDepth(t *testing.T) {
+	jsonOutput := `{
+		"format": {
+			"filename": "test.mp4",
+			"format_name": "mov,mp4,m4a,3gp,3g2,mj2",
+			"duration": "10.000000"
+		},
+		"streams": [
+			{
+				"index": 0,
+				"codec_type": "video",
+				"codec_name": "h264",
+				"profile": "High",
+				"level": 40,
+				"width": 1920,
+				"height": 1080,
+				"r_frame_rate": "30/1",
+				"pix_fmt": "yuv420p",
+				"bits_per_raw_sample": "8"
+			},
+			{
+				"index": 1,
+				"codec_type": "video",
+				"codec_name": "hevc",
+				"profile": "Main 10",
+				"level": 150,
+				"width": 3840,
+				"height": 2160,
+				"r_frame_rate": "24/1",
+				"pix_fmt": "yuv420p10le"
+			},
+			{
+				"index": 2,
+				"codec_type": "audio",
+				"codec_name": "aac",
+				"profile": "LC",
+				"sample_rate": "48000",
+				"channels": 2
+			}
+		]
+	}`
+
+	info, err := parseFFprobeOutput([]byte(jsonOutput))
+	if err != nil {
+		t.Fatalf("parseFFprobeOutput() failed: %v", err)
+	}
+
+	if len(info.VideoStreams) != 2 {
+		t.Fatalf("Expected 2 video streams, got %d", len(info.VideoStreams))
+	}
+
+	h264 := info.VideoStreams[0]
+	if h264.Profile != "High" {
+		t.Errorf("Expected profile 'High', got '%s'", h264.Profile)
+	}
+	if h264.Level != "4.0" {
+		t.Errorf("Expected level '4.0', got '%s'", h264.Level)
+	}
+	if h264.BitDepth != 8 {
+		t.Errorf("Expected bit depth 8, got %d", h264.BitDepth)
+	}
+
+	// Bit depth derived from the pixel format when bits_per_raw_sample is absent
+	hevc := info.VideoStreams[1]
+	if hevc.Level != "5.0" {
+		t.Errorf("Expected level '5.0', got '%s'", hevc.Level)
+	}
+	if hevc.BitDepth != 10 {
+		t.Errorf("Expected bit depth 10, got %d", hevc.BitDepth)
+	}
+
+	if len(info.AudioStreams) != 1 {
+		t.Fatalf("Expected 1 audio stream, got %d", len(info.AudioStreams))
+	}
+	if info.AudioStreams[0].Profile != "LC" {
+		t.Errorf("Expected audio profile 'LC', got '%s'", info.AudioStreams[0].Profile)
+	}
+}
+
 // TestParseInvalidJSON tests error handling for invalid JSON
 func TestParseInvalidJSON(t *testing.T) {
 	_, err := parseFFprobeOutput([]byte("invalid json"))
@@ -0,0 +1,75 @@
+package prober
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/chicogong/media-pipeline/pkg/executor"
+	"github.com/chicogong/media-pipeline/pkg/schemas"
+	"github.com/chicogong/media-pipeline/pkg/storage"
+)
+
+// Downloader fetches a remote URI into a local directory
+// Satisfied by *executor.StorageManager
+type Downloader interface {
+	DownloadInput(ctx context.Context, uri, tempDir string) (string, error)
+}
+
+// WithDownloader sets the downloader ProbeURI uses for URIs ffprobe cannot read directly
+func WithDownloader(d Downloader) ProberOption {
+	return func(p *Prober) {
+		p.downloader = d
+	}
+}
+
+// ProbeURI probes media at a URI. Local paths and file:// URIs are probed in
+// place, HTTP(S) URLs (including presigned S3 URLs) are handed to ffprobe
+// directly, and other schemes such as s3:// are downloaded to a temporary
+// directory first.
+func (p *Prober) ProbeURI(ctx context.Context, uri string) (*schemas.MediaInfo, error) {
+	if p.ffprobePath == "" {
+		return nil, errBinaryMissing()
+	}
+
+	if !strings.Contains(uri, "://") {
+		return p.Probe(ctx, uri)
+	}
+
+	scheme, path, err := storage.ParseURI(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	switch scheme {
+	case "file":
+		return p.Probe(ctx, path)
+	case "http", "https":
+		return p.Probe(ctx, uri)
+	}
+
+	tempDir, err := os.MkdirTemp("", "probe-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	localPath, err := p.getDownloader().DownloadInput(ctx, uri, tempDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s for probing: %w", uri, err)
+	}
+
+	return p.Probe(ctx, localPath)
+}
+
+// getDownloader returns the configured downloader, creating a StorageManager
+// on first use if none was set
+func (p *Prober) getDownloader() Downloader {
+	p.downloaderOnce.Do(func() {
+		if p.downloader == nil {
+			p.downloader = executor.NewStorageManager()
+		}
+	})
+	return p.downloader
+}
@@ -0,0 +1,111 @@
+package prober
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// echoFFprobe writes a fake ffprobe that reports its input as the filename
+func echoFFprobe(t *testing.T) string {
+	t.Helper()
+
+	script := "#!/bin/sh\nfor arg; do last=$arg; done\n" +
+		`printf '{"format":{"filename":"%s","format_name":"mp4"}}' "$last"` + "\n"
+	path := filepath.Join(t.TempDir(), "ffprobe")
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatalf("Failed to write fake ffprobe: %v", err)
+	}
+	return path
+}
+
+// recordingDownloader records downloads and returns a fixed local path
+type recordingDownloader struct {
+	uris []string
+}
+
+func (d *recordingDownloader) DownloadInput(ctx context.Context, uri, tempDir string) (string, error) {
+	d.uris = append(d.uris, uri)
+	return filepath.Join(tempDir, "downloaded.mp4"), nil
+}
+
+// TestProbeURI_SchemeRouting tests which URIs are probed directly and which are downloaded first
+func TestProbeURI_SchemeRouting(t *testing.T) {
+	downloader := &recordingDownloader{}
+	p := NewProber(WithFFprobePath(echoFFprobe(t)), WithDownloader(downloader))
+	ctx := context.Background()
+
+	tests := []struct {
+		uri          string
+		wantInput    string
+		wantDownload bool
+	}{
+		{uri: "/media/local.mp4", wantInput: "/media/local.mp4"},
+		{uri: "file:///media/local.mp4", wantInput: "/media/local.mp4"},
+		{uri: "https://cdn.example.com/video.mp4", wantInput: "https://cdn.example.com/video.mp4"},
+		{uri: "http://cdn.example.com/video.mp4", wantInput: "http://cdn.example.com/video.mp4"},
+		{uri: "s3://bucket/video.mp4", wantDownload: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			downloader.uris = nil
+
+			info, err := p.ProbeURI(ctx, tt.uri)
+			if err != nil {
+				t.Fatalf("ProbeURI() failed: %v", err)
+			}
+
+			if tt.wantDownload {
+				if len(downloader.uris) != 1 || downloader.uris[0] != tt.uri {
+					t.Fatalf("Expected %s to be downloaded, got %v", tt.uri, downloader.uris)
+				}
+				if filepath.Base(info.Format.Filename) != "downloaded.mp4" {
+					t.Errorf("Expected the downloaded file to be probed, got %s", info.Format.Filename)
+				}
+				return
+			}
+
+			if len(downloader.uris) != 0 {
+				t.Errorf("Expected no download, got %v", downloader.uris)
+			}
+			if info.Format.Filename != tt.wantInput {
+				t.Errorf("Expected ffprobe input %s, got %s", tt.wantInput, info.Format.Filename)
+			}
+		})
+	}
+}
+
+// TestProbeURI_HTTP tests probing a remote HTTP URL
+func TestProbeURI_HTTP(t *testing.T) {
+	if !isFFprobeAvailable() {
+		t.Skip("ffprobe not available")
+	}
+
+	const url = "https://test-videos.co.uk/vids/bigbuckbunny/mp4/h264/360/Big_Buck_Bunny_360_10s_1MB.mp4"
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Head(url)
+	if err != nil {
+		t.Skipf("network not available: %v", err)
+	}
+	resp.Body.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	info, err := NewProber().ProbeURI(ctx, url)
+	if err != nil {
+		t.Fatalf("ProbeURI() failed: %v", err)
+	}
+
+	if len(info.VideoStreams) == 0 {
+		t.Error("Expected at least one video stream")
+	}
+	if info.Format.Duration <= 0 {
+		t.Error("Expected positive duration")
+	}
+}
@@ -3,6 +3,7 @@ package schemas
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"regexp"
 	"strconv"
 	"strings"
@@ -19,11 +20,17 @@ func (d Duration) MarshalJSON() ([]byte, error) {
 	return json.Marshal(d.String())
 }
 
-// UnmarshalJSON parses Duration from multiple formats
+// UnmarshalJSON parses Duration from any format accepted by ParseDuration,
+// or from a JSON number of seconds
 func (d *Duration) UnmarshalJSON(b []byte) error {
 	var s string
 	if err := json.Unmarshal(b, &s); err != nil {
-		return err
+		var seconds float64
+		if numErr := json.Unmarshal(b, &seconds); numErr != nil {
+			return fmt.Errorf("invalid duration %s: must be a string or number of seconds", b)
+		}
+		d.Duration = time.Duration(seconds * float64(time.Second))
+		return nil
 	}
 
 	parsed, err := ParseDuration(s)
@@ -36,20 +43,30 @@ func (d *Duration) UnmarshalJSON(b []byte) error {
 }
 
 // ParseDuration parses duration from multiple formats:
+// - Seconds: "90", "2.5"
 // - Go duration: "1h30m", "90s"
-// - Timecode: "01:30:00", "00:05:30.500"
+// - Timecode: "05:30", "01:30:00", "00:05:30.500"
 // - ISO 8601: "PT1H30M"
 func ParseDuration(s string) (time.Duration, error) {
 	s = strings.TrimSpace(s)
 
-	// Try Go duration format first
+	// Try bare seconds first
+	if secondsPattern.MatchString(s) {
+		seconds, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q: %w", s, err)
+		}
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+
+	// Try Go duration format
 	if d, err := time.ParseDuration(s); err == nil {
 		return d, nil
 	}
 
-	// Try timecode format (HH:MM:SS or HH:MM:SS.mmm)
-	if d, err := parseTimecode(s); err == nil {
-		return d, nil
+	// Try timecode format (MM:SS, HH:MM:SS or HH:MM:SS.mmm)
+	if timecodePattern.MatchString(s) {
+		return parseTimecode(s)
 	}
 
 	// Try ISO 8601 format (PT1H30M)
@@ -57,13 +74,81 @@ func ParseDuration(s string) (time.Duration, error) {
 		return parseISO8601(s)
 	}
 
-	return 0, fmt.Errorf("invalid duration format: %s", s)
+	return 0, fmt.Errorf("invalid duration %q: expected seconds (90), MM:SS, HH:MM:SS[.mmm], a Go duration (5m) or ISO 8601 (PT5M)", s)
+}
+
+// secondsPattern matches a bare number of seconds
+var secondsPattern = regexp.MustCompile(`^\d+(?:\.\d+)?$`)
+
+// timecodePattern matches "MM:SS", "HH:MM:SS", each with optional
+// milliseconds
+var timecodePattern = regexp.MustCompile(`^(?:(\d{1,2}):)?(\d{1,2}):(\d{2})(?:\.(\d{1,3}))?$`)
+
+// iso8601Pattern matches ISO 8601 time durations: "PT1H30M", "PT90S"
+var iso8601Pattern = regexp.MustCompile(`^PT(?:\d+H)?(?:\d+M)?(?:\d+S)?$`)
+
+// smpteTimecodePattern matches SMPTE timecodes: "HH:MM:SS:FF" (non-drop-frame)
+// or "HH:MM:SS;FF" / "HH:MM:SS,FF" (drop-frame)
+var smpteTimecodePattern = regexp.MustCompile(`^(\d{1,2}):(\d{2}):(\d{2})([:;,])(\d{2,3})$`)
+
+// IsSMPTETimecode reports whether s is a frame-based SMPTE timecode
+func IsSMPTETimecode(s string) bool {
+	return smpteTimecodePattern.MatchString(strings.TrimSpace(s))
 }
 
-// parseTimecode parses "HH:MM:SS" or "HH:MM:SS.mmm" format
+// ParseTimecode parses an SMPTE timecode at the given frame rate.
+// "HH:MM:SS:FF" is non-drop-frame; "HH:MM:SS;FF" (or ",") is drop-frame,
+// which is only defined for 29.97 and 59.94 fps. Timecodes without a frame
+// field are parsed as by ParseDuration.
+func ParseTimecode(s string, fps float64) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+
+	matches := smpteTimecodePattern.FindStringSubmatch(s)
+	if matches == nil {
+		return ParseDuration(s)
+	}
+	if fps <= 0 {
+		return 0, fmt.Errorf("timecode %s: frame rate must be positive", s)
+	}
+
+	hours, _ := strconv.Atoi(matches[1])
+	minutes, _ := strconv.Atoi(matches[2])
+	seconds, _ := strconv.Atoi(matches[3])
+	frames, _ := strconv.Atoi(matches[5])
+	dropFrame := matches[4] != ":"
+
+	// Timecode counts frames at the nominal integer rate (30 for 29.97)
+	nominal := int(math.Round(fps))
+	if minutes > 59 || seconds > 59 {
+		return 0, fmt.Errorf("timecode %s: minutes and seconds must be below 60", s)
+	}
+	if frames >= nominal {
+		return 0, fmt.Errorf("timecode %s: frame %d out of range for %g fps", s, frames, fps)
+	}
+
+	totalFrames := ((hours*60+minutes)*60+seconds)*nominal + frames
+
+	if dropFrame {
+		// Drop-frame skips the first frame numbers of every minute except
+		// each tenth minute: 2 per minute at 29.97, 4 at 59.94
+		if nominal%30 != 0 || math.Abs(fps-float64(nominal)*1000/1001) > 0.01 {
+			return 0, fmt.Errorf("timecode %s: drop-frame requires 29.97 or 59.94 fps, got %g", s, fps)
+		}
+		dropped := nominal / 15
+
+		totalMinutes := hours*60 + minutes
+		if seconds == 0 && frames < dropped && minutes%10 != 0 {
+			return 0, fmt.Errorf("timecode %s: frame number does not exist in drop-frame timecode", s)
+		}
+		totalFrames -= dropped * (totalMinutes - totalMinutes/10)
+	}
+
+	return time.Duration(math.Round(float64(totalFrames) / fps * float64(time.Second))), nil
+}
+
+// parseTimecode parses "MM:SS", "HH:MM:SS" or "HH:MM:SS.mmm" format
 func parseTimecode(s string) (time.Duration, error) {
-	re := regexp.MustCompile(`^(\d{1,2}):(\d{2}):(\d{2})(?:\.(\d{1,3}))?$`)
-	matches := re.FindStringSubmatch(s)
+	matches := timecodePattern.FindStringSubmatch(s)
 	if matches == nil {
 		return 0, fmt.Errorf("invalid timecode format")
 	}
@@ -71,6 +156,12 @@ func parseTimecode(s string) (time.Duration, error) {
 	hours, _ := strconv.Atoi(matches[1])
 	minutes, _ := strconv.Atoi(matches[2])
 	seconds, _ := strconv.Atoi(matches[3])
+	if seconds >= 60 {
+		return 0, fmt.Errorf("invalid duration %q: seconds must be below 60", s)
+	}
+	if matches[1] != "" && minutes >= 60 {
+		return 0, fmt.Errorf("invalid duration %q: minutes must be below 60", s)
+	}
 
 	d := time.Duration(hours)*time.Hour +
 		time.Duration(minutes)*time.Minute +
@@ -91,8 +182,8 @@ func parseTimecode(s string) (time.Duration, error) {
 
 // parseISO8601 parses "PT1H30M" format
 func parseISO8601(s string) (time.Duration, error) {
-	if !strings.HasPrefix(s, "PT") {
-		return 0, fmt.Errorf("invalid ISO 8601 format")
+	if !iso8601Pattern.MatchString(s) || s == "PT" {
+		return 0, fmt.Errorf("invalid duration %q: malformed ISO 8601 duration", s)
 	}
 
 	s = s[2:] // Remove "PT"
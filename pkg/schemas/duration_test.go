@@ -2,6 +2,7 @@ package schemas
 
 import (
 	"encoding/json"
+	"math"
 	"testing"
 	"time"
 )
@@ -14,10 +15,27 @@ func TestParseDuration(t *testing.T) {
 		wantErr bool
 	}{
 		{name: "go_duration", in: "1h30m", want: 90 * time.Minute},
+		{name: "go_duration_minutes", in: "5m", want: 5 * time.Minute},
+		{name: "seconds", in: "90", want: 90 * time.Second},
+		{name: "fractional_seconds", in: "2.5", want: 2500 * time.Millisecond},
+		{name: "seconds_padded", in: " 30 ", want: 30 * time.Second},
+		{name: "timecode_ms", in: "05:30", want: 5*time.Minute + 30*time.Second},
+		{name: "timecode_ms_long", in: "90:00", want: 90 * time.Minute},
+		{name: "timecode_ms_millis", in: "05:30.250", want: 5*time.Minute + 30250*time.Millisecond},
 		{name: "timecode_hms", in: "01:02:03", want: time.Hour + 2*time.Minute + 3*time.Second},
+		{name: "timecode_millis", in: "00:05:30.500", want: 5*time.Minute + 30500*time.Millisecond},
 		{name: "timecode_millis_padding", in: "00:00:01.5", want: 1500 * time.Millisecond},
 		{name: "iso8601", in: "PT1H30M", want: 90 * time.Minute},
 		{name: "invalid", in: "nope", wantErr: true},
+		{name: "empty", in: "", wantErr: true},
+		{name: "negative_seconds", in: "-5", wantErr: true},
+		{name: "seconds_out_of_range", in: "05:75", wantErr: true},
+		{name: "minutes_out_of_range", in: "01:60:00", wantErr: true},
+		{name: "too_many_fields", in: "1:02:03:04:05", wantErr: true},
+		{name: "millis_too_precise", in: "00:00:01.5000", wantErr: true},
+		{name: "trailing_unit", in: "90x", wantErr: true},
+		{name: "iso8601_malformed", in: "PT5X", wantErr: true},
+		{name: "iso8601_empty", in: "PT", wantErr: true},
 	}
 
 	for _, tc := range tests {
@@ -64,3 +82,160 @@ func TestDuration_JSONRoundTrip(t *testing.T) {
 	}
 }
 
+func TestDuration_UnmarshalJSONFormats(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: `"10s"`, want: 10 * time.Second},
+		{in: `"2m30s"`, want: 150 * time.Second},
+		{in: `"1h"`, want: time.Hour},
+		{in: `"PT1H30M"`, want: 90 * time.Minute},
+		{in: `"00:05:00"`, want: 5 * time.Minute},
+		{in: `"05:30"`, want: 330 * time.Second},
+		{in: `"90"`, want: 90 * time.Second},
+		{in: `90`, want: 90 * time.Second},
+		{in: `1.5`, want: 1500 * time.Millisecond},
+		{in: `"soon"`, wantErr: true},
+		{in: `true`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			var d Duration
+			err := json.Unmarshal([]byte(tt.in), &d)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unmarshal err=%v wantErr=%v", err, tt.wantErr)
+			}
+			if err == nil && d.Duration != tt.want {
+				t.Fatalf("duration mismatch: got=%v want=%v", d.Duration, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobSpec_DurationFieldsJSON(t *testing.T) {
+	in := `{
+		"timeout": "2m30s",
+		"inputs": [{"id": "video", "source": "file://in.mp4", "start_offset": "10s", "duration": 30}],
+		"limits": {"max_duration": "1h"}
+	}`
+
+	var spec JobSpec
+	if err := json.Unmarshal([]byte(in), &spec); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+
+	if spec.Timeout.Duration != 150*time.Second {
+		t.Errorf("timeout mismatch: got=%v", spec.Timeout.Duration)
+	}
+	if spec.Inputs[0].StartOffset.Duration != 10*time.Second {
+		t.Errorf("start_offset mismatch: got=%v", spec.Inputs[0].StartOffset.Duration)
+	}
+	if spec.Inputs[0].Duration.Duration != 30*time.Second {
+		t.Errorf("duration mismatch: got=%v", spec.Inputs[0].Duration.Duration)
+	}
+	if spec.Limits.MaxDuration.Duration != time.Hour {
+		t.Errorf("max_duration mismatch: got=%v", spec.Limits.MaxDuration.Duration)
+	}
+
+	// Durations are written as human-readable strings
+	out, err := json.Marshal(&spec)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+
+	var fields struct {
+		Timeout string `json:"timeout"`
+		Limits  struct {
+			MaxDuration string `json:"max_duration"`
+		} `json:"limits"`
+	}
+	if err := json.Unmarshal(out, &fields); err != nil {
+		t.Fatalf("unmarshal of marshaled spec failed: %v", err)
+	}
+	if fields.Timeout != "2m30s" {
+		t.Errorf("expected timeout \"2m30s\", got %q", fields.Timeout)
+	}
+	if fields.Limits.MaxDuration != "1h0m0s" {
+		t.Errorf("expected max_duration \"1h0m0s\", got %q", fields.Limits.MaxDuration)
+	}
+}
+
+func TestParseTimecode(t *testing.T) {
+	const ntsc = 30000.0 / 1001.0
+
+	frames := func(n int, fps float64) time.Duration {
+		return time.Duration(math.Round(float64(n) / fps * float64(time.Second)))
+	}
+
+	tests := []struct {
+		name    string
+		in      string
+		fps     float64
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "ndf 25fps", in: "00:00:01:12", fps: 25, want: 1480 * time.Millisecond},
+		{name: "ndf 30fps", in: "01:00:00:00", fps: 30, want: time.Hour},
+		{name: "ndf 29.97 runs slow", in: "01:00:00:00", fps: 29.97, want: frames(108000, 29.97)},
+		{name: "df first frame", in: "00:00:00;00", fps: 29.97, want: 0},
+		{name: "df last frame of first minute", in: "00:00:59;29", fps: 29.97, want: frames(1799, 29.97)},
+		{name: "df first frame of minute 1 skips 00 and 01", in: "00:01:00;02", fps: 29.97, want: frames(1800, 29.97)},
+		{name: "df minute 10 keeps frames 00 and 01", in: "00:10:00;00", fps: 29.97, want: frames(17982, 29.97)},
+		{name: "df one hour matches wall clock", in: "01:00:00;00", fps: 29.97, want: frames(107892, 29.97)},
+		{name: "df comma separator", in: "01:00:00,00", fps: 29.97, want: frames(107892, 29.97)},
+		{name: "df exact ntsc rate", in: "00:01:00;02", fps: ntsc, want: frames(1800, ntsc)},
+		{name: "df 59.94 drops four", in: "00:01:00;04", fps: 59.94, want: frames(3600, 59.94)},
+		{name: "falls back without frames", in: "00:01:30", fps: 25, want: 90 * time.Second},
+
+		{name: "df skipped frame 00", in: "00:01:00;00", fps: 29.97, wantErr: true},
+		{name: "df skipped frame 01", in: "00:01:00;01", fps: 29.97, wantErr: true},
+		{name: "df 59.94 skipped frame 03", in: "00:01:00;03", fps: 59.94, wantErr: true},
+		{name: "df at integer rate", in: "00:00:01;00", fps: 30, wantErr: true},
+		{name: "df at 25fps", in: "00:00:01;00", fps: 25, wantErr: true},
+		{name: "frame out of range", in: "00:00:01:30", fps: 29.97, wantErr: true},
+		{name: "seconds out of range", in: "00:00:60:00", fps: 25, wantErr: true},
+		{name: "missing frame rate", in: "00:00:01:00", fps: 0, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTimecode(tt.in, tt.fps)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTimecode(%q, %v) err=%v wantErr=%v", tt.in, tt.fps, err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Fatalf("ParseTimecode(%q, %v) = %v, want %v", tt.in, tt.fps, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInput_UnmarshalJSONTimecode(t *testing.T) {
+	in := `{"id": "video", "source": "file://in.mxf", "frame_rate": 29.97, "start_offset": "00:01:00;02", "duration": "10s"}`
+
+	var input Input
+	if err := json.Unmarshal([]byte(in), &input); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+
+	want, _ := ParseTimecode("00:01:00;02", 29.97)
+	if input.StartOffset == nil || input.StartOffset.Duration != want {
+		t.Errorf("start_offset mismatch: got=%v want=%v", input.StartOffset, want)
+	}
+	if input.Duration == nil || input.Duration.Duration != 10*time.Second {
+		t.Errorf("duration mismatch: got=%v", input.Duration)
+	}
+	if input.ID != "video" || input.FrameRate != 29.97 {
+		t.Errorf("other fields not decoded: %+v", input)
+	}
+
+	// SMPTE timecodes need a frame rate
+	noRate := `{"id": "video", "source": "file://in.mxf", "start_offset": "00:00:10:00"}`
+	var unrated Input
+	if err := json.Unmarshal([]byte(noRate), &unrated); err == nil {
+		t.Error("expected error for timecode without frame_rate")
+	}
+}
@@ -0,0 +1,139 @@
+package schemas
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// codecSet is a set of canonical codec names. A nil set allows any known
+// codec; an empty set allows none.
+type codecSet map[string]bool
+
+func newCodecSet(codecs ...string) codecSet {
+	set := codecSet{}
+	for _, codec := range codecs {
+		set[codec] = true
+	}
+	return set
+}
+
+// containerCodecs lists the video and audio codecs each known container
+// can hold
+var containerCodecs = map[string]struct{ video, audio codecSet }{
+	"mp4":  {newCodecSet("h264", "hevc", "av1", "vp9", "mpeg4"), newCodecSet("aac", "mp3", "ac3", "eac3", "opus", "flac", "alac")},
+	"mov":  {newCodecSet("h264", "hevc", "prores", "mpeg4", "mjpeg"), newCodecSet("aac", "alac", "mp3", "ac3", "pcm_s16le", "pcm_s24le")},
+	"mkv":  {nil, nil},
+	"webm": {newCodecSet("vp8", "vp9", "av1"), newCodecSet("opus", "vorbis")},
+	"avi":  {newCodecSet("h264", "mpeg4", "mjpeg"), newCodecSet("mp3", "ac3", "pcm_s16le")},
+	"flv":  {newCodecSet("h264"), newCodecSet("aac", "mp3")},
+	"ts":   {newCodecSet("h264", "hevc", "mpeg2video"), newCodecSet("aac", "mp3", "ac3", "eac3")},
+	"ogg":  {newCodecSet("theora"), newCodecSet("vorbis", "opus", "flac")},
+	"gif":  {newCodecSet("gif"), newCodecSet()},
+	"mp3":  {newCodecSet(), newCodecSet("mp3")},
+	"m4a":  {newCodecSet(), newCodecSet("aac", "alac")},
+	"wav":  {newCodecSet(), newCodecSet("pcm_s16le", "pcm_s24le")},
+	"flac": {newCodecSet(), newCodecSet("flac")},
+}
+
+// containerAliases maps alternative container names to the known ones
+var containerAliases = map[string]string{
+	"matroska": "mkv",
+	"mpegts":   "ts",
+	"oga":      "ogg",
+}
+
+// videoCodecs and audioCodecs are the known canonical codec names
+var (
+	videoCodecs = newCodecSet("h264", "hevc", "vp8", "vp9", "av1", "mpeg4", "mpeg2video", "prores", "mjpeg", "theora", "gif")
+	audioCodecs = newCodecSet("aac", "mp3", "opus", "vorbis", "flac", "alac", "ac3", "eac3", "pcm_s16le", "pcm_s24le")
+)
+
+// codecAliases maps alternative codec and FFmpeg encoder names to the
+// canonical codec
+var codecAliases = map[string]string{
+	"avc":        "h264",
+	"libx264":    "h264",
+	"h265":       "hevc",
+	"libx265":    "hevc",
+	"libvpx":     "vp8",
+	"libvpx-vp9": "vp9",
+	"libaom-av1": "av1",
+	"libsvtav1":  "av1",
+	"librav1e":   "av1",
+	"prores_ks":  "prores",
+	"libtheora":  "theora",
+	"libfdk_aac": "aac",
+	"libmp3lame": "mp3",
+	"libopus":    "opus",
+	"libvorbis":  "vorbis",
+}
+
+// codecCopy keeps a stream's codec; it is accepted by any container
+const codecCopy = "copy"
+
+// ValidateFormat checks the output's container and codec names against the
+// known ones, and that the container can hold the codecs
+func (o Output) ValidateFormat() error {
+	container := strings.ToLower(o.Format)
+	if alias, ok := containerAliases[container]; ok {
+		container = alias
+	}
+	if container != "" {
+		if _, ok := containerCodecs[container]; !ok {
+			return fmt.Errorf("unknown format '%s' (known: %s)", o.Format, strings.Join(knownContainers(), ", "))
+		}
+	}
+
+	if o.Codec == nil {
+		return nil
+	}
+	if o.Codec.Video != nil && o.Codec.Video.Codec != "" {
+		if err := checkCodec(container, "video", o.Codec.Video.Codec, videoCodecs); err != nil {
+			return err
+		}
+	}
+	if o.Codec.Audio != nil && o.Codec.Audio.Codec != "" {
+		if err := checkCodec(container, "audio", o.Codec.Audio.Codec, audioCodecs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkCodec checks that name is a known codec of the given kind and, if
+// container is set, that the container can hold it
+func checkCodec(container, kind, name string, known codecSet) error {
+	codec := strings.ToLower(name)
+	if codec == codecCopy {
+		return nil
+	}
+	if alias, ok := codecAliases[codec]; ok {
+		codec = alias
+	}
+	if !known[codec] {
+		return fmt.Errorf("unknown %s codec '%s'", kind, name)
+	}
+	if container == "" {
+		return nil
+	}
+
+	allowed := containerCodecs[container].video
+	if kind == "audio" {
+		allowed = containerCodecs[container].audio
+	}
+	if allowed != nil && !allowed[codec] {
+		return fmt.Errorf("%s codec '%s' is not supported in %s", kind, name, container)
+	}
+	return nil
+}
+
+// knownContainers returns the known container names, sorted
+func knownContainers() []string {
+	names := make([]string, 0, len(containerCodecs))
+	for name := range containerCodecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
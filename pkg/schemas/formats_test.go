@@ -0,0 +1,69 @@
+package schemas
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOutput_ValidateFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		video   string
+		audio   string
+		wantErr string
+	}{
+		{name: "no format", video: "libx264", audio: "aac"},
+		{name: "mp4 with h264 and aac", format: "mp4", video: "h264", audio: "aac"},
+		{name: "encoder names", format: "webm", video: "libvpx-vp9", audio: "libopus"},
+		{name: "alias and case", format: "Matroska", video: "H265", audio: "vorbis"},
+		{name: "stream copy", format: "mp4", video: "copy", audio: "copy"},
+		{name: "unknown container", format: "mp5", wantErr: "unknown format 'mp5'"},
+		{name: "unknown video codec", format: "mp4", video: "h246", wantErr: "unknown video codec 'h246'"},
+		{name: "unknown audio codec", audio: "acc", wantErr: "unknown audio codec 'acc'"},
+		{name: "vorbis in mp4", format: "mp4", video: "h264", audio: "vorbis", wantErr: "audio codec 'vorbis' is not supported in mp4"},
+		{name: "h264 in webm", format: "webm", video: "libx264", wantErr: "video codec 'libx264' is not supported in webm"},
+		{name: "video in audio container", format: "mp3", video: "h264", wantErr: "video codec 'h264' is not supported in mp3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := Output{ID: "out", Destination: "s3://bucket/out", Format: tt.format}
+			if tt.video != "" || tt.audio != "" {
+				output.Codec = &CodecParams{}
+				if tt.video != "" {
+					output.Codec.Video = &VideoCodec{Codec: tt.video}
+				}
+				if tt.audio != "" {
+					output.Codec.Audio = &AudioCodec{Codec: tt.audio}
+				}
+			}
+
+			err := output.ValidateFormat()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestJobSpec_ValidateOutputFormat(t *testing.T) {
+	spec := &JobSpec{
+		Inputs: []Input{{ID: "video", Source: "s3://bucket/in.mp4"}},
+		Outputs: []Output{
+			{ID: "video", Destination: "s3://bucket/out.mp4", Format: "mp4",
+				Codec: &CodecParams{Audio: &AudioCodec{Codec: "libvorbis"}}},
+		},
+	}
+
+	err := spec.Validate()
+	if err == nil || !strings.Contains(err.Error(), "output 'video': audio codec 'libvorbis' is not supported in mp4") {
+		t.Errorf("expected an incompatible codec error, got: %v", err)
+	}
+}
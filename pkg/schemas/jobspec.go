@@ -1,7 +1,10 @@
 package schemas
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -18,6 +21,9 @@ type JobSpec struct {
 	Priority int       `json:"priority,omitempty"`
 	Timeout  *Duration `json:"timeout,omitempty"`
 
+	// Jobs that must complete successfully before this job starts
+	DependsOn []string `json:"depends_on,omitempty"`
+
 	// Core Specification
 	Inputs     []Input     `json:"inputs"`
 	Operations []Operation `json:"operations"`
@@ -38,10 +44,59 @@ type Input struct {
 	Format      string            `json:"format,omitempty"`
 	StartOffset *Duration         `json:"start_offset,omitempty"`
 	Duration    *Duration         `json:"duration,omitempty"`
+	FrameRate   float64           `json:"frame_rate,omitempty"` // Used to parse SMPTE timecodes in StartOffset and Duration
 	Metadata    map[string]string `json:"metadata,omitempty"`
 }
 
-// Operation represents a processing operation
+// UnmarshalJSON parses an Input, interpreting SMPTE timecodes in
+// start_offset and duration at the input's frame_rate
+func (in *Input) UnmarshalJSON(b []byte) error {
+	type inputAlias Input
+	aux := struct {
+		*inputAlias
+		StartOffset json.RawMessage `json:"start_offset,omitempty"`
+		Duration    json.RawMessage `json:"duration,omitempty"`
+	}{inputAlias: (*inputAlias)(in)}
+
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+
+	var err error
+	if in.StartOffset, err = parseFrameDuration(aux.StartOffset, in.FrameRate); err != nil {
+		return fmt.Errorf("input %s: start_offset: %w", in.ID, err)
+	}
+	if in.Duration, err = parseFrameDuration(aux.Duration, in.FrameRate); err != nil {
+		return fmt.Errorf("input %s: duration: %w", in.ID, err)
+	}
+	return nil
+}
+
+// parseFrameDuration decodes a JSON duration, parsing SMPTE timecodes at fps
+func parseFrameDuration(raw json.RawMessage, fps float64) (*Duration, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil && IsSMPTETimecode(s) {
+		d, err := ParseTimecode(s, fps)
+		if err != nil {
+			return nil, err
+		}
+		return &Duration{Duration: d}, nil
+	}
+
+	var d Duration
+	if err := json.Unmarshal(raw, &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// Operation represents a processing operation. It names its inputs in
+// exactly one of Input, for a single input, and Inputs, for operators taking
+// several in order, e.g. the base video and the logo of an overlay.
 type Operation struct {
 	Op     string                 `json:"op"`
 	Input  string                 `json:"input,omitempty"`
@@ -50,13 +105,62 @@ type Operation struct {
 	Params map[string]interface{} `json:"params,omitempty"`
 }
 
+// InputRefs returns the operation's input references in the order
+// operators receive them, which matters for e.g. overlay and concat: Input
+// if set, otherwise Inputs
+func (op Operation) InputRefs() []string {
+	if op.Input != "" {
+		return []string{op.Input}
+	}
+	return op.Inputs
+}
+
+// ValidateInputs checks that the operation names its inputs in exactly one
+// of Input and Inputs
+func (op Operation) ValidateInputs() error {
+	switch {
+	case op.Input != "" && len(op.Inputs) > 0:
+		return fmt.Errorf("input and inputs cannot both be set")
+	case op.Input == "" && len(op.Inputs) == 0:
+		return fmt.Errorf("input or inputs is required")
+	}
+	return nil
+}
+
 // Output represents an output destination
 type Output struct {
-	ID          string            `json:"id"`
-	Destination string            `json:"destination"`
-	Format      string            `json:"format,omitempty"`
-	Codec       *CodecParams      `json:"codec,omitempty"`
-	Metadata    map[string]string `json:"metadata,omitempty"`
+	ID           string            `json:"id"`
+	Destination  string            `json:"destination"`
+	Format       string            `json:"format,omitempty"`
+	Codec        *CodecParams      `json:"codec,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+	MetadataMode MetadataMode      `json:"metadata_mode,omitempty"`
+}
+
+// MetadataMode controls which container metadata an output carries
+type MetadataMode string
+
+const (
+	// MetadataModeMerge copies the source metadata and adds the job's tags
+	// and the output's metadata on top (the default)
+	MetadataModeMerge MetadataMode = "merge"
+
+	// MetadataModePreserve copies the source metadata and adds only the
+	// output's metadata
+	MetadataModePreserve MetadataMode = "preserve"
+
+	// MetadataModeStrip drops the source metadata, writing only the
+	// output's metadata
+	MetadataModeStrip MetadataMode = "strip"
+)
+
+// IsValid reports whether m is a known mode; empty means the default
+func (m MetadataMode) IsValid() bool {
+	switch m {
+	case "", MetadataModeMerge, MetadataModePreserve, MetadataModeStrip:
+		return true
+	}
+	return false
 }
 
 // CodecParams specifies codec settings
@@ -91,6 +195,84 @@ type ResourceLimits struct {
 	MaxMemory     int64     `json:"max_memory,omitempty"`
 }
 
+// Normalize canonicalizes a user-submitted spec before validation: IDs,
+// references, sources and destinations are trimmed, operator names are
+// lowercased, nil params become empty maps, repeated inputs with the same
+// ID and source are dropped and a negative priority is reset to 0. It
+// returns a warning for each change that may hide a mistake in the spec.
+func (js *JobSpec) Normalize() []string {
+	warnings := []string{}
+
+	js.JobID = strings.TrimSpace(js.JobID)
+	for i := range js.DependsOn {
+		js.DependsOn[i] = strings.TrimSpace(js.DependsOn[i])
+	}
+
+	if js.Priority < 0 {
+		warnings = append(warnings, fmt.Sprintf("priority %d is negative, using 0", js.Priority))
+		js.Priority = 0
+	}
+
+	inputs := js.Inputs[:0]
+	seen := make(map[[2]string]bool)
+	for _, input := range js.Inputs {
+		input.ID = strings.TrimSpace(input.ID)
+		input.Source = strings.TrimSpace(input.Source)
+
+		key := [2]string{input.ID, input.Source}
+		if seen[key] {
+			warnings = append(warnings, fmt.Sprintf("removed duplicate input %q (%s)", input.ID, input.Source))
+			continue
+		}
+		seen[key] = true
+		inputs = append(inputs, input)
+	}
+	js.Inputs = inputs
+
+	for i := range js.Operations {
+		op := &js.Operations[i]
+		op.Op = strings.ToLower(strings.TrimSpace(op.Op))
+		op.Input = strings.TrimSpace(op.Input)
+		for j := range op.Inputs {
+			op.Inputs[j] = strings.TrimSpace(op.Inputs[j])
+		}
+		op.Output = strings.TrimSpace(op.Output)
+		if op.Params == nil {
+			op.Params = map[string]interface{}{}
+		}
+	}
+
+	for i := range js.Outputs {
+		js.Outputs[i].ID = strings.TrimSpace(js.Outputs[i].ID)
+		js.Outputs[i].Destination = strings.TrimSpace(js.Outputs[i].Destination)
+	}
+
+	return warnings
+}
+
+// ParamsValidator checks the params of an operation against its operator,
+// e.g. an operators.Registry
+type ParamsValidator interface {
+	ValidateParams(op string, params map[string]interface{}) error
+}
+
+// ValidateWithRegistry checks the JobSpec like Validate, then checks every
+// operation's params with reg. Param errors are reported together, each
+// with its operation's index.
+func (js *JobSpec) ValidateWithRegistry(reg ParamsValidator) error {
+	if err := js.Validate(); err != nil {
+		return err
+	}
+
+	var errs []error
+	for i, op := range js.Operations {
+		if err := reg.ValidateParams(op.Op, op.Params); err != nil {
+			errs = append(errs, fmt.Errorf("operation %d (%s): %w", i, op.Op, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // Validate checks if the JobSpec is valid
 func (js *JobSpec) Validate() error {
 	// Build a map of available inputs (initially just the inputs array)
@@ -109,24 +291,36 @@ func (js *JobSpec) Validate() error {
 		availableInputs[input.ID] = true
 	}
 
+	// Record which operation produces each output, to explain references
+	// to outputs that are not available yet
+	producedBy := make(map[string]int)
+	for i, op := range js.Operations {
+		if _, ok := producedBy[op.Output]; !ok && op.Output != "" {
+			producedBy[op.Output] = i
+		}
+	}
+
 	// Validate operations and track outputs as new available inputs
 	for i, op := range js.Operations {
 		if op.Op == "" {
 			return fmt.Errorf("operation %d: operator name cannot be empty", i)
 		}
 
-		// Check single input reference
-		if op.Input != "" {
-			if !availableInputs[op.Input] {
-				return fmt.Errorf("operation %d (%s): input '%s' not found", i, op.Op, op.Input)
-			}
+		// Check input references
+		if err := op.ValidateInputs(); err != nil {
+			return fmt.Errorf("operation %d (%s): %w", i, op.Op, err)
 		}
-
-		// Check multi-input references
-		for _, inputID := range op.Inputs {
-			if !availableInputs[inputID] {
-				return fmt.Errorf("operation %d (%s): input '%s' not found", i, op.Op, inputID)
+		for _, inputID := range op.InputRefs() {
+			if availableInputs[inputID] {
+				continue
 			}
+			if inputID == op.Output {
+				return fmt.Errorf("operation %d (%s): input '%s' is the operation's own output", i, op.Op, inputID)
+			}
+			if j, ok := producedBy[inputID]; ok && j > i {
+				return fmt.Errorf("operation %d (%s): input '%s' is produced by later operation %d (%s)", i, op.Op, inputID, j, js.Operations[j].Op)
+			}
+			return fmt.Errorf("operation %d (%s): input '%s' not found", i, op.Op, inputID)
 		}
 
 		// Add output as available input for subsequent operations
@@ -147,6 +341,12 @@ func (js *JobSpec) Validate() error {
 		if output.Destination == "" {
 			return fmt.Errorf("output '%s': destination cannot be empty", output.ID)
 		}
+		if !output.MetadataMode.IsValid() {
+			return fmt.Errorf("output '%s': invalid metadata_mode '%s'", output.ID, output.MetadataMode)
+		}
+		if err := output.ValidateFormat(); err != nil {
+			return fmt.Errorf("output '%s': %w", output.ID, err)
+		}
 		// Check that output ID refers to something that was produced
 		if !availableInputs[output.ID] {
 			return fmt.Errorf("output '%s': refers to non-existent input/operation output", output.ID)
@@ -0,0 +1,237 @@
+package schemas
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestJobSpec_Normalize(t *testing.T) {
+	spec := &JobSpec{
+		JobID:     " job_1 ",
+		Priority:  -5,
+		DependsOn: []string{" job_0\n"},
+		Inputs: []Input{
+			{ID: " video ", Source: " s3://bucket/in.mp4\t"},
+			{ID: "video", Source: "s3://bucket/in.mp4"},
+			{ID: "logo", Source: "file:///tmp/logo.png "},
+		},
+		Operations: []Operation{
+			{Op: " Trim ", Input: "video ", Output: " trimmed"},
+			{Op: "OVERLAY", Inputs: []string{"trimmed ", " logo"}, Output: "branded",
+				Params: map[string]interface{}{"x": 10}},
+		},
+		Outputs: []Output{
+			{ID: " branded ", Destination: " s3://bucket/out.mp4 "},
+		},
+	}
+
+	warnings := spec.Normalize()
+
+	if spec.JobID != "job_1" {
+		t.Errorf("JobID = %q, want job_1", spec.JobID)
+	}
+	if spec.DependsOn[0] != "job_0" {
+		t.Errorf("DependsOn[0] = %q, want job_0", spec.DependsOn[0])
+	}
+	if spec.Priority != 0 {
+		t.Errorf("Priority = %d, want 0", spec.Priority)
+	}
+
+	// Inputs are trimmed and the repeated input is dropped
+	if len(spec.Inputs) != 2 {
+		t.Fatalf("expected 2 inputs, got %+v", spec.Inputs)
+	}
+	if spec.Inputs[0].ID != "video" || spec.Inputs[0].Source != "s3://bucket/in.mp4" {
+		t.Errorf("unexpected first input %+v", spec.Inputs[0])
+	}
+	if spec.Inputs[1].Source != "file:///tmp/logo.png" {
+		t.Errorf("Source = %q, want trimmed logo source", spec.Inputs[1].Source)
+	}
+
+	// Operations are lowercased, references trimmed and params non-nil
+	trim := spec.Operations[0]
+	if trim.Op != "trim" || trim.Input != "video" || trim.Output != "trimmed" {
+		t.Errorf("unexpected trim operation %+v", trim)
+	}
+	if trim.Params == nil {
+		t.Error("expected nil params to become an empty map")
+	}
+	overlay := spec.Operations[1]
+	if overlay.Op != "overlay" || overlay.Inputs[0] != "trimmed" || overlay.Inputs[1] != "logo" {
+		t.Errorf("unexpected overlay operation %+v", overlay)
+	}
+	if overlay.Params["x"] != 10 {
+		t.Errorf("expected existing params to be kept, got %v", overlay.Params)
+	}
+
+	// Outputs are trimmed
+	if spec.Outputs[0].ID != "branded" || spec.Outputs[0].Destination != "s3://bucket/out.mp4" {
+		t.Errorf("unexpected output %+v", spec.Outputs[0])
+	}
+
+	// Priority reset and dropped input are reported
+	if len(warnings) != 2 {
+		t.Errorf("expected 2 warnings, got %v", warnings)
+	}
+
+	// The normalized spec is valid
+	if err := spec.Validate(); err != nil {
+		t.Errorf("normalized spec failed validation: %v", err)
+	}
+}
+
+func TestJobSpec_NormalizeKeepsConflictingInputs(t *testing.T) {
+	spec := &JobSpec{
+		Inputs: []Input{
+			{ID: "video", Source: "s3://bucket/a.mp4"},
+			{ID: "video", Source: "s3://bucket/b.mp4"},
+		},
+	}
+
+	if warnings := spec.Normalize(); len(warnings) != 0 {
+		t.Errorf("expected no warnings, got %v", warnings)
+	}
+	// Inputs with the same ID but different sources are left for Validate to reject
+	if len(spec.Inputs) != 2 {
+		t.Errorf("expected both inputs to be kept, got %+v", spec.Inputs)
+	}
+}
+
+// requiredParams rejects operations missing any of the listed params
+type requiredParams map[string][]string
+
+func (r requiredParams) ValidateParams(op string, params map[string]interface{}) error {
+	for _, name := range r[op] {
+		if _, ok := params[name]; !ok {
+			return fmt.Errorf("missing required parameter '%s'", name)
+		}
+	}
+	return nil
+}
+
+func TestJobSpec_ValidateWithRegistry(t *testing.T) {
+	spec := &JobSpec{
+		Inputs: []Input{{ID: "video", Source: "s3://bucket/in.mp4"}},
+		Operations: []Operation{
+			{Op: "scale", Input: "video", Output: "small", Params: map[string]interface{}{"height": 360}},
+			{Op: "trim", Input: "small", Output: "clip", Params: map[string]interface{}{"start": "10s"}},
+			{Op: "scale", Input: "clip", Output: "tiny", Params: map[string]interface{}{}},
+		},
+		Outputs: []Output{{ID: "tiny", Destination: "s3://bucket/out.mp4"}},
+	}
+	reg := requiredParams{"scale": {"width"}}
+
+	if err := spec.Validate(); err != nil {
+		t.Fatalf("expected the spec to be structurally valid: %v", err)
+	}
+
+	err := spec.ValidateWithRegistry(reg)
+	if err == nil {
+		t.Fatal("expected missing params to be reported")
+	}
+	for _, want := range []string{"operation 0 (scale): missing required parameter 'width'", "operation 2 (scale)"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in error, got: %v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "operation 1") {
+		t.Errorf("expected the valid trim not to be reported, got: %v", err)
+	}
+
+	// Structural errors are reported first
+	spec.Outputs[0].ID = "missing"
+	if err := spec.ValidateWithRegistry(reg); err == nil || !strings.Contains(err.Error(), "non-existent") {
+		t.Errorf("expected a structural error, got: %v", err)
+	}
+}
+
+func TestJobSpec_ValidateInputs(t *testing.T) {
+	tests := []struct {
+		name    string
+		op      Operation
+		wantErr string
+	}{
+		{"input", Operation{Op: "trim", Input: "a", Output: "out"}, ""},
+		{"inputs", Operation{Op: "concat", Inputs: []string{"a", "b"}, Output: "out"}, ""},
+		{"both", Operation{Op: "overlay", Input: "a", Inputs: []string{"b"}, Output: "out"}, "input and inputs cannot both be set"},
+		{"neither", Operation{Op: "trim", Output: "out"}, "input or inputs is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &JobSpec{
+				Inputs: []Input{
+					{ID: "a", Source: "s3://bucket/a.mp4"},
+					{ID: "b", Source: "s3://bucket/b.mp4"},
+				},
+				Operations: []Operation{tt.op},
+				Outputs:    []Output{{ID: "out", Destination: "s3://bucket/out.mp4"}},
+			}
+
+			err := spec.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestJobSpec_ValidateReferenceOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		operations []Operation
+		wantErr    string
+	}{
+		{
+			name: "self reference",
+			operations: []Operation{
+				{Op: "scale", Input: "loop", Output: "loop"},
+			},
+			wantErr: "operation 0 (scale): input 'loop' is the operation's own output",
+		},
+		{
+			name: "forward reference",
+			operations: []Operation{
+				{Op: "scale", Input: "trimmed", Output: "scaled"},
+				{Op: "trim", Input: "video", Output: "trimmed"},
+			},
+			wantErr: "operation 0 (scale): input 'trimmed' is produced by later operation 1 (trim)",
+		},
+		{
+			name: "cycle",
+			operations: []Operation{
+				{Op: "scale", Input: "b", Output: "a"},
+				{Op: "trim", Input: "a", Output: "b"},
+			},
+			wantErr: "operation 0 (scale): input 'b' is produced by later operation 1 (trim)",
+		},
+		{
+			name: "unknown reference",
+			operations: []Operation{
+				{Op: "scale", Input: "missing", Output: "scaled"},
+			},
+			wantErr: "operation 0 (scale): input 'missing' not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &JobSpec{
+				Inputs:     []Input{{ID: "video", Source: "s3://bucket/in.mp4"}},
+				Operations: tt.operations,
+			}
+
+			err := spec.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
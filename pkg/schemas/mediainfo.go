@@ -0,0 +1,39 @@
+package schemas
+
+// HasVideo reports whether the media has a video stream
+func (mi *MediaInfo) HasVideo() bool {
+	return mi != nil && len(mi.VideoStreams) > 0
+}
+
+// HasAudio reports whether the media has an audio stream
+func (mi *MediaInfo) HasAudio() bool {
+	return mi != nil && len(mi.AudioStreams) > 0
+}
+
+// PrimaryVideoStream returns the first video stream, or nil if there is
+// none. The stream is not a copy: changes to it change mi.
+func (mi *MediaInfo) PrimaryVideoStream() *VideoStream {
+	if !mi.HasVideo() {
+		return nil
+	}
+	return &mi.VideoStreams[0]
+}
+
+// PrimaryAudioStream returns the first audio stream, or nil if there is
+// none. The stream is not a copy: changes to it change mi.
+func (mi *MediaInfo) PrimaryAudioStream() *AudioStream {
+	if !mi.HasAudio() {
+		return nil
+	}
+	return &mi.AudioStreams[0]
+}
+
+// AspectRatio returns the width of the primary video stream divided by its
+// height, in pixels, or 0 if there is no video or its size is unknown
+func (mi *MediaInfo) AspectRatio() float64 {
+	video := mi.PrimaryVideoStream()
+	if video == nil || video.Width <= 0 || video.Height <= 0 {
+		return 0
+	}
+	return float64(video.Width) / float64(video.Height)
+}
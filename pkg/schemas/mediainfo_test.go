@@ -0,0 +1,52 @@
+package schemas
+
+import "testing"
+
+func TestMediaInfo_StreamHelpers(t *testing.T) {
+	var nilInfo *MediaInfo
+	empty := &MediaInfo{}
+	full := &MediaInfo{
+		VideoStreams: []VideoStream{{Codec: "h264", Width: 1920, Height: 1080}, {Codec: "mjpeg"}},
+		AudioStreams: []AudioStream{{Codec: "aac", Channels: 2}},
+	}
+
+	for name, mi := range map[string]*MediaInfo{"nil": nilInfo, "empty": empty} {
+		if mi.HasVideo() || mi.HasAudio() {
+			t.Errorf("%s: expected no video or audio", name)
+		}
+		if mi.PrimaryVideoStream() != nil || mi.PrimaryAudioStream() != nil {
+			t.Errorf("%s: expected no primary streams", name)
+		}
+		if got := mi.AspectRatio(); got != 0 {
+			t.Errorf("%s: expected aspect ratio 0, got %v", name, got)
+		}
+	}
+
+	if !full.HasVideo() || !full.HasAudio() {
+		t.Error("expected video and audio")
+	}
+	if video := full.PrimaryVideoStream(); video == nil || video.Codec != "h264" {
+		t.Errorf("expected the first video stream, got %+v", video)
+	}
+	if audio := full.PrimaryAudioStream(); audio == nil || audio.Codec != "aac" {
+		t.Errorf("expected the first audio stream, got %+v", audio)
+	}
+	if got, want := full.AspectRatio(), 16.0/9.0; got != want {
+		t.Errorf("expected aspect ratio %v, got %v", want, got)
+	}
+
+	// The primary stream is the one in the slice
+	full.PrimaryVideoStream().Width = 1280
+	if full.VideoStreams[0].Width != 1280 {
+		t.Error("expected changes to the primary video stream to be kept")
+	}
+
+	audioOnly := &MediaInfo{AudioStreams: []AudioStream{{Codec: "mp3"}}}
+	if audioOnly.HasVideo() || audioOnly.AspectRatio() != 0 {
+		t.Error("expected an audio-only file to have no video and no aspect ratio")
+	}
+	unknownSize := &MediaInfo{VideoStreams: []VideoStream{{Codec: "h264"}}}
+	if unknownSize.AspectRatio() != 0 {
+		t.Error("expected aspect ratio 0 for a video of unknown size")
+	}
+}
@@ -0,0 +1,55 @@
+package schemas
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ProberInterface probes a local media file
+// Satisfied by *prober.Prober
+type ProberInterface interface {
+	Probe(ctx context.Context, filePath string) (*MediaInfo, error)
+}
+
+// Populate fills in FileSize, SHA256, MD5, Duration and MediaInfo from the file
+// at localPath. Probing is skipped if p is nil; a probe failure is returned
+// after the size and hash have been set.
+func (f *OutputFile) Populate(ctx context.Context, localPath string, p ProberInterface) error {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to open output file: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat output file: %w", err)
+	}
+	f.FileSize = info.Size()
+
+	sha256Hash := sha256.New()
+	md5Hash := md5.New()
+	if _, err := io.Copy(io.MultiWriter(sha256Hash, md5Hash), file); err != nil {
+		return fmt.Errorf("failed to hash output file: %w", err)
+	}
+	f.SHA256 = hex.EncodeToString(sha256Hash.Sum(nil))
+	f.MD5 = hex.EncodeToString(md5Hash.Sum(nil))
+
+	if p == nil {
+		return nil
+	}
+
+	mediaInfo, err := p.Probe(ctx, localPath)
+	if err != nil {
+		return fmt.Errorf("failed to probe output file: %w", err)
+	}
+	f.MediaInfo = mediaInfo
+	f.Duration = mediaInfo.Format.Duration.Seconds()
+
+	return nil
+}
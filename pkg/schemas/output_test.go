@@ -0,0 +1,82 @@
+package schemas
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// stubProber returns fixed media info for any file
+type stubProber struct {
+	info *MediaInfo
+	err  error
+}
+
+func (p *stubProber) Probe(ctx context.Context, filePath string) (*MediaInfo, error) {
+	return p.info, p.err
+}
+
+func TestOutputFile_Populate(t *testing.T) {
+	localPath := filepath.Join(t.TempDir(), "output.mp4")
+	if err := os.WriteFile(localPath, []byte("hello"), 0644); err != nil {
+		t.Fatalf("failed to write output file: %v", err)
+	}
+
+	p := &stubProber{info: &MediaInfo{
+		Format: FormatInfo{Format: "mp4", Duration: 2500 * time.Millisecond, Size: 5},
+	}}
+
+	f := &OutputFile{OutputID: "out", Destination: "file://" + localPath}
+	if err := f.Populate(context.Background(), localPath, p); err != nil {
+		t.Fatalf("Populate() failed: %v", err)
+	}
+
+	if f.FileSize != 5 {
+		t.Errorf("FileSize = %d, want 5", f.FileSize)
+	}
+	// sha256("hello")
+	if want := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"; f.SHA256 != want {
+		t.Errorf("SHA256 = %s, want %s", f.SHA256, want)
+	}
+	// md5("hello")
+	if want := "5d41402abc4b2a76b9719d911017c592"; f.MD5 != want {
+		t.Errorf("MD5 = %s, want %s", f.MD5, want)
+	}
+	if f.Duration != 2.5 {
+		t.Errorf("Duration = %v, want 2.5", f.Duration)
+	}
+	if f.MediaInfo == nil || f.MediaInfo.Format.Format != "mp4" {
+		t.Errorf("MediaInfo = %+v, want probe result", f.MediaInfo)
+	}
+}
+
+func TestOutputFile_PopulateProbeError(t *testing.T) {
+	localPath := filepath.Join(t.TempDir(), "output.bin")
+	if err := os.WriteFile(localPath, []byte("data"), 0644); err != nil {
+		t.Fatalf("failed to write output file: %v", err)
+	}
+
+	f := &OutputFile{}
+	err := f.Populate(context.Background(), localPath, &stubProber{err: errors.New("not media")})
+	if err == nil {
+		t.Fatal("expected probe error")
+	}
+
+	// Size and hash are still recorded
+	if f.FileSize != 4 || f.SHA256 == "" {
+		t.Errorf("expected size and hash to be set, got size=%d sha256=%q", f.FileSize, f.SHA256)
+	}
+	if f.MediaInfo != nil {
+		t.Errorf("expected no media info, got %+v", f.MediaInfo)
+	}
+}
+
+func TestOutputFile_PopulateMissingFile(t *testing.T) {
+	f := &OutputFile{}
+	if err := f.Populate(context.Background(), filepath.Join(t.TempDir(), "missing.mp4"), nil); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+}
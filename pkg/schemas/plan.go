@@ -1,6 +1,12 @@
 package schemas
 
-import "time"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"time"
+)
 
 // ProcessingPlan is the compiled execution plan
 type ProcessingPlan struct {
@@ -15,12 +21,42 @@ type ProcessingPlan struct {
 	ExecutionOrder   []string           `json:"execution_order"`   // Topological sort order
 	ExecutionStages  [][]string         `json:"execution_stages"`  // Parallel execution stages
 	ResourceEstimate *ResourceEstimates `json:"resource_estimate,omitempty"` // Resource estimates
+	Warnings         []string           `json:"warnings,omitempty"`          // Problems that do not prevent execution
 
 	// Generated Artifacts
 	FFmpegVersion string          `json:"ffmpeg_version"`
 	Commands      []FFmpegCommand `json:"commands"`
 }
 
+// Fingerprint returns a hex SHA-256 of the plan's structure: its nodes
+// (sorted by ID), edges and execution order. Plans that differ only in
+// their IDs, creation time or generated artifacts have the same fingerprint.
+func (p *ProcessingPlan) Fingerprint() string {
+	nodes := append([]*PlanNode(nil), p.Nodes...)
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+
+	edges := append([]*PlanEdge(nil), p.Edges...)
+	sort.SliceStable(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		if edges[i].To != edges[j].To {
+			return edges[i].To < edges[j].To
+		}
+		return edges[i].InputIndex < edges[j].InputIndex
+	})
+
+	h := sha256.New()
+	// Plans are built from decoded JSON, so they always encode; map keys
+	// are written sorted
+	_ = json.NewEncoder(h).Encode(struct {
+		Nodes          []*PlanNode `json:"nodes"`
+		Edges          []*PlanEdge `json:"edges"`
+		ExecutionOrder []string    `json:"execution_order"`
+	}{nodes, edges, p.ExecutionOrder})
+	return hex.EncodeToString(h.Sum(nil))
+}
+
 // PlanNode represents a node in the execution DAG
 type PlanNode struct {
 	ID   string `json:"id"`
@@ -33,76 +69,126 @@ type PlanNode struct {
 	// For operation nodes
 	Operator string                 `json:"operator,omitempty"`
 	Params   map[string]interface{} `json:"params,omitempty"`
+	Fused    []FusedOperation       `json:"fused,omitempty"` // Operations merged into this node, applied in order
 
 	// For output nodes
-	OutputID string `json:"output_id,omitempty"`
-	DestURI  string `json:"dest_uri,omitempty"`
+	OutputID       string            `json:"output_id,omitempty"`
+	DestURI        string            `json:"dest_uri,omitempty"`
+	OutputMetadata map[string]string `json:"output_metadata,omitempty"` // Container tags to write
+	MetadataMode   MetadataMode      `json:"metadata_mode,omitempty"`   // Whether source metadata is kept
 
 	// Metadata (computed during planning)
 	Metadata  *MediaInfo     `json:"metadata,omitempty"` // Computed output metadata
 	Estimates *NodeEstimates `json:"estimates,omitempty"`
 }
 
+// FusedOperation is one of the operations merged into a fused node
+type FusedOperation struct {
+	Operator string                 `json:"operator"`
+	Params   map[string]interface{} `json:"params,omitempty"`
+}
+
+// Operations returns the operations an operation node applies: its fused
+// operations, or just its own operator
+func (n *PlanNode) Operations() []FusedOperation {
+	if len(n.Fused) > 0 {
+		return n.Fused
+	}
+	return []FusedOperation{{Operator: n.Operator, Params: n.Params}}
+}
+
 // PlanEdge represents a dependency between nodes
 type PlanEdge struct {
 	From       string `json:"from"`
 	To         string `json:"to"`
 	StreamType string `json:"stream_type,omitempty"` // "video", "audio", "both"
+
+	// InputIndex is the edge's position among the inputs of the operation
+	// it feeds, in the order of Operation.InputRefs
+	InputIndex int `json:"input_index,omitempty"`
 }
 
 // MediaInfo contains detected media properties
 type MediaInfo struct {
-	Format       FormatInfo    `json:"format"`
-	VideoStreams []VideoStream `json:"video_streams,omitempty"`
-	AudioStreams []AudioStream `json:"audio_streams,omitempty"`
+	Format          FormatInfo       `json:"format"`
+	VideoStreams    []VideoStream    `json:"video_streams,omitempty"`
+	AudioStreams    []AudioStream    `json:"audio_streams,omitempty"`
+	SubtitleStreams []SubtitleStream `json:"subtitle_streams,omitempty"`
+	Chapters        []Chapter        `json:"chapters,omitempty"`
 }
 
 // FormatInfo contains format-level information
 type FormatInfo struct {
-	Filename  string        `json:"filename,omitempty"`
-	Format    string        `json:"format,omitempty"`
-	Duration  time.Duration `json:"duration"`
-	Size      int64         `json:"size"`
-	BitRate   int64         `json:"bit_rate,omitempty"`
-	StartTime time.Duration `json:"start_time,omitempty"`
+	Filename  string            `json:"filename,omitempty"`
+	Format    string            `json:"format,omitempty"`
+	Duration  time.Duration     `json:"duration"`
+	Size      int64             `json:"size"`
+	BitRate   int64             `json:"bit_rate,omitempty"`
+	StartTime time.Duration     `json:"start_time,omitempty"`
+	Tags      map[string]string `json:"tags,omitempty"`
 }
 
 // VideoStream represents a video stream
 type VideoStream struct {
-	Index       int           `json:"index"`
-	Codec       string        `json:"codec"`
-	Width       int           `json:"width"`
-	Height      int           `json:"height"`
-	FrameRate   float64       `json:"frame_rate"`
-	PixelFormat string        `json:"pixel_format,omitempty"`
-	BitRate     int64         `json:"bit_rate,omitempty"`
-	Duration    time.Duration `json:"duration,omitempty"`
+	Index       int               `json:"index"`
+	Codec       string            `json:"codec"`
+	Width       int               `json:"width"`
+	Height      int               `json:"height"`
+	FrameRate   float64           `json:"frame_rate"`
+	PixelFormat string            `json:"pixel_format,omitempty"`
+	BitRate     int64             `json:"bit_rate,omitempty"`
+	Duration    time.Duration     `json:"duration,omitempty"`
+	Rotation    int               `json:"rotation,omitempty"` // Clockwise display rotation in degrees (0, 90, 180, 270)
+	FrameCount  int64             `json:"frame_count,omitempty"`
+	SAR         string            `json:"sample_aspect_ratio,omitempty"`  // e.g. "4:3" for anamorphic content
+	DAR         string            `json:"display_aspect_ratio,omitempty"` // e.g. "16:9"
+	Profile     string            `json:"profile,omitempty"`              // e.g. "High"
+	Level       string            `json:"level,omitempty"`                // e.g. "4.0"
+	BitDepth    int               `json:"bit_depth,omitempty"`            // Bits per sample, 0 if unknown
+	Tags        map[string]string `json:"tags,omitempty"`
 }
 
 // AudioStream represents an audio stream
 type AudioStream struct {
-	Index      int           `json:"index"`
-	Codec      string        `json:"codec"`
-	SampleRate int           `json:"sample_rate"`
-	Channels   int           `json:"channels"`
-	BitRate    int64         `json:"bit_rate,omitempty"`
-	Duration   time.Duration `json:"duration,omitempty"`
+	Index      int               `json:"index"`
+	Codec      string            `json:"codec"`
+	SampleRate int               `json:"sample_rate"`
+	Channels   int               `json:"channels"`
+	BitRate    int64             `json:"bit_rate,omitempty"`
+	Duration   time.Duration     `json:"duration,omitempty"`
+	Profile    string            `json:"profile,omitempty"` // e.g. "LC"
+	Tags       map[string]string `json:"tags,omitempty"`
+}
+
+// SubtitleStream represents a subtitle stream
+type SubtitleStream struct {
+	Index    int    `json:"index"`
+	Codec    string `json:"codec"`
+	Language string `json:"language,omitempty"`
+}
+
+// Chapter represents a chapter marker
+type Chapter struct {
+	ID    int           `json:"id"`
+	Start time.Duration `json:"start"`
+	End   time.Duration `json:"end"`
+	Title string        `json:"title,omitempty"`
 }
 
 // NodeEstimates contains resource estimates for a node
 type NodeEstimates struct {
-	Duration time.Duration `json:"duration"` // Estimated processing time
-	MemoryMB int64         `json:"memory_mb"` // Peak memory in MB
-	DiskMB   int64         `json:"disk_mb"`   // Disk space in MB
+	Duration time.Duration `json:"duration"`            // Estimated processing time
+	MemoryMB int64         `json:"memory_mb"`           // Peak memory in MB
+	DiskMB   int64         `json:"disk_mb"`             // Disk space in MB
 	CPUCores float64       `json:"cpu_cores,omitempty"` // CPU cores utilized
 }
 
 // ResourceEstimates contains total resource estimates
 type ResourceEstimates struct {
 	NodeEstimates map[string]*NodeEstimates `json:"node_estimates"` // Per-node estimates
-	TotalDuration time.Duration              `json:"total_duration"` // Total processing time
-	PeakMemoryMB  int64                      `json:"peak_memory_mb"` // Peak memory across all stages
-	TotalDiskMB   int64                      `json:"total_disk_mb"`  // Total disk space needed
+	TotalDuration time.Duration             `json:"total_duration"` // Total processing time
+	PeakMemoryMB  int64                     `json:"peak_memory_mb"` // Peak memory across all stages
+	TotalDiskMB   int64                     `json:"total_disk_mb"`  // Total disk space needed
 }
 
 // FFmpegCommand represents a generated FFmpeg command
@@ -0,0 +1,54 @@
+package schemas
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// fingerprintPlan returns a small input -> trim -> output plan
+func fingerprintPlan() *ProcessingPlan {
+	return &ProcessingPlan{
+		PlanID:    "plan_1",
+		JobID:     "job_1",
+		CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Nodes: []*PlanNode{
+			{ID: "input_video", Type: "input", InputID: "video", SourceURI: "s3://bucket/in.mp4"},
+			{ID: "op_0_trim", Type: "operation", Operator: "trim",
+				Params: map[string]interface{}{"start": "00:00:10", "duration": "00:00:30"}},
+			{ID: "output_trimmed", Type: "output", OutputID: "trimmed", DestURI: "s3://bucket/out.mp4"},
+		},
+		Edges: []*PlanEdge{
+			{From: "input_video", To: "op_0_trim", StreamType: "both"},
+			{From: "op_0_trim", To: "output_trimmed", StreamType: "both"},
+		},
+		ExecutionOrder: []string{"input_video", "op_0_trim", "output_trimmed"},
+	}
+}
+
+func TestProcessingPlan_Fingerprint(t *testing.T) {
+	want := fingerprintPlan().Fingerprint()
+	if len(want) != 64 {
+		t.Fatalf("expected a hex SHA-256, got %q", want)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		plan := fingerprintPlan()
+		plan.PlanID = "plan_2"
+		plan.CreatedAt = time.Now()
+		rng.Shuffle(len(plan.Nodes), func(i, j int) {
+			plan.Nodes[i], plan.Nodes[j] = plan.Nodes[j], plan.Nodes[i]
+		})
+
+		if got := plan.Fingerprint(); got != want {
+			t.Fatalf("fingerprint of reordered plan = %s, want %s", got, want)
+		}
+	}
+
+	changed := fingerprintPlan()
+	changed.Nodes[1].Params["start"] = "00:00:20"
+	if changed.Fingerprint() == want {
+		t.Error("expected different params to change the fingerprint")
+	}
+}
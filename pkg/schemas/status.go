@@ -19,15 +19,17 @@ const (
 
 // JobStatus represents real-time job status
 type JobStatus struct {
-	JobID       string       `json:"job_id"`
-	Status      JobState     `json:"status"`
-	Progress    *Progress    `json:"progress,omitempty"`
-	Error       *ErrorInfo   `json:"error,omitempty"`
-	CreatedAt   time.Time    `json:"created_at"`
-	UpdatedAt   time.Time    `json:"updated_at"`
-	StartedAt   *time.Time   `json:"started_at,omitempty"`
-	CompletedAt *time.Time   `json:"completed_at,omitempty"`
-	OutputFiles []OutputFile `json:"output_files,omitempty"`
+	JobID       string            `json:"job_id"`
+	Status      JobState          `json:"status"`
+	Progress    *Progress         `json:"progress,omitempty"`
+	Error       *ErrorInfo        `json:"error,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	StartedAt   *time.Time        `json:"started_at,omitempty"`
+	CompletedAt *time.Time        `json:"completed_at,omitempty"`
+	OutputFiles []OutputFile      `json:"output_files,omitempty"`
+	Execution   *ExecutionStats   `json:"execution,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
 }
 
 // Progress represents job progress information
@@ -79,11 +81,21 @@ type OutputFile struct {
 	OutputID    string     `json:"output_id"`
 	Destination string     `json:"destination"`
 	FileSize    int64      `json:"file_size"`
+	SHA256      string     `json:"sha256,omitempty"`
 	MD5         string     `json:"md5,omitempty"`
 	Duration    float64    `json:"duration,omitempty"`
 	MediaInfo   *MediaInfo `json:"media_info,omitempty"`
 }
 
+// ExecutionStats records how a job's plan was executed
+type ExecutionStats struct {
+	WallClockSeconds float64 `json:"wall_clock_seconds"`
+	FFmpegExitCode   int     `json:"ffmpeg_exit_code"`
+	BytesRead        int64   `json:"bytes_read"`
+	BytesWritten     int64   `json:"bytes_written"`
+	Command          string  `json:"command,omitempty"`
+}
+
 // ErrorInfo contains error details
 type ErrorInfo struct {
 	Code             string                 `json:"code"`
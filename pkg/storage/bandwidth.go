@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"context"
+	"io"
+
+	"golang.org/x/time/rate"
+)
+
+// maxBandwidthBurst caps the bytes read in a single call so throttled
+// transfers stay smooth at high limits
+const maxBandwidthBurst = 64 * 1024
+
+// BandwidthLimiter is an io.Reader that limits how fast the underlying
+// reader is consumed
+type BandwidthLimiter struct {
+	ctx     context.Context
+	reader  io.Reader
+	limiter *rate.Limiter
+}
+
+// NewBandwidthLimiter wraps r so that it yields at most bytesPerSecond.
+// Waiting for bandwidth is abandoned when ctx is cancelled.
+func NewBandwidthLimiter(ctx context.Context, r io.Reader, bytesPerSecond int64) *BandwidthLimiter {
+	burst := bytesPerSecond
+	if burst > maxBandwidthBurst {
+		burst = maxBandwidthBurst
+	}
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &BandwidthLimiter{
+		ctx:     ctx,
+		reader:  r,
+		limiter: rate.NewLimiter(rate.Limit(bytesPerSecond), int(burst)),
+	}
+}
+
+// Read reads up to one burst from the underlying reader, then waits until
+// the bytes read fit within the rate limit
+func (bl *BandwidthLimiter) Read(p []byte) (int, error) {
+	if len(p) > bl.limiter.Burst() {
+		p = p[:bl.limiter.Burst()]
+	}
+
+	n, err := bl.reader.Read(p)
+	if n > 0 {
+		if waitErr := bl.limiter.WaitN(bl.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+// LimitReader wraps r in a BandwidthLimiter, or returns r unchanged when
+// bytesPerSecond is not positive
+func LimitReader(ctx context.Context, r io.Reader, bytesPerSecond int64) io.Reader {
+	if bytesPerSecond <= 0 {
+		return r
+	}
+	return NewBandwidthLimiter(ctx, r, bytesPerSecond)
+}
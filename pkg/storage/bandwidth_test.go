@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBandwidthLimiter(t *testing.T) {
+	const limit = 100 * 1024 // 100 KB/s
+	data := bytes.Repeat([]byte("x"), 150*1024)
+
+	start := time.Now()
+	n, err := io.Copy(io.Discard, NewBandwidthLimiter(context.Background(), bytes.NewReader(data), limit))
+	elapsed := time.Since(start)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(data)), n)
+
+	// The first burst is free, the remaining ~86 KB take ~0.85s
+	assert.GreaterOrEqual(t, elapsed, 700*time.Millisecond)
+	assert.Less(t, elapsed, 3*time.Second)
+}
+
+func TestBandwidthLimiter_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	data := bytes.Repeat([]byte("x"), 1024*1024)
+	_, err := io.Copy(io.Discard, NewBandwidthLimiter(ctx, bytes.NewReader(data), 1024))
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestLimitReader_Unlimited(t *testing.T) {
+	r := bytes.NewReader([]byte("data"))
+	assert.Same(t, io.Reader(r), LimitReader(context.Background(), r, 0))
+}
+
+// BenchmarkBandwidthLimiter measures throughput of a 10 MB transfer limited to 1 MB/s
+func BenchmarkBandwidthLimiter(b *testing.B) {
+	const (
+		limit = 1024 * 1024
+		size  = 10 * 1024 * 1024
+	)
+	data := bytes.Repeat([]byte("x"), size)
+
+	b.SetBytes(size)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		start := time.Now()
+		if _, err := io.Copy(io.Discard, NewBandwidthLimiter(context.Background(), bytes.NewReader(data), limit)); err != nil {
+			b.Fatalf("copy failed: %v", err)
+		}
+		b.ReportMetric(float64(size)/time.Since(start).Seconds()/(1024*1024), "MiB/s-actual")
+	}
+}
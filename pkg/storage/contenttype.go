@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"mime"
+	"path"
+	"strings"
+)
+
+// mediaContentTypes covers media extensions missing from the system MIME
+// tables of minimal container images
+var mediaContentTypes = map[string]string{
+	".mp4":  "video/mp4",
+	".m4v":  "video/x-m4v",
+	".mov":  "video/quicktime",
+	".mkv":  "video/x-matroska",
+	".webm": "video/webm",
+	".avi":  "video/x-msvideo",
+	".ts":   "video/mp2t",
+	".m4a":  "audio/mp4",
+	".mp3":  "audio/mpeg",
+	".aac":  "audio/aac",
+	".wav":  "audio/wav",
+	".flac": "audio/flac",
+	".ogg":  "audio/ogg",
+	".opus": "audio/opus",
+	".m3u8": "application/vnd.apple.mpegurl",
+	".mpd":  "application/dash+xml",
+	".vtt":  "text/vtt",
+	".srt":  "application/x-subrip",
+}
+
+// ContentTypeForURI returns the content type implied by the file extension
+// of uri, or "" if it is unknown
+func ContentTypeForURI(uri string) string {
+	// Drop any query string or fragment before taking the extension
+	if i := strings.IndexAny(uri, "?#"); i >= 0 {
+		uri = uri[:i]
+	}
+
+	ext := strings.ToLower(path.Ext(uri))
+	if ext == "" {
+		return ""
+	}
+	if contentType, ok := mediaContentTypes[ext]; ok {
+		return contentType
+	}
+	return mime.TypeByExtension(ext)
+}
+
+// ExtensionForContentType returns the usual file extension for contentType,
+// including the leading dot, or "" if it is unknown
+func ExtensionForContentType(contentType string) string {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		contentType = mediaType
+	}
+	for ext, known := range mediaContentTypes {
+		if known == contentType {
+			return ext
+		}
+	}
+
+	// mime returns extensions in lexical order, so prefer the well-known ones
+	exts, _ := mime.ExtensionsByType(contentType)
+	for _, ext := range exts {
+		switch ext {
+		case ".jpg", ".png", ".gif", ".webp", ".svg", ".txt":
+			return ext
+		}
+	}
+	if len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
@@ -0,0 +1,27 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentTypeForURI(t *testing.T) {
+	tests := []struct {
+		uri  string
+		want string
+	}{
+		{"s3://bucket/videos/out.mp4", "video/mp4"},
+		{"file:///tmp/OUT.MOV", "video/quicktime"},
+		{"https://cdn.example.com/audio.m4a?token=abc", "audio/mp4"},
+		{"s3://bucket/stream/index.m3u8", "application/vnd.apple.mpegurl"},
+		{"s3://bucket/thumb.png", "image/png"},
+		{"s3://bucket/no-extension", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			assert.Equal(t, tt.want, ContentTypeForURI(tt.uri))
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// DataStorage implements Storage for data: URIs, whose content is embedded
+// in the URI itself (data:image/png;base64,iVBORw0...). It is read-only and
+// intended for small assets such as watermarks.
+type DataStorage struct{}
+
+// NewDataStorage creates a new data: URI storage backend
+func NewDataStorage() *DataStorage {
+	return &DataStorage{}
+}
+
+// ParseDataURI decodes a data: URI and returns its media type and content.
+// The media type defaults to text/plain as in RFC 2397.
+func ParseDataURI(uri string) (mediaType string, data []byte, err error) {
+	rest, ok := strings.CutPrefix(uri, "data:")
+	if !ok {
+		return "", nil, fmt.Errorf("not a data URI")
+	}
+
+	header, payload, ok := strings.Cut(rest, ",")
+	if !ok {
+		return "", nil, fmt.Errorf("invalid data URI: missing ','")
+	}
+
+	isBase64 := false
+	if trimmed, found := strings.CutSuffix(header, ";base64"); found {
+		header = trimmed
+		isBase64 = true
+	}
+
+	mediaType = header
+	if i := strings.IndexByte(mediaType, ';'); i >= 0 {
+		mediaType = mediaType[:i]
+	}
+	if mediaType == "" {
+		mediaType = "text/plain"
+	}
+
+	if isBase64 {
+		// Tolerate percent-encoding and missing padding
+		if unescaped, err := url.PathUnescape(payload); err == nil {
+			payload = unescaped
+		}
+		data, err = base64.StdEncoding.DecodeString(payload)
+		if err != nil {
+			data, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(payload, "="))
+		}
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid data URI: %w", err)
+		}
+		return mediaType, data, nil
+	}
+
+	unescaped, err := url.PathUnescape(payload)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid data URI: %w", err)
+	}
+	return mediaType, []byte(unescaped), nil
+}
+
+// Get returns the decoded content of a data: URI
+func (ds *DataStorage) Get(ctx context.Context, uri string) (io.ReadCloser, error) {
+	_, data, err := ParseDataURI(uri)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// GetRange returns part of the decoded content of a data: URI
+func (ds *DataStorage) GetRange(ctx context.Context, uri string, offset, length int64) (io.ReadCloser, error) {
+	if err := checkRange(offset); err != nil {
+		return nil, err
+	}
+
+	_, data, err := ParseDataURI(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	data = data[offset:]
+	if length > 0 && length < int64(len(data)) {
+		data = data[:length]
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Put is not supported for data: URIs (read-only)
+func (ds *DataStorage) Put(ctx context.Context, uri string, data io.Reader) error {
+	return fmt.Errorf("Put operation not supported for data: URIs (read-only)")
+}
+
+// Delete is not supported for data: URIs (read-only)
+func (ds *DataStorage) Delete(ctx context.Context, uri string) error {
+	return fmt.Errorf("data: URIs do not support Delete operations (read-only)")
+}
+
+// Exists reports whether uri is a valid data: URI
+func (ds *DataStorage) Exists(ctx context.Context, uri string) (bool, error) {
+	_, _, err := ParseDataURI(uri)
+	return err == nil, nil
+}
+
+// Stat returns the decoded size and media type of a data: URI
+func (ds *DataStorage) Stat(ctx context.Context, uri string) (*ObjectInfo, error) {
+	mediaType, data, err := ParseDataURI(uri)
+	if err != nil {
+		return nil, err
+	}
+	return &ObjectInfo{
+		Size:        int64(len(data)),
+		ContentType: mediaType,
+	}, nil
+}
+
+// ListObjects is not supported for data: URIs
+func (ds *DataStorage) ListObjects(ctx context.Context, dir string, recursive bool, pattern string) ([]ObjectInfo, error) {
+	return nil, fmt.Errorf("ListObjects operation not supported for data: URIs")
+}
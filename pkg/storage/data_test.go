@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDataURI(t *testing.T) {
+	tests := []struct {
+		uri       string
+		mediaType string
+		data      string
+		wantErr   bool
+	}{
+		{"data:text/plain;base64,aGVsbG8=", "text/plain", "hello", false},
+		{"data:image/png;base64,aGVsbG8", "image/png", "hello", false},
+		{"data:image/svg+xml;charset=utf-8;base64,aGVsbG8=", "image/svg+xml", "hello", false},
+		{"data:,hello%20world", "text/plain", "hello world", false},
+		{"data:;base64,aGVsbG8%3D", "text/plain", "hello", false},
+		{"data:image/png;base64,!!!", "", "", true},
+		{"data:image/png;base64", "", "", true},
+		{"file:///tmp/x.png", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			mediaType, data, err := ParseDataURI(tt.uri)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.mediaType, mediaType)
+			assert.Equal(t, tt.data, string(data))
+		})
+	}
+}
+
+func TestDataStorage(t *testing.T) {
+	ds := NewDataStorage()
+	ctx := context.Background()
+	uri := "data:image/png;base64,aGVsbG8="
+
+	reader, err := ds.Get(ctx, uri)
+	require.NoError(t, err)
+	content, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(content))
+
+	reader, err = ds.GetRange(ctx, uri, 1, 3)
+	require.NoError(t, err)
+	content, err = io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "ell", string(content))
+
+	info, err := ds.Stat(ctx, uri)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), info.Size)
+	assert.Equal(t, "image/png", info.ContentType)
+
+	exists, err := ds.Exists(ctx, uri)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	assert.Error(t, ds.Put(ctx, uri, nil))
+	assert.Error(t, ds.Delete(ctx, uri))
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+)
+
+// HTTPStatusError is returned when an HTTP storage request gets an
+// unexpected response status
+type HTTPStatusError struct {
+	Method     string
+	StatusCode int
+}
+
+// Error implements the error interface
+func (e *HTTPStatusError) Error() string {
+	if e.Method == http.MethodHead {
+		return fmt.Sprintf("HTTP HEAD request failed with status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("HTTP request failed with status %d", e.StatusCode)
+}
+
+// HTTPStatusCode returns the response status code
+func (e *HTTPStatusError) HTTPStatusCode() int {
+	return e.StatusCode
+}
+
+// IsTransient reports whether err is a failure that may succeed if the
+// operation is retried: a 5xx or 429 response, a dropped or refused
+// connection, or a network timeout. Cancellation is never transient.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	// Both HTTPStatusError and AWS SDK response errors report their status
+	var statusErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &statusErr) {
+		code := statusErr.HTTPStatusCode()
+		return code >= 500 || code == http.StatusTooManyRequests
+	}
+
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
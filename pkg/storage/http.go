@@ -5,18 +5,122 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
+	"time"
 )
 
 // HTTPStorage implements Storage for HTTP/HTTPS downloads
 type HTTPStorage struct {
-	client *http.Client
+	client       *http.Client
+	headers      http.Header
+	timeout      time.Duration
+	maxRedirects int
+}
+
+// DefaultHTTPMaxRedirects is the number of redirects followed by default
+const DefaultHTTPMaxRedirects = 10
+
+// sharedHTTPTransport pools connections across HTTPStorage instances that
+// do not supply their own client
+var sharedHTTPTransport = newSharedHTTPTransport()
+
+// newSharedHTTPTransport returns the default transport with more idle
+// connections kept per host, since inputs often come from a single CDN
+func newSharedHTTPTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = 16
+	return transport
+}
+
+// HTTPOption is a functional option for HTTPStorage
+type HTTPOption func(*HTTPStorage)
+
+// WithHTTPHeader adds a header sent with every request, e.g. Authorization
+func WithHTTPHeader(key, value string) HTTPOption {
+	return func(hs *HTTPStorage) {
+		hs.headers.Add(key, value)
+	}
+}
+
+// WithHTTPHeaders adds headers sent with every request
+func WithHTTPHeaders(headers http.Header) HTTPOption {
+	return func(hs *HTTPStorage) {
+		for key, values := range headers {
+			for _, value := range values {
+				hs.headers.Add(key, value)
+			}
+		}
+	}
+}
+
+// WithHTTPTimeout limits each request, including reading the response body
+// (0 = no timeout)
+func WithHTTPTimeout(d time.Duration) HTTPOption {
+	return func(hs *HTTPStorage) {
+		hs.timeout = d
+	}
+}
+
+// WithHTTPMaxRedirects sets how many redirects are followed before a
+// request fails (0 = do not follow redirects)
+func WithHTTPMaxRedirects(n int) HTTPOption {
+	return func(hs *HTTPStorage) {
+		hs.maxRedirects = n
+	}
+}
+
+// WithHTTPClient uses client for requests, e.g. to share its connection
+// pool with other components. Timeout and redirect options are applied to
+// a copy of it.
+func WithHTTPClient(client *http.Client) HTTPOption {
+	return func(hs *HTTPStorage) {
+		hs.client = client
+	}
 }
 
 // NewHTTPStorage creates a new HTTP storage backend
-func NewHTTPStorage() *HTTPStorage {
-	return &HTTPStorage{
-		client: &http.Client{},
+func NewHTTPStorage(opts ...HTTPOption) *HTTPStorage {
+	hs := &HTTPStorage{
+		headers:      make(http.Header),
+		maxRedirects: DefaultHTTPMaxRedirects,
+	}
+	for _, opt := range opts {
+		opt(hs)
+	}
+
+	client := &http.Client{Transport: sharedHTTPTransport}
+	if hs.client != nil {
+		copied := *hs.client
+		client = &copied
 	}
+	if hs.timeout > 0 {
+		client.Timeout = hs.timeout
+	}
+	maxRedirects := hs.maxRedirects
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if maxRedirects <= 0 {
+			return http.ErrUseLastResponse
+		}
+		if len(via) > maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		return nil
+	}
+	hs.client = client
+
+	return hs
+}
+
+// newRequest creates a request for uri with the configured headers
+func (hs *HTTPStorage) newRequest(ctx context.Context, method, uri string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, uri, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for key, values := range hs.headers {
+		req.Header[key] = append([]string(nil), values...)
+	}
+	return req, nil
 }
 
 // Get downloads a file over HTTP/HTTPS
@@ -30,9 +134,9 @@ func (hs *HTTPStorage) Get(ctx context.Context, uri string) (io.ReadCloser, erro
 		return nil, fmt.Errorf("HTTP storage only supports http:// and https:// URIs, got %s://", scheme)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", uri, nil)
+	req, err := hs.newRequest(ctx, http.MethodGet, uri)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
 
 	resp, err := hs.client.Do(req)
@@ -42,12 +146,55 @@ func (hs *HTTPStorage) Get(ctx context.Context, uri string) (io.ReadCloser, erro
 
 	if resp.StatusCode != http.StatusOK {
 		resp.Body.Close()
-		return nil, fmt.Errorf("HTTP request failed with status %d", resp.StatusCode)
+		return nil, &HTTPStatusError{Method: http.MethodGet, StatusCode: resp.StatusCode}
 	}
 
 	return resp.Body, nil
 }
 
+// GetRange downloads part of a file with an HTTP Range request. If the
+// server ignores the range and sends the whole file, the bytes before
+// offset are skipped.
+func (hs *HTTPStorage) GetRange(ctx context.Context, uri string, offset, length int64) (io.ReadCloser, error) {
+	if err := checkRange(offset); err != nil {
+		return nil, err
+	}
+
+	scheme, _, err := ParseURI(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	if scheme != "http" && scheme != "https" {
+		return nil, fmt.Errorf("HTTP storage only supports http:// and https:// URIs, got %s://", scheme)
+	}
+
+	req, err := hs.newRequest(ctx, http.MethodGet, uri)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", rangeHeader(offset, length))
+
+	resp, err := hs.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download: %w", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		if _, err := io.CopyN(io.Discard, resp.Body, offset); err != nil && err != io.EOF {
+			resp.Body.Close()
+			return nil, fmt.Errorf("failed to skip to offset %d: %w", offset, err)
+		}
+	default:
+		resp.Body.Close()
+		return nil, &HTTPStatusError{Method: http.MethodGet, StatusCode: resp.StatusCode}
+	}
+
+	return limitReadCloser(resp.Body, length), nil
+}
+
 // Put is not supported for HTTP storage (read-only)
 func (hs *HTTPStorage) Put(ctx context.Context, uri string, data io.Reader) error {
 	return fmt.Errorf("Put operation not supported for HTTP storage (read-only)")
@@ -58,6 +205,11 @@ func (hs *HTTPStorage) Delete(ctx context.Context, uri string) error {
 	return fmt.Errorf("HTTP storage does not support Delete operations (read-only)")
 }
 
+// ListObjects is not supported for HTTP storage (no directory listings)
+func (hs *HTTPStorage) ListObjects(ctx context.Context, dir string, recursive bool, pattern string) ([]ObjectInfo, error) {
+	return nil, fmt.Errorf("HTTP storage does not support ListObjects operations")
+}
+
 // Exists checks if a file exists by sending a HEAD request
 func (hs *HTTPStorage) Exists(ctx context.Context, uri string) (bool, error) {
 	scheme, _, err := ParseURI(uri)
@@ -69,9 +221,9 @@ func (hs *HTTPStorage) Exists(ctx context.Context, uri string) (bool, error) {
 		return false, fmt.Errorf("HTTP storage only supports http:// and https:// URIs, got %s://", scheme)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "HEAD", uri, nil)
+	req, err := hs.newRequest(ctx, http.MethodHead, uri)
 	if err != nil {
-		return false, fmt.Errorf("failed to create request: %w", err)
+		return false, err
 	}
 
 	resp, err := hs.client.Do(req)
@@ -82,3 +234,42 @@ func (hs *HTTPStorage) Exists(ctx context.Context, uri string) (bool, error) {
 
 	return resp.StatusCode == http.StatusOK, nil
 }
+
+// Stat returns file metadata from the response headers of a HEAD request.
+// Size is -1 if the server does not send Content-Length.
+func (hs *HTTPStorage) Stat(ctx context.Context, uri string) (*ObjectInfo, error) {
+	scheme, _, err := ParseURI(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	if scheme != "http" && scheme != "https" {
+		return nil, fmt.Errorf("HTTP storage only supports http:// and https:// URIs, got %s://", scheme)
+	}
+
+	req, err := hs.newRequest(ctx, http.MethodHead, uri)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := hs.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &HTTPStatusError{Method: http.MethodHead, StatusCode: resp.StatusCode}
+	}
+
+	info := &ObjectInfo{
+		Size:        resp.ContentLength,
+		ETag:        strings.Trim(resp.Header.Get("ETag"), `"`),
+		ContentType: resp.Header.Get("Content-Type"),
+	}
+	if lastModified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.LastModified = lastModified
+	}
+
+	return info, nil
+}
@@ -5,7 +5,9 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -82,3 +84,186 @@ func TestHTTPStorage_Exists(t *testing.T) {
 	require.NoError(t, err)
 	assert.False(t, exists)
 }
+
+func TestHTTPStorage_Stat(t *testing.T) {
+	lastModified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodHead, r.Method)
+		if r.URL.Path != "/video.mp4" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Length", "1048576")
+		w.Header().Set("Content-Type", "video/mp4")
+		w.Header().Set("ETag", `"abc123"`)
+		w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	storage := NewHTTPStorage()
+	ctx := context.Background()
+
+	info, err := storage.Stat(ctx, server.URL+"/video.mp4")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1048576), info.Size)
+	assert.Equal(t, "video/mp4", info.ContentType)
+	assert.Equal(t, "abc123", info.ETag)
+	assert.True(t, lastModified.Equal(info.LastModified))
+
+	_, err = storage.Stat(ctx, server.URL+"/missing.mp4")
+	assert.Error(t, err)
+}
+
+func TestHTTPStorage_Headers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer cdn-token" || r.Header.Get("X-Tenant") != "acme" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Length", "4")
+		w.Write([]byte("data"))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+
+	_, err := NewHTTPStorage().Get(ctx, server.URL+"/in.mp4")
+	require.Error(t, err)
+
+	storage := NewHTTPStorage(
+		WithHTTPHeader("Authorization", "Bearer cdn-token"),
+		WithHTTPHeaders(http.Header{"X-Tenant": {"acme"}}),
+	)
+
+	reader, err := storage.Get(ctx, server.URL+"/in.mp4")
+	require.NoError(t, err)
+	content, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.NoError(t, reader.Close())
+	assert.Equal(t, "data", string(content))
+
+	// HEAD requests carry the headers too
+	info, err := storage.Stat(ctx, server.URL+"/in.mp4")
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), info.Size)
+}
+
+func TestHTTPStorage_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	storage := NewHTTPStorage(WithHTTPTimeout(50 * time.Millisecond))
+
+	start := time.Now()
+	_, err := storage.Get(context.Background(), server.URL+"/slow.mp4")
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestHTTPStorage_MaxRedirects(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/final.mp4", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("final"))
+	})
+	mux.HandleFunc("/hop2", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/final.mp4", http.StatusFound)
+	})
+	mux.HandleFunc("/hop1", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/hop2", http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	ctx := context.Background()
+
+	// Redirects are followed by default
+	reader, err := NewHTTPStorage().Get(ctx, server.URL+"/hop1")
+	require.NoError(t, err)
+	content, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.NoError(t, reader.Close())
+	assert.Equal(t, "final", string(content))
+
+	_, err = NewHTTPStorage(WithHTTPMaxRedirects(1)).Get(ctx, server.URL+"/hop1")
+	assert.ErrorContains(t, err, "stopped after 1 redirects")
+
+	_, err = NewHTTPStorage(WithHTTPMaxRedirects(0)).Get(ctx, server.URL+"/hop1")
+	var statusErr *HTTPStatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusFound, statusErr.StatusCode)
+}
+
+func TestHTTPStorage_SharedClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("data"))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: http.DefaultTransport}
+	storage := NewHTTPStorage(WithHTTPClient(client), WithHTTPTimeout(time.Second))
+
+	exists, err := storage.Exists(context.Background(), server.URL+"/in.mp4")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	// The caller's client is not modified
+	assert.Zero(t, client.Timeout)
+	assert.Nil(t, client.CheckRedirect)
+}
+
+func TestHTTPStorage_GetRange(t *testing.T) {
+	content := "0123456789"
+	var rangeHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rangeHeader = r.Header.Get("Range")
+		http.ServeContent(w, r, "video.mp4", time.Time{}, strings.NewReader(content))
+	}))
+	defer server.Close()
+
+	storage := NewHTTPStorage()
+	ctx := context.Background()
+
+	reader, err := storage.GetRange(ctx, server.URL+"/video.mp4", 2, 3)
+	require.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.NoError(t, reader.Close())
+	assert.Equal(t, "234", string(data))
+	assert.Equal(t, "bytes=2-4", rangeHeader)
+
+	reader, err = storage.GetRange(ctx, server.URL+"/video.mp4", 6, 0)
+	require.NoError(t, err)
+	data, err = io.ReadAll(reader)
+	require.NoError(t, err)
+	require.NoError(t, reader.Close())
+	assert.Equal(t, "6789", string(data))
+	assert.Equal(t, "bytes=6-", rangeHeader)
+
+	// Unsatisfiable ranges are reported as status errors
+	_, err = storage.GetRange(ctx, server.URL+"/video.mp4", 20, 5)
+	var statusErr *HTTPStatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, statusErr.StatusCode)
+}
+
+func TestHTTPStorage_GetRange_IgnoredByServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("0123456789"))
+	}))
+	defer server.Close()
+
+	reader, err := NewHTTPStorage().GetRange(context.Background(), server.URL+"/video.mp4", 4, 2)
+	require.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.NoError(t, reader.Close())
+	assert.Equal(t, "45", string(data))
+}
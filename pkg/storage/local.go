@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 )
@@ -18,6 +19,11 @@ func NewLocalStorage() *LocalStorage {
 
 // Get reads a local file
 func (ls *LocalStorage) Get(ctx context.Context, uri string) (io.ReadCloser, error) {
+	return ls.open(uri)
+}
+
+// open opens the local file named by uri
+func (ls *LocalStorage) open(uri string) (*os.File, error) {
 	scheme, path, err := ParseURI(uri)
 	if err != nil {
 		return nil, err
@@ -35,6 +41,19 @@ func (ls *LocalStorage) Get(ctx context.Context, uri string) (io.ReadCloser, err
 	return file, nil
 }
 
+// GetRange reads part of a local file
+func (ls *LocalStorage) GetRange(ctx context.Context, uri string, offset, length int64) (io.ReadCloser, error) {
+	if err := checkRange(offset); err != nil {
+		return nil, err
+	}
+
+	file, err := ls.open(uri)
+	if err != nil {
+		return nil, err
+	}
+	return seekRange(file, offset, length)
+}
+
 // Put writes data to a local file
 func (ls *LocalStorage) Put(ctx context.Context, uri string, data io.Reader) error {
 	scheme, path, err := ParseURI(uri)
@@ -105,3 +124,86 @@ func (ls *LocalStorage) Exists(ctx context.Context, uri string) (bool, error) {
 	}
 	return false, err
 }
+
+// Stat returns the size and modification time of a local file.
+// The content type is guessed from the file extension.
+func (ls *LocalStorage) Stat(ctx context.Context, uri string) (*ObjectInfo, error) {
+	scheme, path, err := ParseURI(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	if scheme != "file" {
+		return nil, fmt.Errorf("local storage only supports file:// URIs, got %s://", scheme)
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	if fi.IsDir() {
+		return nil, fmt.Errorf("not a file: %s", path)
+	}
+
+	return &ObjectInfo{
+		Size:         fi.Size(),
+		LastModified: fi.ModTime(),
+		ContentType:  ContentTypeForURI(path),
+	}, nil
+}
+
+// ListObjects lists the files in a local directory
+func (ls *LocalStorage) ListObjects(ctx context.Context, dir string, recursive bool, pattern string) ([]ObjectInfo, error) {
+	scheme, root, err := ParseURI(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if scheme != "file" {
+		return nil, fmt.Errorf("local storage only supports file:// URIs, got %s://", scheme)
+	}
+
+	// Validate the pattern up front rather than per file
+	if _, err := matchPattern(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	var objects []ObjectInfo
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if path != root && !recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if matched, _ := matchPattern(pattern, path); !matched {
+			return nil
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		objects = append(objects, ObjectInfo{
+			URI:          "file://" + path,
+			Size:         fi.Size(),
+			LastModified: fi.ModTime(),
+			ContentType:  ContentTypeForURI(path),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list directory: %w", err)
+	}
+
+	return objects, nil
+}
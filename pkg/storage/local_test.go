@@ -39,6 +39,35 @@ func TestLocalStorage_GetPut(t *testing.T) {
 	assert.Equal(t, testContent, string(content))
 }
 
+func TestLocalStorage_GetRange(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "test.txt")
+	require.NoError(t, os.WriteFile(testFile, []byte("0123456789"), 0644))
+
+	storage := NewLocalStorage()
+	ctx := context.Background()
+
+	tests := []struct {
+		offset, length int64
+		want           string
+	}{
+		{2, 3, "234"},
+		{7, 0, "789"},
+		{8, 10, "89"},
+		{10, 5, ""},
+	}
+	for _, tt := range tests {
+		reader, err := storage.GetRange(ctx, "file://"+testFile, tt.offset, tt.length)
+		require.NoError(t, err)
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		require.NoError(t, reader.Close())
+		assert.Equal(t, tt.want, string(content), "offset=%d length=%d", tt.offset, tt.length)
+	}
+
+	_, err := storage.GetRange(ctx, "file://"+testFile, -1, 3)
+	assert.Error(t, err)
+}
+
 func TestLocalStorage_Exists(t *testing.T) {
 	tmpDir := t.TempDir()
 	existingFile := filepath.Join(tmpDir, "existing.txt")
@@ -74,3 +103,105 @@ func TestLocalStorage_Delete(t *testing.T) {
 	_, err = os.Stat(testFile)
 	assert.True(t, os.IsNotExist(err))
 }
+
+func TestLocalStorage_Stat(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "video.mp4")
+	require.NoError(t, os.WriteFile(testFile, []byte("0123456789"), 0644))
+
+	storage := NewLocalStorage()
+	ctx := context.Background()
+
+	info, err := storage.Stat(ctx, "file://"+testFile)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), info.Size)
+	assert.False(t, info.LastModified.IsZero())
+	assert.Equal(t, "video/mp4", info.ContentType)
+
+	// Missing file
+	_, err = storage.Stat(ctx, "file://"+filepath.Join(tmpDir, "missing.mp4"))
+	assert.Error(t, err)
+}
+
+func TestLocalStorage_ListObjects(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"a.mp4", "b.mov", "nested/c.mp4", "nested/deeper/d.mp4"} {
+		path := filepath.Join(tmpDir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(name), 0644))
+	}
+
+	storage := NewLocalStorage()
+	ctx := context.Background()
+	dir := "file://" + tmpDir
+
+	uris := func(objects []ObjectInfo) []string {
+		var result []string
+		for _, obj := range objects {
+			result = append(result, strings.TrimPrefix(obj.URI, dir+"/"))
+		}
+		return result
+	}
+
+	t.Run("non-recursive", func(t *testing.T) {
+		objects, err := storage.ListObjects(ctx, dir, false, "")
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"a.mp4", "b.mov"}, uris(objects))
+	})
+
+	t.Run("recursive", func(t *testing.T) {
+		objects, err := storage.ListObjects(ctx, dir, true, "")
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"a.mp4", "b.mov", "nested/c.mp4", "nested/deeper/d.mp4"}, uris(objects))
+
+		for _, obj := range objects {
+			assert.Greater(t, obj.Size, int64(0))
+			assert.False(t, obj.LastModified.IsZero())
+		}
+	})
+
+	t.Run("pattern", func(t *testing.T) {
+		objects, err := storage.ListObjects(ctx, dir, true, "*.mp4")
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"a.mp4", "nested/c.mp4", "nested/deeper/d.mp4"}, uris(objects))
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		_, err := storage.ListObjects(ctx, dir, true, "[")
+		assert.Error(t, err)
+	})
+}
+
+func TestLocalStorage_List(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"frames/frame_0001.png", "frames/frame_0002.png", "frames/thumb.png", "frames/frame_x/frame_0003.png"} {
+		path := filepath.Join(tmpDir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(name), 0644))
+	}
+
+	storage := NewLocalStorage()
+	ctx := context.Background()
+	base := "file://" + tmpDir
+
+	uris, err := List(ctx, storage, base+"/frames/frame_")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		base + "/frames/frame_0001.png",
+		base + "/frames/frame_0002.png",
+		base + "/frames/frame_x/frame_0003.png",
+	}, uris)
+
+	// A trailing slash lists the whole directory
+	uris, err = List(ctx, storage, base+"/frames/")
+	require.NoError(t, err)
+	assert.Len(t, uris, 4)
+
+	// Missing directories list nothing
+	uris, err = List(ctx, storage, base+"/missing/frame_")
+	require.NoError(t, err)
+	assert.Empty(t, uris)
+
+	_, err = List(ctx, storage, "s3://bucket/frames/")
+	assert.Error(t, err)
+}
@@ -0,0 +1,43 @@
+package storage
+
+import "io"
+
+// ProgressFunc receives the number of bytes transferred so far and the
+// total size of the transfer, or -1 if the size is unknown
+type ProgressFunc func(bytesTransferred, total int64)
+
+// ProgressReader reports the bytes read through it to a ProgressFunc
+type ProgressReader struct {
+	r           io.Reader
+	total       int64
+	transferred int64
+	onProgress  ProgressFunc
+}
+
+// NewProgressReader wraps r so that onProgress is called after every read
+// that returns data. If onProgress is nil, r is returned unchanged.
+func NewProgressReader(r io.Reader, total int64, onProgress ProgressFunc) io.Reader {
+	if onProgress == nil {
+		return r
+	}
+	return &ProgressReader{
+		r:          r,
+		total:      total,
+		onProgress: onProgress,
+	}
+}
+
+// Read reads from the underlying reader and reports progress
+func (p *ProgressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.transferred += int64(n)
+		p.onProgress(p.transferred, p.total)
+	}
+	return n, err
+}
+
+// Transferred returns the number of bytes read so far
+func (p *ProgressReader) Transferred() int64 {
+	return p.transferred
+}
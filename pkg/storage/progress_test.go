@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgressReader(t *testing.T) {
+	data := bytes.Repeat([]byte("x"), 10000)
+
+	var reports [][2]int64
+	r := NewProgressReader(bytes.NewReader(data), int64(len(data)), func(transferred, total int64) {
+		reports = append(reports, [2]int64{transferred, total})
+	})
+
+	// ReadAll reads in growing chunks, producing several reports
+	out, err := io.ReadAll(io.LimitReader(r, int64(len(data))))
+	require.NoError(t, err)
+	assert.Equal(t, data, out)
+
+	require.Greater(t, len(reports), 1)
+	for i, report := range reports {
+		assert.Equal(t, int64(len(data)), report[1])
+		if i > 0 {
+			assert.Greater(t, report[0], reports[i-1][0])
+		}
+	}
+	assert.Equal(t, int64(len(data)), reports[len(reports)-1][0])
+	assert.Equal(t, int64(len(data)), r.(*ProgressReader).Transferred())
+}
+
+func TestNewProgressReader_NilFunc(t *testing.T) {
+	src := bytes.NewReader([]byte("data"))
+	assert.Same(t, src, NewProgressReader(src, 4, nil))
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// BackendFactory creates a Storage for a registered URI scheme
+type BackendFactory func() (Storage, error)
+
+// backendRegistry holds the custom storage backends registered by scheme
+var backendRegistry = struct {
+	mu        sync.RWMutex
+	factories map[string]BackendFactory
+}{
+	factories: make(map[string]BackendFactory),
+}
+
+// RegisterBackend registers a factory for URIs with the given scheme, e.g.
+// RegisterBackend("gs", newGCSStorage). Registered backends take precedence
+// over the built-in ones and their schemes are allowed by IsAllowedScheme.
+// Registering a scheme again replaces its factory.
+func RegisterBackend(scheme string, factory BackendFactory) {
+	if scheme == "" || factory == nil {
+		panic(fmt.Sprintf("storage: invalid backend registration for scheme %q", scheme))
+	}
+
+	backendRegistry.mu.Lock()
+	defer backendRegistry.mu.Unlock()
+	backendRegistry.factories[scheme] = factory
+}
+
+// UnregisterBackend removes the factory registered for scheme
+func UnregisterBackend(scheme string) {
+	backendRegistry.mu.Lock()
+	defer backendRegistry.mu.Unlock()
+	delete(backendRegistry.factories, scheme)
+}
+
+// LookupBackend returns the factory registered for scheme
+func LookupBackend(scheme string) (BackendFactory, bool) {
+	backendRegistry.mu.RLock()
+	defer backendRegistry.mu.RUnlock()
+	factory, ok := backendRegistry.factories[scheme]
+	return factory, ok
+}
+
+// RegisteredBackends returns the schemes with a registered backend, sorted
+func RegisteredBackends() []string {
+	backendRegistry.mu.RLock()
+	defer backendRegistry.mu.RUnlock()
+
+	schemes := make([]string, 0, len(backendRegistry.factories))
+	for scheme := range backendRegistry.factories {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
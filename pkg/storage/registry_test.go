@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterBackend(t *testing.T) {
+	_, ok := LookupBackend("mem")
+	require.False(t, ok)
+	assert.False(t, IsAllowedScheme("mem"))
+
+	local := NewLocalStorage()
+	RegisterBackend("mem", func() (Storage, error) { return local, nil })
+	defer UnregisterBackend("mem")
+
+	factory, ok := LookupBackend("mem")
+	require.True(t, ok)
+	stor, err := factory()
+	require.NoError(t, err)
+	assert.Same(t, local, stor)
+
+	assert.True(t, IsAllowedScheme("mem"))
+	assert.Contains(t, RegisteredBackends(), "mem")
+
+	UnregisterBackend("mem")
+	_, ok = LookupBackend("mem")
+	assert.False(t, ok)
+}
+
+func TestRegisterBackend_Invalid(t *testing.T) {
+	assert.Panics(t, func() { RegisterBackend("", func() (Storage, error) { return nil, nil }) })
+	assert.Panics(t, func() { RegisterBackend("mem", nil) })
+}
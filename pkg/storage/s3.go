@@ -1,23 +1,42 @@
 package storage
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/aws/smithy-go"
 )
 
+// DefaultS3PartSize is the multipart upload part size used when
+// S3Storage.PartSize is not set. Uploads smaller than one part are sent
+// with a single PutObject request.
+const DefaultS3PartSize int64 = 16 * 1024 * 1024
+
+// DefaultS3UploadConcurrency is the number of parts uploaded in parallel
+// when S3Storage.Concurrency is not set
+const DefaultS3UploadConcurrency = 4
+
 // S3Storage implements Storage for Amazon S3
 type S3Storage struct {
 	client *s3.Client
+
+	// PartSize is the multipart upload part size in bytes (minimum 5MB)
+	PartSize int64
+
+	// Concurrency is the number of parts uploaded in parallel
+	Concurrency int
 }
 
 // NewS3Storage creates a new S3 storage backend
@@ -33,6 +52,33 @@ func NewS3Storage(ctx context.Context) (*S3Storage, error) {
 	}, nil
 }
 
+// NewS3StorageWithOptions creates an S3 storage backend for an
+// S3-compatible service such as MinIO or Cloudflare R2. An empty endpoint
+// or region keeps the SDK default. Path-style addressing
+// (https://endpoint/bucket/key) is required by most self-hosted services.
+func NewS3StorageWithOptions(ctx context.Context, endpoint, region string, pathStyle bool) (*S3Storage, error) {
+	var loadOpts []func(*config.LoadOptions) error
+	if region != "" {
+		loadOpts = append(loadOpts, config.WithRegion(region))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+		}
+		o.UsePathStyle = pathStyle
+	})
+
+	return &S3Storage{
+		client: client,
+	}, nil
+}
+
 // NewS3StorageWithClient creates a new S3 storage with a custom client
 // Useful for testing and custom configurations
 func NewS3StorageWithClient(client *s3.Client) *S3Storage {
@@ -88,25 +134,136 @@ func (s *S3Storage) Get(ctx context.Context, uri string) (io.ReadCloser, error)
 	return result.Body, nil
 }
 
-// Put uploads data to S3
-func (s *S3Storage) Put(ctx context.Context, uri string, data io.Reader) error {
+// GetRange downloads part of an object from S3 using a Range request
+func (s *S3Storage) GetRange(ctx context.Context, uri string, offset, length int64) (io.ReadCloser, error) {
+	if err := checkRange(offset); err != nil {
+		return nil, err
+	}
+
 	bucket, key, err := parseS3URI(uri)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+	result, err := s.client.GetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(bucket),
 		Key:    aws.String(key),
-		Body:   data,
+		Range:  aws.String(rangeHeader(offset, length)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get S3 object range: %w", err)
+	}
+
+	return limitReadCloser(result.Body, length), nil
+}
+
+// Put uploads data to S3.
+// Data that fits in a single part is sent with PutObject; larger streams
+// use a multipart upload so they are never fully buffered in memory.
+func (s *S3Storage) Put(ctx context.Context, uri string, data io.Reader) error {
+	return s.PutWithContentType(ctx, uri, data, "")
+}
+
+// PutWithContentType uploads data like Put, storing contentType as the
+// object's Content-Type. An empty contentType is detected from the key's
+// extension.
+func (s *S3Storage) PutWithContentType(ctx context.Context, uri string, data io.Reader, contentType string) error {
+	bucket, key, err := parseS3URI(uri)
+	if err != nil {
+		return err
+	}
+
+	if contentType == "" {
+		contentType = ContentTypeForURI(key)
+	}
+	var contentTypeValue *string
+	if contentType != "" {
+		contentTypeValue = aws.String(contentType)
+	}
+
+	partSize := s.PartSize
+	if partSize <= 0 {
+		partSize = DefaultS3PartSize
+	}
+
+	// Read up to one part to decide between a single and a multipart upload
+	var head bytes.Buffer
+	n, err := io.CopyN(&head, data, partSize)
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("failed to read upload data: %w", err)
+	}
+
+	if n < partSize {
+		_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:      aws.String(bucket),
+			Key:         aws.String(key),
+			Body:        bytes.NewReader(head.Bytes()),
+			ContentType: contentTypeValue,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to put S3 object: %w", err)
+		}
+		return nil
+	}
+
+	concurrency := s.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultS3UploadConcurrency
+	}
+
+	uploader := manager.NewUploader(s.client, func(u *manager.Uploader) {
+		u.PartSize = partSize
+		u.Concurrency = concurrency
+	})
+
+	_, err = uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		Body:        io.MultiReader(&head, data),
+		ContentType: contentTypeValue,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to put S3 object: %w", err)
+		return fmt.Errorf("failed to upload S3 object: %w", err)
 	}
 
 	return nil
 }
 
+// Presign returns a URL granting temporary access to an S3 object without
+// credentials. method is http.MethodGet (download) or http.MethodPut (upload).
+func (s *S3Storage) Presign(ctx context.Context, uri string, expiry time.Duration, method string) (string, error) {
+	bucket, key, err := parseS3URI(uri)
+	if err != nil {
+		return "", err
+	}
+	if expiry <= 0 {
+		return "", fmt.Errorf("presign expiry must be positive, got %v", expiry)
+	}
+
+	presigner := s3.NewPresignClient(s.client, s3.WithPresignExpires(expiry))
+
+	var req *v4.PresignedHTTPRequest
+	switch method {
+	case http.MethodGet:
+		req, err = presigner.PresignGetObject(ctx, &s3.GetObjectInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(key),
+		})
+	case http.MethodPut:
+		req, err = presigner.PresignPutObject(ctx, &s3.PutObjectInput{
+			Bucket: aws.String(bucket),
+			Key:    aws.String(key),
+		})
+	default:
+		return "", fmt.Errorf("unsupported presign method: %s", method)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to presign S3 object: %w", err)
+	}
+
+	return req.URL, nil
+}
+
 // Delete removes an object from S3
 func (s *S3Storage) Delete(ctx context.Context, uri string) error {
 	bucket, key, err := parseS3URI(uri)
@@ -169,3 +326,97 @@ func (s *S3Storage) Exists(ctx context.Context, uri string) (bool, error) {
 
 	return true, nil
 }
+
+// Stat returns object metadata from a HeadObject request
+func (s *S3Storage) Stat(ctx context.Context, uri string) (*ObjectInfo, error) {
+	bucket, key, err := parseS3URI(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat S3 object: %w", err)
+	}
+
+	info := &ObjectInfo{
+		Size:        -1,
+		ETag:        strings.Trim(aws.ToString(result.ETag), `"`),
+		ContentType: aws.ToString(result.ContentType),
+	}
+	if result.ContentLength != nil {
+		info.Size = *result.ContentLength
+	}
+	if result.LastModified != nil {
+		info.LastModified = *result.LastModified
+	}
+
+	return info, nil
+}
+
+// ListObjects lists the objects under a prefix using ListObjectsV2.
+// Without recursive, only objects directly under the prefix are returned.
+func (s *S3Storage) ListObjects(ctx context.Context, dir string, recursive bool, pattern string) ([]ObjectInfo, error) {
+	scheme, path, err := ParseURI(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if scheme != "s3" {
+		return nil, fmt.Errorf("S3 storage only supports s3:// URIs, got %s://", scheme)
+	}
+
+	bucket, prefix, _ := strings.Cut(path, "/")
+	if bucket == "" {
+		return nil, fmt.Errorf("invalid S3 URI: missing bucket name")
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	if _, err := matchPattern(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	}
+	if !recursive {
+		input.Delimiter = aws.String("/")
+	}
+
+	var objects []ObjectInfo
+	paginator := s3.NewListObjectsV2Paginator(s.client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list S3 objects: %w", err)
+		}
+
+		for _, obj := range page.Contents {
+			key := aws.ToString(obj.Key)
+			if strings.HasSuffix(key, "/") {
+				continue // Directory placeholder
+			}
+			if matched, _ := matchPattern(pattern, key); !matched {
+				continue
+			}
+
+			info := ObjectInfo{
+				URI:  "s3://" + bucket + "/" + key,
+				Size: aws.ToInt64(obj.Size),
+				ETag: strings.Trim(aws.ToString(obj.ETag), `"`),
+			}
+			if obj.LastModified != nil {
+				info.LastModified = *obj.LastModified
+			}
+			objects = append(objects, info)
+		}
+	}
+
+	return objects, nil
+}
@@ -2,9 +2,19 @@ package storage
 
 import (
 	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestParseS3URI(t *testing.T) {
@@ -117,6 +127,298 @@ func TestS3StorageInterface(t *testing.T) {
 	var _ Storage = storage
 }
 
+func TestNewS3StorageWithOptions_MinIO(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "minioadmin")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "minioadmin")
+	t.Setenv("AWS_CONFIG_FILE", "/nonexistent")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/nonexistent")
+
+	var gotPath, gotHost, gotAuth string
+	minio := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotHost = r.Host
+		gotAuth = r.Header.Get("Authorization")
+		w.Header().Set("Content-Length", "4")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer minio.Close()
+
+	ctx := context.Background()
+	storage, err := NewS3StorageWithOptions(ctx, minio.URL, "us-east-1", true)
+	require.NoError(t, err)
+
+	exists, err := storage.Exists(ctx, "s3://media/videos/input.mp4")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	// Path-style requests go to the custom endpoint with the bucket in the path
+	assert.Equal(t, "/media/videos/input.mp4", gotPath)
+	assert.Equal(t, strings.TrimPrefix(minio.URL, "http://"), gotHost)
+	assert.Contains(t, gotAuth, "Credential=minioadmin/")
+	assert.Contains(t, gotAuth, "/us-east-1/s3/")
+}
+
+// newTestS3Storage returns an S3Storage backed by a fake S3 endpoint
+func newTestS3Storage(handler http.HandlerFunc) (*S3Storage, func()) {
+	server := httptest.NewServer(handler)
+	client := s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(server.URL),
+		UsePathStyle: true,
+		Credentials:  aws.AnonymousCredentials{},
+	})
+	return NewS3StorageWithClient(client), server.Close
+}
+
+func TestS3Storage_Stat(t *testing.T) {
+	lastModified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	storage, cleanup := newTestS3Storage(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodHead, r.Method)
+		if r.URL.Path != "/my-bucket/videos/input.mp4" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Length", "2048")
+		w.Header().Set("Content-Type", "video/mp4")
+		w.Header().Set("ETag", `"d41d8cd98f00b204e9800998ecf8427e"`)
+		w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+		w.WriteHeader(http.StatusOK)
+	})
+	defer cleanup()
+
+	ctx := context.Background()
+
+	info, err := storage.Stat(ctx, "s3://my-bucket/videos/input.mp4")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2048), info.Size)
+	assert.Equal(t, "video/mp4", info.ContentType)
+	assert.Equal(t, "d41d8cd98f00b204e9800998ecf8427e", info.ETag)
+	assert.True(t, lastModified.Equal(info.LastModified))
+
+	_, err = storage.Stat(ctx, "s3://my-bucket/videos/missing.mp4")
+	assert.Error(t, err)
+}
+
+func TestS3Storage_GetRange(t *testing.T) {
+	var rangeHeader string
+	storage, cleanup := newTestS3Storage(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "/my-bucket/videos/input.mp4", r.URL.Path)
+		rangeHeader = r.Header.Get("Range")
+		http.ServeContent(w, r, "input.mp4", time.Time{}, strings.NewReader("0123456789"))
+	})
+	defer cleanup()
+
+	reader, err := storage.GetRange(context.Background(), "s3://my-bucket/videos/input.mp4", 3, 4)
+	require.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.NoError(t, reader.Close())
+
+	assert.Equal(t, "bytes=3-6", rangeHeader)
+	assert.Equal(t, "3456", string(data))
+}
+
+func TestS3Storage_ListObjects(t *testing.T) {
+	var delimiter string
+	storage, cleanup := newTestS3Storage(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/my-bucket", r.URL.Path)
+		assert.Equal(t, "videos/", r.URL.Query().Get("prefix"))
+		delimiter = r.URL.Query().Get("delimiter")
+
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<ListBucketResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Name>my-bucket</Name>
+  <Prefix>videos/</Prefix>
+  <KeyCount>2</KeyCount>
+  <IsTruncated>false</IsTruncated>
+  <Contents><Key>videos/a.mp4</Key><Size>100</Size><ETag>"etag-a"</ETag><LastModified>2024-01-02T03:04:05.000Z</LastModified></Contents>
+  <Contents><Key>videos/b.mov</Key><Size>200</Size><ETag>"etag-b"</ETag><LastModified>2024-01-02T03:04:05.000Z</LastModified></Contents>
+</ListBucketResult>`))
+	})
+	defer cleanup()
+
+	ctx := context.Background()
+
+	objects, err := storage.ListObjects(ctx, "s3://my-bucket/videos", false, "*.mp4")
+	require.NoError(t, err)
+	assert.Equal(t, "/", delimiter)
+	require.Len(t, objects, 1)
+	assert.Equal(t, "s3://my-bucket/videos/a.mp4", objects[0].URI)
+	assert.Equal(t, int64(100), objects[0].Size)
+	assert.Equal(t, "etag-a", objects[0].ETag)
+
+	objects, err = storage.ListObjects(ctx, "s3://my-bucket/videos/", true, "")
+	require.NoError(t, err)
+	assert.Equal(t, "", delimiter)
+	assert.Len(t, objects, 2)
+}
+
+func TestS3Storage_List(t *testing.T) {
+	storage, cleanup := newTestS3Storage(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/my-bucket", r.URL.Path)
+		assert.Equal(t, "frames/", r.URL.Query().Get("prefix"))
+		assert.Empty(t, r.URL.Query().Get("delimiter"))
+
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<ListBucketResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Name>my-bucket</Name>
+  <Prefix>frames/</Prefix>
+  <KeyCount>4</KeyCount>
+  <IsTruncated>false</IsTruncated>
+  <Contents><Key>frames/frame_0001.png</Key><Size>100</Size></Contents>
+  <Contents><Key>frames/frame_0002.png</Key><Size>100</Size></Contents>
+  <Contents><Key>frames/frame_dir/</Key><Size>0</Size></Contents>
+  <Contents><Key>frames/thumb.png</Key><Size>100</Size></Contents>
+</ListBucketResult>`))
+	})
+	defer cleanup()
+
+	uris, err := List(context.Background(), storage, "s3://my-bucket/frames/frame_")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"s3://my-bucket/frames/frame_0001.png",
+		"s3://my-bucket/frames/frame_0002.png",
+	}, uris)
+}
+
+func TestS3Storage_PutSmallObject(t *testing.T) {
+	var requests []string
+	var body []byte
+	storage, cleanup := newTestS3Storage(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.RawQuery)
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	})
+	defer cleanup()
+
+	err := storage.Put(context.Background(), "s3://my-bucket/small.txt", strings.NewReader("hello"))
+	require.NoError(t, err)
+
+	// Small uploads use a single PutObject request
+	require.Len(t, requests, 1)
+	assert.Equal(t, http.MethodPut, strings.Fields(requests[0])[0])
+	assert.Contains(t, string(body), "hello")
+}
+
+func TestS3Storage_PutContentType(t *testing.T) {
+	var contentType string
+	storage, cleanup := newTestS3Storage(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		w.WriteHeader(http.StatusOK)
+	})
+	defer cleanup()
+
+	ctx := context.Background()
+
+	// Detected from the destination extension
+	require.NoError(t, storage.Put(ctx, "s3://my-bucket/videos/out.mp4", strings.NewReader("video")))
+	assert.Equal(t, "video/mp4", contentType)
+
+	// An explicit hint wins
+	require.NoError(t, storage.PutWithContentType(ctx, "s3://my-bucket/videos/out.bin", strings.NewReader("video"), "video/webm"))
+	assert.Equal(t, "video/webm", contentType)
+}
+
+func TestS3Storage_PutMultipart(t *testing.T) {
+	const partSize = 5 * 1024 * 1024
+	const size = 2*partSize + 1024
+
+	var mu sync.Mutex
+	var created, completed bool
+	parts := make(map[string]int)
+	storage, cleanup := newTestS3Storage(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		query := r.URL.Query()
+		assert.Equal(t, "/my-bucket/large.mp4", r.URL.Path)
+
+		switch {
+		case r.Method == http.MethodPost && query.Has("uploads"):
+			created = true
+			assert.Equal(t, "video/mp4", r.Header.Get("Content-Type"))
+			w.Header().Set("Content-Type", "application/xml")
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<InitiateMultipartUploadResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Bucket>my-bucket</Bucket><Key>large.mp4</Key><UploadId>upload-1</UploadId>
+</InitiateMultipartUploadResult>`))
+		case r.Method == http.MethodPut && query.Get("uploadId") == "upload-1":
+			data, err := io.ReadAll(r.Body)
+			assert.NoError(t, err)
+			parts[query.Get("partNumber")] = len(data)
+			w.Header().Set("ETag", `"etag-`+query.Get("partNumber")+`"`)
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodPost && query.Get("uploadId") == "upload-1":
+			completed = true
+			w.Header().Set("Content-Type", "application/xml")
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<CompleteMultipartUploadResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Bucket>my-bucket</Bucket><Key>large.mp4</Key><ETag>"etag-final"</ETag>
+</CompleteMultipartUploadResult>`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+	defer cleanup()
+
+	storage.PartSize = partSize
+	storage.Concurrency = 2
+
+	// A non-seekable stream larger than one part
+	data := io.LimitReader(zeroReader{}, size)
+	err := storage.Put(context.Background(), "s3://my-bucket/large.mp4", data)
+	require.NoError(t, err)
+
+	assert.True(t, created)
+	assert.True(t, completed)
+	assert.Equal(t, map[string]int{"1": partSize, "2": partSize, "3": 1024}, parts)
+}
+
+func TestS3Storage_Presign(t *testing.T) {
+	client := s3.New(s3.Options{
+		Region: "us-east-1",
+		Credentials: aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret"}, nil
+		}),
+	})
+	storage := NewS3StorageWithClient(client)
+	ctx := context.Background()
+
+	for _, method := range []string{http.MethodGet, http.MethodPut} {
+		signed, err := storage.Presign(ctx, "s3://my-bucket/videos/output.mp4", 15*time.Minute, method)
+		require.NoError(t, err, method)
+		require.NotEmpty(t, signed, method)
+
+		parsed, err := url.Parse(signed)
+		require.NoError(t, err)
+		assert.Contains(t, parsed.Path, "videos/output.mp4")
+		assert.Equal(t, "900", parsed.Query().Get("X-Amz-Expires"))
+		assert.NotEmpty(t, parsed.Query().Get("X-Amz-Signature"))
+		assert.Contains(t, parsed.Query().Get("X-Amz-Credential"), "AKIDEXAMPLE/")
+	}
+
+	_, err := storage.Presign(ctx, "s3://my-bucket/videos/output.mp4", time.Minute, http.MethodDelete)
+	assert.Error(t, err)
+
+	_, err = storage.Presign(ctx, "s3://my-bucket/videos/output.mp4", 0, http.MethodGet)
+	assert.Error(t, err)
+
+	_, err = storage.Presign(ctx, "s3://my-bucket", time.Minute, http.MethodGet)
+	assert.Error(t, err)
+}
+
+// zeroReader is an endless stream of zero bytes
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
 // Note: Integration tests that actually interact with S3 should be in a separate
 // file (e.g., s3_integration_test.go) and run with a build tag like:
 // //go:build integration
@@ -0,0 +1,366 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// DefaultSFTPPort is used when an sftp:// URI has no port
+const DefaultSFTPPort = "22"
+
+// SFTPStorage implements Storage for sftp://[user@]host[:port]/path URIs.
+// A connection is opened for each operation.
+type SFTPStorage struct {
+	user            string
+	auth            []ssh.AuthMethod
+	hostKeyCallback ssh.HostKeyCallback
+	timeout         time.Duration
+}
+
+// SFTPOption is a functional option for SFTPStorage
+type SFTPOption func(*SFTPStorage) error
+
+// WithSFTPUser sets the user for URIs that do not specify one
+func WithSFTPUser(user string) SFTPOption {
+	return func(s *SFTPStorage) error {
+		s.user = user
+		return nil
+	}
+}
+
+// WithSFTPPassword enables password authentication
+func WithSFTPPassword(password string) SFTPOption {
+	return func(s *SFTPStorage) error {
+		s.auth = append(s.auth, ssh.Password(password))
+		return nil
+	}
+}
+
+// WithSFTPPrivateKey enables public key authentication with a PEM encoded
+// private key. passphrase may be empty for unencrypted keys.
+func WithSFTPPrivateKey(pemBytes []byte, passphrase string) SFTPOption {
+	return func(s *SFTPStorage) error {
+		var signer ssh.Signer
+		var err error
+		if passphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(pemBytes, []byte(passphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey(pemBytes)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to parse SFTP private key: %w", err)
+		}
+		s.auth = append(s.auth, ssh.PublicKeys(signer))
+		return nil
+	}
+}
+
+// WithSFTPHostKeyCallback sets how server host keys are verified.
+// By default they are checked against ~/.ssh/known_hosts.
+func WithSFTPHostKeyCallback(callback ssh.HostKeyCallback) SFTPOption {
+	return func(s *SFTPStorage) error {
+		s.hostKeyCallback = callback
+		return nil
+	}
+}
+
+// WithSFTPTimeout sets the connection timeout
+func WithSFTPTimeout(d time.Duration) SFTPOption {
+	return func(s *SFTPStorage) error {
+		s.timeout = d
+		return nil
+	}
+}
+
+// NewSFTPStorage creates a new SFTP storage backend
+func NewSFTPStorage(opts ...SFTPOption) (*SFTPStorage, error) {
+	s := &SFTPStorage{
+		timeout: 30 * time.Second,
+	}
+
+	for _, opt := range opts {
+		if err := opt(s); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.hostKeyCallback == nil {
+		s.hostKeyCallback = defaultHostKeyCallback()
+	}
+
+	return s, nil
+}
+
+// defaultHostKeyCallback verifies host keys against the user's known_hosts
+// file, rejecting every host if it cannot be loaded
+func defaultHostKeyCallback() ssh.HostKeyCallback {
+	home, err := os.UserHomeDir()
+	if err == nil {
+		if callback, err := knownhosts.New(filepath.Join(home, ".ssh", "known_hosts")); err == nil {
+			return callback
+		}
+	}
+
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		return fmt.Errorf("cannot verify host key for %s: no known_hosts file and no host key callback configured", hostname)
+	}
+}
+
+// sftpConn is an open SFTP session
+type sftpConn struct {
+	ssh  *ssh.Client
+	sftp *sftp.Client
+}
+
+// Close closes the SFTP session and the SSH connection
+func (c *sftpConn) Close() error {
+	c.sftp.Close()
+	return c.ssh.Close()
+}
+
+// connect opens a session for uri and returns the remote path it refers to
+func (s *SFTPStorage) connect(ctx context.Context, uri string) (*sftpConn, string, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid URI: %w", err)
+	}
+	if parsed.Scheme != "sftp" {
+		return nil, "", fmt.Errorf("SFTP storage only supports sftp:// URIs, got %s://", parsed.Scheme)
+	}
+	if parsed.Hostname() == "" {
+		return nil, "", fmt.Errorf("invalid SFTP URI: missing host")
+	}
+	if parsed.Path == "" || parsed.Path == "/" {
+		return nil, "", fmt.Errorf("invalid SFTP URI: missing path")
+	}
+
+	user := s.user
+	auth := s.auth
+	if parsed.User != nil {
+		user = parsed.User.Username()
+		if password, ok := parsed.User.Password(); ok {
+			auth = append([]ssh.AuthMethod{ssh.Password(password)}, auth...)
+		}
+	}
+
+	port := parsed.Port()
+	if port == "" {
+		port = DefaultSFTPPort
+	}
+	addr := net.JoinHostPort(parsed.Hostname(), port)
+
+	dialer := net.Dialer{Timeout: s.timeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(netConn, addr, &ssh.ClientConfig{
+		User:            user,
+		Auth:            auth,
+		HostKeyCallback: s.hostKeyCallback,
+		Timeout:         s.timeout,
+	})
+	if err != nil {
+		netConn.Close()
+		return nil, "", fmt.Errorf("SSH handshake with %s failed: %w", addr, err)
+	}
+	sshClient := ssh.NewClient(sshConn, chans, reqs)
+
+	sftpClient, err := sftp.NewClient(sshClient)
+	if err != nil {
+		sshClient.Close()
+		return nil, "", fmt.Errorf("failed to start SFTP session: %w", err)
+	}
+
+	return &sftpConn{ssh: sshClient, sftp: sftpClient}, parsed.Path, nil
+}
+
+// sftpReader closes the SFTP session along with the remote file
+type sftpReader struct {
+	*sftp.File
+	conn *sftpConn
+}
+
+// Close closes the remote file and its session
+func (r *sftpReader) Close() error {
+	err := r.File.Close()
+	r.conn.Close()
+	return err
+}
+
+// Get opens a remote file for reading
+func (s *SFTPStorage) Get(ctx context.Context, uri string) (io.ReadCloser, error) {
+	return s.open(ctx, uri)
+}
+
+// open connects and opens the remote file named by uri
+func (s *SFTPStorage) open(ctx context.Context, uri string) (*sftpReader, error) {
+	conn, remotePath, err := s.connect(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := conn.sftp.Open(remotePath)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open remote file: %w", err)
+	}
+
+	return &sftpReader{File: file, conn: conn}, nil
+}
+
+// GetRange opens a remote file for reading from offset
+func (s *SFTPStorage) GetRange(ctx context.Context, uri string, offset, length int64) (io.ReadCloser, error) {
+	if err := checkRange(offset); err != nil {
+		return nil, err
+	}
+
+	reader, err := s.open(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	return seekRange(reader, offset, length)
+}
+
+// Put uploads data to a remote file, creating parent directories
+func (s *SFTPStorage) Put(ctx context.Context, uri string, data io.Reader) error {
+	conn, remotePath, err := s.connect(ctx, uri)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := conn.sftp.MkdirAll(path.Dir(remotePath)); err != nil {
+		return fmt.Errorf("failed to create remote directories: %w", err)
+	}
+
+	file, err := conn.sftp.Create(remotePath)
+	if err != nil {
+		return fmt.Errorf("failed to create remote file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(file, data); err != nil {
+		return fmt.Errorf("failed to write remote file: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a remote file
+func (s *SFTPStorage) Delete(ctx context.Context, uri string) error {
+	conn, remotePath, err := s.connect(ctx, uri)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := conn.sftp.Remove(remotePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete remote file: %w", err)
+	}
+
+	return nil
+}
+
+// Exists checks if a remote file exists
+func (s *SFTPStorage) Exists(ctx context.Context, uri string) (bool, error) {
+	conn, remotePath, err := s.connect(ctx, uri)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	_, err = conn.sftp.Stat(remotePath)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to check remote file existence: %w", err)
+}
+
+// Stat returns the size and modification time of a remote file
+func (s *SFTPStorage) Stat(ctx context.Context, uri string) (*ObjectInfo, error) {
+	conn, remotePath, err := s.connect(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	fi, err := conn.sftp.Stat(remotePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat remote file: %w", err)
+	}
+	if fi.IsDir() {
+		return nil, fmt.Errorf("not a file: %s", remotePath)
+	}
+
+	return &ObjectInfo{
+		Size:         fi.Size(),
+		LastModified: fi.ModTime(),
+		ContentType:  ContentTypeForURI(remotePath),
+	}, nil
+}
+
+// ListObjects lists the files in a remote directory
+func (s *SFTPStorage) ListObjects(ctx context.Context, dir string, recursive bool, pattern string) ([]ObjectInfo, error) {
+	if _, err := matchPattern(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	conn, root, err := s.connect(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	parsed, _ := url.Parse(dir)
+	base := "sftp://" + parsed.Host
+
+	var objects []ObjectInfo
+	walker := conn.sftp.Walk(root)
+	for walker.Step() {
+		if err := walker.Err(); err != nil {
+			return nil, fmt.Errorf("failed to list remote directory: %w", err)
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		fi := walker.Stat()
+		if fi.IsDir() {
+			if walker.Path() != root && !recursive {
+				walker.SkipDir()
+			}
+			continue
+		}
+
+		if matched, _ := matchPattern(pattern, walker.Path()); !matched {
+			continue
+		}
+
+		objects = append(objects, ObjectInfo{
+			URI:          base + walker.Path(),
+			Size:         fi.Size(),
+			LastModified: fi.ModTime(),
+			ContentType:  ContentTypeForURI(walker.Path()),
+		})
+	}
+
+	return objects, nil
+}
@@ -0,0 +1,195 @@
+package storage
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+// startSFTPServer runs an in-process SFTP server accepting user/password
+// and returns its address and host key
+func startSFTPServer(t *testing.T, user, password string) (string, ssh.PublicKey) {
+	t.Helper()
+
+	_, hostPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	hostSigner, err := ssh.NewSignerFromKey(hostPriv)
+	require.NoError(t, err)
+
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(c ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if c.User() == user && string(pass) == password {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("access denied")
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveSFTPConn(conn, config)
+		}
+	}()
+
+	return listener.Addr().String(), hostSigner.PublicKey()
+}
+
+// serveSFTPConn serves the sftp subsystem on one SSH connection
+func serveSFTPConn(conn net.Conn, config *ssh.ServerConfig) {
+	defer conn.Close()
+
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+
+		go func(in <-chan *ssh.Request) {
+			for req := range in {
+				isSFTP := req.Type == "subsystem" && len(req.Payload) > 4 && string(req.Payload[4:]) == "sftp"
+				req.Reply(isSFTP, nil)
+			}
+		}(requests)
+
+		server, err := sftp.NewServer(channel)
+		if err != nil {
+			channel.Close()
+			continue
+		}
+		server.Serve()
+		server.Close()
+	}
+}
+
+func TestSFTPStorage(t *testing.T) {
+	addr, hostKey := startSFTPServer(t, "media", "secret")
+	root := t.TempDir()
+
+	storage, err := NewSFTPStorage(
+		WithSFTPUser("media"),
+		WithSFTPPassword("secret"),
+		WithSFTPHostKeyCallback(ssh.FixedHostKey(hostKey)),
+	)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	uri := "sftp://" + addr + root + "/uploads/video.mp4"
+
+	// Put creates parent directories
+	require.NoError(t, storage.Put(ctx, uri, strings.NewReader("sftp content")))
+	assert.FileExists(t, filepath.Join(root, "uploads", "video.mp4"))
+
+	exists, err := storage.Exists(ctx, uri)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	reader, err := storage.Get(ctx, uri)
+	require.NoError(t, err)
+	content, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.NoError(t, reader.Close())
+	assert.Equal(t, "sftp content", string(content))
+
+	reader, err = storage.GetRange(ctx, uri, 5, 3)
+	require.NoError(t, err)
+	content, err = io.ReadAll(reader)
+	require.NoError(t, err)
+	require.NoError(t, reader.Close())
+	assert.Equal(t, "con", string(content))
+
+	info, err := storage.Stat(ctx, uri)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("sftp content")), info.Size)
+	assert.Equal(t, "video/mp4", info.ContentType)
+
+	objects, err := storage.ListObjects(ctx, "sftp://"+addr+root, true, "*.mp4")
+	require.NoError(t, err)
+	require.Len(t, objects, 1)
+	assert.Equal(t, uri, objects[0].URI)
+
+	uris, err := List(ctx, storage, "sftp://"+addr+root+"/uploads/vid")
+	require.NoError(t, err)
+	assert.Equal(t, []string{uri}, uris)
+
+	require.NoError(t, storage.Delete(ctx, uri))
+	exists, err = storage.Exists(ctx, uri)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	// Deleting a missing file is not an error
+	assert.NoError(t, storage.Delete(ctx, uri))
+}
+
+func TestSFTPStorage_URICredentials(t *testing.T) {
+	addr, hostKey := startSFTPServer(t, "media", "secret")
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "in.mp4"), []byte("data"), 0644))
+
+	storage, err := NewSFTPStorage(WithSFTPHostKeyCallback(ssh.FixedHostKey(hostKey)))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	exists, err := storage.Exists(ctx, "sftp://media:secret@"+addr+root+"/in.mp4")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	_, err = storage.Exists(ctx, "sftp://media:wrong@"+addr+root+"/in.mp4")
+	assert.Error(t, err)
+}
+
+func TestSFTPStorage_RejectsUnknownHostKey(t *testing.T) {
+	addr, _ := startSFTPServer(t, "media", "secret")
+
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherSigner, err := ssh.NewSignerFromKey(otherKey)
+	require.NoError(t, err)
+
+	storage, err := NewSFTPStorage(
+		WithSFTPUser("media"),
+		WithSFTPPassword("secret"),
+		WithSFTPHostKeyCallback(ssh.FixedHostKey(otherSigner.PublicKey())),
+	)
+	require.NoError(t, err)
+
+	_, err = storage.Exists(context.Background(), "sftp://"+addr+"/tmp/in.mp4")
+	assert.Error(t, err)
+}
+
+func TestNewSFTPStorage_InvalidKey(t *testing.T) {
+	_, err := NewSFTPStorage(WithSFTPPrivateKey([]byte("not a key"), ""))
+	assert.Error(t, err)
+}
@@ -2,19 +2,30 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
 )
 
 // AllowedSchemes is the whitelist of allowed URI schemes
-var AllowedSchemes = []string{"https", "http", "s3", "gs", "azure", "file"}
+var AllowedSchemes = []string{"https", "http", "s3", "gs", "azure", "file", "sftp", "data"}
 
 // Storage is the interface for all storage backends
 type Storage interface {
 	// Get downloads a file from the given URI and returns a reader
 	Get(ctx context.Context, uri string) (io.ReadCloser, error)
 
+	// GetRange returns a reader for length bytes of the file starting at
+	// offset. A length of 0 or less reads to the end of the file.
+	GetRange(ctx context.Context, uri string, offset, length int64) (io.ReadCloser, error)
+
 	// Put uploads data to the given URI
 	Put(ctx context.Context, uri string, data io.Reader) error
 
@@ -23,16 +34,95 @@ type Storage interface {
 
 	// Exists checks if a file exists at the given URI
 	Exists(ctx context.Context, uri string) (bool, error)
+
+	// Stat returns metadata about the file at the given URI without downloading it
+	Stat(ctx context.Context, uri string) (*ObjectInfo, error)
+
+	// ListObjects lists the files under a directory URI. Subdirectories are
+	// descended into if recursive is set; a non-empty pattern is a glob that
+	// file names must match.
+	ListObjects(ctx context.Context, dir string, recursive bool, pattern string) ([]ObjectInfo, error)
 }
 
-// ParseURI parses a URI and returns scheme and path
+// ObjectInfo describes a stored file
+type ObjectInfo struct {
+	URI          string    // Set by ListObjects
+	Size         int64     // Size in bytes, -1 if unknown
+	LastModified time.Time // Zero if unknown
+	ETag         string
+	ContentType  string
+}
+
+// List returns the URIs of all files in s whose URI starts with prefix, in
+// lexical order. Unlike ListObjects the prefix need not name a directory,
+// e.g. s3://bucket/frames/frame_ matches every frame file. The directory
+// containing the prefix is listed recursively and filtered; a missing
+// directory lists nothing.
+func List(ctx context.Context, s Storage, prefix string) ([]string, error) {
+	scheme, pathPrefix, err := ParseURI(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := prefix
+	if i := strings.LastIndex(prefix, "/"); i >= 0 && !strings.HasSuffix(prefix, "/") {
+		dir = prefix[:i+1]
+	}
+
+	objects, err := s.ListObjects(ctx, dir, true, "")
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Compare paths rather than URIs, which may differ in form (bare local
+	// paths, credentials in the authority)
+	var uris []string
+	for _, obj := range objects {
+		objScheme, objPath, err := ParseURI(obj.URI)
+		if err != nil || objScheme != scheme {
+			continue
+		}
+		if strings.HasPrefix(objPath, pathPrefix) {
+			uris = append(uris, obj.URI)
+		}
+	}
+	sort.Strings(uris)
+	return uris, nil
+}
+
+// windowsPathRegex matches Windows drive paths (C:\video.mp4, C:/video.mp4)
+// and UNC paths (\\server\share)
+var windowsPathRegex = regexp.MustCompile(`^([A-Za-z]:[\\/]|\\\\)`)
+
+// ParseURI parses a URI and returns scheme and path. Paths without a scheme,
+// including Windows drive and UNC paths, are local files and are returned
+// unchanged with the "file" scheme. For data: URIs the path is everything
+// after the scheme. For file:// URIs a non-local host is
+// treated as the start of a relative path (file://out.mp4 is out.mp4).
 func ParseURI(uri string) (scheme string, path string, err error) {
 	if uri == "" {
 		return "", "", fmt.Errorf("URI cannot be empty")
 	}
 
+	// data: URIs carry their content and are not URL-shaped
+	if rest, ok := strings.CutPrefix(uri, "data:"); ok {
+		return "data", rest, nil
+	}
+
+	// Bare local paths are not URL-decoded, so '%' and spaces are literal
+	if windowsPathRegex.MatchString(uri) || (!strings.Contains(uri, "://") && !strings.HasPrefix(uri, "file:")) {
+		return "file", uri, nil
+	}
+
 	parsed, err := url.Parse(uri)
 	if err != nil {
+		// Local paths may contain '%' that is not a valid escape
+		if rest, ok := strings.CutPrefix(uri, "file://"); ok {
+			return "file", fileURIPath("", rest), nil
+		}
 		return "", "", fmt.Errorf("invalid URI: %w", err)
 	}
 
@@ -42,7 +132,10 @@ func ParseURI(uri string) (scheme string, path string, err error) {
 
 	// For file:// URIs, use the full path
 	if parsed.Scheme == "file" {
-		return parsed.Scheme, parsed.Path, nil
+		if parsed.Opaque != "" {
+			return parsed.Scheme, parsed.Opaque, nil // file:relative/path
+		}
+		return parsed.Scheme, fileURIPath(parsed.Host, parsed.Path), nil
 	}
 
 	// For other URIs (s3://, https://, etc.), combine host and path
@@ -54,12 +147,83 @@ func ParseURI(uri string) (scheme string, path string, err error) {
 	return parsed.Scheme, path, nil
 }
 
-// IsAllowedScheme checks if a URI scheme is in the whitelist
+// fileURIPath returns the local path of a file:// URI with the given host
+// and path
+func fileURIPath(host, path string) string {
+	if host != "" && host != "localhost" {
+		// file://relative/path
+		return host + path
+	}
+
+	// file:///C:/video.mp4 names a Windows drive path
+	if len(path) >= 3 && path[0] == '/' && windowsPathRegex.MatchString(path[1:]) {
+		return path[1:]
+	}
+	return path
+}
+
+// checkRange validates the arguments of GetRange
+func checkRange(offset int64) error {
+	if offset < 0 {
+		return fmt.Errorf("invalid range offset %d", offset)
+	}
+	return nil
+}
+
+// rangeHeader returns the HTTP Range header value for GetRange arguments
+func rangeHeader(offset, length int64) string {
+	if length <= 0 {
+		return fmt.Sprintf("bytes=%d-", offset)
+	}
+	return fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)
+}
+
+// rangeReadCloser limits reads from a ReadCloser while closing the original
+type rangeReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// limitReadCloser returns rc limited to length bytes, or rc itself if
+// length is 0 or less
+func limitReadCloser(rc io.ReadCloser, length int64) io.ReadCloser {
+	if length <= 0 {
+		return rc
+	}
+	return &rangeReadCloser{Reader: io.LimitReader(rc, length), Closer: rc}
+}
+
+// seekRange positions a seekable file at offset and limits it to length
+// bytes. The file is closed if seeking fails.
+func seekRange(file io.ReadSeekCloser, offset, length int64) (io.ReadCloser, error) {
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to seek to offset %d: %w", offset, err)
+	}
+	return limitReadCloser(file, length), nil
+}
+
+// matchPattern reports whether the base name of path matches a glob pattern.
+// An empty pattern matches everything.
+func matchPattern(pattern, path string) (bool, error) {
+	if pattern == "" {
+		return true, nil
+	}
+	matched, err := filepath.Match(pattern, filepath.Base(path))
+	if err != nil {
+		return false, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	return matched, nil
+}
+
+// IsAllowedScheme checks if a URI scheme is in the whitelist or has a
+// registered backend
 func IsAllowedScheme(scheme string) bool {
 	for _, allowed := range AllowedSchemes {
 		if scheme == allowed {
 			return true
 		}
 	}
-	return false
+	_, ok := LookupBackend(scheme)
+	return ok
 }
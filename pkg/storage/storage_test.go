@@ -17,8 +17,43 @@ func TestParseURI(t *testing.T) {
 		{"s3://bucket/key/video.mp4", "s3", "bucket/key/video.mp4", false},
 		{"file:///tmp/video.mp4", "file", "/tmp/video.mp4", false},
 		{"gs://bucket/object", "gs", "bucket/object", false},
-		{"invalid-uri", "", "", true},
 		{"", "", "", true},
+
+		// Scheme-less paths are local files, returned unchanged
+		{"/tmp/x.mp4", "file", "/tmp/x.mp4", false},
+		{"videos/in.mp4", "file", "videos/in.mp4", false},
+		{"relative-file", "file", "relative-file", false},
+		{"/tmp/my video.mp4", "file", "/tmp/my video.mp4", false},
+		{"/tmp/100%.mp4", "file", "/tmp/100%.mp4", false},
+		{"/tmp/a:b.mp4", "file", "/tmp/a:b.mp4", false},
+		{`C:\video.mp4`, "file", `C:\video.mp4`, false},
+		{"C:/videos/in.mp4", "file", "C:/videos/in.mp4", false},
+		{`\\server\share\in.mp4`, "file", `\\server\share\in.mp4`, false},
+
+		// file:// edge cases
+		{"file:///tmp/my%20video.mp4", "file", "/tmp/my video.mp4", false},
+		{"file:///tmp/my video.mp4", "file", "/tmp/my video.mp4", false},
+		{"file:///tmp/100%.mp4", "file", "/tmp/100%.mp4", false},
+		{"file://localhost/tmp/x.mp4", "file", "/tmp/x.mp4", false},
+		{"file://output.mp4", "file", "output.mp4", false},
+		{"file://videos/out.mp4", "file", "videos/out.mp4", false},
+		{"file:///C:/videos/in.mp4", "file", "C:/videos/in.mp4", false},
+
+		// data: URIs keep everything after the scheme
+		{"data:image/png;base64,iVBORw0KGgo=", "data", "image/png;base64,iVBORw0KGgo=", false},
+		{"file:/tmp/x.mp4", "file", "/tmp/x.mp4", false},
+		{"FILE:///tmp/x.mp4", "file", "/tmp/x.mp4", false},
+
+		// Remote URIs keep double slashes and decode spaces
+		{"s3://bucket//nested//key.mp4", "s3", "bucket//nested//key.mp4", false},
+		{"s3://bucket/my%20video.mp4", "s3", "bucket/my video.mp4", false},
+		{"https://example.com/a%2Fb/video.mp4", "https", "example.com/a/b/video.mp4", false},
+		{"S3://bucket/key", "s3", "bucket/key", false},
+
+		// Malformed URIs
+		{"https://exa mple.com/video.mp4", "", "", true},
+		{"s3://bucket/%zz", "", "", true},
+		{"://missing-scheme", "", "", true},
 	}
 
 	for _, tt := range tests {
@@ -42,7 +42,7 @@ func Example_basic() {
 	}
 
 	// Retrieve the job
-	retrieved, err := s.GetJob(ctx, job.JobID)
+	retrieved, err := s.GetJob(ctx, job.JobID, "")
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -82,7 +82,7 @@ func Example_updateStatus() {
 	}
 
 	// Retrieve updated job
-	updated, err := s.GetJob(ctx, job.JobID)
+	updated, err := s.GetJob(ctx, job.JobID, "")
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -122,7 +122,7 @@ func Example_errorHandling() {
 	}
 
 	// Retrieve job with error
-	failed, err := s.GetJob(ctx, job.JobID)
+	failed, err := s.GetJob(ctx, job.JobID, "")
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -240,7 +240,7 @@ func Example_concurrentAccess() {
 	<-done
 
 	// Retrieve final state
-	final, err := s.GetJob(ctx, job.JobID)
+	final, err := s.GetJob(ctx, job.JobID, "")
 	if err != nil {
 		log.Fatal(err)
 	}
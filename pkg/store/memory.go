@@ -2,8 +2,15 @@ package store
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/chicogong/media-pipeline/pkg/schemas"
@@ -12,19 +19,32 @@ import (
 // MemoryStore is an in-memory implementation of Store
 // Thread-safe for concurrent access
 type MemoryStore struct {
-	mu   sync.RWMutex
-	jobs map[string]*Job
+	mu    sync.RWMutex
+	jobs  map[string]*Job
+	queue PriorityQueue // Pending jobs ordered by priority
+
+	// version counts changes so callers can tell whether a snapshot is
+	// stale without taking the lock
+	version atomic.Uint64
 }
 
 // NewMemoryStore creates a new in-memory store
 func NewMemoryStore() *MemoryStore {
 	return &MemoryStore{
-		jobs: make(map[string]*Job),
+		jobs:  make(map[string]*Job),
+		queue: NewHeapQueue(),
 	}
 }
 
 // CreateJob creates a new job
 func (m *MemoryStore) CreateJob(ctx context.Context, job *Job) error {
+	return m.CreateJobWithLimit(ctx, job, nil, 0)
+}
+
+// CreateJobWithLimit creates a new job if fewer than limit jobs match
+// filter. The jobs are counted under the same lock as the insert, so
+// concurrent callers cannot exceed the limit.
+func (m *MemoryStore) CreateJobWithLimit(ctx context.Context, job *Job, filter *ListFilter, limit int) error {
 	if job.JobID == "" {
 		return ErrInvalidJobID
 	}
@@ -36,15 +56,29 @@ func (m *MemoryStore) CreateJob(ctx context.Context, job *Job) error {
 		return ErrJobExists
 	}
 
+	if limit > 0 {
+		count := 0
+		for _, existing := range m.jobs {
+			if m.matchesFilter(existing, filter) {
+				count++
+			}
+		}
+		if count >= limit {
+			return ErrJobLimitReached
+		}
+	}
+
 	// Deep copy to avoid external modifications
 	jobCopy := m.copyJob(job)
 	m.jobs[job.JobID] = jobCopy
+	m.syncQueue(jobCopy)
+	m.version.Add(1)
 
 	return nil
 }
 
-// GetJob retrieves a job by ID
-func (m *MemoryStore) GetJob(ctx context.Context, jobID string) (*Job, error) {
+// GetJob retrieves a job by ID, scoped to ownerID when it is non-empty
+func (m *MemoryStore) GetJob(ctx context.Context, jobID, ownerID string) (*Job, error) {
 	if jobID == "" {
 		return nil, ErrInvalidJobID
 	}
@@ -53,7 +87,7 @@ func (m *MemoryStore) GetJob(ctx context.Context, jobID string) (*Job, error) {
 	defer m.mu.RUnlock()
 
 	job, exists := m.jobs[jobID]
-	if !exists {
+	if !exists || !job.OwnedBy(ownerID) {
 		return nil, ErrJobNotFound
 	}
 
@@ -80,6 +114,8 @@ func (m *MemoryStore) UpdateJob(ctx context.Context, job *Job) error {
 	// Deep copy and store
 	jobCopy := m.copyJob(job)
 	m.jobs[job.JobID] = jobCopy
+	m.syncQueue(jobCopy)
+	m.version.Add(1)
 
 	return nil
 }
@@ -98,6 +134,8 @@ func (m *MemoryStore) DeleteJob(ctx context.Context, jobID string) error {
 	}
 
 	delete(m.jobs, jobID)
+	m.queue.Remove(jobID)
+	m.version.Add(1)
 	return nil
 }
 
@@ -121,6 +159,21 @@ func (m *MemoryStore) ListJobs(ctx context.Context, filter *ListFilter) ([]*Job,
 	return m.paginateJobs(jobs, filter), nil
 }
 
+// CountJobs returns the number of jobs matching the filter, ignoring its
+// limit and offset
+func (m *MemoryStore) CountJobs(ctx context.Context, filter *ListFilter) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	count := 0
+	for _, job := range m.jobs {
+		if m.matchesFilter(job, filter) {
+			count++
+		}
+	}
+	return count, nil
+}
+
 // UpdateJobStatus updates job status and progress
 func (m *MemoryStore) UpdateJobStatus(ctx context.Context, jobID string, status schemas.JobState, progress *schemas.Progress) error {
 	if jobID == "" {
@@ -138,6 +191,7 @@ func (m *MemoryStore) UpdateJobStatus(ctx context.Context, jobID string, status
 	// Update status
 	job.Status = status
 	job.Updated = time.Now()
+	m.syncQueue(job)
 
 	// Update progress
 	if progress != nil {
@@ -159,6 +213,7 @@ func (m *MemoryStore) UpdateJobStatus(ctx context.Context, jobID string, status
 			job.CompletedAt = &now
 		}
 	}
+	m.version.Add(1)
 
 	return nil
 }
@@ -192,10 +247,203 @@ func (m *MemoryStore) UpdateJobError(ctx context.Context, jobID string, err *sch
 	}
 
 	job.Updated = time.Now()
+	m.version.Add(1)
 
 	return nil
 }
 
+// PeekNextJob returns the highest-priority pending job without claiming it
+func (m *MemoryStore) PeekNextJob(ctx context.Context, workerID string) (*Job, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	job := m.queue.Peek()
+	if job == nil {
+		return nil, ErrNoPendingJobs
+	}
+
+	return m.copyJob(job), nil
+}
+
+// ClaimJob removes the highest-priority pending job from the queue, assigns it
+// to workerID and moves it to the validating state
+func (m *MemoryStore) ClaimJob(ctx context.Context, workerID string) (*Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job := m.queue.Pop()
+	if job == nil {
+		return nil, ErrNoPendingJobs
+	}
+
+	job.WorkerID = workerID
+	job.Status = schemas.JobStateValidating
+	job.Updated = time.Now()
+	m.version.Add(1)
+
+	return m.copyJob(job), nil
+}
+
+// Snapshot serializes every job to JSON so the store can be restored with
+// LoadSnapshot. Only the read lock is held, so writers are blocked just for
+// the duration of the encoding.
+func (m *MemoryStore) Snapshot() ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	data, err := json.Marshal(m.jobs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	return data, nil
+}
+
+// Version returns a counter that changes whenever a job is created, updated
+// or deleted. It can be compared across calls to skip unchanged snapshots.
+func (m *MemoryStore) Version() uint64 {
+	return m.version.Load()
+}
+
+// LoadSnapshot creates a memory store from data written by Snapshot.
+// Pending jobs are re-queued by priority.
+func LoadSnapshot(data []byte) (*MemoryStore, error) {
+	m := NewMemoryStore()
+
+	var jobs map[string]*Job
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+
+	for jobID, job := range jobs {
+		if job == nil || job.JobID != jobID {
+			return nil, fmt.Errorf("invalid snapshot entry for job %q", jobID)
+		}
+		m.jobs[jobID] = job
+		m.syncQueue(job)
+	}
+
+	return m, nil
+}
+
+// LoadSnapshotFile creates a memory store from a snapshot file written by
+// SaveSnapshotFile, or an empty store if the file does not exist yet
+func LoadSnapshotFile(path string) (*MemoryStore, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return NewMemoryStore(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return LoadSnapshot(data)
+}
+
+// SaveSnapshotFile writes a snapshot of the store to path, replacing it
+// atomically
+func (m *MemoryStore) SaveSnapshotFile(path string) error {
+	data, err := m.Snapshot()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Export returns a deep copy of every job, sorted by creation time
+func (m *MemoryStore) Export() []*Job {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	jobs := make([]*Job, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		jobs = append(jobs, m.cloneJob(job))
+	}
+	sortByCreated(jobs)
+	return jobs
+}
+
+// Import inserts jobs as they are, keeping their timestamps and statuses.
+// Jobs that already exist or have no ID are skipped; the returned error
+// joins one ErrJobExists or ErrInvalidJobID per skipped job.
+func (m *MemoryStore) Import(jobs []*Job) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var errs []error
+	imported := false
+	for _, job := range jobs {
+		if job == nil || job.JobID == "" {
+			errs = append(errs, ErrInvalidJobID)
+			continue
+		}
+		if _, exists := m.jobs[job.JobID]; exists {
+			errs = append(errs, fmt.Errorf("job %s: %w", job.JobID, ErrJobExists))
+			continue
+		}
+
+		jobCopy := m.cloneJob(job)
+		m.jobs[job.JobID] = jobCopy
+		m.syncQueue(jobCopy)
+		imported = true
+	}
+
+	if imported {
+		m.version.Add(1)
+	}
+	return errors.Join(errs...)
+}
+
+// ExportToJSON writes every job in s to w as a JSON array sorted by
+// creation time, for loading with ImportFromJSON
+func ExportToJSON(w io.Writer, s Store) error {
+	var jobs []*Job
+	if m, ok := s.(*MemoryStore); ok {
+		jobs = m.Export()
+	} else {
+		var err error
+		jobs, err = s.ListJobs(context.Background(), nil)
+		if err != nil {
+			return fmt.Errorf("failed to list jobs: %w", err)
+		}
+		sortByCreated(jobs)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(jobs); err != nil {
+		return fmt.Errorf("failed to encode jobs: %w", err)
+	}
+	return nil
+}
+
+// ImportFromJSON creates a memory store from a JSON array of jobs, such as
+// the output of ExportToJSON
+func ImportFromJSON(r io.Reader) (*MemoryStore, error) {
+	var jobs []*Job
+	if err := json.NewDecoder(r).Decode(&jobs); err != nil {
+		return nil, fmt.Errorf("failed to decode jobs: %w", err)
+	}
+
+	m := NewMemoryStore()
+	if err := m.Import(jobs); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // Close closes the store (no-op for memory store)
 func (m *MemoryStore) Close() error {
 	return nil
@@ -221,6 +469,10 @@ func (m *MemoryStore) copyJob(job *Job) *Job {
 	}
 
 	// Copy pointers
+	if job.Execution != nil {
+		e := *job.Execution
+		copy.Execution = &e
+	}
 	if job.StartedAt != nil {
 		t := *job.StartedAt
 		copy.StartedAt = &t
@@ -257,11 +509,52 @@ func (m *MemoryStore) copyJob(job *Job) *Job {
 	return copy
 }
 
+// cloneJob returns a deep copy of job, including the spec, plan and output
+// files that copyJob shares with the original. Jobs that cannot be encoded
+// as JSON fall back to copyJob.
+func (m *MemoryStore) cloneJob(job *Job) *Job {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return m.copyJob(job)
+	}
+
+	var clone Job
+	if err := json.Unmarshal(data, &clone); err != nil {
+		return m.copyJob(job)
+	}
+	return &clone
+}
+
+// sortByCreated sorts jobs by creation time, oldest first, then by ID
+func sortByCreated(jobs []*Job) {
+	sort.Slice(jobs, func(i, j int) bool {
+		if !jobs[i].Created.Equal(jobs[j].Created) {
+			return jobs[i].Created.Before(jobs[j].Created)
+		}
+		return jobs[i].JobID < jobs[j].JobID
+	})
+}
+
+// syncQueue keeps the pending queue in step with a stored job's status
+// Must be called with the write lock held
+func (m *MemoryStore) syncQueue(job *Job) {
+	if job.Status == schemas.JobStatePending {
+		m.queue.Push(job)
+	} else {
+		m.queue.Remove(job.JobID)
+	}
+}
+
 func (m *MemoryStore) matchesFilter(job *Job, filter *ListFilter) bool {
 	if filter == nil {
 		return true
 	}
 
+	// Owner filter
+	if !job.OwnedBy(filter.UserID) {
+		return false
+	}
+
 	// Status filter
 	if len(filter.Status) > 0 {
 		found := false
@@ -276,6 +569,11 @@ func (m *MemoryStore) matchesFilter(job *Job, filter *ListFilter) bool {
 		}
 	}
 
+	// Tag filter
+	if !job.HasTags(filter.Tags) {
+		return false
+	}
+
 	// Time range filters
 	if filter.CreatedAfter != nil && job.Created.Before(*filter.CreatedAfter) {
 		return false
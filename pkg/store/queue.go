@@ -0,0 +1,135 @@
+package store
+
+import (
+	"container/heap"
+)
+
+// PriorityQueue orders pending jobs by priority (highest first), then by
+// creation time (oldest first)
+type PriorityQueue interface {
+	// Push adds a job to the queue, or updates its position if already queued
+	Push(job *Job)
+
+	// Pop removes and returns the next job, or nil if the queue is empty
+	Pop() *Job
+
+	// Peek returns the next job without removing it, or nil if the queue is empty
+	Peek() *Job
+
+	// Remove removes a job from the queue, reporting whether it was queued
+	Remove(jobID string) bool
+
+	// Len returns the number of queued jobs
+	Len() int
+}
+
+// HeapQueue is a PriorityQueue backed by container/heap
+// Not safe for concurrent use; callers must provide their own locking
+type HeapQueue struct {
+	items jobHeap
+	index map[string]*queueItem // job ID -> item
+}
+
+// NewHeapQueue creates an empty heap-based priority queue
+func NewHeapQueue() *HeapQueue {
+	return &HeapQueue{
+		index: make(map[string]*queueItem),
+	}
+}
+
+// Push adds a job to the queue, or updates its position if already queued
+func (q *HeapQueue) Push(job *Job) {
+	if item, ok := q.index[job.JobID]; ok {
+		item.job = job
+		heap.Fix(&q.items, item.pos)
+		return
+	}
+
+	item := &queueItem{job: job}
+	q.index[job.JobID] = item
+	heap.Push(&q.items, item)
+}
+
+// Pop removes and returns the next job, or nil if the queue is empty
+func (q *HeapQueue) Pop() *Job {
+	if len(q.items) == 0 {
+		return nil
+	}
+
+	item := heap.Pop(&q.items).(*queueItem)
+	delete(q.index, item.job.JobID)
+	return item.job
+}
+
+// Peek returns the next job without removing it, or nil if the queue is empty
+func (q *HeapQueue) Peek() *Job {
+	if len(q.items) == 0 {
+		return nil
+	}
+	return q.items[0].job
+}
+
+// Remove removes a job from the queue, reporting whether it was queued
+func (q *HeapQueue) Remove(jobID string) bool {
+	item, ok := q.index[jobID]
+	if !ok {
+		return false
+	}
+
+	heap.Remove(&q.items, item.pos)
+	delete(q.index, jobID)
+	return true
+}
+
+// Len returns the number of queued jobs
+func (q *HeapQueue) Len() int {
+	return len(q.items)
+}
+
+// queueItem is a heap entry tracking its own position for heap.Fix/Remove
+type queueItem struct {
+	job *Job
+	pos int
+}
+
+// jobHeap implements heap.Interface as a min-heap on (-priority, created_at)
+type jobHeap []*queueItem
+
+func (h jobHeap) Len() int { return len(h) }
+
+func (h jobHeap) Less(i, j int) bool {
+	pi, pj := jobPriority(h[i].job), jobPriority(h[j].job)
+	if pi != pj {
+		return -pi < -pj
+	}
+	return h[i].job.Created.Before(h[j].job.Created)
+}
+
+func (h jobHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].pos = i
+	h[j].pos = j
+}
+
+func (h *jobHeap) Push(x interface{}) {
+	item := x.(*queueItem)
+	item.pos = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *jobHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return item
+}
+
+// jobPriority returns the job's spec priority (0 if no spec)
+func jobPriority(job *Job) int {
+	if job.Spec == nil {
+		return 0
+	}
+	return job.Spec.Priority
+}
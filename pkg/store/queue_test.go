@@ -0,0 +1,129 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/chicogong/media-pipeline/pkg/schemas"
+)
+
+func TestHeapQueue_Order(t *testing.T) {
+	q := NewHeapQueue()
+	base := time.Now()
+
+	q.Push(&Job{JobID: "a", Created: base, Spec: &schemas.JobSpec{Priority: 0}})
+	q.Push(&Job{JobID: "b", Created: base.Add(time.Second), Spec: &schemas.JobSpec{Priority: 3}})
+	q.Push(&Job{JobID: "c", Created: base, Spec: &schemas.JobSpec{Priority: 3}})
+	q.Push(&Job{JobID: "d", Created: base}) // nil spec = priority 0, same age as "a"
+
+	if q.Len() != 4 {
+		t.Fatalf("expected 4 queued jobs, got %d", q.Len())
+	}
+	if got := q.Peek().JobID; got != "c" {
+		t.Errorf("expected peek c, got %s", got)
+	}
+
+	// Remove and re-push with a higher priority
+	if !q.Remove("a") {
+		t.Fatal("expected a to be removed")
+	}
+	if q.Remove("a") {
+		t.Error("expected second remove of a to fail")
+	}
+	q.Push(&Job{JobID: "a", Created: base, Spec: &schemas.JobSpec{Priority: 9}})
+
+	var order []string
+	for q.Len() > 0 {
+		order = append(order, q.Pop().JobID)
+	}
+
+	want := []string{"a", "c", "b", "d"}
+	if fmt.Sprint(order) != fmt.Sprint(want) {
+		t.Errorf("expected order %v, got %v", want, order)
+	}
+	if q.Pop() != nil {
+		t.Error("expected nil from empty queue")
+	}
+}
+
+func TestHeapQueue_PushUpdatesPosition(t *testing.T) {
+	q := NewHeapQueue()
+	base := time.Now()
+
+	q.Push(&Job{JobID: "a", Created: base, Spec: &schemas.JobSpec{Priority: 1}})
+	q.Push(&Job{JobID: "b", Created: base, Spec: &schemas.JobSpec{Priority: 2}})
+	q.Push(&Job{JobID: "a", Created: base, Spec: &schemas.JobSpec{Priority: 3}})
+
+	if q.Len() != 2 {
+		t.Fatalf("expected 2 queued jobs, got %d", q.Len())
+	}
+	if got := q.Peek().JobID; got != "a" {
+		t.Errorf("expected a after priority bump, got %s", got)
+	}
+}
+
+const benchmarkQueuedJobs = 10000
+
+// newBenchmarkStore creates a store with benchmarkQueuedJobs pending jobs
+func newBenchmarkStore(b *testing.B) *MemoryStore {
+	b.Helper()
+
+	s := NewMemoryStore()
+	base := time.Now()
+	for i := 0; i < benchmarkQueuedJobs; i++ {
+		job := &Job{
+			JobID:   fmt.Sprintf("job-%d", i),
+			Created: base.Add(time.Duration(i) * time.Millisecond),
+			Status:  schemas.JobStatePending,
+			Spec:    &schemas.JobSpec{Priority: i % 10},
+		}
+		if err := s.CreateJob(context.Background(), job); err != nil {
+			b.Fatalf("CreateJob() failed: %v", err)
+		}
+	}
+	return s
+}
+
+// scanNextJob finds the next pending job with a linear scan (the pre-heap approach)
+func scanNextJob(m *MemoryStore) *Job {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var best *Job
+	for _, job := range m.jobs {
+		if job.Status != schemas.JobStatePending {
+			continue
+		}
+		if best == nil ||
+			jobPriority(job) > jobPriority(best) ||
+			(jobPriority(job) == jobPriority(best) && job.Created.Before(best.Created)) {
+			best = job
+		}
+	}
+	return best
+}
+
+func BenchmarkNextJob_Scan(b *testing.B) {
+	s := newBenchmarkStore(b)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if scanNextJob(s) == nil {
+			b.Fatal("expected a pending job")
+		}
+	}
+}
+
+func BenchmarkNextJob_Heap(b *testing.B) {
+	s := newBenchmarkStore(b)
+	ctx := context.Background()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := s.PeekNextJob(ctx, "bench"); err != nil {
+			b.Fatalf("PeekNextJob() failed: %v", err)
+		}
+	}
+}
@@ -18,6 +18,13 @@ var (
 
 	// ErrInvalidJobID is returned for invalid job IDs
 	ErrInvalidJobID = errors.New("invalid job ID")
+
+	// ErrNoPendingJobs is returned when no job is waiting to be claimed
+	ErrNoPendingJobs = errors.New("no pending jobs")
+
+	// ErrJobLimitReached is returned by CreateJobWithLimit when the limit
+	// of matching jobs has been reached
+	ErrJobLimitReached = errors.New("job limit reached")
 )
 
 // Store is the interface for job state persistence
@@ -25,8 +32,14 @@ type Store interface {
 	// CreateJob creates a new job with initial state
 	CreateJob(ctx context.Context, job *Job) error
 
-	// GetJob retrieves a job by ID
-	GetJob(ctx context.Context, jobID string) (*Job, error)
+	// CreateJobWithLimit creates a new job only if fewer than limit jobs
+	// match filter, returning ErrJobLimitReached otherwise. The count and
+	// the creation happen atomically. A limit of 0 or less means no limit.
+	CreateJobWithLimit(ctx context.Context, job *Job, filter *ListFilter, limit int) error
+
+	// GetJob retrieves a job by ID. A non-empty ownerID restricts the lookup
+	// to jobs owned by that user; other users' jobs report ErrJobNotFound
+	GetJob(ctx context.Context, jobID, ownerID string) (*Job, error)
 
 	// UpdateJob updates an existing job
 	UpdateJob(ctx context.Context, job *Job) error
@@ -37,12 +50,22 @@ type Store interface {
 	// ListJobs lists jobs with optional filtering
 	ListJobs(ctx context.Context, filter *ListFilter) ([]*Job, error)
 
+	// CountJobs returns the number of jobs matching the filter, ignoring
+	// its limit and offset
+	CountJobs(ctx context.Context, filter *ListFilter) (int, error)
+
 	// UpdateJobStatus updates job status and progress
 	UpdateJobStatus(ctx context.Context, jobID string, status schemas.JobState, progress *schemas.Progress) error
 
 	// UpdateJobError records an error for a job
 	UpdateJobError(ctx context.Context, jobID string, err *schemas.ErrorInfo) error
 
+	// PeekNextJob returns the highest-priority pending job without claiming it
+	PeekNextJob(ctx context.Context, workerID string) (*Job, error)
+
+	// ClaimJob assigns the highest-priority pending job to a worker
+	ClaimJob(ctx context.Context, workerID string) (*Job, error)
+
 	// Close closes the store and releases resources
 	Close() error
 }
@@ -69,6 +92,7 @@ type Job struct {
 
 	// Outputs
 	OutputFiles []schemas.OutputFile `json:"output_files,omitempty"`
+	Execution   *schemas.ExecutionStats `json:"execution,omitempty"`
 
 	// Metadata
 	RetryCount int `json:"retry_count"`
@@ -77,9 +101,15 @@ type Job struct {
 
 // ListFilter defines filtering criteria for listing jobs
 type ListFilter struct {
+	// Owner filter (empty = all users)
+	UserID string `json:"user_id,omitempty"`
+
 	// Status filters
 	Status []schemas.JobState `json:"status,omitempty"`
 
+	// Tag filter: jobs must have every listed tag with the given value
+	Tags map[string]string `json:"tags,omitempty"`
+
 	// Time range filters
 	CreatedAfter  *time.Time `json:"created_after,omitempty"`
 	CreatedBefore *time.Time `json:"created_before,omitempty"`
@@ -105,7 +135,37 @@ func (j *Job) ToJobStatus() *schemas.JobStatus {
 		StartedAt:   j.StartedAt,
 		CompletedAt: j.CompletedAt,
 		OutputFiles: j.OutputFiles,
+		Execution:   j.Execution,
+		Tags:        j.tags(),
+	}
+}
+
+// tags returns the job's spec tags, or nil if it has no spec
+func (j *Job) tags() map[string]string {
+	if j.Spec == nil {
+		return nil
+	}
+	return j.Spec.Tags
+}
+
+// HasTags reports whether the job has every tag in tags with the same value
+func (j *Job) HasTags(tags map[string]string) bool {
+	jobTags := j.tags()
+	for key, value := range tags {
+		if v, ok := jobTags[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+// OwnedBy reports whether the job belongs to userID
+// An empty userID matches every job
+func (j *Job) OwnedBy(userID string) bool {
+	if userID == "" {
+		return true
 	}
+	return j.Spec != nil && j.Spec.UserID == userID
 }
 
 // IsTerminal returns true if the job is in a terminal state
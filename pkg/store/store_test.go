@@ -1,7 +1,12 @@
 package store
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
 
@@ -33,7 +38,7 @@ func testStore(t *testing.T, newStore func() Store) {
 		}
 
 		// Verify job was created
-		retrieved, err := s.GetJob(ctx, job.JobID)
+		retrieved, err := s.GetJob(ctx, job.JobID, "")
 		if err != nil {
 			t.Fatalf("GetJob() failed: %v", err)
 		}
@@ -70,6 +75,47 @@ func testStore(t *testing.T, newStore func() Store) {
 		}
 	})
 
+	t.Run("CreateJobWithLimit", func(t *testing.T) {
+		s := newStore()
+		defer s.Close()
+
+		ctx := context.Background()
+		active := &ListFilter{UserID: "alice", Status: []schemas.JobState{schemas.JobStatePending}}
+
+		newJob := func(id, userID string) *Job {
+			return &Job{
+				JobID:   id,
+				Created: time.Now(),
+				Updated: time.Now(),
+				Status:  schemas.JobStatePending,
+				Spec:    &schemas.JobSpec{UserID: userID},
+			}
+		}
+
+		for _, id := range []string{"limit-1", "limit-2"} {
+			if err := s.CreateJobWithLimit(ctx, newJob(id, "alice"), active, 2); err != nil {
+				t.Fatalf("CreateJobWithLimit(%s) failed: %v", id, err)
+			}
+		}
+
+		if err := s.CreateJobWithLimit(ctx, newJob("limit-3", "alice"), active, 2); err != ErrJobLimitReached {
+			t.Errorf("Expected ErrJobLimitReached, got %v", err)
+		}
+		if _, err := s.GetJob(ctx, "limit-3", ""); err != ErrJobNotFound {
+			t.Errorf("Expected the rejected job not to be stored, got %v", err)
+		}
+
+		// Only matching jobs count towards the limit
+		if err := s.CreateJobWithLimit(ctx, newJob("limit-bob", "bob"), &ListFilter{UserID: "bob"}, 2); err != nil {
+			t.Errorf("CreateJobWithLimit() for another user failed: %v", err)
+		}
+
+		// No limit
+		if err := s.CreateJobWithLimit(ctx, newJob("limit-4", "alice"), active, 0); err != nil {
+			t.Errorf("CreateJobWithLimit() without a limit failed: %v", err)
+		}
+	})
+
 	t.Run("GetJob", func(t *testing.T) {
 		s := newStore()
 		defer s.Close()
@@ -87,7 +133,7 @@ func testStore(t *testing.T, newStore func() Store) {
 			t.Fatalf("CreateJob() failed: %v", err)
 		}
 
-		retrieved, err := s.GetJob(ctx, job.JobID)
+		retrieved, err := s.GetJob(ctx, job.JobID, "")
 		if err != nil {
 			t.Fatalf("GetJob() failed: %v", err)
 		}
@@ -102,12 +148,39 @@ func testStore(t *testing.T, newStore func() Store) {
 		defer s.Close()
 
 		ctx := context.Background()
-		_, err := s.GetJob(ctx, "nonexistent")
+		_, err := s.GetJob(ctx, "nonexistent", "")
 		if err != ErrJobNotFound {
 			t.Errorf("Expected ErrJobNotFound, got %v", err)
 		}
 	})
 
+	t.Run("GetJobScopedToOwner", func(t *testing.T) {
+		s := newStore()
+		defer s.Close()
+
+		ctx := context.Background()
+		job := &Job{
+			JobID:   "owned-job",
+			Created: time.Now(),
+			Updated: time.Now(),
+			Status:  schemas.JobStatePending,
+			Spec:    &schemas.JobSpec{UserID: "alice"},
+		}
+		if err := s.CreateJob(ctx, job); err != nil {
+			t.Fatalf("CreateJob() failed: %v", err)
+		}
+
+		if _, err := s.GetJob(ctx, job.JobID, "alice"); err != nil {
+			t.Errorf("Owner lookup failed: %v", err)
+		}
+		if _, err := s.GetJob(ctx, job.JobID, "bob"); err != ErrJobNotFound {
+			t.Errorf("Expected ErrJobNotFound for other user, got %v", err)
+		}
+		if _, err := s.GetJob(ctx, job.JobID, ""); err != nil {
+			t.Errorf("Unscoped lookup failed: %v", err)
+		}
+	})
+
 	t.Run("UpdateJob", func(t *testing.T) {
 		s := newStore()
 		defer s.Close()
@@ -134,7 +207,7 @@ func testStore(t *testing.T, newStore func() Store) {
 		}
 
 		// Verify update
-		retrieved, err := s.GetJob(ctx, job.JobID)
+		retrieved, err := s.GetJob(ctx, job.JobID, "")
 		if err != nil {
 			t.Fatalf("GetJob() failed: %v", err)
 		}
@@ -173,7 +246,7 @@ func testStore(t *testing.T, newStore func() Store) {
 		}
 
 		// Verify update
-		retrieved, err := s.GetJob(ctx, job.JobID)
+		retrieved, err := s.GetJob(ctx, job.JobID, "")
 		if err != nil {
 			t.Fatalf("GetJob() failed: %v", err)
 		}
@@ -219,7 +292,7 @@ func testStore(t *testing.T, newStore func() Store) {
 		}
 
 		// Verify error was recorded
-		retrieved, err := s.GetJob(ctx, job.JobID)
+		retrieved, err := s.GetJob(ctx, job.JobID, "")
 		if err != nil {
 			t.Fatalf("GetJob() failed: %v", err)
 		}
@@ -256,7 +329,7 @@ func testStore(t *testing.T, newStore func() Store) {
 		}
 
 		// Verify job is deleted
-		_, err = s.GetJob(ctx, job.JobID)
+		_, err = s.GetJob(ctx, job.JobID, "")
 		if err != ErrJobNotFound {
 			t.Errorf("Expected ErrJobNotFound after delete, got %v", err)
 		}
@@ -332,6 +405,79 @@ func testStore(t *testing.T, newStore func() Store) {
 		}
 	})
 
+	t.Run("ListJobsByUser", func(t *testing.T) {
+		s := newStore()
+		defer s.Close()
+
+		ctx := context.Background()
+		for i, user := range []string{"alice", "bob", "alice"} {
+			job := &Job{
+				JobID:   "user-job-" + string(rune(i+'0')),
+				Created: time.Now(),
+				Updated: time.Now(),
+				Status:  schemas.JobStatePending,
+				Spec:    &schemas.JobSpec{UserID: user},
+			}
+			if err := s.CreateJob(ctx, job); err != nil {
+				t.Fatalf("CreateJob() failed: %v", err)
+			}
+		}
+
+		jobs, err := s.ListJobs(ctx, &ListFilter{UserID: "alice"})
+		if err != nil {
+			t.Fatalf("ListJobs() failed: %v", err)
+		}
+		if len(jobs) != 2 {
+			t.Errorf("Expected 2 jobs for alice, got %d", len(jobs))
+		}
+		for _, job := range jobs {
+			if job.Spec.UserID != "alice" {
+				t.Errorf("Unexpected owner %q in results", job.Spec.UserID)
+			}
+		}
+	})
+
+	t.Run("ListJobsByTag", func(t *testing.T) {
+		s := newStore()
+		defer s.Close()
+
+		ctx := context.Background()
+		tagSets := []map[string]string{
+			{"project": "promo", "env": "prod"},
+			{"project": "promo", "env": "staging"},
+			{"project": "archive"},
+			nil,
+		}
+		for i, tags := range tagSets {
+			job := &Job{
+				JobID:   "tag-job-" + string(rune(i+'0')),
+				Created: time.Now(),
+				Updated: time.Now(),
+				Status:  schemas.JobStatePending,
+				Spec:    &schemas.JobSpec{Tags: tags},
+			}
+			if err := s.CreateJob(ctx, job); err != nil {
+				t.Fatalf("CreateJob() failed: %v", err)
+			}
+		}
+
+		jobs, err := s.ListJobs(ctx, &ListFilter{Tags: map[string]string{"project": "promo"}})
+		if err != nil {
+			t.Fatalf("ListJobs() failed: %v", err)
+		}
+		if len(jobs) != 2 {
+			t.Errorf("Expected 2 promo jobs, got %d", len(jobs))
+		}
+
+		jobs, err = s.ListJobs(ctx, &ListFilter{Tags: map[string]string{"project": "promo", "env": "prod"}})
+		if err != nil {
+			t.Fatalf("ListJobs() failed: %v", err)
+		}
+		if len(jobs) != 1 || jobs[0].JobID != "tag-job-0" {
+			t.Errorf("Expected only tag-job-0, got %d jobs", len(jobs))
+		}
+	})
+
 	t.Run("ListJobsWithLimit", func(t *testing.T) {
 		s := newStore()
 		defer s.Close()
@@ -362,6 +508,121 @@ func testStore(t *testing.T, newStore func() Store) {
 			t.Errorf("Expected 3 jobs (limit), got %d", len(listed))
 		}
 	})
+
+	t.Run("CountJobs", func(t *testing.T) {
+		s := newStore()
+		defer s.Close()
+
+		ctx := context.Background()
+
+		for i := 0; i < 5; i++ {
+			status := schemas.JobStatePending
+			if i%2 == 1 {
+				status = schemas.JobStateCompleted
+			}
+			job := &Job{
+				JobID:   "count-" + string(rune(i+'0')),
+				Created: time.Now(),
+				Updated: time.Now(),
+				Status:  status,
+			}
+			if err := s.CreateJob(ctx, job); err != nil {
+				t.Fatalf("CreateJob() failed: %v", err)
+			}
+		}
+
+		// Limit and offset do not affect the count
+		count, err := s.CountJobs(ctx, &ListFilter{Limit: 2, Offset: 1})
+		if err != nil {
+			t.Fatalf("CountJobs() failed: %v", err)
+		}
+		if count != 5 {
+			t.Errorf("Expected 5 jobs, got %d", count)
+		}
+
+		count, err = s.CountJobs(ctx, &ListFilter{Status: []schemas.JobState{schemas.JobStatePending}, Limit: 1})
+		if err != nil {
+			t.Fatalf("CountJobs() failed: %v", err)
+		}
+		if count != 3 {
+			t.Errorf("Expected 3 pending jobs, got %d", count)
+		}
+
+		count, err = s.CountJobs(ctx, nil)
+		if err != nil {
+			t.Fatalf("CountJobs() failed: %v", err)
+		}
+		if count != 5 {
+			t.Errorf("Expected 5 jobs without a filter, got %d", count)
+		}
+	})
+
+	t.Run("ClaimJobByPriority", func(t *testing.T) {
+		s := newStore()
+		defer s.Close()
+
+		ctx := context.Background()
+		base := time.Now()
+
+		// Two jobs share the top priority; the older one should win
+		jobs := []*Job{
+			{JobID: "low", Created: base, Status: schemas.JobStatePending, Spec: &schemas.JobSpec{Priority: 1}},
+			{JobID: "high-new", Created: base.Add(2 * time.Second), Status: schemas.JobStatePending, Spec: &schemas.JobSpec{Priority: 5}},
+			{JobID: "high-old", Created: base.Add(time.Second), Status: schemas.JobStatePending, Spec: &schemas.JobSpec{Priority: 5}},
+			{JobID: "done", Created: base, Status: schemas.JobStateCompleted, Spec: &schemas.JobSpec{Priority: 10}},
+		}
+		for _, job := range jobs {
+			if err := s.CreateJob(ctx, job); err != nil {
+				t.Fatalf("CreateJob() failed: %v", err)
+			}
+		}
+
+		peeked, err := s.PeekNextJob(ctx, "worker-1")
+		if err != nil {
+			t.Fatalf("PeekNextJob() failed: %v", err)
+		}
+		if peeked.JobID != "high-old" {
+			t.Errorf("Expected peek to return high-old, got %s", peeked.JobID)
+		}
+
+		for _, want := range []string{"high-old", "high-new", "low"} {
+			claimed, err := s.ClaimJob(ctx, "worker-1")
+			if err != nil {
+				t.Fatalf("ClaimJob() failed: %v", err)
+			}
+			if claimed.JobID != want {
+				t.Errorf("Expected to claim %s, got %s", want, claimed.JobID)
+			}
+			if claimed.WorkerID != "worker-1" {
+				t.Errorf("Expected worker-1, got %s", claimed.WorkerID)
+			}
+			if claimed.IsPending() {
+				t.Errorf("Expected claimed job %s to leave pending state", claimed.JobID)
+			}
+		}
+
+		if _, err := s.ClaimJob(ctx, "worker-1"); err != ErrNoPendingJobs {
+			t.Errorf("Expected ErrNoPendingJobs, got %v", err)
+		}
+	})
+
+	t.Run("ClaimJobSkipsNonPending", func(t *testing.T) {
+		s := newStore()
+		defer s.Close()
+
+		ctx := context.Background()
+		job := &Job{JobID: "cancelled", Created: time.Now(), Status: schemas.JobStatePending}
+		if err := s.CreateJob(ctx, job); err != nil {
+			t.Fatalf("CreateJob() failed: %v", err)
+		}
+		if err := s.UpdateJobStatus(ctx, job.JobID, schemas.JobStateCancelled, nil); err != nil {
+			t.Fatalf("UpdateJobStatus() failed: %v", err)
+		}
+
+		if _, err := s.PeekNextJob(ctx, "worker-1"); err != ErrNoPendingJobs {
+			t.Errorf("Expected ErrNoPendingJobs, got %v", err)
+		}
+	})
 }
 
 // TestMemoryStore runs all tests against the memory store
@@ -370,3 +631,303 @@ func TestMemoryStore(t *testing.T) {
 		return NewMemoryStore()
 	})
 }
+
+func TestMemoryStoreSnapshot(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	started := created.Add(time.Minute)
+	completed := created.Add(2 * time.Minute)
+
+	done := &Job{
+		JobID:   "job-done",
+		Created: created,
+		Updated: completed,
+		Spec: &schemas.JobSpec{
+			Tags: map[string]string{"project": "promo"},
+			Inputs: []schemas.Input{
+				{ID: "video", Source: "s3://bucket/in.mp4", StartOffset: &schemas.Duration{Duration: 5 * time.Second}},
+			},
+			Operations: []schemas.Operation{
+				{Op: "trim", Input: "video", Output: "clip", Params: map[string]interface{}{"duration": "10s"}},
+			},
+			Outputs: []schemas.Output{
+				{ID: "clip", Destination: "s3://bucket/out.mp4"},
+			},
+		},
+		Plan: &schemas.ProcessingPlan{
+			PlanID:    "plan-1",
+			JobID:     "job-done",
+			CreatedAt: created,
+			Nodes: []*schemas.PlanNode{
+				{ID: "video", Type: "input", InputID: "video", SourceURI: "s3://bucket/in.mp4"},
+				{ID: "trim_0", Type: "operation", Operator: "trim", Params: map[string]interface{}{"duration": "10s"}},
+				{ID: "clip", Type: "output", OutputID: "clip", DestURI: "s3://bucket/out.mp4"},
+			},
+			Edges: []*schemas.PlanEdge{
+				{From: "video", To: "trim_0"},
+				{From: "trim_0", To: "clip"},
+			},
+			ExecutionOrder:  []string{"video", "trim_0", "clip"},
+			ExecutionStages: [][]string{{"video"}, {"trim_0"}, {"clip"}},
+		},
+		Status:      schemas.JobStateCompleted,
+		Progress:    &schemas.Progress{OverallPercent: 100, CurrentStep: "completed"},
+		StartedAt:   &started,
+		CompletedAt: &completed,
+		OutputFiles: []schemas.OutputFile{
+			{OutputID: "clip", Destination: "s3://bucket/out.mp4", FileSize: 1024, SHA256: "abc", Duration: 10},
+		},
+		RetryCount: 1,
+		WorkerID:   "worker-1",
+	}
+	failed := &Job{
+		JobID:   "job-failed",
+		Created: created,
+		Updated: completed,
+		Spec:    &schemas.JobSpec{},
+		Status:  schemas.JobStateFailed,
+		Error:   &schemas.ErrorInfo{Code: "EXECUTION_ERROR", Message: "boom", Retryable: true},
+	}
+	pending := &Job{
+		JobID:   "job-pending",
+		Created: created,
+		Updated: created,
+		Spec:    &schemas.JobSpec{Priority: 5},
+		Status:  schemas.JobStatePending,
+	}
+
+	for _, job := range []*Job{done, failed, pending} {
+		if err := m.CreateJob(ctx, job); err != nil {
+			t.Fatalf("CreateJob(%s) failed: %v", job.JobID, err)
+		}
+	}
+	if m.Version() == 0 {
+		t.Error("Expected version to advance after writes")
+	}
+
+	data, err := m.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot() failed: %v", err)
+	}
+
+	restored, err := LoadSnapshot(data)
+	if err != nil {
+		t.Fatalf("LoadSnapshot() failed: %v", err)
+	}
+
+	for _, want := range []*Job{done, failed, pending} {
+		got, err := restored.GetJob(ctx, want.JobID, "")
+		if err != nil {
+			t.Fatalf("GetJob(%s) failed: %v", want.JobID, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			gotJSON, _ := json.Marshal(got)
+			wantJSON, _ := json.Marshal(want)
+			t.Errorf("Job %s not preserved:\n got: %s\nwant: %s", want.JobID, gotJSON, wantJSON)
+		}
+	}
+
+	// Pending jobs are queued again
+	claimed, err := restored.ClaimJob(ctx, "worker-2")
+	if err != nil {
+		t.Fatalf("ClaimJob() failed: %v", err)
+	}
+	if claimed.JobID != pending.JobID {
+		t.Errorf("Expected to claim %s, got %s", pending.JobID, claimed.JobID)
+	}
+}
+
+func TestLoadSnapshotInvalid(t *testing.T) {
+	if _, err := LoadSnapshot([]byte("not json")); err == nil {
+		t.Error("Expected error for malformed snapshot")
+	}
+	if _, err := LoadSnapshot([]byte(`{"a": {"job_id": "b"}}`)); err == nil {
+		t.Error("Expected error for mismatched job ID")
+	}
+}
+
+func TestSnapshotFileRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "jobs.json")
+
+	// A missing file yields an empty store
+	m, err := LoadSnapshotFile(path)
+	if err != nil {
+		t.Fatalf("LoadSnapshotFile() failed: %v", err)
+	}
+	if count, _ := m.CountJobs(ctx, &ListFilter{}); count != 0 {
+		t.Errorf("Expected empty store, got %d jobs", count)
+	}
+
+	job := &Job{
+		JobID:   "job-pending",
+		Created: time.Now(),
+		Updated: time.Now(),
+		Spec:    &schemas.JobSpec{},
+		Status:  schemas.JobStatePending,
+	}
+	if err := m.CreateJob(ctx, job); err != nil {
+		t.Fatalf("CreateJob() failed: %v", err)
+	}
+	if err := m.SaveSnapshotFile(path); err != nil {
+		t.Fatalf("SaveSnapshotFile() failed: %v", err)
+	}
+
+	restored, err := LoadSnapshotFile(path)
+	if err != nil {
+		t.Fatalf("LoadSnapshotFile() failed: %v", err)
+	}
+	claimed, err := restored.ClaimJob(ctx, "worker-1")
+	if err != nil {
+		t.Fatalf("ClaimJob() failed: %v", err)
+	}
+	if claimed.JobID != job.JobID {
+		t.Errorf("Expected to claim %s, got %s", job.JobID, claimed.JobID)
+	}
+}
+
+func TestMemoryStoreExport(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i, jobID := range []string{"job-c", "job-a", "job-b"} {
+		job := &Job{
+			JobID:   jobID,
+			Created: base.Add(time.Duration(i) * time.Minute),
+			Updated: base,
+			Status:  schemas.JobStateCompleted,
+			Spec: &schemas.JobSpec{
+				Tags: map[string]string{"index": string(rune('0' + i))},
+			},
+			OutputFiles: []schemas.OutputFile{{OutputID: "out", FileSize: 1}},
+		}
+		if err := m.CreateJob(ctx, job); err != nil {
+			t.Fatalf("CreateJob(%s) failed: %v", jobID, err)
+		}
+	}
+
+	jobs := m.Export()
+	var ids []string
+	for _, job := range jobs {
+		ids = append(ids, job.JobID)
+	}
+	if want := []string{"job-c", "job-a", "job-b"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("Export() order = %v, want %v", ids, want)
+	}
+
+	// Modifying the export must not touch the store
+	jobs[0].Spec.Tags["index"] = "changed"
+	jobs[0].OutputFiles[0].FileSize = 99
+
+	stored, err := m.GetJob(ctx, "job-c", "")
+	if err != nil {
+		t.Fatalf("GetJob() failed: %v", err)
+	}
+	if stored.Spec.Tags["index"] != "0" || stored.OutputFiles[0].FileSize != 1 {
+		t.Errorf("Export() shares data with the store: %+v", stored)
+	}
+}
+
+func TestMemoryStoreImport(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	existing := &Job{JobID: "job-1", Created: created, Status: schemas.JobStateCompleted, Spec: &schemas.JobSpec{}}
+	if err := m.CreateJob(ctx, existing); err != nil {
+		t.Fatalf("CreateJob() failed: %v", err)
+	}
+
+	err := m.Import([]*Job{
+		{JobID: "job-1", Created: created, Status: schemas.JobStateFailed, Spec: &schemas.JobSpec{}},
+		{JobID: "job-2", Created: created, Updated: created, Status: schemas.JobStatePending, Spec: &schemas.JobSpec{}},
+		{JobID: ""},
+	})
+	if !errors.Is(err, ErrJobExists) {
+		t.Errorf("Import() error = %v, want ErrJobExists", err)
+	}
+	if !errors.Is(err, ErrInvalidJobID) {
+		t.Errorf("Import() error = %v, want ErrInvalidJobID", err)
+	}
+
+	// The duplicate is skipped, the new job is inserted as is
+	job, err := m.GetJob(ctx, "job-1", "")
+	if err != nil || job.Status != schemas.JobStateCompleted {
+		t.Errorf("Expected job-1 to be unchanged, got %+v (err=%v)", job, err)
+	}
+	job, err = m.GetJob(ctx, "job-2", "")
+	if err != nil {
+		t.Fatalf("GetJob(job-2) failed: %v", err)
+	}
+	if !job.Updated.Equal(created) {
+		t.Errorf("Expected imported timestamps to be kept, got %v", job.Updated)
+	}
+
+	// Imported pending jobs are queued
+	claimed, err := m.ClaimJob(ctx, "worker-1")
+	if err != nil || claimed.JobID != "job-2" {
+		t.Errorf("Expected to claim job-2, got %+v (err=%v)", claimed, err)
+	}
+}
+
+func TestExportImportJSON(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	completed := created.Add(time.Minute)
+	jobs := []*Job{
+		{
+			JobID:       "job-done",
+			Created:     created,
+			Updated:     completed,
+			Spec:        &schemas.JobSpec{Tags: map[string]string{"project": "promo"}},
+			Status:      schemas.JobStateCompleted,
+			CompletedAt: &completed,
+			OutputFiles: []schemas.OutputFile{{OutputID: "clip", FileSize: 1024}},
+		},
+		{
+			JobID:   "job-failed",
+			Created: created.Add(time.Second),
+			Updated: completed,
+			Spec:    &schemas.JobSpec{},
+			Status:  schemas.JobStateFailed,
+			Error:   &schemas.ErrorInfo{Code: "EXECUTION_ERROR", Message: "boom"},
+		},
+	}
+	if err := m.Import(jobs); err != nil {
+		t.Fatalf("Import() failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := ExportToJSON(&buf, m); err != nil {
+		t.Fatalf("ExportToJSON() failed: %v", err)
+	}
+
+	restored, err := ImportFromJSON(&buf)
+	if err != nil {
+		t.Fatalf("ImportFromJSON() failed: %v", err)
+	}
+
+	for _, want := range jobs {
+		got, err := restored.GetJob(ctx, want.JobID, "")
+		if err != nil {
+			t.Fatalf("GetJob(%s) failed: %v", want.JobID, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			gotJSON, _ := json.Marshal(got)
+			wantJSON, _ := json.Marshal(want)
+			t.Errorf("Job %s not preserved:\n got: %s\nwant: %s", want.JobID, gotJSON, wantJSON)
+		}
+	}
+
+	if _, err := ImportFromJSON(bytes.NewBufferString(`[{"job_id": "a"}, {"job_id": "a"}]`)); !errors.Is(err, ErrJobExists) {
+		t.Errorf("Expected ErrJobExists for duplicate jobs, got %v", err)
+	}
+	if _, err := ImportFromJSON(bytes.NewBufferString("not json")); err == nil {
+		t.Error("Expected error for malformed JSON")
+	}
+}
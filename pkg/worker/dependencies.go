@@ -0,0 +1,156 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/chicogong/media-pipeline/pkg/schemas"
+	"github.com/chicogong/media-pipeline/pkg/store"
+)
+
+// awaitDependencies reports whether every job listed in the job's DependsOn
+// has completed. If not, the job is handed back with requeueLater so it does
+// not hold a worker while it waits, and false is returned. It also returns
+// false if the job should not run at all: a dependency failed (the job is
+// then failed with DEPENDENCY_FAILED) or the dependencies form a cycle.
+func (p *Processor) awaitDependencies(ctx context.Context, jobID string) bool {
+	job, err := p.store.GetJob(ctx, jobID, "")
+	if err != nil {
+		return false
+	}
+	if job.Spec == nil || len(job.Spec.DependsOn) == 0 {
+		return true
+	}
+
+	if err := p.CheckDependencyCycle(ctx, jobID, job.Spec.DependsOn); err != nil {
+		p.failJob(ctx, jobID, &schemas.ErrorInfo{
+			Code:      "DEPENDENCY_CYCLE",
+			Message:   err.Error(),
+			Retryable: false,
+		})
+		return false
+	}
+
+	ready, failure := p.checkDependencies(ctx, job.Spec.DependsOn)
+	if failure != nil {
+		p.failJob(ctx, jobID, failure)
+		return false
+	}
+	if ready {
+		return true
+	}
+
+	log.Printf("Job %s waiting for dependencies %v, re-checking in %v",
+		jobID, job.Spec.DependsOn, p.DependencyPollInterval)
+	p.requeueLater(jobID)
+	return false
+}
+
+// requeueLater puts a job back in line after DependencyPollInterval, using
+// Requeue if set and the store's pending queue otherwise. Jobs that reached
+// a terminal state in the meantime are dropped.
+func (p *Processor) requeueLater(jobID string) {
+	time.AfterFunc(p.DependencyPollInterval, func() {
+		// The job no longer belongs to the caller's context
+		ctx := context.Background()
+
+		job, err := p.store.GetJob(ctx, jobID, "")
+		if err != nil || job.IsTerminal() {
+			return
+		}
+
+		if p.Requeue != nil {
+			if err := p.Requeue(jobID); err != nil {
+				log.Printf("Failed to requeue job %s: %v", jobID, err)
+				p.requeueLater(jobID)
+			}
+			return
+		}
+
+		job.Status = schemas.JobStatePending
+		job.WorkerID = ""
+		if err := p.store.UpdateJob(ctx, job); err != nil {
+			log.Printf("Failed to requeue job %s: %v", jobID, err)
+		}
+	})
+}
+
+// checkDependencies reports whether all dependencies have completed. A
+// non-nil ErrorInfo means a dependency can never complete.
+func (p *Processor) checkDependencies(ctx context.Context, deps []string) (bool, *schemas.ErrorInfo) {
+	ready := true
+	for _, depID := range deps {
+		dep, err := p.store.GetJob(ctx, depID, "")
+		if err == store.ErrJobNotFound {
+			return false, &schemas.ErrorInfo{
+				Code:      "DEPENDENCY_FAILED",
+				Message:   fmt.Sprintf("Dependency %s does not exist", depID),
+				Retryable: false,
+			}
+		}
+		if err != nil {
+			return false, &schemas.ErrorInfo{
+				Code:      "DEPENDENCY_FAILED",
+				Message:   fmt.Sprintf("Failed to get dependency %s: %v", depID, err),
+				Retryable: true,
+			}
+		}
+
+		switch dep.Status {
+		case schemas.JobStateCompleted:
+		case schemas.JobStateFailed, schemas.JobStateCancelled:
+			return false, &schemas.ErrorInfo{
+				Code:      "DEPENDENCY_FAILED",
+				Message:   fmt.Sprintf("Dependency %s is %s", depID, dep.Status),
+				Retryable: false,
+			}
+		default:
+			ready = false
+		}
+	}
+	return ready, nil
+}
+
+// CheckDependencyCycle walks the dependency graph reachable from deps and
+// returns an error if it contains a cycle, whether or not the cycle leads
+// back to jobID. A job depending on a cycle could never run either.
+func (p *Processor) CheckDependencyCycle(ctx context.Context, jobID string, deps []string) error {
+	const (
+		visiting = 1 // on the current path
+		visited  = 2 // fully explored
+	)
+	state := make(map[string]int)
+
+	var visit func(id string, deps []string, path []string) error
+	visit = func(id string, deps []string, path []string) error {
+		path = append(path, id)
+		state[id] = visiting
+
+		for _, next := range deps {
+			switch state[next] {
+			case visiting:
+				return fmt.Errorf("circular dependency: %s", strings.Join(append(path, next), " -> "))
+			case visited:
+				continue
+			}
+
+			dep, err := p.store.GetJob(ctx, next, "")
+			if err != nil || dep.Spec == nil {
+				// Missing dependencies are reported when the job runs
+				state[next] = visited
+				continue
+			}
+			if err := visit(next, dep.Spec.DependsOn, path); err != nil {
+				return err
+			}
+		}
+
+		state[id] = visited
+		return nil
+	}
+
+	return visit(jobID, deps, nil)
+}
@@ -0,0 +1,225 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chicogong/media-pipeline/pkg/executor"
+	"github.com/chicogong/media-pipeline/pkg/schemas"
+	"github.com/chicogong/media-pipeline/pkg/store"
+)
+
+// recordingExecutor records the job ID of each plan it executes
+type recordingExecutor struct {
+	mu    sync.Mutex
+	order []string
+}
+
+func (e *recordingExecutor) Execute(ctx context.Context, plan *schemas.ProcessingPlan, opts *executor.ExecuteOptions) (executor.ExecutionResult, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.order = append(e.order, plan.JobID)
+	return executor.ExecutionResult{}, nil
+}
+
+// dependentJob returns a pending job with a minimal spec depending on deps
+func dependentJob(jobID string, deps ...string) *store.Job {
+	return &store.Job{
+		JobID:   jobID,
+		Created: time.Now(),
+		Updated: time.Now(),
+		Status:  schemas.JobStatePending,
+		Spec: &schemas.JobSpec{
+			JobID:     jobID,
+			DependsOn: deps,
+			Inputs: []schemas.Input{
+				{ID: "input1", Source: "file://test.mp4"},
+			},
+			Operations: []schemas.Operation{
+				{Op: "trim", Input: "input1", Output: "trimmed"},
+			},
+			Outputs: []schemas.Output{
+				{ID: "trimmed", Destination: "file://output.mp4"},
+			},
+		},
+	}
+}
+
+func TestProcessDependencyChain(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	exec := &recordingExecutor{}
+	p := NewProcessor(s, exec)
+	p.DependencyPollInterval = 5 * time.Millisecond
+
+	// A single loop stands in for a pool with one slot: a waiting job must
+	// be requeued rather than block it
+	queue := make(chan string, 10)
+	p.Requeue = func(jobID string) error {
+		queue <- jobID
+		return nil
+	}
+
+	// c depends on b, which depends on a
+	for _, job := range []*store.Job{
+		dependentJob("job-a"),
+		dependentJob("job-b", "job-a"),
+		dependentJob("job-c", "job-b"),
+	} {
+		if err := s.CreateJob(nil, job); err != nil {
+			t.Fatalf("Failed to create test job: %v", err)
+		}
+	}
+
+	// Queue the jobs in reverse order so the dependents have to wait
+	for _, id := range []string{"job-c", "job-b", "job-a"} {
+		queue <- id
+	}
+
+	deadline := time.After(5 * time.Second)
+	for completed := 0; completed < 3; {
+		select {
+		case id := <-queue:
+			p.Process(context.Background(), id)
+			if job, err := s.GetJob(nil, id, ""); err == nil && job.IsTerminal() {
+				completed++
+			}
+		case <-deadline:
+			t.Fatalf("Timed out with %d of 3 jobs completed", completed)
+		}
+	}
+
+	for _, id := range []string{"job-a", "job-b", "job-c"} {
+		job, err := s.GetJob(nil, id, "")
+		if err != nil {
+			t.Fatalf("Failed to get job %s: %v", id, err)
+		}
+		if job.Status != schemas.JobStateCompleted {
+			t.Errorf("Expected %s to be completed, got %s", id, job.Status)
+		}
+	}
+
+	expected := []string{"job-a", "job-b", "job-c"}
+	if len(exec.order) != len(expected) {
+		t.Fatalf("Expected %d executions, got %v", len(expected), exec.order)
+	}
+	for i, id := range expected {
+		if exec.order[i] != id {
+			t.Errorf("Expected execution order %v, got %v", expected, exec.order)
+			break
+		}
+	}
+}
+
+func TestProcessDependencyFailed(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	p := NewProcessor(s, &recordingExecutor{})
+
+	upstream := dependentJob("upstream")
+	upstream.Status = schemas.JobStateFailed
+	if err := s.CreateJob(nil, upstream); err != nil {
+		t.Fatalf("Failed to create test job: %v", err)
+	}
+	if err := s.CreateJob(nil, dependentJob("downstream", "upstream")); err != nil {
+		t.Fatalf("Failed to create test job: %v", err)
+	}
+
+	p.Process(context.Background(), "downstream")
+
+	job, err := s.GetJob(nil, "downstream", "")
+	if err != nil {
+		t.Fatalf("Failed to get job: %v", err)
+	}
+	if job.Status != schemas.JobStateFailed {
+		t.Errorf("Expected status failed, got %s", job.Status)
+	}
+	if job.Error == nil || job.Error.Code != "DEPENDENCY_FAILED" {
+		t.Errorf("Expected DEPENDENCY_FAILED error, got %+v", job.Error)
+	}
+}
+
+func TestProcessDependencyCycle(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	p := NewProcessor(s, &recordingExecutor{})
+
+	for _, job := range []*store.Job{
+		dependentJob("cycle-a", "cycle-c"),
+		dependentJob("cycle-b", "cycle-a"),
+		dependentJob("cycle-c", "cycle-b"),
+	} {
+		if err := s.CreateJob(nil, job); err != nil {
+			t.Fatalf("Failed to create test job: %v", err)
+		}
+	}
+
+	p.Process(context.Background(), "cycle-a")
+
+	job, err := s.GetJob(nil, "cycle-a", "")
+	if err != nil {
+		t.Fatalf("Failed to get job: %v", err)
+	}
+	if job.Error == nil || job.Error.Code != "DEPENDENCY_CYCLE" {
+		t.Errorf("Expected DEPENDENCY_CYCLE error, got %+v", job.Error)
+	}
+}
+
+func TestProcessDependencyRequeuedToStore(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	p := NewProcessor(s, &recordingExecutor{})
+	p.DependencyPollInterval = 5 * time.Millisecond
+
+	if err := s.CreateJob(nil, dependentJob("upstream")); err != nil {
+		t.Fatalf("Failed to create test job: %v", err)
+	}
+	if err := s.CreateJob(nil, dependentJob("downstream", "upstream")); err != nil {
+		t.Fatalf("Failed to create test job: %v", err)
+	}
+
+	// Claim the dependent job the way a worker would
+	for {
+		job, err := s.ClaimJob(nil, "worker-1")
+		if err != nil {
+			t.Fatalf("ClaimJob() failed: %v", err)
+		}
+		if job.JobID == "downstream" {
+			break
+		}
+	}
+
+	// Process must return straight away instead of waiting for upstream
+	done := make(chan struct{})
+	go func() {
+		p.Process(context.Background(), "downstream")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Process() blocked waiting for dependencies")
+	}
+
+	// The job is handed back to the pending queue after the poll interval
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		job, err := s.PeekNextJob(nil, "")
+		if err == nil && job.JobID == "downstream" {
+			if job.WorkerID != "" {
+				t.Errorf("Expected requeued job to be unassigned, got worker %q", job.WorkerID)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected downstream to be requeued in the store")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
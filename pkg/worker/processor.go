@@ -0,0 +1,388 @@
+// Package worker processes jobs from the store, either in-process for the API
+// server or as a standalone daemon pulling from the pending queue
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/chicogong/media-pipeline/pkg/executor"
+	"github.com/chicogong/media-pipeline/pkg/operators"
+	"github.com/chicogong/media-pipeline/pkg/planner"
+	"github.com/chicogong/media-pipeline/pkg/prober"
+	"github.com/chicogong/media-pipeline/pkg/schemas"
+	"github.com/chicogong/media-pipeline/pkg/store"
+)
+
+const (
+	// DefaultTimeoutWarningThreshold is the default lead time for timeout warnings
+	DefaultTimeoutWarningThreshold = 30 * time.Second
+
+	// DefaultRetryBackoff is the default delay before an automatic retry
+	DefaultRetryBackoff = 5 * time.Second
+
+	// DefaultDependencyPollInterval is the default dependency re-check interval
+	DefaultDependencyPollInterval = 2 * time.Second
+)
+
+// JobExecutor runs processing plans
+// Satisfied by *executor.Executor
+type JobExecutor interface {
+	Execute(ctx context.Context, plan *schemas.ProcessingPlan, opts *executor.ExecuteOptions) (executor.ExecutionResult, error)
+}
+
+// Processor drives a single job through planning and execution, recording
+// progress, errors and retries in the store
+type Processor struct {
+	store    store.Store
+	planner  *planner.CachingPlanner
+	executor JobExecutor
+	prober   schemas.ProberInterface
+	webhooks *webhookNotifier
+
+	// TimeoutWarningThreshold is how long before a job's timeout the
+	// job.timeout_warning webhook is sent
+	TimeoutWarningThreshold time.Duration
+
+	// MaxAutoRetries is how many times a job that failed with a retryable
+	// error is re-run automatically (0 = never)
+	MaxAutoRetries int
+
+	// RetryBackoff is the delay before an automatic retry when the error
+	// does not specify RetryAfter
+	RetryBackoff time.Duration
+
+	// DependencyPollInterval is how long a job whose dependencies have not
+	// completed waits before it is requeued to check them again
+	DependencyPollInterval time.Duration
+
+	// Requeue, if set, puts a job waiting on its dependencies back in the
+	// caller's queue. Without it the job is returned to the store's pending
+	// queue for any worker to claim.
+	Requeue func(jobID string) error
+
+	// RequeueRetries leaves retried jobs pending in the store instead of
+	// re-running them in-process, so any worker can claim them
+	RequeueRetries bool
+
+	// DefaultJobTimeout limits jobs whose spec sets no timeout (0 = none)
+	DefaultJobTimeout time.Duration
+
+	// WebhookSecret, if set, signs webhook payloads; see WebhookSignatureHeader
+	WebhookSecret string
+}
+
+// ProcessorOption is a functional option for Processor
+type ProcessorOption func(*Processor)
+
+// WithPlanner plans jobs with planner instead of a planner backed by the
+// global operator registry. Plans are cached as usual.
+func WithPlanner(pl *planner.Planner) ProcessorOption {
+	return func(p *Processor) {
+		p.planner = planner.NewCachingPlanner(pl)
+	}
+}
+
+// WithProber probes downloaded inputs with prober
+func WithProber(prober schemas.ProberInterface) ProcessorOption {
+	return func(p *Processor) {
+		p.prober = prober
+	}
+}
+
+// NewProcessor creates a processor that executes plans with exec.
+// A nil exec uses an executor backed by the global operator registry.
+func NewProcessor(s store.Store, exec JobExecutor, opts ...ProcessorOption) *Processor {
+	registry := operators.GlobalRegistry()
+	if exec == nil {
+		exec = executor.NewExecutor(registry)
+	}
+
+	p := &Processor{
+		store:    s,
+		planner:  planner.NewCachingPlanner(planner.NewPlannerWithRegistry(registry)),
+		executor: exec,
+		prober:   prober.NewProber(),
+		webhooks: newWebhookNotifier(),
+
+		TimeoutWarningThreshold: DefaultTimeoutWarningThreshold,
+		RetryBackoff:            DefaultRetryBackoff,
+		DependencyPollInterval:  DefaultDependencyPollInterval,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// Process runs a job to completion if its dependencies have completed,
+// re-running it while it fails with retryable errors and has retries left.
+// A job whose dependencies are still running is requeued instead.
+func (p *Processor) Process(ctx context.Context, jobID string) {
+	for {
+		if !p.awaitDependencies(ctx, jobID) {
+			return
+		}
+
+		failure := p.runJob(ctx, jobID)
+		if failure == nil || !p.prepareRetry(ctx, jobID, failure) {
+			return
+		}
+	}
+}
+
+// runJob makes a single processing attempt. It returns the error recorded
+// on the job, or nil if the job completed or could not be loaded.
+func (p *Processor) runJob(ctx context.Context, jobID string) *schemas.ErrorInfo {
+	// Get job from store
+	job, err := p.store.GetJob(ctx, jobID, "")
+	if err != nil {
+		return nil
+	}
+
+	// Store updates use the parent context so they still land after a timeout
+	runCtx := ctx
+	timeout := p.DefaultJobTimeout
+	if job.Spec != nil && job.Spec.Timeout != nil && job.Spec.Timeout.Duration > 0 {
+		timeout = job.Spec.Timeout.Duration
+	}
+	if job.Spec != nil && timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		if job.Spec.WebhookURL != "" {
+			stop := p.scheduleTimeoutWarning(runCtx, job, time.Now().Add(timeout))
+			defer stop()
+		}
+	}
+
+	// Update status to validating
+	p.store.UpdateJobStatus(ctx, jobID, schemas.JobStateValidating, &schemas.Progress{
+		OverallPercent: 10,
+		CurrentStep:    "validating",
+	})
+
+	// TODO: Validate JobSpec
+
+	// Update status to planning
+	p.store.UpdateJobStatus(ctx, jobID, schemas.JobStatePlanning, &schemas.Progress{
+		OverallPercent: 20,
+		CurrentStep:    "planning",
+	})
+
+	// Create processing plan
+	plan, err := p.planner.Plan(runCtx, job.Spec, nil)
+	if runCtx.Err() == context.DeadlineExceeded {
+		return p.failJobTimeout(ctx, jobID, timeout)
+	}
+	if err != nil {
+		return p.failJob(ctx, jobID, &schemas.ErrorInfo{
+			Code:      "PLANNING_ERROR",
+			Message:   fmt.Sprintf("Failed to create plan: %v", err),
+			Retryable: false,
+		})
+	}
+
+	// Save plan
+	job.Plan = plan
+	p.store.UpdateJob(ctx, job)
+
+	// Update status to processing
+	p.store.UpdateJobStatus(ctx, jobID, schemas.JobStateProcessing, &schemas.Progress{
+		OverallPercent: 50,
+		CurrentStep:    "processing",
+	})
+
+	// Execute plan
+	var outputFiles []schemas.OutputFile
+	execOpts := &executor.ExecuteOptions{
+		Limits: job.Spec.Limits,
+		OnDownloadProgress: func(progress *schemas.DownloadProgress) {
+			p.store.UpdateJobStatus(ctx, jobID, schemas.JobStateDownloadingInputs, &schemas.Progress{
+				OverallPercent: 50,
+				CurrentStep:    "downloading_inputs",
+				StepProgress:   &schemas.StepProgress{DownloadProgress: progress},
+			})
+		},
+		OnUploadProgress: func(progress *schemas.UploadProgress) {
+			p.store.UpdateJobStatus(ctx, jobID, schemas.JobStateUploadingOutputs, &schemas.Progress{
+				OverallPercent: 90,
+				CurrentStep:    "uploading_outputs",
+				StepProgress:   &schemas.StepProgress{UploadProgress: progress},
+			})
+		},
+		OnOutput: func(outputID, localPath, destURI string) {
+			outputFiles = append(outputFiles, p.describeOutput(runCtx, outputID, localPath, destURI))
+		},
+		OnProgress: func(progress *executor.Progress) {
+			// Update progress in store (simple progress based on frame count)
+			percent := 50.0 + (float64(progress.Frame) / 1000.0) // Simplified progress
+			if percent > 90 {
+				percent = 90 // Cap at 90% until completion
+			}
+			p.store.UpdateJobStatus(ctx, jobID, schemas.JobStateProcessing, &schemas.Progress{
+				OverallPercent: percent,
+				CurrentStep:    "processing",
+			})
+		},
+	}
+
+	if limits := job.Spec.Limits; limits != nil && limits.MaxOutputSize > 0 {
+		execOpts.MaxOutputBytes = limits.MaxOutputSize
+	}
+
+	result, err := p.executor.Execute(runCtx, plan, execOpts)
+	if err != nil {
+		if runCtx.Err() == context.DeadlineExceeded {
+			return p.failJobTimeout(ctx, jobID, timeout)
+		}
+		if errors.Is(err, executor.ErrOutputSizeLimitExceeded) {
+			return p.failJob(ctx, jobID, &schemas.ErrorInfo{
+				Code:      "OUTPUT_TOO_LARGE",
+				Message:   err.Error(),
+				Retryable: false,
+			})
+		}
+		if errors.Is(err, executor.ErrNodeTimeout) {
+			return p.failJob(ctx, jobID, &schemas.ErrorInfo{
+				Code:      "NODE_TIMEOUT",
+				Message:   err.Error(),
+				Retryable: false,
+			})
+		}
+		return p.failJob(ctx, jobID, &schemas.ErrorInfo{
+			Code:           "EXECUTION_ERROR",
+			Message:        fmt.Sprintf("Failed to execute: %v", err),
+			FFmpegExitCode: result.FFmpegExitCode,
+			Retryable:      true,
+		})
+	}
+
+	// Record outputs and execution stats before marking the job completed
+	for i := range outputFiles {
+		if size, ok := result.OutputSizes[outputFiles[i].OutputID]; ok {
+			outputFiles[i].FileSize = size
+		}
+	}
+	if job, err := p.store.GetJob(ctx, jobID, ""); err == nil {
+		job.OutputFiles = outputFiles
+		job.Execution = &schemas.ExecutionStats{
+			WallClockSeconds: result.WallClockDuration.Seconds(),
+			FFmpegExitCode:   result.FFmpegExitCode,
+			BytesRead:        result.TotalBytesRead,
+			BytesWritten:     result.TotalBytesWritten,
+			Command:          result.CommandExecuted,
+		}
+		p.store.UpdateJob(ctx, job)
+	}
+
+	// Update status to completed
+	p.store.UpdateJobStatus(ctx, jobID, schemas.JobStateCompleted, &schemas.Progress{
+		OverallPercent: 100,
+		CurrentStep:    "completed",
+	})
+	return nil
+}
+
+// describeOutput builds the OutputFile for a finished output. Probe
+// failures are logged; size and hash are still recorded.
+func (p *Processor) describeOutput(ctx context.Context, outputID, localPath, destURI string) schemas.OutputFile {
+	file := schemas.OutputFile{
+		OutputID:    outputID,
+		Destination: destURI,
+	}
+	if err := file.Populate(ctx, localPath, p.prober); err != nil {
+		log.Printf("Failed to inspect output %s: %v", outputID, err)
+	}
+	return file
+}
+
+// failJob records errInfo on the job and marks it as failed
+func (p *Processor) failJob(ctx context.Context, jobID string, errInfo *schemas.ErrorInfo) *schemas.ErrorInfo {
+	p.store.UpdateJobError(ctx, jobID, errInfo)
+	p.store.UpdateJobStatus(ctx, jobID, schemas.JobStateFailed, nil)
+	return errInfo
+}
+
+// failJobTimeout marks a job as failed because it exceeded its timeout
+func (p *Processor) failJobTimeout(ctx context.Context, jobID string, timeout time.Duration) *schemas.ErrorInfo {
+	return p.failJob(ctx, jobID, &schemas.ErrorInfo{
+		Code:      "JOB_TIMEOUT",
+		Message:   fmt.Sprintf("Job exceeded timeout of %s", timeout),
+		Retryable: false,
+	})
+}
+
+// prepareRetry decides whether a failed job should be retried. If so, it
+// waits for the backoff, bumps RetryCount and resets the job to pending.
+// It reports whether the retry should run in-process.
+func (p *Processor) prepareRetry(ctx context.Context, jobID string, failure *schemas.ErrorInfo) bool {
+	if !failure.Retryable || p.MaxAutoRetries <= 0 {
+		return false
+	}
+
+	job, err := p.store.GetJob(ctx, jobID, "")
+	if err != nil || job.RetryCount >= p.MaxAutoRetries {
+		return false
+	}
+
+	delay := p.RetryBackoff
+	if failure.RetryAfter != nil {
+		delay = *failure.RetryAfter
+	}
+
+	log.Printf("Retrying job %s (retry %d/%d) in %v: %s",
+		jobID, job.RetryCount+1, p.MaxAutoRetries, delay, failure.Message)
+
+	select {
+	case <-time.After(delay):
+	case <-ctx.Done():
+		return false
+	}
+
+	// Re-read the job: it may have been cancelled while we waited
+	job, err = p.store.GetJob(ctx, jobID, "")
+	if err != nil || job.Status != schemas.JobStateFailed {
+		return false
+	}
+
+	job.RetryCount++
+	job.Status = schemas.JobStatePending
+	job.Error = nil
+	job.CompletedAt = nil
+	if err := p.store.UpdateJob(ctx, job); err != nil {
+		return false
+	}
+
+	// Queued retries are picked up again by whichever worker claims them
+	return !p.RequeueRetries
+}
+
+// scheduleTimeoutWarning sends a job.timeout_warning webhook once the job is
+// within TimeoutWarningThreshold of its deadline. The returned func cancels
+// a warning that has not fired yet.
+func (p *Processor) scheduleTimeoutWarning(ctx context.Context, job *store.Job, deadline time.Time) func() {
+	delay := time.Until(deadline) - p.TimeoutWarningThreshold
+	if delay < 0 {
+		delay = 0
+	}
+
+	timer := time.AfterFunc(delay, func() {
+		if ctx.Err() != nil {
+			return
+		}
+		// Delivery must not be cut short when the job itself times out
+		p.webhooks.Notify(context.WithoutCancel(ctx), job.Spec.WebhookURL, p.WebhookSecret, &WebhookEvent{
+			Event:    WebhookEventTimeoutWarning,
+			JobID:    job.JobID,
+			Deadline: &deadline,
+		})
+	})
+	return func() { timer.Stop() }
+}
@@ -0,0 +1,401 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chicogong/media-pipeline/pkg/executor"
+	"github.com/chicogong/media-pipeline/pkg/schemas"
+	"github.com/chicogong/media-pipeline/pkg/store"
+)
+
+// blockingExecutor blocks until the context is done
+type blockingExecutor struct{}
+
+func (blockingExecutor) Execute(ctx context.Context, plan *schemas.ProcessingPlan, opts *executor.ExecuteOptions) (executor.ExecutionResult, error) {
+	<-ctx.Done()
+	return executor.ExecutionResult{}, ctx.Err()
+}
+
+func TestProcessTimeout(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	p := NewProcessor(s, blockingExecutor{})
+	p.WebhookSecret = "secret"
+
+	warnings := make(chan WebhookEvent, 1)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if got, want := r.Header.Get(WebhookSignatureHeader), SignWebhook("secret", body); got != want {
+			t.Errorf("Expected signature %s, got %s", want, got)
+		}
+
+		var event WebhookEvent
+		json.Unmarshal(body, &event)
+		warnings <- event
+	}))
+	defer hook.Close()
+
+	job := &store.Job{
+		JobID:   "timeout-job",
+		Created: time.Now(),
+		Updated: time.Now(),
+		Status:  schemas.JobStatePending,
+		Spec: &schemas.JobSpec{
+			Timeout:    &schemas.Duration{Duration: 100 * time.Millisecond},
+			WebhookURL: hook.URL,
+			Inputs: []schemas.Input{
+				{ID: "input1", Source: "file://test.mp4"},
+			},
+			Operations: []schemas.Operation{
+				{Op: "trim", Input: "input1", Output: "trimmed"},
+			},
+			Outputs: []schemas.Output{
+				{ID: "trimmed", Destination: "file://output.mp4"},
+			},
+		},
+	}
+	if err := s.CreateJob(nil, job); err != nil {
+		t.Fatalf("Failed to create test job: %v", err)
+	}
+
+	start := time.Now()
+	p.Process(context.Background(), job.JobID)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Process did not honour the timeout, took %v", elapsed)
+	}
+
+	updated, err := s.GetJob(nil, job.JobID, "")
+	if err != nil {
+		t.Fatalf("Failed to get job: %v", err)
+	}
+	if updated.Status != schemas.JobStateFailed {
+		t.Errorf("Expected status failed, got %s", updated.Status)
+	}
+	if updated.Error == nil || updated.Error.Code != "JOB_TIMEOUT" {
+		t.Fatalf("Expected JOB_TIMEOUT error, got %+v", updated.Error)
+	}
+	if updated.Error.Retryable {
+		t.Error("Expected timeout error to be non-retryable")
+	}
+
+	// The timeout is shorter than the warning threshold, so the warning
+	// fires immediately
+	select {
+	case event := <-warnings:
+		if event.Event != WebhookEventTimeoutWarning || event.JobID != job.JobID {
+			t.Errorf("Unexpected webhook event %+v", event)
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("Expected timeout warning webhook")
+	}
+}
+
+func TestProcessDefaultTimeout(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	p := NewProcessor(s, blockingExecutor{})
+	p.DefaultJobTimeout = 100 * time.Millisecond
+
+	// No timeout in the spec, so the processor's default applies
+	job := &store.Job{
+		JobID:   "default-timeout-job",
+		Created: time.Now(),
+		Updated: time.Now(),
+		Status:  schemas.JobStatePending,
+		Spec: &schemas.JobSpec{
+			Inputs: []schemas.Input{
+				{ID: "input1", Source: "file://test.mp4"},
+			},
+			Operations: []schemas.Operation{
+				{Op: "trim", Input: "input1", Output: "trimmed"},
+			},
+			Outputs: []schemas.Output{
+				{ID: "trimmed", Destination: "file://output.mp4"},
+			},
+		},
+	}
+	if err := s.CreateJob(nil, job); err != nil {
+		t.Fatalf("Failed to create test job: %v", err)
+	}
+
+	p.Process(context.Background(), job.JobID)
+
+	updated, err := s.GetJob(nil, job.JobID, "")
+	if err != nil {
+		t.Fatalf("Failed to get job: %v", err)
+	}
+	if updated.Status != schemas.JobStateFailed {
+		t.Errorf("Expected status failed, got %s", updated.Status)
+	}
+	if updated.Error == nil || updated.Error.Code != "JOB_TIMEOUT" {
+		t.Fatalf("Expected JOB_TIMEOUT error, got %+v", updated.Error)
+	}
+	if want := "Job exceeded timeout of 100ms"; updated.Error.Message != want {
+		t.Errorf("Expected message %q, got %q", want, updated.Error.Message)
+	}
+}
+
+// flakyExecutor fails a fixed number of times before succeeding
+type flakyExecutor struct {
+	failures int
+	calls    int
+}
+
+func (e *flakyExecutor) Execute(ctx context.Context, plan *schemas.ProcessingPlan, opts *executor.ExecuteOptions) (executor.ExecutionResult, error) {
+	e.calls++
+	if e.calls <= e.failures {
+		return executor.ExecutionResult{}, fmt.Errorf("transient failure %d", e.calls)
+	}
+	return executor.ExecutionResult{}, nil
+}
+
+func TestProcessAutoRetry(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	exec := &flakyExecutor{failures: 2}
+	p := NewProcessor(s, exec)
+	p.MaxAutoRetries = 2
+	p.RetryBackoff = time.Millisecond
+
+	job := &store.Job{
+		JobID:   "retry-job",
+		Created: time.Now(),
+		Updated: time.Now(),
+		Status:  schemas.JobStatePending,
+		Spec: &schemas.JobSpec{
+			Inputs: []schemas.Input{
+				{ID: "input1", Source: "file://test.mp4"},
+			},
+			Operations: []schemas.Operation{
+				{Op: "trim", Input: "input1", Output: "trimmed"},
+			},
+			Outputs: []schemas.Output{
+				{ID: "trimmed", Destination: "file://output.mp4"},
+			},
+		},
+	}
+	if err := s.CreateJob(nil, job); err != nil {
+		t.Fatalf("Failed to create test job: %v", err)
+	}
+
+	p.Process(context.Background(), job.JobID)
+
+	updated, err := s.GetJob(nil, job.JobID, "")
+	if err != nil {
+		t.Fatalf("Failed to get job: %v", err)
+	}
+	if updated.Status != schemas.JobStateCompleted {
+		t.Errorf("Expected status completed, got %s (error: %+v)", updated.Status, updated.Error)
+	}
+	if updated.RetryCount != 2 {
+		t.Errorf("Expected 2 retries, got %d", updated.RetryCount)
+	}
+	if exec.calls != 3 {
+		t.Errorf("Expected 3 execution attempts, got %d", exec.calls)
+	}
+}
+
+func TestProcessAutoRetryExhausted(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	exec := &flakyExecutor{failures: 5}
+	p := NewProcessor(s, exec)
+	p.MaxAutoRetries = 1
+	p.RetryBackoff = time.Millisecond
+
+	job := &store.Job{
+		JobID:   "retry-exhausted-job",
+		Created: time.Now(),
+		Updated: time.Now(),
+		Status:  schemas.JobStatePending,
+		Spec: &schemas.JobSpec{
+			Inputs: []schemas.Input{
+				{ID: "input1", Source: "file://test.mp4"},
+			},
+			Operations: []schemas.Operation{
+				{Op: "trim", Input: "input1", Output: "trimmed"},
+			},
+			Outputs: []schemas.Output{
+				{ID: "trimmed", Destination: "file://output.mp4"},
+			},
+		},
+	}
+	if err := s.CreateJob(nil, job); err != nil {
+		t.Fatalf("Failed to create test job: %v", err)
+	}
+
+	p.Process(context.Background(), job.JobID)
+
+	updated, err := s.GetJob(nil, job.JobID, "")
+	if err != nil {
+		t.Fatalf("Failed to get job: %v", err)
+	}
+	if updated.Status != schemas.JobStateFailed {
+		t.Errorf("Expected status failed, got %s", updated.Status)
+	}
+	if exec.calls != 2 {
+		t.Errorf("Expected 2 execution attempts, got %d", exec.calls)
+	}
+}
+
+// outputExecutor writes one output file and reports it through OnOutput
+type outputExecutor struct {
+	dir string
+}
+
+func (e *outputExecutor) Execute(ctx context.Context, plan *schemas.ProcessingPlan, opts *executor.ExecuteOptions) (executor.ExecutionResult, error) {
+	localPath := filepath.Join(e.dir, "output.mp4")
+	if err := os.WriteFile(localPath, []byte("encoded"), 0644); err != nil {
+		return executor.ExecutionResult{}, err
+	}
+	opts.OnOutput("trimmed", localPath, "file://output.mp4")
+	return executor.ExecutionResult{
+		WallClockDuration: time.Second,
+		OutputSizes:       map[string]int64{"trimmed": int64(len("encoded"))},
+		TotalBytesWritten: int64(len("encoded")),
+		CommandExecuted:   "ffmpeg -i test.mp4 output.mp4",
+	}, nil
+}
+
+// stubProber reports a fixed duration for every file
+type stubProber struct{}
+
+func (stubProber) Probe(ctx context.Context, filePath string) (*schemas.MediaInfo, error) {
+	return &schemas.MediaInfo{Format: schemas.FormatInfo{Format: "mp4", Duration: 3 * time.Second}}, nil
+}
+
+func TestProcessRecordsOutputFiles(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	p := NewProcessor(s, &outputExecutor{dir: t.TempDir()})
+	p.prober = stubProber{}
+
+	job := &store.Job{
+		JobID:   "output-job",
+		Created: time.Now(),
+		Updated: time.Now(),
+		Status:  schemas.JobStatePending,
+		Spec: &schemas.JobSpec{
+			Inputs: []schemas.Input{
+				{ID: "input1", Source: "file://test.mp4"},
+			},
+			Operations: []schemas.Operation{
+				{Op: "trim", Input: "input1", Output: "trimmed"},
+			},
+			Outputs: []schemas.Output{
+				{ID: "trimmed", Destination: "file://output.mp4"},
+			},
+		},
+	}
+	if err := s.CreateJob(nil, job); err != nil {
+		t.Fatalf("Failed to create test job: %v", err)
+	}
+
+	p.Process(context.Background(), job.JobID)
+
+	updated, err := s.GetJob(nil, job.JobID, "")
+	if err != nil {
+		t.Fatalf("Failed to get job: %v", err)
+	}
+	if updated.Status != schemas.JobStateCompleted {
+		t.Fatalf("Expected status completed, got %s (error: %+v)", updated.Status, updated.Error)
+	}
+	if len(updated.OutputFiles) != 1 {
+		t.Fatalf("Expected 1 output file, got %d", len(updated.OutputFiles))
+	}
+
+	out := updated.OutputFiles[0]
+	if out.OutputID != "trimmed" || out.Destination != "file://output.mp4" {
+		t.Errorf("Unexpected output identity: %+v", out)
+	}
+	if out.FileSize != int64(len("encoded")) {
+		t.Errorf("Expected file size %d, got %d", len("encoded"), out.FileSize)
+	}
+	if out.SHA256 == "" {
+		t.Error("Expected SHA256 to be set")
+	}
+	if out.Duration != 3 || out.MediaInfo == nil {
+		t.Errorf("Expected probed duration and media info, got %v %+v", out.Duration, out.MediaInfo)
+	}
+	if updated.Execution == nil {
+		t.Fatal("Expected execution stats to be recorded")
+	}
+	if updated.Execution.WallClockSeconds != 1 || updated.Execution.BytesWritten != int64(len("encoded")) {
+		t.Errorf("Unexpected execution stats: %+v", updated.Execution)
+	}
+	if updated.Execution.Command != "ffmpeg -i test.mp4 output.mp4" {
+		t.Errorf("Expected the executed command to be recorded, got %q", updated.Execution.Command)
+	}
+}
+
+// oversizeExecutor fails as if an output grew past the size limit
+type oversizeExecutor struct {
+	maxOutputBytes int64
+}
+
+func (e *oversizeExecutor) Execute(ctx context.Context, plan *schemas.ProcessingPlan, opts *executor.ExecuteOptions) (executor.ExecutionResult, error) {
+	e.maxOutputBytes = opts.MaxOutputBytes
+	return executor.ExecutionResult{}, fmt.Errorf("%w: output.mp4 is 2048 bytes (limit %d)", executor.ErrOutputSizeLimitExceeded, opts.MaxOutputBytes)
+}
+
+func TestProcessOutputSizeLimit(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	exec := &oversizeExecutor{}
+	p := NewProcessor(s, exec)
+	p.MaxAutoRetries = 3
+
+	job := &store.Job{
+		JobID:   "oversize-job",
+		Created: time.Now(),
+		Updated: time.Now(),
+		Status:  schemas.JobStatePending,
+		Spec: &schemas.JobSpec{
+			Inputs: []schemas.Input{
+				{ID: "input1", Source: "file://test.mp4"},
+			},
+			Operations: []schemas.Operation{
+				{Op: "trim", Input: "input1", Output: "trimmed"},
+			},
+			Outputs: []schemas.Output{
+				{ID: "trimmed", Destination: "file://output.mp4"},
+			},
+			Limits: &schemas.ResourceLimits{MaxOutputSize: 1024},
+		},
+	}
+	if err := s.CreateJob(nil, job); err != nil {
+		t.Fatalf("Failed to create test job: %v", err)
+	}
+
+	p.Process(context.Background(), job.JobID)
+
+	if exec.maxOutputBytes != 1024 {
+		t.Errorf("Expected MaxOutputBytes 1024, got %d", exec.maxOutputBytes)
+	}
+
+	updated, err := s.GetJob(nil, job.JobID, "")
+	if err != nil {
+		t.Fatalf("Failed to get job: %v", err)
+	}
+	if updated.Status != schemas.JobStateFailed {
+		t.Fatalf("Expected status failed, got %s", updated.Status)
+	}
+	if updated.Error == nil || updated.Error.Code != "OUTPUT_TOO_LARGE" || updated.Error.Retryable {
+		t.Errorf("Expected non-retryable OUTPUT_TOO_LARGE error, got %+v", updated.Error)
+	}
+}
@@ -0,0 +1,90 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Webhook event names
+const (
+	WebhookEventTimeoutWarning = "job.timeout_warning"
+)
+
+// WebhookSignatureHeader carries "sha256=" and the hex HMAC-SHA256 of a
+// webhook's body, keyed with the processor's WebhookSecret
+const WebhookSignatureHeader = "X-Webhook-Signature"
+
+// WebhookEvent is the JSON payload posted to a job's webhook URL
+type WebhookEvent struct {
+	Event     string     `json:"event"`
+	JobID     string     `json:"job_id"`
+	Deadline  *time.Time `json:"deadline,omitempty"`
+	Timestamp time.Time  `json:"timestamp"`
+}
+
+// webhookNotifier delivers webhook events over HTTP
+type webhookNotifier struct {
+	client *http.Client
+}
+
+// newWebhookNotifier creates a notifier with a bounded request timeout
+func newWebhookNotifier() *webhookNotifier {
+	return &webhookNotifier{
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Notify posts event to url, signed with secret if it is set. Delivery
+// failures are logged, not returned, so a broken webhook never affects job
+// processing.
+func (n *webhookNotifier) Notify(ctx context.Context, url, secret string, event *WebhookEvent) {
+	if err := n.send(ctx, url, secret, event); err != nil {
+		log.Printf("webhook %s for job %s failed: %v", event.Event, event.JobID, err)
+	}
+}
+
+func (n *webhookNotifier) send(ctx context.Context, url, secret string, event *WebhookEvent) error {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		req.Header.Set(WebhookSignatureHeader, SignWebhook(secret, body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// SignWebhook returns the WebhookSignatureHeader value for body
+func SignWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,108 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/chicogong/media-pipeline/pkg/store"
+)
+
+const (
+	// DefaultPollInterval is the initial delay before re-polling an empty queue
+	DefaultPollInterval = 500 * time.Millisecond
+
+	// DefaultMaxPollInterval caps the empty-queue backoff
+	DefaultMaxPollInterval = 10 * time.Second
+)
+
+// Worker claims pending jobs from the store and processes them with a
+// bounded pool of goroutines
+type Worker struct {
+	store     store.Store
+	processor *Processor
+	id        string
+
+	// Concurrency is the maximum number of jobs processed at once
+	Concurrency int
+
+	// PollInterval is the initial delay before re-polling an empty queue;
+	// it doubles on each consecutive empty poll up to MaxPollInterval
+	PollInterval    time.Duration
+	MaxPollInterval time.Duration
+}
+
+// NewWorker creates a worker identified by id that processes claimed jobs
+// with p. Retries are left in the queue so any worker can pick them up.
+func NewWorker(s store.Store, p *Processor, id string) *Worker {
+	p.RequeueRetries = true
+
+	return &Worker{
+		store:           s,
+		processor:       p,
+		id:              id,
+		Concurrency:     1,
+		PollInterval:    DefaultPollInterval,
+		MaxPollInterval: DefaultMaxPollInterval,
+	}
+}
+
+// Run claims and processes jobs until ctx is cancelled. On shutdown it stops
+// claiming new jobs and waits for in-flight jobs to finish before returning.
+func (w *Worker) Run(ctx context.Context) error {
+	concurrency := w.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	slots := make(chan struct{}, concurrency)
+	var inFlight sync.WaitGroup
+	defer inFlight.Wait()
+
+	// In-flight jobs are drained rather than aborted on shutdown
+	jobCtx := context.WithoutCancel(ctx)
+	backoff := w.PollInterval
+
+	for {
+		// Wait for a free slot
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil
+		}
+
+		job, err := w.store.ClaimJob(ctx, w.id)
+		if err != nil {
+			<-slots
+
+			if !errors.Is(err, store.ErrNoPendingJobs) {
+				log.Printf("worker %s: failed to claim job: %v", w.id, err)
+			}
+
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return nil
+			}
+
+			backoff *= 2
+			if backoff > w.MaxPollInterval {
+				backoff = w.MaxPollInterval
+			}
+			continue
+		}
+
+		backoff = w.PollInterval
+
+		inFlight.Add(1)
+		go func(jobID string) {
+			defer inFlight.Done()
+			defer func() { <-slots }()
+
+			log.Printf("worker %s: processing job %s", w.id, jobID)
+			w.processor.Process(jobCtx, jobID)
+		}(job.JobID)
+	}
+}
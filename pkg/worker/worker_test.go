@@ -0,0 +1,78 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/chicogong/media-pipeline/pkg/schemas"
+	"github.com/chicogong/media-pipeline/pkg/store"
+)
+
+func TestWorkerProcessesAllJobs(t *testing.T) {
+	s := store.NewMemoryStore()
+	defer s.Close()
+
+	exec := &recordingExecutor{}
+	w := NewWorker(s, NewProcessor(s, exec), "worker-1")
+	w.Concurrency = 2
+	w.PollInterval = 5 * time.Millisecond
+
+	const jobCount = 5
+	for i := 0; i < jobCount; i++ {
+		if err := s.CreateJob(nil, dependentJob(fmt.Sprintf("worker-job-%d", i))); err != nil {
+			t.Fatalf("Failed to create test job: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- w.Run(ctx)
+	}()
+
+	// Wait until every job reaches a terminal state
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		completed, err := s.ListJobs(ctx, &store.ListFilter{
+			Status: []schemas.JobState{schemas.JobStateCompleted},
+		})
+		if err != nil {
+			t.Fatalf("ListJobs() failed: %v", err)
+		}
+		if len(completed) == jobCount {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d completed jobs, got %d", jobCount, len(completed))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run() returned error: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run() did not return after shutdown")
+	}
+
+	exec.mu.Lock()
+	defer exec.mu.Unlock()
+	if len(exec.order) != jobCount {
+		t.Errorf("Expected %d executions, got %d", jobCount, len(exec.order))
+	}
+
+	for i := 0; i < jobCount; i++ {
+		job, err := s.GetJob(nil, fmt.Sprintf("worker-job-%d", i), "")
+		if err != nil {
+			t.Fatalf("Failed to get job: %v", err)
+		}
+		if job.WorkerID != "worker-1" {
+			t.Errorf("Expected job %s claimed by worker-1, got %q", job.JobID, job.WorkerID)
+		}
+	}
+}